@@ -64,6 +64,7 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 	}
 
 	templateFlags, toJSON, fromJSON, processTemplate := base.TemplateProcessor(plugins)
+	planFlags, printPlan := cli.PlanOutput()
 
 	///////////////////////////////////////////////////////////////////////////////////
 	// commit
@@ -91,18 +92,28 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 				return err
 			}
 
-			details, err := groupPlugin.CommitGroup(spec, *pretend)
-			if err == nil {
-				if *pretend {
-					fmt.Printf("Committing %s would involve: %s\n", spec.ID, details)
-				} else {
-					fmt.Printf("Committed %s: %s\n", spec.ID, details)
+			if *pretend {
+				plan, err := groupPlugin.PlanCommit(spec)
+				if err != nil {
+					return err
 				}
+				return printPlan(os.Stdout, cli.Plan{
+					Name:      string(spec.ID),
+					Message:   []string{plan.Explain},
+					Changes:   plan.Changes,
+					Instances: &plan.Instances,
+				})
+			}
+
+			details, err := groupPlugin.CommitGroup(spec, false)
+			if err == nil {
+				fmt.Printf("Committed %s: %s\n", spec.ID, details)
 			}
 			return err
 		},
 	}
 	commit.Flags().AddFlagSet(templateFlags)
+	commit.Flags().AddFlagSet(planFlags)
 
 	///////////////////////////////////////////////////////////////////////////////////
 	// free
@@ -40,6 +40,7 @@ import (
 	_ "github.com/docker/infrakit/pkg/cli/backend/sh"
 
 	// Supported "kinds"
+	_ "github.com/docker/infrakit/pkg/run/v0/autoscale"
 	_ "github.com/docker/infrakit/pkg/run/v0/aws"
 	_ "github.com/docker/infrakit/pkg/run/v0/enrollment"
 	_ "github.com/docker/infrakit/pkg/run/v0/file"
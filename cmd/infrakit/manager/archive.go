@@ -0,0 +1,159 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/manager"
+	manager_run "github.com/docker/infrakit/pkg/run/v0/manager"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// backendFlags returns the flags of a backend of the manager, with the given prefix, and the function that opens
+// the snapshot of the specs in the backend.
+func backendFlags(prefix string) (*pflag.FlagSet, func() (store.Snapshot, func(), error)) {
+	flags := pflag.NewFlagSet(prefix+"backend", pflag.ExitOnError)
+	backend := flags.String(prefix+"backend", manager_run.DefaultOptions.Backend,
+		"Backend of the manager: file, etcd or swarm")
+	settings := flags.String(prefix+"settings", "", "Settings of the backend, in JSON or YAML")
+	keyFile := flags.String(prefix+"key-file", "", "File of the keys encrypting the specs in the backend")
+
+	return flags, func() (store.Snapshot, func(), error) {
+		options := manager_run.DefaultOptions
+		if *backend != options.Backend {
+			// The default settings are of the default backend
+			options.Settings = nil
+		}
+		options.Backend = *backend
+		if *settings != "" {
+			any, err := types.AnyYAML([]byte(*settings))
+			if err != nil {
+				return nil, nil, err
+			}
+			options.Settings = any
+		}
+		if *keyFile != "" {
+			options.Encryption = &manager_run.EncryptionConfig{KeyFile: *keyFile}
+		}
+		return manager_run.OpenSnapshot(options)
+	}
+}
+
+// importArchive writes the archive into the backend opened
+func importArchive(archive manager.Archive, open func() (store.Snapshot, func(), error), overwrite bool) error {
+	snapshot, cleanup, err := open()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return manager.Import(archive, snapshot, overwrite)
+}
+
+// archiveCommands returns the commands that export and import the specs stored by the manager.  They access the
+// backends directly, so they do not need a running manager.
+func archiveCommands() []*cobra.Command {
+
+	// Only the checks of the root command: the backends are accessed directly, not through the leader
+	preRun := func(c *cobra.Command, args []string) error {
+		return cli.EnsurePersistentPreRunE(c)
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////
+	// export
+	export := &cobra.Command{
+		Use:               "export",
+		Short:             "Export the specs stored in a backend, with their history, into an archive",
+		PersistentPreRunE: preRun,
+	}
+	fromFlags, openFrom := backendFlags("")
+	export.Flags().AddFlagSet(fromFlags)
+	output := export.Flags().String("output", "", "File of the archive.  Written to stdout if not set")
+	toFlags, openTo := backendFlags("to-")
+	export.Flags().AddFlagSet(toFlags)
+	exportOverwrite := export.Flags().Bool("overwrite", false,
+		"Import into the backend set by --to-backend even if it has specs already")
+	export.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		snapshot, cleanup, err := openFrom()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		archive, err := manager.Export(snapshot)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("to-backend") {
+			if err := importArchive(archive, openTo, *exportOverwrite); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Imported %d revisions\n", len(archive.Revisions))
+		}
+
+		buff, err := json.MarshalIndent(archive, "", "  ")
+		if err != nil {
+			return err
+		}
+		switch {
+		case *output != "":
+			return ioutil.WriteFile(*output, buff, 0600)
+		case !cmd.Flags().Changed("to-backend"):
+			fmt.Println(string(buff))
+		}
+		return nil
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////
+	// import
+	importCmd := &cobra.Command{
+		Use:               "import <archive_file>",
+		Short:             "Import an archive of specs into a backend.  Read from stdin if the file is '-'",
+		PersistentPreRunE: preRun,
+	}
+	intoFlags, openInto := backendFlags("")
+	importCmd.Flags().AddFlagSet(intoFlags)
+	overwrite := importCmd.Flags().Bool("overwrite", false, "Import even if the backend has specs already")
+	importCmd.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		var buff []byte
+		var err error
+		if args[0] == "-" {
+			buff, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			buff, err = ioutil.ReadFile(args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		archive := manager.Archive{}
+		if err := json.Unmarshal(buff, &archive); err != nil {
+			return err
+		}
+		if err := importArchive(archive, openInto, *overwrite); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d revisions\n", len(archive.Revisions))
+		return nil
+	}
+
+	return []*cobra.Command{export, importCmd}
+}
@@ -1,10 +1,12 @@
 package manager
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/infrakit/cmd/infrakit/base"
 	"github.com/docker/infrakit/pkg/cli"
@@ -32,6 +34,8 @@ func init() {
 // Command is the entrypoint
 func Command(plugins func() discovery.Plugins) *cobra.Command {
 
+	var managerPlugin manager.Manager
+
 	var groupPlugin group.Plugin
 	var groupPluginName string
 
@@ -67,6 +71,8 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 					log.Debug("Found manager", "name", name, "leader", isLeader)
 					if isLeader {
 
+						managerPlugin = m
+
 						groupPlugin = group_rpc.Adapt(rpcClient)
 						groupPluginName = name
 
@@ -75,7 +81,7 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 						updatablePlugin = metadata_rpc.AdaptUpdatable(rpcClient)
 						updatablePluginName = name
 
-						log.Debug("Found updatable", "name", name, "addr", endpoint.Address)
+						log.Debug("Found updatable", "name", updatablePluginName, "addr", endpoint.Address)
 						break
 					}
 				}
@@ -94,6 +100,8 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 
 	///////////////////////////////////////////////////////////////////////////////////
 	// commit
+	var prune, message, author *string
+	var yes *bool
 	commit := &cobra.Command{
 		Use:   "commit <template_URL>",
 		Short: "Commit a multi-group configuration, as specified by the URL.  Read from stdin if url is '-'",
@@ -125,6 +133,22 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 				return err
 			}
 
+			// Groups of the manager are committed together, as a single revision
+			if specs, managed := managedGroups(groupPluginName, groups); managed {
+				options := manager.CommitOptions{
+					Prune:   manager.PruneMode(*prune),
+					Author:  *author,
+					Message: *message,
+				}
+				return applyChanges(func(options manager.CommitOptions) (manager.GroupChanges, error) {
+					return managerPlugin.CommitGroups(specs, options)
+				}, options, *pretend, *yes)
+			}
+
+			if *prune != "" {
+				return fmt.Errorf("cannot prune groups of plugins other than %v", groupPluginName)
+			}
+
 			// Check the list of plugins
 			for _, gp := range groups {
 
@@ -163,6 +187,11 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 			return nil
 		},
 	}
+	prune = commit.Flags().String("prune", "",
+		"Remove the groups missing from the configuration: 'free' to release them or 'destroy' to destroy them")
+	yes = commit.Flags().Bool("yes", false, "Apply the changes without asking for confirmation")
+	message = commit.Flags().String("message", "", "Message describing the change, kept in the history")
+	author = commit.Flags().String("author", os.Getenv("USER"), "Author of the change, kept in the history")
 	commit.Flags().AddFlagSet(templateFlags)
 
 	///////////////////////////////////////////////////////////////////////////////////
@@ -357,7 +386,103 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 	}
 	change.AddCommand(changeList, changeGet)
 
-	cmd.AddCommand(commit, inspect, change, leader)
+	///////////////////////////////////////////////////////////////////////////////////
+	// history
+	history := &cobra.Command{
+		Use:   "history",
+		Short: "History lists the revisions of the configurations committed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if len(args) != 0 {
+				cmd.Usage()
+				os.Exit(1)
+			}
+
+			revisions, err := managerPlugin.Revisions()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%-10s\t%-25s\t%-15s\t%s\n", "REVISION", "TIME", "AUTHOR", "MESSAGE")
+			for _, r := range revisions {
+				fmt.Printf("%-10d\t%-25s\t%-15s\t%s\n", r.Number, r.Time.Format(time.RFC3339), r.Author, r.Message)
+			}
+			return nil
+		},
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////
+	// diff
+	diff := &cobra.Command{
+		Use:   "diff <revision> <revision>",
+		Short: "Diff shows the differences between the configurations of two revisions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if len(args) != 2 {
+				cmd.Usage()
+				os.Exit(1)
+			}
+
+			buffs := [][]byte{}
+			for _, arg := range args {
+				number, err := strconv.Atoi(arg)
+				if err != nil {
+					return err
+				}
+				specs, err := managerPlugin.InspectRevision(number)
+				if err != nil {
+					return err
+				}
+				buff, err := types.AnyValueMust(specs).MarshalYAML()
+				if err != nil {
+					return err
+				}
+				buffs = append(buffs, buff)
+			}
+
+			// Render the delta
+			dmp := diffmatchpatch.New()
+			diffs := dmp.DiffMain(string(buffs[0]), string(buffs[1]), false)
+			fmt.Println(dmp.DiffPrettyText(diffs))
+			return nil
+		},
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////
+	// rollback
+	rollback := &cobra.Command{
+		Use:   "rollback <revision>",
+		Short: "Rollback commits the configuration of a previous revision",
+	}
+	rollbackPrune := rollback.Flags().String("prune", "",
+		"Remove the groups added since the revision: 'free' to release them or 'destroy' to destroy them")
+	rollbackYes := rollback.Flags().Bool("yes", false, "Apply the changes without asking for confirmation")
+	rollbackMessage := rollback.Flags().String("message", "", "Message describing the change, kept in the history")
+	rollbackAuthor := rollback.Flags().String("author", os.Getenv("USER"), "Author of the change, kept in the history")
+	rollback.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		number, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		options := manager.CommitOptions{
+			Prune:   manager.PruneMode(*rollbackPrune),
+			Author:  *rollbackAuthor,
+			Message: *rollbackMessage,
+		}
+		return applyChanges(func(options manager.CommitOptions) (manager.GroupChanges, error) {
+			return managerPlugin.Rollback(number, options)
+		}, options, *pretend, *rollbackYes)
+	}
+
+	cmd.AddCommand(commit, inspect, change, leader, history, diff, rollback)
+	cmd.AddCommand(archiveCommands()...)
 
 	return cmd
 }
@@ -376,6 +501,76 @@ func parseBool(text string) (bool, error) {
 	return false, err
 }
 
+// managedGroups returns the group specs if all the groups are of the manager.
+func managedGroups(managerName string, groups []plugin.Spec) ([]group.Spec, bool) {
+	specs := []group.Spec{}
+	for _, gp := range groups {
+		if lookup, _ := gp.Plugin.GetLookupAndType(); lookup != managerName {
+			return nil, false
+		}
+		spec := group.Spec{}
+		if gp.Properties != nil {
+			if err := gp.Properties.Decode(&spec); err != nil {
+				return nil, false
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, true
+}
+
+// applyChanges shows the plan of changes and then applies them.  Groups are removed only on confirmation.
+func applyChanges(commit func(manager.CommitOptions) (manager.GroupChanges, error),
+	options manager.CommitOptions, pretend, yes bool) error {
+
+	if err := options.Prune.Validate(); err != nil {
+		return err
+	}
+
+	options.Pretend = true
+	changes, err := commit(options)
+	if err != nil {
+		return err
+	}
+
+	printChanges(changes)
+	if pretend || changes.Empty() {
+		return nil
+	}
+
+	if len(changes.Remove) > 0 && !yes {
+		fmt.Print("Apply these changes? [y/N] ")
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if agree, err := parseBool(strings.TrimSpace(answer)); err != nil || !agree {
+			fmt.Println("Not applied")
+			return nil
+		}
+	}
+
+	options.Pretend = false
+	_, err = commit(options)
+	return err
+}
+
+func printChanges(changes manager.GroupChanges) {
+	if changes.Empty() {
+		fmt.Println("No changes")
+		return
+	}
+	for _, id := range changes.Add {
+		fmt.Printf("+ %v (add)\n", id)
+	}
+	for _, id := range changes.Change {
+		fmt.Printf("~ %v (change)\n", id)
+	}
+	for _, id := range changes.Remove {
+		fmt.Printf("- %v (%s)\n", id, changes.Prune)
+	}
+}
+
 func getGlobalConfig(groupPlugin group.Plugin, groupPluginName string) ([]plugin.Spec, error) {
 	specs, err := groupPlugin.InspectGroups()
 	if err != nil {
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/infrakit/pkg/discovery"
+	"github.com/docker/infrakit/pkg/rpc/audit"
+	"github.com/spf13/cobra"
+)
+
+func auditCommand(plugins func() discovery.Plugins) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the audit log of the mutating calls to the plugins",
+	}
+
+	file := cmd.Flags().String("file", os.Getenv(audit.EnvFile), "File of the audit log")
+	identity := cmd.Flags().String("identity", "", "Identity of the callers")
+	name := cmd.Flags().String("plugin", "", "Name of the plugins")
+	method := cmd.Flags().String("method", "", "Pattern of the methods, such as *.Destroy*")
+	since := cmd.Flags().Duration("since", 0, "Show only the calls within the duration, such as 1h")
+	digest := cmd.Flags().Bool("digest", false, "Show the digests of the arguments")
+
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if *file == "" {
+			return fmt.Errorf("no audit log file, see --file or %v", audit.EnvFile)
+		}
+
+		filter := audit.Filter{
+			Identity: *identity,
+			Plugin:   *name,
+			Method:   *method,
+		}
+		if *since > 0 {
+			filter.Since = time.Now().Add(-*since)
+		}
+
+		entries, err := audit.NewFileLog(*file, 0, 0).Entries(filter)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-30s\t%-20s\t%-20s\t%-30s\t%-s\n", "TIME", "IDENTITY", "PLUGIN", "METHOD", "OUTCOME")
+		for _, entry := range entries {
+			outcome := string(entry.Outcome)
+			if entry.Error != "" {
+				outcome = fmt.Sprintf("%v: %v", outcome, entry.Error)
+			}
+			if *digest {
+				outcome = fmt.Sprintf("%v\t%v", outcome, entry.Digest)
+			}
+			fmt.Printf("%-30s\t%-20s\t%-20s\t%-30s\t%-s\n",
+				entry.Time.Format(time.RFC3339Nano), entry.Identity, entry.Plugin, entry.Method, outcome)
+		}
+		return nil
+	}
+
+	return cmd
+}
@@ -2,11 +2,14 @@ package mux
 
 import (
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/leader"
 	"github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/rpc/mux"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,12 @@ type config struct {
 	interval     *time.Duration
 	pollInterval *time.Duration
 	location     *string
+	tlsCA        *string
+	tlsCert      *string
+	tlsKey       *string
+	authTokens   *string
+	authPolicy   *string
+	authProxies  *[]string
 	plugins      func() discovery.Plugins
 	poller       *leader.Poller
 	store        leader.Store
@@ -38,6 +47,21 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 	interval := cmd.PersistentFlags().DurationP("scan", "s", 1*time.Minute, "Scan interval to check for plugins")
 	pollInterval := cmd.PersistentFlags().DurationP("poll-interval", "p", 5*time.Second, "Leader polling interval")
 	locateURL := cmd.Flags().StringP("locate-url", "u", "", "Locate URL of this node, eg. http://public_ip:24864")
+	tlsCA := cmd.PersistentFlags().String("tls-ca", os.Getenv(rpc.EnvTLSCAFile),
+		"CA certificate verifying the clients and the leader")
+	tlsCert := cmd.PersistentFlags().String("tls-cert", os.Getenv(rpc.EnvTLSCertFile),
+		"Certificate of the mux. Serve TLS if set")
+	tlsKey := cmd.PersistentFlags().String("tls-key", os.Getenv(rpc.EnvTLSKeyFile), "Private key of the certificate")
+	authTokens := cmd.PersistentFlags().String("auth-tokens", os.Getenv(auth.EnvTokensFile),
+		"File of the bearer tokens of the callers, by identity")
+	authPolicy := cmd.PersistentFlags().String("auth-policy", os.Getenv(auth.EnvPolicyFile),
+		"File of the policy of the methods allowed to the callers")
+	var proxies []string
+	if defaults := auth.DefaultOptions(); defaults != nil {
+		proxies = defaults.Proxies
+	}
+	authProxies := cmd.PersistentFlags().StringSlice("auth-proxies", proxies,
+		"Identities of the muxes trusted to pass the identity of their callers")
 
 	config := &config{
 		location:     locateURL,
@@ -46,6 +70,12 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 		autoStop:     autoStop,
 		interval:     interval,
 		pollInterval: pollInterval,
+		tlsCA:        tlsCA,
+		tlsCert:      tlsCert,
+		tlsKey:       tlsKey,
+		authTokens:   authTokens,
+		authPolicy:   authPolicy,
+		authProxies:  authProxies,
 	}
 
 	cmd.RunE = func(c *cobra.Command, args []string) error {
@@ -79,12 +109,20 @@ func runMux(config *config) error {
 	if err != nil {
 		return err
 	}
+	options := mux.Options{
+		Leadership: leadership,
+		Registry:   config.store,
+	}
+	tls := rpc.TLSOptions{CAFile: *config.tlsCA, CertFile: *config.tlsCert, KeyFile: *config.tlsKey}
+	if tls != (rpc.TLSOptions{}) {
+		options.TLS = &tls
+	}
+	guard := auth.Options{TokensFile: *config.authTokens, PolicyFile: *config.authPolicy, Proxies: *config.authProxies}
+	if guard.TokensFile != "" || guard.PolicyFile != "" || len(guard.Proxies) > 0 {
+		options.Auth = &guard
+	}
 	logger.Info("Starting mux server", "listen", *config.listen)
-	server, err := mux.NewServer(*config.listen, advertise.Host, config.plugins,
-		mux.Options{
-			Leadership: leadership,
-			Registry:   config.store,
-		})
+	server, err := mux.NewServer(*config.listen, advertise.Host, config.plugins, options)
 	if err != nil {
 		return err
 	}
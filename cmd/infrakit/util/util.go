@@ -26,6 +26,7 @@ func Command(plugins func() discovery.Plugins) *cobra.Command {
 		mux.Command(plugins),
 		fileServerCommand(plugins),
 		trackCommand(plugins),
+		auditCommand(plugins),
 	)
 
 	return util
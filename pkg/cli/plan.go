@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/spf13/pflag"
+)
+
+// Plan is the plan of a commit, as printed by the commands that pretend to commit.
+type Plan struct {
+	// Name is the name of what is committed
+	Name string
+
+	// Message explains the plan
+	Message []string
+
+	// Changes are the field-level differences between the current and the committed spec
+	Changes []types.Change `json:",omitempty"`
+
+	// Instances are the instances destroyed, created or left alone, if known
+	Instances *instance.Plan `json:",omitempty"`
+}
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// PlanFunc is a function that writes a plan to the output writer
+type PlanFunc func(w io.Writer, plan Plan) error
+
+// PlanOutput returns the flagset and the func for printing plans.  Plans are printed as a diff by default, or as
+// json for processing by other tools.
+func PlanOutput() (*pflag.FlagSet, PlanFunc) {
+
+	fs := pflag.NewFlagSet("plan", pflag.ExitOnError)
+	format := fs.String("plan-format", "diff", "Format of the plan when pretending: diff or json")
+	noColor := fs.Bool("no-color", false, "True to print the diff of the plan without colors")
+
+	return fs, func(w io.Writer, plan Plan) error {
+		switch *format {
+		case "json":
+			buff, err := json.MarshalIndent(plan, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, string(buff))
+			return nil
+		case "diff":
+			writePlanDiff(w, plan, !*noColor)
+			return nil
+		}
+		return fmt.Errorf("unknown plan format: %s", *format)
+	}
+}
+
+func writePlanDiff(w io.Writer, plan Plan, color bool) {
+	line := func(c, format string, args ...interface{}) {
+		s := fmt.Sprintf(format, args...)
+		if color && c != "" {
+			s = c + s + colorReset
+		}
+		fmt.Fprintln(w, s)
+	}
+
+	line("", "Committing %s would involve:", plan.Name)
+	for _, message := range plan.Message {
+		line("", "  %s", message)
+	}
+
+	if len(plan.Changes) > 0 {
+		line("", "")
+		line("", "--- current")
+		line("", "+++ committed")
+		for _, change := range plan.Changes {
+			line(colorCyan, "@@ %s @@", change.Path)
+			if change.Old != nil {
+				line(colorRed, "- %s", compactJSON(change.Old))
+			}
+			if change.New != nil {
+				line(colorGreen, "+ %s", compactJSON(change.New))
+			}
+		}
+	}
+
+	if plan.Instances == nil {
+		return
+	}
+
+	line("", "")
+	line("", "Instances:")
+	for _, id := range plan.Instances.Destroy {
+		line(colorRed, "- %s (destroy)", id)
+	}
+	for _, id := range plan.Instances.LogicalIDs {
+		line(colorGreen, "+ %s (create)", id)
+	}
+	if n := plan.Instances.Create - len(plan.Instances.LogicalIDs); n > 0 {
+		line(colorGreen, "+ %d new instances (create)", n)
+	}
+	for _, id := range plan.Instances.Keep {
+		line("", "  %s (keep)", id)
+	}
+}
+
+func compactJSON(any *types.Any) string {
+	buff := bytes.Buffer{}
+	if err := json.Compact(&buff, any.Bytes()); err != nil {
+		return any.String()
+	}
+	return buff.String()
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanOutput(t *testing.T) {
+	plan := Plan{
+		Name:    "workers",
+		Message: []string{"Performing a rolling update on 2 instances"},
+		Changes: []types.Change{
+			{
+				Path: "Instance/Properties/Tags",
+				Old:  types.AnyValueMust(map[string]string{"env": "dev"}),
+				New:  types.AnyValueMust(map[string]string{"env": "prod"}),
+			},
+			{
+				Path: "Instance/Properties/Volumes",
+				New:  types.AnyValueMust([]string{"data"}),
+			},
+		},
+		Instances: &instance.Plan{
+			Destroy: []instance.ID{"i-1"},
+			Create:  2,
+			Keep:    []instance.ID{"i-2"},
+		},
+	}
+
+	flags, print := PlanOutput()
+
+	buff := bytes.Buffer{}
+	require.NoError(t, flags.Parse([]string{"--no-color"}))
+	require.NoError(t, print(&buff, plan))
+	require.Equal(t, `Committing workers would involve:
+  Performing a rolling update on 2 instances
+
+--- current
++++ committed
+@@ Instance/Properties/Tags @@
+- {"env":"dev"}
++ {"env":"prod"}
+@@ Instance/Properties/Volumes @@
++ ["data"]
+
+Instances:
+- i-1 (destroy)
++ 2 new instances (create)
+  i-2 (keep)
+`, buff.String())
+
+	buff.Reset()
+	require.NoError(t, flags.Parse([]string{"--no-color=false"}))
+	require.NoError(t, print(&buff, plan))
+	require.Contains(t, buff.String(), colorRed+"- i-1 (destroy)"+colorReset)
+
+	buff.Reset()
+	require.NoError(t, flags.Parse([]string{"--plan-format", "json"}))
+	require.NoError(t, print(&buff, plan))
+	decoded := Plan{}
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &decoded))
+	require.Equal(t, plan.Instances, decoded.Instances)
+	require.Equal(t, "Instance/Properties/Volumes", decoded.Changes[1].Path)
+
+	require.NoError(t, flags.Parse([]string{"--plan-format", "xml"}))
+	require.Error(t, print(&buff, plan))
+}
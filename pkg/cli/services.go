@@ -39,12 +39,19 @@ type Services struct {
 
 	// Output is the function that does output
 	Output OutputFunc
+
+	// PlanFlags are flags that control the format of plans
+	PlanFlags *pflag.FlagSet
+
+	// PrintPlan is the function that prints plans
+	PrintPlan PlanFunc
 }
 
 // NewServices creates an instance of common services for all commands
 func NewServices(plugins func() discovery.Plugins) *Services {
 	flags, toJSON, fromJSON, processTemplate := templateProcessor(plugins)
 	outputFlags, outputFunc := Output()
+	planFlags, planFunc := PlanOutput()
 	return &Services{
 		Plugins:              plugins,
 		ProcessTemplateFlags: flags,
@@ -53,6 +60,8 @@ func NewServices(plugins func() discovery.Plugins) *Services {
 		FromJSON:             fromJSON,
 		OutputFlags:          outputFlags,
 		Output:               outputFunc,
+		PlanFlags:            planFlags,
+		PrintPlan:            planFunc,
 	}
 }
 
@@ -17,8 +17,11 @@ func Commit(name string, services *cli.Services) *cobra.Command {
 		Use:   "commit <group configuration url>",
 		Short: "Commit a group configuration. Read from stdin if url is '-'",
 	}
+	pretend := false
+	commit.Flags().BoolVar(&pretend, "pretend", pretend, "Don't actually commit, only show the plan")
 	//	commit.Flags().AddFlagSet(services.OutputFlags)
 	commit.Flags().AddFlagSet(services.ProcessTemplateFlags)
+	commit.Flags().AddFlagSet(services.PlanFlags)
 
 	commit.RunE = func(cmd *cobra.Command, args []string) error {
 
@@ -47,6 +50,19 @@ func Commit(name string, services *cli.Services) *cobra.Command {
 			return err
 		}
 
+		if pretend {
+			_, plan, err := c.Plan(controller.Enforce, spec)
+			if err != nil {
+				return err
+			}
+			return services.PrintPlan(os.Stdout, cli.Plan{
+				Name:      spec.Metadata.Name,
+				Message:   plan.Message,
+				Changes:   plan.Changes,
+				Instances: plan.Instances,
+			})
+		}
+
 		object, err := c.Commit(controller.Enforce, spec)
 		if err != nil {
 			return err
@@ -0,0 +1,74 @@
+package group
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/spf13/cobra"
+)
+
+// Canary returns the Canary command
+func Canary(name string, services *cli.Services) *cobra.Command {
+
+	canary := &cobra.Command{
+		Use:   "canary",
+		Short: "Promote or abort the canary phase of a group update",
+	}
+
+	// run resolves the group ID and applies the operation to the group
+	run := func(cmd *cobra.Command, args []string, op func(group.Plugin, group.ID) error) error {
+
+		pluginName := plugin.Name(name)
+		_, gid := pluginName.GetLookupAndType()
+		if gid == "" {
+			if len(args) < 1 {
+				cmd.Usage()
+				os.Exit(1)
+			} else {
+				gid = args[0]
+			}
+		}
+
+		groupPlugin, err := LoadPlugin(services.Plugins(), name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(groupPlugin, "group plugin not found", "name", name)
+
+		return op(groupPlugin, group.ID(gid))
+	}
+
+	promote := &cobra.Command{
+		Use:   "promote <group ID>",
+		Short: "Promote the canary and continue the update with the rest of the group",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, args, func(groupPlugin group.Plugin, groupID group.ID) error {
+				if err := groupPlugin.PromoteCanary(groupID); err != nil {
+					return err
+				}
+				fmt.Println("Promoted canary of", groupID)
+				return nil
+			})
+		},
+	}
+
+	abort := &cobra.Command{
+		Use:   "abort <group ID>",
+		Short: "Abort the canary and roll the group back to its previous configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, args, func(groupPlugin group.Plugin, groupID group.ID) error {
+				if err := groupPlugin.AbortCanary(groupID); err != nil {
+					return err
+				}
+				fmt.Println("Aborted canary of", groupID)
+				return nil
+			})
+		},
+	}
+
+	canary.AddCommand(promote, abort)
+	return canary
+}
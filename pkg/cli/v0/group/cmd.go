@@ -24,6 +24,9 @@ func init() {
 			Destroy,
 			Scale,
 			DestroyInstances,
+			Canary,
+			Pause,
+			Resume,
 		})
 }
 
@@ -43,6 +46,9 @@ func Group(name string, services *cli.Services) *cobra.Command {
 		Destroy(name, services),
 		Scale(name, services),
 		DestroyInstances(name, services),
+		Canary(name, services),
+		Pause(name, services),
+		Resume(name, services),
 	)
 
 	return group
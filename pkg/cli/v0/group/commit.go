@@ -48,18 +48,28 @@ func Commit(name string, services *cli.Services) *cobra.Command {
 			return err
 		}
 
-		details, err := groupPlugin.CommitGroup(spec, pretend)
+		if pretend {
+			plan, err := groupPlugin.PlanCommit(spec)
+			if err != nil {
+				return err
+			}
+			return services.PrintPlan(os.Stdout, cli.Plan{
+				Name:      string(spec.ID),
+				Message:   []string{plan.Explain},
+				Changes:   plan.Changes,
+				Instances: &plan.Instances,
+			})
+		}
+
+		details, err := groupPlugin.CommitGroup(spec, false)
 		if err != nil {
 			return err
 		}
 
-		if pretend {
-			fmt.Printf("Committing %s would involve: %s\n", spec.ID, details)
-		} else {
-			fmt.Printf("Committed %s: %s\n", spec.ID, details)
-		}
+		fmt.Printf("Committed %s: %s\n", spec.ID, details)
 		return nil
 	}
 	commit.Flags().AddFlagSet(services.ProcessTemplateFlags)
+	commit.Flags().AddFlagSet(services.PlanFlags)
 	return commit
 }
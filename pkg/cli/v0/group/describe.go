@@ -6,6 +6,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/docker/infrakit/pkg/cli"
 	"github.com/docker/infrakit/pkg/plugin"
@@ -22,6 +23,7 @@ func Describe(name string, services *cli.Services) *cobra.Command {
 	}
 
 	quiet := describe.Flags().BoolP("quiet", "q", false, "Print rows without column headers")
+	history := describe.Flags().Bool("history", false, "Print the recent commits to the group instead of its members")
 	describe.RunE = func(cmd *cobra.Command, args []string) error {
 
 		pluginName := plugin.Name(name)
@@ -50,7 +52,12 @@ func Describe(name string, services *cli.Services) *cobra.Command {
 
 		return services.Output(os.Stdout, desc,
 			func(io.Writer, interface{}) error {
+				if *history {
+					printHistory(desc.History, *quiet)
+					return nil
+				}
 				if !*quiet {
+					printUpdate(desc)
 					fmt.Printf("%-30s\t%-30s\t%-s\n", "ID", "LOGICAL", "TAGS")
 				}
 				for _, d := range desc.Instances {
@@ -73,3 +80,39 @@ func Describe(name string, services *cli.Services) *cobra.Command {
 	describe.Flags().AddFlagSet(services.OutputFlags)
 	return describe
 }
+
+// printUpdate prints the status of the update in progress and of the last rollback, if any.
+func printUpdate(desc group.Description) {
+	if u := desc.Update; u != nil {
+		fmt.Printf("Update:    %s, %d replaced, %d remaining, started %s\n",
+			u.Phase, u.Replaced, u.Remaining, u.Started.Format(time.RFC3339))
+		fmt.Printf("Plan:      %s\n", u.Plan)
+		if u.LastError != "" {
+			fmt.Printf("Error:     %s\n", u.LastError)
+		}
+	}
+	if r := desc.Rollback; r != nil {
+		fmt.Printf("Rollback:  %s -> %s at %s: %s\n", r.From, r.To, r.Time.Format(time.RFC3339), r.Reason)
+	}
+	if desc.Update != nil || desc.Rollback != nil {
+		fmt.Println()
+	}
+}
+
+// printHistory prints the recent commits to a group, most recent first.
+func printHistory(history []group.Commit, quiet bool) {
+	if !quiet {
+		fmt.Printf("%-25s\t%-12s\t%-30s\t%-s\n", "STARTED", "PHASE", "HASH", "PLAN")
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		c := history[i]
+		plan := c.Plan
+		if c.Rollback {
+			plan = "Rollback: " + plan
+		}
+		if c.Error != "" {
+			plan = fmt.Sprintf("%s (%s)", plan, c.Error)
+		}
+		fmt.Printf("%-25s\t%-12s\t%-30s\t%-s\n", c.Started.Format(time.RFC3339), c.Phase, c.Hash, plan)
+	}
+}
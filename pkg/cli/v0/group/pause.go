@@ -0,0 +1,49 @@
+package group
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/spf13/cobra"
+)
+
+// Pause returns the Pause command
+func Pause(name string, services *cli.Services) *cobra.Command {
+
+	pause := &cobra.Command{
+		Use:   "pause <group ID>",
+		Short: "Pause the update in progress before it replaces any more instances",
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			pluginName := plugin.Name(name)
+			_, gid := pluginName.GetLookupAndType()
+			if gid == "" {
+				if len(args) < 1 {
+					cmd.Usage()
+					os.Exit(1)
+				} else {
+					gid = args[0]
+				}
+			}
+
+			groupPlugin, err := LoadPlugin(services.Plugins(), name)
+			if err != nil {
+				return err
+			}
+			cli.MustNotNil(groupPlugin, "group plugin not found", "name", name)
+
+			groupID := group.ID(gid)
+			err = groupPlugin.PauseUpdate(groupID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Paused update of", groupID)
+			return nil
+		},
+	}
+	return pause
+}
@@ -164,7 +164,8 @@ const (
 	apiViewTemplate = `
 Plugin:     {{var "plugin"}}
 Implements: {{range $spi := .Implements}}{{$spi.Name}}/{{$spi.Version}} {{end}}
-Interfaces: {{range $iface := .Interfaces}}
+{{if .Circuits}}Circuits:   {{range $address, $state := .Circuits}}{{$address}}={{$state}} {{end}}
+{{end}}Interfaces: {{range $iface := .Interfaces}}
   SPI:      {{$iface.Name}}/{{$iface.Version}}
   RPC:      {{range $method := $iface.Methods}}
     Method: {{$method.Request | q "method" }}
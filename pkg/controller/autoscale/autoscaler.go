@@ -0,0 +1,440 @@
+package autoscale
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller"
+	autoscale "github.com/docker/infrakit/pkg/controller/autoscale/types"
+	"github.com/docker/infrakit/pkg/discovery"
+	"github.com/docker/infrakit/pkg/manager"
+	"github.com/docker/infrakit/pkg/plugin"
+	group_rpc "github.com/docker/infrakit/pkg/rpc/group"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/types"
+	"golang.org/x/net/context"
+)
+
+// autoscaler implements the internal.Managed interface.
+// It periodically reads the value of a metric, from a metadata path or an event topic, and resizes a group
+// so that the value stays near a target.  The size is kept within the bounds of the spec, and changes are
+// held back for a cooldown period after each resize.
+type autoscaler struct {
+	manager.Leadership
+
+	spec       types.Spec
+	properties autoscale.Properties
+	options    autoscale.Options
+
+	plugins func() discovery.Plugins
+
+	poller *controller.Poller
+	ticker <-chan time.Time
+	lock   sync.RWMutex
+
+	groupPlugin group.Plugin // the plugin of the group being scaled
+	groupLookup string       // the lookup name of the group plugin
+	metric      metricSource // source of the value driving the size
+	state       autoscale.State
+	running     bool
+}
+
+func newAutoscaler(plugins func() discovery.Plugins,
+	leader manager.Leadership, options autoscale.Options) *autoscaler {
+	a := &autoscaler{
+		Leadership: leader,
+		plugins:    plugins,
+		options:    options,
+	}
+
+	interval := a.options.SyncInterval.Duration()
+	if interval == 0 {
+		interval = autoscale.DefaultSyncInterval
+	}
+	a.ticker = time.Tick(interval)
+
+	return a
+}
+
+// newPoller returns the poller that syncs the size of the group at each tick.  A poller cannot be run again once
+// stopped, so a new one is made each time the autoscaler is started.
+func (a *autoscaler) newPoller() *controller.Poller {
+	return controller.Poll(
+		// This determines if the action should be taken when time is up
+		func() bool {
+			return mustTrue(a.IsLeader())
+		},
+		// This does the work.  Errors are kept in the state rather than returned, to keep the poller running.
+		func() (err error) {
+			if err := a.sync(); err != nil {
+				log.Warn("Cannot autoscale", "name", a.spec.Metadata.Name, "err", err)
+			}
+			return nil
+		},
+		a.ticker)
+}
+
+func mustTrue(v bool, e error) bool {
+	if e != nil {
+		return false
+	}
+	return v
+}
+
+// object returns the state
+func (a *autoscaler) object() (*types.Object, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	state, err := types.AnyValue(a.state)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Object{
+		Spec:  a.spec,
+		State: state,
+	}, nil
+}
+
+// Plan implements internal.Managed.Plan
+func (a *autoscaler) Plan(operation controller.Operation, spec types.Spec) (*types.Object, *controller.Plan, error) {
+
+	if operation == controller.Destroy {
+		o, err := a.object()
+		return o, &controller.Plan{
+			Message: []string{fmt.Sprintf("Stop autoscaling group %s", a.currentProperties().Group)},
+		}, err
+	}
+
+	properties, err := parseProperties(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gp, gid, err := a.getGroupPlugin(properties.Group)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size, err := gp.Size(gid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &controller.Plan{
+		Message: []string{fmt.Sprintf("Group %s has %d instances, bounded by %d to %d",
+			properties.Group, size, properties.Min, properties.Max)},
+	}
+
+	state := autoscale.State{Size: uint(size)}
+
+	value, err := a.planValue(properties)
+	if err != nil {
+		plan.Message = append(plan.Message, fmt.Sprintf("Cannot read the metric: %v", err))
+		state.Error = err.Error()
+	} else {
+		desired, held := a.resize(properties, uint(size), value, time.Now())
+		plan.Message = append(plan.Message, fmt.Sprintf("Metric value is %v against a target of %v",
+			value, properties.Target))
+
+		switch {
+		case held != "":
+			plan.Message = append(plan.Message, held)
+		case desired == uint(size):
+			plan.Message = append(plan.Message, "No change to the size of the group")
+		default:
+			plan.Message = append(plan.Message, fmt.Sprintf("Resize the group from %d to %d", size, desired))
+		}
+		state.Value = &value
+		state.Desired = desired
+	}
+
+	return &types.Object{
+		Spec:  spec,
+		State: types.AnyValueMust(state),
+	}, plan, nil
+}
+
+// planValue reads the metric for a plan.  Event topics cannot be read on demand, so the value is only available
+// if the topic is already being watched.
+func (a *autoscaler) planValue(properties autoscale.Properties) (float64, error) {
+	if properties.Metric == a.currentProperties().Metric {
+		if source := a.currentMetric(); source != nil {
+			return source.Value()
+		}
+	}
+
+	if properties.Metric.Event != "" {
+		return 0, fmt.Errorf("waiting for an event on topic %s", properties.Metric.Event)
+	}
+
+	source, err := newMetricSource(a.plugins, properties.Metric)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Stop()
+	return source.Value()
+}
+
+func parseProperties(spec types.Spec) (autoscale.Properties, error) {
+	properties := autoscale.Properties{}
+	if spec.Properties == nil {
+		return properties, fmt.Errorf("missing properties")
+	}
+	if err := spec.Properties.Decode(&properties); err != nil {
+		return properties, err
+	}
+	return properties, properties.Validate()
+}
+
+func (a *autoscaler) updateSpec(spec types.Spec) error {
+	properties, err := parseProperties(spec)
+	if err != nil {
+		return err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if spec.Options != nil {
+		options := autoscale.Options{}
+		if err := spec.Options.Decode(&options); err != nil {
+			return err
+		}
+		a.options = options
+	}
+
+	// Watch the new metric from the next sync
+	if a.metric != nil && properties.Metric != a.properties.Metric {
+		a.metric.Stop()
+		a.metric = nil
+	}
+	a.properties = properties
+	a.spec = spec
+	// set identity
+	a.spec.Metadata.Identity = &types.Identity{
+		ID: a.spec.Metadata.Name,
+	}
+	return nil
+}
+
+func (a *autoscaler) currentProperties() autoscale.Properties {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.properties
+}
+
+func (a *autoscaler) currentMetric() metricSource {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.metric
+}
+
+func (a *autoscaler) getMetric(properties autoscale.Properties) (metricSource, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.metric == nil {
+		source, err := newMetricSource(a.plugins, properties.Metric)
+		if err != nil {
+			return nil, err
+		}
+		a.metric = source
+	}
+	return a.metric, nil
+}
+
+func (a *autoscaler) getGroupPlugin(name plugin.Name) (group.Plugin, group.ID, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	lookup, gid := name.GetLookupAndType()
+	if a.groupPlugin != nil && a.groupLookup == lookup {
+		return a.groupPlugin, group.ID(gid), nil
+	}
+
+	endpoint, err := a.plugins().Find(plugin.Name(lookup))
+	if err != nil {
+		return nil, "", err
+	}
+	gp, err := group_rpc.NewClient(endpoint.Address)
+	if err != nil {
+		return nil, "", err
+	}
+	a.groupPlugin = gp
+	a.groupLookup = lookup
+	return gp, group.ID(gid), nil
+}
+
+// resize returns the size the group should be set to now.  If a change of size is held back by a cooldown, the
+// current size is returned along with the reason.
+func (a *autoscaler) resize(properties autoscale.Properties, current uint, value float64,
+	now time.Time) (uint, string) {
+
+	desired := properties.DesiredSize(current, value)
+
+	a.lock.RLock()
+	last := a.state.LastScaled
+	a.lock.RUnlock()
+
+	switch {
+	case desired > current && now.Sub(last) < properties.ScaleUpCooldown.Duration():
+		return current, fmt.Sprintf("Growing the group to %d is held by the cooldown until %v",
+			desired, last.Add(properties.ScaleUpCooldown.Duration()))
+	case desired < current && now.Sub(last) < properties.ScaleDownCooldown.Duration():
+		return current, fmt.Sprintf("Shrinking the group to %d is held by the cooldown until %v",
+			desired, last.Add(properties.ScaleDownCooldown.Duration()))
+	}
+	return desired, ""
+}
+
+func (a *autoscaler) setState(update func(*autoscale.State)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	update(&a.state)
+}
+
+// sync reads the metric and resizes the group if necessary.
+func (a *autoscaler) sync() (err error) {
+	defer func() {
+		a.setState(func(s *autoscale.State) {
+			s.Error = ""
+			if err != nil {
+				s.Error = err.Error()
+			}
+		})
+	}()
+
+	properties := a.currentProperties()
+
+	gp, gid, err := a.getGroupPlugin(properties.Group)
+	if err != nil {
+		return err
+	}
+
+	size, err := gp.Size(gid)
+	if err != nil {
+		return err
+	}
+
+	source, err := a.getMetric(properties)
+	if err != nil {
+		return err
+	}
+
+	value, err := source.Value()
+	if err != nil {
+		a.setState(func(s *autoscale.State) {
+			s.Size = uint(size)
+			s.Value = nil
+		})
+		return err
+	}
+
+	now := time.Now()
+	desired, held := a.resize(properties, uint(size), value, now)
+	a.setState(func(s *autoscale.State) {
+		s.Size = uint(size)
+		s.Value = &value
+		s.Desired = properties.DesiredSize(uint(size), value)
+	})
+
+	if held != "" {
+		log.Debug("Holding", "group", properties.Group, "reason", held, "V", debugV)
+		return nil
+	}
+	if desired == uint(size) {
+		return nil
+	}
+
+	log.Info("Resizing group", "group", properties.Group, "value", value, "from", size, "to", desired)
+	if err := gp.SetSize(gid, int(desired)); err != nil {
+		return err
+	}
+
+	a.setState(func(s *autoscale.State) {
+		s.Size = desired
+		s.LastScaled = now
+	})
+	return nil
+}
+
+// Enforce implements internal.Managed.Enforce
+func (a *autoscaler) Enforce(spec types.Spec) (*types.Object, error) {
+	log.Debug("Enforce", "spec", spec, "V", debugV)
+
+	if err := a.updateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	a.Start()
+	return a.object()
+}
+
+// Inspect implements internal.Managed.Inspect
+func (a *autoscaler) Inspect() (*types.Object, error) {
+	return a.object()
+}
+
+// Free implements internal.Managed.Free
+func (a *autoscaler) Free() (*types.Object, error) {
+	return a.Pause()
+}
+
+// Pause implements internal.Managed.Pause
+func (a *autoscaler) Pause() (*types.Object, error) {
+	if a.Running() {
+		a.Stop()
+	}
+	return a.Inspect()
+}
+
+// Terminate implements internal.Managed.Terminate.  The group is left at its current size.
+func (a *autoscaler) Terminate() (*types.Object, error) {
+	if a.Running() {
+		a.Stop()
+	}
+	return a.object()
+}
+
+// Start implements internal/ControlLoop.Start
+func (a *autoscaler) Start() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.running {
+		return
+	}
+	a.poller = a.newPoller()
+	go a.poller.Run(context.Background())
+	a.running = true
+}
+
+// Stop implements internal/ControlLoop.Stop
+func (a *autoscaler) Stop() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.poller != nil {
+		a.poller.Stop()
+		a.poller = nil
+	}
+	if a.metric != nil {
+		a.metric.Stop()
+		a.metric = nil
+	}
+	a.running = false
+	return nil
+}
+
+// Running implements internal/ControlLoop.Running
+func (a *autoscaler) Running() bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.running
+}
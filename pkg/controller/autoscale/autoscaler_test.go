@@ -0,0 +1,205 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller"
+	autoscale "github.com/docker/infrakit/pkg/controller/autoscale/types"
+	"github.com/docker/infrakit/pkg/discovery"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/group"
+	group_test "github.com/docker/infrakit/pkg/testing/group"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLeader func() (bool, error)
+
+func (f fakeLeader) IsLeader() (bool, error) {
+	return f()
+}
+
+type fakePlugins map[string]*plugin.Endpoint
+
+func (f fakePlugins) Find(name plugin.Name) (*plugin.Endpoint, error) {
+	lookup, _ := name.GetLookupAndType()
+	if v, has := f[lookup]; has {
+		return v, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func (f fakePlugins) List() (map[string]*plugin.Endpoint, error) {
+	return (map[string]*plugin.Endpoint)(f), nil
+}
+
+type fakeMetric struct {
+	value float64
+	err   error
+}
+
+func (f *fakeMetric) Value() (float64, error) {
+	return f.value, f.err
+}
+
+func (f *fakeMetric) Stop() {
+}
+
+func TestAutoscaler(t *testing.T) {
+
+	size := 4
+	resized := []int{}
+
+	autoscaler := newAutoscaler(
+		func() discovery.Plugins {
+			return fakePlugins{
+				"group": &plugin.Endpoint{},
+			}
+		},
+		fakeLeader(func() (bool, error) { return false, nil }),
+		autoscale.Options{})
+	autoscaler.groupPlugin = &group_test.Plugin{
+		DoSize: func(gid group.ID) (int, error) {
+			require.Equal(t, group.ID("workers"), gid)
+			return size, nil
+		},
+		DoSetSize: func(gid group.ID, n int) error {
+			require.Equal(t, group.ID("workers"), gid)
+			size = n
+			resized = append(resized, n)
+			return nil
+		},
+	}
+	autoscaler.groupLookup = "group"
+
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: autoscale
+metadata:
+  name: workers
+properties:
+  Group: group/workers
+  Metric:
+    Metadata: metrics/workers/cpu
+  Min: 2
+  Max: 10
+  Target: 50
+  ScaleDownCooldown: 1h
+`)).Decode(&spec))
+
+	require.NoError(t, autoscaler.updateSpec(spec))
+
+	metric := &fakeMetric{value: 75}
+	autoscaler.metric = metric
+
+	_, plan, err := autoscaler.Plan(controller.Enforce, spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Group group/workers has 4 instances, bounded by 2 to 10",
+		"Metric value is 75 against a target of 50",
+		"Resize the group from 4 to 6",
+	}, plan.Message)
+	require.Empty(t, resized)
+
+	require.NoError(t, autoscaler.sync())
+	require.Equal(t, []int{6}, resized)
+
+	// The group is not shrunk during the cooldown
+	metric.value = 10
+	require.NoError(t, autoscaler.sync())
+	require.Equal(t, []int{6}, resized)
+
+	object, err := autoscaler.Inspect()
+	require.NoError(t, err)
+	state := autoscale.State{}
+	require.NoError(t, object.State.Decode(&state))
+	require.Equal(t, uint(6), state.Size)
+	require.Equal(t, uint(2), state.Desired)
+	require.Equal(t, 10., *state.Value)
+	require.False(t, state.LastScaled.IsZero())
+
+	_, plan, err = autoscaler.Plan(controller.Enforce, spec)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(plan.Message))
+	require.Contains(t, plan.Message[2], "Shrinking the group to 2 is held by the cooldown")
+
+	// Errors reading the metric are reported in the state
+	metric.err = fmt.Errorf("boom")
+	require.Error(t, autoscaler.sync())
+	object, err = autoscaler.Inspect()
+	require.NoError(t, err)
+	require.NoError(t, object.State.Decode(&state))
+	require.Equal(t, "boom", state.Error)
+	require.Equal(t, []int{6}, resized)
+}
+
+func TestEventMetric(t *testing.T) {
+	metric := &eventMetric{topic: "metrics/cpu", done: make(chan struct{})}
+
+	_, err := metric.Value()
+	require.Error(t, err)
+
+	metric.update(event.Event{ID: "1"}.Init().WithDataMust(42))
+	v, err := metric.Value()
+	require.NoError(t, err)
+	require.Equal(t, 42., v)
+
+	metric.update(event.Event{ID: "2"}.Init().WithDataMust("12.5"))
+	v, err = metric.Value()
+	require.NoError(t, err)
+	require.Equal(t, 12.5, v)
+
+	metric.update(event.Event{ID: "3"}.Init().WithDataMust("not a number"))
+	_, err = metric.Value()
+	require.Error(t, err)
+
+	metric.Stop()
+}
+
+func TestAutoscalerRestart(t *testing.T) {
+
+	polled := make(chan struct{}, 1)
+	autoscaler := newAutoscaler(
+		func() discovery.Plugins { return fakePlugins{} },
+		fakeLeader(func() (bool, error) {
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+			return false, nil
+		}),
+		autoscale.Options{SyncInterval: types.FromDuration(10 * time.Millisecond)})
+
+	waitPolled := func() {
+		select {
+		case <-polled:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "not polled")
+		}
+	}
+
+	autoscaler.Start()
+	require.True(t, autoscaler.Running())
+	waitPolled()
+
+	// Stopping more than once, as by Pause and then Terminate, is not an error
+	_, err := autoscaler.Pause()
+	require.NoError(t, err)
+	require.False(t, autoscaler.Running())
+	_, err = autoscaler.Terminate()
+	require.NoError(t, err)
+	require.NoError(t, autoscaler.Stop())
+
+	// Polling resumes once started again, as by an Enforce after a Free
+	select {
+	case <-polled:
+	default:
+	}
+	autoscaler.Start()
+	require.True(t, autoscaler.Running())
+	waitPolled()
+	require.NoError(t, autoscaler.Stop())
+}
@@ -0,0 +1,50 @@
+package autoscale
+
+import (
+	"github.com/docker/infrakit/pkg/controller"
+	autoscale "github.com/docker/infrakit/pkg/controller/autoscale/types"
+	"github.com/docker/infrakit/pkg/controller/internal"
+	"github.com/docker/infrakit/pkg/discovery"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/manager"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var (
+	log    = logutil.New("module", "controller/autoscale")
+	debugV = logutil.V(200)
+)
+
+// NewController returns a controller implementation
+func NewController(plugins func() discovery.Plugins, leader manager.Leadership,
+	options autoscale.Options) controller.Controller {
+	return internal.NewController(
+		leader,
+		// the constructor
+		func(spec types.Spec) (internal.Managed, error) {
+			return newAutoscaler(plugins, leader, options), nil
+		},
+		// the key function
+		func(metadata types.Metadata) string {
+			return metadata.Name
+		},
+	)
+}
+
+// NewTypedControllers return typed controllers
+func NewTypedControllers(plugins func() discovery.Plugins, leader manager.Leadership,
+	options autoscale.Options) func() (map[string]controller.Controller, error) {
+
+	return (internal.NewController(
+		leader,
+		// the constructor
+		func(spec types.Spec) (internal.Managed, error) {
+			log.Debug("Creating managed object", "spec", spec)
+			return newAutoscaler(plugins, leader, options), nil
+		},
+		// the key function
+		func(metadata types.Metadata) string {
+			return metadata.Name
+		},
+	)).ManagedObjects
+}
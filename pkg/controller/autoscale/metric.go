@@ -0,0 +1,160 @@
+package autoscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	autoscale "github.com/docker/infrakit/pkg/controller/autoscale/types"
+	"github.com/docker/infrakit/pkg/discovery"
+	"github.com/docker/infrakit/pkg/plugin"
+	metadata_template "github.com/docker/infrakit/pkg/plugin/metadata/template"
+	event_rpc "github.com/docker/infrakit/pkg/rpc/event"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// metricSource provides the current value of the metric that drives the size of a group.
+type metricSource interface {
+	// Value returns the current value of the metric.
+	Value() (float64, error)
+
+	// Stop releases the resources held by the source.
+	Stop()
+}
+
+func newMetricSource(plugins func() discovery.Plugins, metric autoscale.Metric) (metricSource, error) {
+	if metric.Metadata != "" {
+		return &metadataMetric{
+			path: metric.Metadata,
+			get:  metadata_template.MetadataFunc(plugins),
+		}, nil
+	}
+	return subscribe(plugins, metric.Event)
+}
+
+// metadataMetric reads the value of the metric from a metadata path each time.
+type metadataMetric struct {
+	path string
+	get  func(string) (interface{}, error)
+}
+
+func (m *metadataMetric) Value() (float64, error) {
+	v, err := m.get(m.path)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat(v)
+}
+
+func (m *metadataMetric) Stop() {
+}
+
+// eventMetric keeps the value of the metric from the most recent event on a topic.
+type eventMetric struct {
+	topic string
+	value *float64
+	err   error
+	lock  sync.Mutex
+	done  chan<- struct{}
+}
+
+func subscribe(plugins func() discovery.Plugins, topic string) (*eventMetric, error) {
+	path := types.PathFromString(topic).Clean()
+	first := path.Index(0)
+	if first == nil || path.Len() < 2 {
+		return nil, fmt.Errorf("bad event topic: %s", topic)
+	}
+
+	endpoint, err := plugins().Find(plugin.Name(*first))
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := event_rpc.NewClient(endpoint.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriber, is := client.(event.Subscriber)
+	if !is {
+		return nil, fmt.Errorf("not a subscriber: %s", *first)
+	}
+
+	stream, done, err := subscriber.SubscribeOn(path.Shift(1))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &eventMetric{topic: topic, done: done}
+	go m.receive(stream)
+	return m, nil
+}
+
+func (m *eventMetric) receive(stream <-chan *event.Event) {
+	for evt := range stream {
+		m.update(evt)
+	}
+	log.Info("Event stream closed", "topic", m.topic)
+}
+
+func (m *eventMetric) update(evt *event.Event) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if evt.Type == event.TypeError {
+		m.err = fmt.Errorf("error from event topic %s: %s", m.topic, evt.Error)
+		return
+	}
+
+	var v interface{}
+	if evt.Data != nil {
+		if err := evt.Data.Decode(&v); err != nil {
+			m.err = err
+			return
+		}
+	}
+
+	value, err := toFloat(v)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.value = &value
+	m.err = nil
+}
+
+func (m *eventMetric) Value() (float64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.value == nil {
+		return 0, fmt.Errorf("no event received yet on topic %s", m.topic)
+	}
+	return *m.value, nil
+}
+
+func (m *eventMetric) Stop() {
+	close(m.done)
+}
+
+// toFloat converts a value decoded from metadata or event data to a number.
+func toFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
@@ -0,0 +1,171 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/run/depends"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// DefaultSyncInterval is the default interval for reading the metric and resizing the group
+	DefaultSyncInterval = 10 * time.Second
+)
+
+func init() {
+	depends.Register("autoscale", types.InterfaceSpec(controller.InterfaceSpec), ResolveDependencies)
+}
+
+// ResolveDependencies returns a list of dependencies by parsing the opaque Properties blob.
+func ResolveDependencies(spec types.Spec) ([]plugin.Name, error) {
+	if spec.Properties == nil {
+		return nil, nil
+	}
+
+	properties := Properties{}
+	err := spec.Properties.Decode(&properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return []plugin.Name{properties.Group}, nil
+}
+
+// Metric is the source of the value that drives the size of the group.  Exactly one of the fields is set.
+type Metric struct {
+
+	// Metadata is a metadata path of the form plugin_name/path/to/value.  The value is read at every sync.
+	Metadata string `json:",omitempty" yaml:",omitempty"`
+
+	// Event is an event topic of the form plugin_name/path/to/topic.  The value is the data of the most
+	// recent event published on the topic.
+	Event string `json:",omitempty" yaml:",omitempty"`
+}
+
+// Step is a fixed adjustment of the group size, applied when the difference between the value of the metric
+// and the target falls within its bounds.
+type Step struct {
+
+	// LowerBound is the smallest difference (inclusive) to which the step applies.  Unbounded if not set.
+	LowerBound *float64 `json:",omitempty" yaml:",omitempty"`
+
+	// UpperBound is the largest difference (exclusive) to which the step applies.  Unbounded if not set.
+	UpperBound *float64 `json:",omitempty" yaml:",omitempty"`
+
+	// Adjustment is the number of instances added to the group, or removed if negative.
+	Adjustment int
+}
+
+// Applies returns true if the difference between the value and the target falls within the bounds of the step.
+func (s Step) Applies(difference float64) bool {
+	return (s.LowerBound == nil || difference >= *s.LowerBound) &&
+		(s.UpperBound == nil || difference < *s.UpperBound)
+}
+
+// Properties is the schema of the configuration in the types.Spec.Properties
+type Properties struct {
+
+	// Group is the name of the group to scale, e.g. group/workers
+	Group plugin.Name
+
+	// Metric is the source of the value that drives the size of the group
+	Metric Metric
+
+	// Min is the smallest size of the group
+	Min uint
+
+	// Max is the largest size of the group
+	Max uint
+
+	// Target is the value of the metric that the group is sized to maintain.  Without Steps, the metric is
+	// taken to be a per-instance load (e.g. CPU utilization) and the group is resized in proportion to the
+	// value over the target.
+	Target float64
+
+	// Steps, if set, adjust the size of the group by fixed amounts instead, depending on how far the value of
+	// the metric is from the target.  The first step that applies is used.
+	Steps []Step `json:",omitempty" yaml:",omitempty"`
+
+	// ScaleUpCooldown is the time after the group was last resized before it may be grown.
+	ScaleUpCooldown types.Duration `json:",omitempty" yaml:",omitempty"`
+
+	// ScaleDownCooldown is the time after the group was last resized before it may be shrunk.
+	ScaleDownCooldown types.Duration `json:",omitempty" yaml:",omitempty"`
+}
+
+// Validate checks the properties for errors
+func (p Properties) Validate() error {
+	if p.Group == "" {
+		return errors.New("missing group")
+	}
+	if (p.Metric.Metadata == "") == (p.Metric.Event == "") {
+		return errors.New("exactly one of metadata path or event topic must be set for the metric")
+	}
+	if p.Max == 0 {
+		return errors.New("max must be greater than 0")
+	}
+	if p.Min > p.Max {
+		return fmt.Errorf("min %d is greater than max %d", p.Min, p.Max)
+	}
+	if len(p.Steps) == 0 && p.Target <= 0 {
+		return errors.New("target must be greater than 0 without steps")
+	}
+	return nil
+}
+
+// DesiredSize returns the size of the group for the given value of the metric, within the bounds of the
+// group.  Cooldowns are not considered.
+func (p Properties) DesiredSize(current uint, value float64) uint {
+	desired := float64(current)
+
+	if len(p.Steps) > 0 {
+		for _, step := range p.Steps {
+			if step.Applies(value - p.Target) {
+				desired += float64(step.Adjustment)
+				break
+			}
+		}
+	} else if p.Target > 0 {
+		desired = math.Ceil(float64(current) * value / p.Target)
+	}
+
+	switch {
+	case desired < float64(p.Min):
+		return p.Min
+	case desired > float64(p.Max):
+		return p.Max
+	}
+	return uint(desired)
+}
+
+// Options is the controller options
+type Options struct {
+
+	// SyncInterval is the time interval between reading the metric and resizing the group. Syntax
+	// is go's time.Duration string representation (e.g. 1m, 30s)
+	SyncInterval types.Duration
+}
+
+// State is the observed state of an autoscaled group
+type State struct {
+
+	// Size is the size of the group when last observed
+	Size uint
+
+	// Value is the value of the metric when last observed
+	Value *float64 `json:",omitempty" yaml:",omitempty"`
+
+	// Desired is the size the group should have for the value
+	Desired uint
+
+	// LastScaled is the time the group was last resized by the controller
+	LastScaled time.Time `json:",omitempty" yaml:",omitempty"`
+
+	// Error is the error encountered in the last sync, if any
+	Error string `json:",omitempty" yaml:",omitempty"`
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+func TestParseProperties(t *testing.T) {
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: autoscale
+metadata:
+  name: workers
+properties:
+  Group: group/workers
+  Metric:
+    Metadata: metrics/workers/cpu
+  Min: 2
+  Max: 10
+  Target: 50
+  ScaleUpCooldown: 1m
+`)).Decode(&spec))
+
+	p := Properties{}
+	require.NoError(t, spec.Properties.Decode(&p))
+	require.NoError(t, p.Validate())
+	require.Equal(t, plugin.Name("group/workers"), p.Group)
+	require.Equal(t, "metrics/workers/cpu", p.Metric.Metadata)
+	require.Equal(t, 50., p.Target)
+	require.Equal(t, "1m0s", p.ScaleUpCooldown.Duration().String())
+
+	deps, err := ResolveDependencies(spec)
+	require.NoError(t, err)
+	require.Equal(t, []plugin.Name{"group/workers"}, deps)
+}
+
+func TestValidate(t *testing.T) {
+	valid := Properties{Group: "group/workers", Metric: Metric{Event: "metrics/cpu"}, Max: 3, Target: 1}
+	require.NoError(t, valid.Validate())
+
+	p := valid
+	p.Group = ""
+	require.Error(t, p.Validate())
+
+	p = valid
+	p.Metric.Metadata = "metrics/cpu"
+	require.Error(t, p.Validate())
+
+	p = valid
+	p.Metric = Metric{}
+	require.Error(t, p.Validate())
+
+	p = valid
+	p.Min = 4
+	require.Error(t, p.Validate())
+
+	p = valid
+	p.Target = 0
+	require.Error(t, p.Validate())
+
+	p.Steps = []Step{{Adjustment: 1}}
+	require.NoError(t, p.Validate())
+}
+
+func TestDesiredSizeTargetTracking(t *testing.T) {
+	p := Properties{Min: 2, Max: 10, Target: 50}
+
+	require.Equal(t, uint(4), p.DesiredSize(4, 50))
+	require.Equal(t, uint(6), p.DesiredSize(4, 75))
+	require.Equal(t, uint(3), p.DesiredSize(4, 30))
+	require.Equal(t, uint(2), p.DesiredSize(4, 1))
+	require.Equal(t, uint(10), p.DesiredSize(4, 500))
+	require.Equal(t, uint(2), p.DesiredSize(0, 100))
+}
+
+func TestDesiredSizeSteps(t *testing.T) {
+	p := Properties{
+		Min:    1,
+		Max:    10,
+		Target: 100,
+		Steps: []Step{
+			{LowerBound: bound(50), Adjustment: 3},
+			{LowerBound: bound(0), UpperBound: bound(50), Adjustment: 1},
+			{UpperBound: bound(-50), Adjustment: -2},
+		},
+	}
+
+	require.Equal(t, uint(8), p.DesiredSize(5, 200))
+	require.Equal(t, uint(6), p.DesiredSize(5, 120))
+	require.Equal(t, uint(6), p.DesiredSize(5, 100))
+	require.Equal(t, uint(5), p.DesiredSize(5, 80))
+	require.Equal(t, uint(3), p.DesiredSize(5, 10))
+	require.Equal(t, uint(1), p.DesiredSize(2, 10))
+	require.Equal(t, uint(10), p.DesiredSize(9, 200))
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"github.com/docker/infrakit/pkg/spi"
+	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -9,6 +10,12 @@ import (
 type Plan struct {
 	// Message contains human-friendly message
 	Message []string
+
+	// Changes are the field-level differences between the current and the committed spec
+	Changes []types.Change `json:",omitempty"`
+
+	// Instances are the instances destroyed, created or left alone, for controllers that manage instances
+	Instances *instance.Plan `json:",omitempty"`
 }
 
 // Operation is the action to be taken for a commit
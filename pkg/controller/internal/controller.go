@@ -125,7 +125,7 @@ func (c *Controller) Plan(operation controller.Operation,
 		object = *o
 	}
 	if p != nil {
-		plan = controller.Plan{}
+		plan = *p
 	}
 	err = e
 	return
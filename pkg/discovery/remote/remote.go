@@ -11,6 +11,8 @@ import (
 	"github.com/docker/infrakit/pkg/discovery"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -77,6 +79,15 @@ func (r *remotePluginDiscovery) List() (map[string]*plugin.Endpoint, error) {
 		// for each remote, we issue the OPTIONS call to get information about
 		// master, plugins
 		c := &http.Client{}
+		if remote.Scheme == "https" {
+			// Verify the remote and present the certificate set by the environment, if any
+			tlsConfig, err := rpc.DefaultTLSOptions().ClientConfig()
+			if err != nil {
+				return nil, err
+			}
+			c.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		}
+		c.Transport = auth.ClientTransport(c.Transport)
 
 		// List of plugins and leadership information is available via HTTP OPTIONS call
 		body, err := doHTTPOptions(remote, nil, c)
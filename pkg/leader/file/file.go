@@ -18,6 +18,15 @@ var log = logutil.New("module", "leader/file")
 // This implementation checks a file for its content.  If the content matches the id of the detector
 // then this instance is the leader.
 func NewDetector(pollInterval time.Duration, filename, id string) (*leader.Poller, error) {
+	check, err := checkFile(filename, id)
+	if err != nil {
+		return nil, err
+	}
+	return leader.NewPoller(pollInterval, check), nil
+}
+
+// checkFile returns the func that checks if the content of the file matches the id
+func checkFile(filename, id string) (leader.CheckLeaderFunc, error) {
 	// file must exist
 	info, err := os.Stat(filename)
 	if err != nil {
@@ -28,16 +37,15 @@ func NewDetector(pollInterval time.Duration, filename, id string) (*leader.Polle
 		return nil, fmt.Errorf("file %s must be a file", filename)
 	}
 
-	return leader.NewPoller(pollInterval,
-		func() (bool, error) {
-			content, err := ioutil.ReadFile(filename)
+	return func() (bool, error) {
+		content, err := ioutil.ReadFile(filename)
 
-			match := strings.Trim(string(content), " \t\n")
+		match := strings.Trim(string(content), " \t\n")
 
-			log.Debug("poll for leadership", "id", id, "file", filename, "match", match, "err", err, "V", logutil.V(500))
+		log.Debug("poll for leadership", "id", id, "file", filename, "match", match, "err", err, "V", logutil.V(500))
 
-			return match == id, err
-		}), nil
+		return match == id, err
+	}, nil
 }
 
 // Store is the location of a file that stores the location of the leader
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/util/flock"
+)
+
+// NewLeaseDetector returns a detector that holds the leadership by a lease, kept in the lease file.  As with
+// NewDetector, the node whose id is in the leader file is elected, but it only becomes the leader once the lease of
+// the previous leader is released or has expired.
+func NewLeaseDetector(pollInterval, ttl time.Duration, filename, leaseFile, id string) (*leader.Poller, error) {
+	check, err := checkFile(filename, id)
+	if err != nil {
+		return nil, err
+	}
+	return leader.NewLeasePoller(pollInterval, ttl, id, check, NewLeaseStore(leaseFile)), nil
+}
+
+// LeaseStore is the location of a file that stores the lease of leadership.  The file is locked while the lease is
+// swapped, so the lease can be shared by processes on the same host or on a shared volume that supports locks.
+type LeaseStore string
+
+// NewLeaseStore returns the lease store implementation
+func NewLeaseStore(s string) leader.LeaseStore {
+	return LeaseStore(s)
+}
+
+// GetLease returns the current lease, or the zero lease if the file does not exist.
+func (s LeaseStore) GetLease() (leader.Lease, error) {
+	lease := leader.Lease{}
+	content, err := ioutil.ReadFile(string(s))
+	if os.IsNotExist(err) || (err == nil && len(content) == 0) {
+		return lease, nil
+	}
+	if err != nil {
+		return lease, err
+	}
+	err = json.Unmarshal(content, &lease)
+	return lease, err
+}
+
+// CompareAndSwapLease writes the next lease if the lease in the file is still the expected one.
+func (s LeaseStore) CompareAndSwapLease(expected, next leader.Lease) (bool, error) {
+	f, err := os.OpenFile(string(s), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if err := flock.Lock(f); err != nil {
+		return false, err
+	}
+	defer flock.Unlock(f)
+
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return false, err
+	}
+	current := leader.Lease{}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &current); err != nil {
+			return false, err
+		}
+	}
+	if !current.Same(expected) {
+		log.Debug("lease changed", "file", string(s), "expected", expected, "current", current)
+		return false, nil
+	}
+
+	buff, err := json.Marshal(next)
+	if err != nil {
+		return false, err
+	}
+	if err := f.Truncate(0); err != nil {
+		return false, err
+	}
+	if _, err := f.WriteAt(buff, 0); err != nil {
+		return false, err
+	}
+	return true, f.Sync()
+}
@@ -0,0 +1,74 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-file-test-lease")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewLeaseStore(filepath.Join(dir, "lease"))
+
+	lease, err := store.GetLease()
+	require.NoError(t, err)
+	require.Equal(t, leader.Lease{}, lease)
+
+	first := leader.Lease{Holder: "instance1", Epoch: 1, Expires: time.Now().Add(time.Minute)}
+	swapped, err := store.CompareAndSwapLease(leader.Lease{}, first)
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	lease, err = store.GetLease()
+	require.NoError(t, err)
+	require.True(t, first.Same(lease))
+
+	// Swapping from a lease that has changed since fails
+	swapped, err = store.CompareAndSwapLease(leader.Lease{}, leader.Lease{Holder: "instance2", Epoch: 1})
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	second := leader.Lease{Holder: "instance2", Epoch: 2, Expires: time.Now().Add(time.Minute)}
+	swapped, err = store.CompareAndSwapLease(lease, second)
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	lease, err = store.GetLease()
+	require.NoError(t, err)
+	require.True(t, second.Same(lease))
+}
+
+func TestLeaseDetector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-file-test-lease")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	leaderFile := filepath.Join(dir, "leader")
+	require.NoError(t, ioutil.WriteFile(leaderFile, []byte("instance1"), 0644))
+
+	detector, err := NewLeaseDetector(10*time.Millisecond, time.Second, leaderFile, leaderFile+".lease", "instance1")
+	require.NoError(t, err)
+
+	events, err := detector.Start()
+	require.NoError(t, err)
+
+	for event := range events {
+		if event.Status == leader.Leader {
+			require.Equal(t, uint64(1), event.Epoch)
+			break
+		}
+	}
+	detector.Stop()
+
+	lease, err := NewLeaseStore(leaderFile + ".lease").GetLease()
+	require.NoError(t, err)
+	require.Equal(t, "instance1", lease.Holder)
+}
@@ -1,7 +1,9 @@
 package leader
 
 import (
+	"fmt"
 	"net/url"
+	"time"
 )
 
 // Status indicates leadership status
@@ -38,7 +40,30 @@ type Store interface {
 // Leadership is a struct that captures the leadership state, possibly error if exception occurs
 type Leadership struct {
 	Status Status
-	Error  error
+
+	// Epoch is the fencing token of the leadership.  It increases each time the leadership changes hands, so
+	// operations carrying the epoch of an earlier leader can be told apart and rejected.  Zero if the detector
+	// does not keep epochs.
+	Epoch uint64
+
+	// Expires is when the leadership lapses unless it is renewed.  Zero if the detector does not hold leases.
+	Expires time.Time
+
+	Error error
+}
+
+// ErrStaleEpoch is the error returned when an operation carries the epoch of a leadership that has since changed
+// hands.
+type ErrStaleEpoch struct {
+	// Epoch is the epoch of the operation rejected
+	Epoch uint64
+
+	// Current is the latest epoch known
+	Current uint64
+}
+
+func (e ErrStaleEpoch) Error() string {
+	return fmt.Sprintf("stale epoch %d, current epoch is %d", e.Epoch, e.Current)
 }
 
 // Detector is the interface for determining whether this instance is a leader
@@ -0,0 +1,112 @@
+package leader
+
+import (
+	"time"
+)
+
+// Lease is the lease of leadership shared by all the candidates.  Only the holder of an unexpired lease acts as the
+// leader.
+type Lease struct {
+	// Holder is the id of the node holding the lease
+	Holder string
+
+	// Epoch is incremented each time the lease is acquired.  It is the fencing token of the leadership.
+	Epoch uint64
+
+	// Expires is when the lease expires unless it is renewed
+	Expires time.Time
+}
+
+// Same returns true if the leases are the same.  Times are compared as instants, since they may have been through
+// an encoding.
+func (l Lease) Same(other Lease) bool {
+	return l.Holder == other.Holder && l.Epoch == other.Epoch && l.Expires.Equal(other.Expires)
+}
+
+// LeaseStore is implemented by the environment that persists the lease of leadership.
+type LeaseStore interface {
+
+	// GetLease returns the current lease.  The zero Lease is returned if no lease has been acquired yet.
+	GetLease() (Lease, error)
+
+	// CompareAndSwapLease replaces the current lease with next, only if the current lease is the same as
+	// expected.  It returns false if the lease has been changed since.
+	CompareAndSwapLease(expected, next Lease) (bool, error)
+}
+
+// NewLeasePoller returns a detector that holds the leadership by a lease.  The check function elects the leader, as
+// with NewPoller, but the node elected only becomes the leader once it acquires the lease, which requires the lease
+// of the previous leader to be released or expired.  The leader renews its lease at each poll, so the ttl must be
+// longer than the poll interval.  A leader that cannot renew its lease in time loses the leadership.  Since the
+// expiration is compared across nodes, the clocks of the nodes are assumed to be reasonably synchronized.
+func NewLeasePoller(pollInterval, ttl time.Duration, id string, check CheckLeaderFunc, store LeaseStore) *Poller {
+	l := &leaseHolder{
+		id:    id,
+		ttl:   ttl,
+		check: check,
+		store: store,
+		now:   time.Now,
+	}
+	return NewLeadershipPoller(pollInterval, l.poll)
+}
+
+type leaseHolder struct {
+	id    string
+	ttl   time.Duration
+	check CheckLeaderFunc
+	store LeaseStore
+	now   func() time.Time
+}
+
+func (l *leaseHolder) poll() Leadership {
+	elected, err := l.check()
+	if err != nil {
+		return Leadership{Status: Unknown, Error: err}
+	}
+
+	current, err := l.store.GetLease()
+	if err != nil {
+		return Leadership{Status: Unknown, Error: err}
+	}
+
+	now := l.now()
+	held := current.Holder == l.id && now.Before(current.Expires)
+
+	var next Lease
+	switch {
+
+	case !elected:
+		if held {
+			// Release the lease so that the next leader does not have to wait for it to expire.
+			released := current
+			released.Expires = now
+			if _, err := l.store.CompareAndSwapLease(current, released); err != nil {
+				log.Warn("Cannot release lease", "id", l.id, "err", err)
+			}
+		}
+		return Leadership{Status: NotLeader}
+
+	case held:
+		next = current
+		next.Expires = now.Add(l.ttl)
+
+	case !now.Before(current.Expires):
+		next = Lease{Holder: l.id, Epoch: current.Epoch + 1, Expires: now.Add(l.ttl)}
+
+	default:
+		log.Debug("Elected but the lease is held", "id", l.id, "holder", current.Holder, "expires", current.Expires)
+		return Leadership{Status: NotLeader}
+	}
+
+	swapped, err := l.store.CompareAndSwapLease(current, next)
+	if err != nil {
+		return Leadership{Status: Unknown, Error: err}
+	}
+	if !swapped {
+		return Leadership{Status: NotLeader}
+	}
+	if next.Epoch != current.Epoch {
+		log.Info("Acquired lease", "id", l.id, "epoch", next.Epoch)
+	}
+	return Leadership{Status: Leader, Epoch: next.Epoch, Expires: next.Expires}
+}
@@ -0,0 +1,103 @@
+package leader
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memLeaseStore struct {
+	lease Lease
+	lock  sync.Mutex
+}
+
+func (s *memLeaseStore) GetLease() (Lease, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lease, nil
+}
+
+func (s *memLeaseStore) CompareAndSwapLease(expected, next Lease) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.lease.Same(expected) {
+		return false, nil
+	}
+	s.lease = next
+	return true, nil
+}
+
+func TestLeaseHolder(t *testing.T) {
+	store := &memLeaseStore{}
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+
+	electedA, electedB := true, false
+	a := &leaseHolder{id: "a", ttl: 10 * time.Second, store: store, now: clock,
+		check: func() (bool, error) { return electedA, nil }}
+	b := &leaseHolder{id: "b", ttl: 10 * time.Second, store: store, now: clock,
+		check: func() (bool, error) { return electedB, nil }}
+
+	// a acquires the first epoch
+	l := a.poll()
+	require.Equal(t, Leader, l.Status)
+	require.Equal(t, uint64(1), l.Epoch)
+	require.Equal(t, now.Add(10*time.Second), l.Expires)
+
+	require.Equal(t, NotLeader, b.poll().Status)
+
+	// a renews the lease without changing the epoch
+	now = now.Add(5 * time.Second)
+	l = a.poll()
+	require.Equal(t, Leader, l.Status)
+	require.Equal(t, uint64(1), l.Epoch)
+	require.Equal(t, now.Add(10*time.Second), l.Expires)
+
+	// b is elected but a has not stopped polling yet; b must wait for the lease to expire
+	electedB = true
+	require.Equal(t, NotLeader, b.poll().Status)
+
+	// a stops renewing: b takes over once the lease expires, with a new epoch
+	now = now.Add(10 * time.Second)
+	l = b.poll()
+	require.Equal(t, Leader, l.Status)
+	require.Equal(t, uint64(2), l.Epoch)
+
+	// a is stale and cannot take the lease back
+	require.Equal(t, NotLeader, a.poll().Status)
+
+	// b steps down and releases the lease, so a takes over without waiting
+	electedB = false
+	require.Equal(t, NotLeader, b.poll().Status)
+	l = a.poll()
+	require.Equal(t, Leader, l.Status)
+	require.Equal(t, uint64(3), l.Epoch)
+}
+
+// racingLeaseStore lets another node take the lease between the read and the swap
+type racingLeaseStore struct {
+	*memLeaseStore
+	race Lease
+}
+
+func (s *racingLeaseStore) GetLease() (Lease, error) {
+	current, err := s.memLeaseStore.GetLease()
+	s.memLeaseStore.lease = s.race
+	return current, err
+}
+
+func TestLeaseHolderLostRace(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := &racingLeaseStore{
+		memLeaseStore: &memLeaseStore{},
+		race:          Lease{Holder: "b", Epoch: 1, Expires: now.Add(10 * time.Second)},
+	}
+
+	a := &leaseHolder{id: "a", ttl: 10 * time.Second, store: store, now: func() time.Time { return now },
+		check: func() (bool, error) { return true, nil }}
+
+	require.Equal(t, NotLeader, a.poll().Status)
+	require.Equal(t, "b", store.lease.Holder)
+}
@@ -14,7 +14,7 @@ type Poller struct {
 	pollInterval time.Duration
 	tick         <-chan time.Time
 	stop         chan struct{}
-	pollFunc     CheckLeaderFunc
+	pollFunc     func() Leadership
 	store        Store
 	lock         sync.Mutex
 	receivers    []chan Leadership
@@ -22,6 +22,27 @@ type Poller struct {
 
 // NewPoller returns a detector implementation given the poll interval and function that polls
 func NewPoller(pollInterval time.Duration, f CheckLeaderFunc) *Poller {
+	return NewLeadershipPoller(pollInterval, func() Leadership {
+		isLeader, err := f()
+		event := Leadership{}
+		if err != nil {
+			event.Status = Unknown
+			event.Error = err
+		} else {
+			if isLeader {
+				event.Status = Leader
+
+			} else {
+				event.Status = NotLeader
+			}
+		}
+		return event
+	})
+}
+
+// NewLeadershipPoller returns a detector implementation given the poll interval and the function that polls for the
+// leadership, for backends that know more than whether this is the leader, such as the epoch of the leadership.
+func NewLeadershipPoller(pollInterval time.Duration, f func() Leadership) *Poller {
 	return &Poller{
 		pollInterval: pollInterval,
 		tick:         time.Tick(pollInterval),
@@ -70,19 +91,7 @@ func (l *Poller) poll() {
 
 		case <-l.tick:
 
-			isLeader, err := l.pollFunc()
-			event := Leadership{}
-			if err != nil {
-				event.Status = Unknown
-				event.Error = err
-			} else {
-				if isLeader {
-					event.Status = Leader
-
-				} else {
-					event.Status = NotLeader
-				}
-			}
+			event := l.pollFunc()
 
 			for _, receiver := range l.receivers {
 				receiver <- event
@@ -0,0 +1,54 @@
+package raft
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/util/raft"
+)
+
+var log = logutil.New("module", "leader/raft")
+
+// NewDetector returns an implementation of leader detector.  The leader of the raft cluster is the leader, and the
+// raft term is the epoch of the leadership.
+func NewDetector(pollInterval time.Duration, store *raft.Store) *leader.Poller {
+	return leader.NewLeadershipPoller(pollInterval, func() leader.Leadership {
+		isLeader, term := store.Status()
+		log.Debug("poll for leadership", "id", store.ID(), "leader", isLeader, "term", term, "V", logutil.V(500))
+		if isLeader {
+			return leader.Leadership{Status: leader.Leader, Epoch: term}
+		}
+		return leader.Leadership{Status: leader.NotLeader}
+	})
+}
+
+const (
+	// DefaultKey is the key used to persist the location
+	DefaultKey = "infrakit/leader/location"
+)
+
+// Store stores the location of the leader in the raft cluster
+type Store struct {
+	store *raft.Store
+}
+
+// NewStore returns a store for registration of leader location
+func NewStore(s *raft.Store) leader.Store {
+	return &Store{store: s}
+}
+
+// UpdateLocation writes the location.  Only the leader can update the location.
+func (s Store) UpdateLocation(location *url.URL) error {
+	return s.store.Put(DefaultKey, []byte(location.String()))
+}
+
+// GetLocation returns the location of the leader, as known to this node
+func (s Store) GetLocation() (*url.URL, error) {
+	v, has := s.store.Get(DefaultKey)
+	if !has {
+		return nil, nil
+	}
+	return url.Parse(string(v))
+}
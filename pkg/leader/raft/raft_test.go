@@ -0,0 +1,48 @@
+package raft
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/util/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRaftDetectorAndStore(t *testing.T) {
+	s, err := raft.NewStore(raft.Config{
+		ID:                "m1",
+		Members:           []string{"m1"},
+		HeartbeatInterval: 5 * time.Millisecond,
+		ElectionTimeout:   20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	s.Start()
+	defer s.Stop()
+
+	detector := NewDetector(10*time.Millisecond, s)
+	events, err := detector.Start()
+	require.NoError(t, err)
+
+	for event := range events {
+		if event.Status == leader.Leader {
+			require.True(t, event.Epoch > 0)
+			break
+		}
+	}
+	detector.Stop()
+
+	store := NewStore(s)
+	u, err := store.GetLocation()
+	require.NoError(t, err)
+	require.Nil(t, u)
+
+	loc, err := url.Parse("tcp://10.10.1.100:24864")
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateLocation(loc))
+
+	u, err = store.GetLocation()
+	require.NoError(t, err)
+	require.Equal(t, loc.String(), u.String())
+}
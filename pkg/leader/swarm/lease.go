@@ -0,0 +1,84 @@
+package swarm
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/util/docker"
+	"golang.org/x/net/context"
+)
+
+const (
+	// LeaseLabel is the label for the swarm annotation that stores the lease of leadership
+	LeaseLabel = "infrakit.leader.lease"
+)
+
+// NewLeaseDetector returns a detector that holds the leadership by a lease, kept in the swarm annotations.  As with
+// NewDetector, the swarm leader is elected, but it only becomes the leader once the lease of the previous leader is
+// released or has expired.  The id identifies this node as the holder of the lease.
+func NewLeaseDetector(pollInterval, ttl time.Duration, id string, client docker.APIClientCloser) *leader.Poller {
+	return leader.NewLeasePoller(pollInterval, ttl, id,
+		func() (bool, error) {
+			return amISwarmLeader(context.Background(), client)
+		},
+		NewLeaseStore(client))
+}
+
+// LeaseStore stores the lease of leadership in the swarm annotations
+type LeaseStore struct {
+	client docker.APIClientCloser
+}
+
+// NewLeaseStore constructs a lease store
+func NewLeaseStore(c docker.APIClientCloser) leader.LeaseStore {
+	return &LeaseStore{client: c}
+}
+
+func (s LeaseStore) inspect() (swarm.Swarm, leader.Lease, error) {
+	lease := leader.Lease{}
+	info, err := s.client.SwarmInspect(context.Background())
+	if err != nil {
+		return info, lease, err
+	}
+	if info.ClusterInfo.Spec.Annotations.Labels != nil {
+		if l, has := info.ClusterInfo.Spec.Annotations.Labels[LeaseLabel]; has {
+			err = json.Unmarshal([]byte(l), &lease)
+		}
+	}
+	return info, lease, err
+}
+
+// GetLease returns the current lease
+func (s LeaseStore) GetLease() (leader.Lease, error) {
+	_, lease, err := s.inspect()
+	return lease, err
+}
+
+// CompareAndSwapLease updates the lease if it is still the expected one.  The update is made against the version of
+// the swarm inspected, so the update fails with an error if the swarm has been updated since.
+func (s LeaseStore) CompareAndSwapLease(expected, next leader.Lease) (bool, error) {
+	info, current, err := s.inspect()
+	if err != nil {
+		return false, err
+	}
+	if !current.Same(expected) {
+		log.Debug("lease changed", "expected", expected, "current", current)
+		return false, nil
+	}
+	buff, err := json.Marshal(next)
+	if err != nil {
+		return false, err
+	}
+	if info.ClusterInfo.Spec.Annotations.Labels == nil {
+		info.ClusterInfo.Spec.Annotations.Labels = map[string]string{}
+	}
+	info.ClusterInfo.Spec.Annotations.Labels[LeaseLabel] = string(buff)
+	err = s.client.SwarmUpdate(context.Background(), info.ClusterInfo.Meta.Version, info.ClusterInfo.Spec,
+		swarm.UpdateFlags{})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
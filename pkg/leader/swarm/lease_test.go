@@ -0,0 +1,56 @@
+package swarm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/infrakit/pkg/leader"
+	mock_client "github.com/docker/infrakit/pkg/mock/docker/docker/client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwarmLeaseStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_client.NewMockAPIClientCloser(ctrl)
+
+	store := NewLeaseStore(client)
+
+	swarmInfo := swarm.Swarm{}
+	swarmInfo.Version = swarm.Version{Index: 10}
+
+	client.EXPECT().SwarmInspect(gomock.Any()).Return(swarmInfo, nil)
+	lease, err := store.GetLease()
+	require.NoError(t, err)
+	require.Equal(t, leader.Lease{}, lease)
+
+	next := leader.Lease{Holder: "node1", Epoch: 1, Expires: time.Now().Add(time.Minute)}
+	buff, err := json.Marshal(next)
+	require.NoError(t, err)
+	expectedSpec := swarm.Spec{
+		Annotations: swarm.Annotations{
+			Labels: map[string]string{LeaseLabel: string(buff)},
+		},
+	}
+
+	client.EXPECT().SwarmInspect(gomock.Any()).Return(swarmInfo, nil)
+	client.EXPECT().SwarmUpdate(gomock.Any(), swarm.Version{Index: 10}, expectedSpec, swarm.UpdateFlags{}).Return(nil)
+	swapped, err := store.CompareAndSwapLease(leader.Lease{}, next)
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	// The lease has changed: no update is attempted
+	swarmInfo.Spec = expectedSpec
+	client.EXPECT().SwarmInspect(gomock.Any()).Return(swarmInfo, nil)
+	swapped, err = store.CompareAndSwapLease(leader.Lease{}, leader.Lease{Holder: "node2", Epoch: 1})
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	client.EXPECT().SwarmInspect(gomock.Any()).Return(swarmInfo, nil)
+	lease, err = store.GetLease()
+	require.NoError(t, err)
+	require.True(t, next.Same(lease))
+}
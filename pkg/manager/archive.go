@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// ArchiveVersion is the version of the format of the archives written by Export
+const ArchiveVersion = 1
+
+// Archive is a portable copy of the specs stored by the manager, independent of the backend, for backups and for
+// migrating between backends.
+type Archive struct {
+	// Version is the version of the format of the archive
+	Version int
+
+	// Time is when the archive was exported
+	Time time.Time
+
+	// Revisions are the revisions of the specs, oldest first.  The last revision has the current specs.
+	Revisions []ArchivedRevision
+}
+
+// ArchivedRevision is a revision of the specs in an archive
+type ArchivedRevision struct {
+	store.Revision
+
+	// Specs are the specs committed in the revision
+	Specs []ArchivedSpec
+}
+
+// ArchivedSpec is a spec and the plugin that handles it
+type ArchivedSpec struct {
+	// Handler is the plugin that handles the spec
+	Handler plugin.Name
+
+	// Spec is the spec
+	Spec types.Spec
+}
+
+func archivedRevision(revision store.Revision, stored globalSpec) ArchivedRevision {
+	specs := []ArchivedSpec{}
+	for _, p := range stored.sorted() {
+		specs = append(specs, ArchivedSpec{Handler: p.Record.Handler, Spec: p.Record.Spec})
+	}
+	return ArchivedRevision{Revision: revision, Specs: specs}
+}
+
+func (r ArchivedRevision) globalSpec() globalSpec {
+	stored := globalSpec{}
+	for _, s := range r.Specs {
+		stored.updateSpec(s.Spec, s.Handler)
+	}
+	stored.data = stored.sorted()
+	return stored
+}
+
+// Export reads the specs stored in the snapshot into an archive, with their history if the snapshot keeps one.
+func Export(snapshot store.Snapshot) (Archive, error) {
+	archive := Archive{
+		Version:   ArchiveVersion,
+		Time:      time.Now(),
+		Revisions: []ArchivedRevision{},
+	}
+
+	versioned, is := snapshot.(store.Versioned)
+	if !is {
+		stored := globalSpec{}
+		if err := stored.load(snapshot); err != nil {
+			return archive, err
+		}
+		if len(stored.index) > 0 {
+			revision := store.Revision{Number: 1, Time: archive.Time, Message: "Exported without history"}
+			archive.Revisions = append(archive.Revisions, archivedRevision(revision, stored))
+		}
+		return archive, nil
+	}
+
+	revisions, err := versioned.Revisions()
+	if err != nil {
+		return archive, err
+	}
+	for _, revision := range revisions {
+		stored := globalSpec{}
+		if err := stored.loadRevision(snapshot, revision.Number); err != nil {
+			return archive, err
+		}
+		archive.Revisions = append(archive.Revisions, archivedRevision(revision, stored))
+	}
+	return archive, nil
+}
+
+// Validate returns an error if the archive cannot be imported: if its format is unknown, if its revisions are out
+// of order, or if any revision has specs that are incomplete, duplicated or with cyclic dependencies.
+func (a Archive) Validate() error {
+	if a.Version < 1 || a.Version > ArchiveVersion {
+		return fmt.Errorf("unsupported archive version %d", a.Version)
+	}
+	for i, revision := range a.Revisions {
+		if i > 0 && revision.Number <= a.Revisions[i-1].Number {
+			return fmt.Errorf("revision %d is out of order", revision.Number)
+		}
+		specs := []types.Spec{}
+		for _, s := range revision.Specs {
+			if s.Spec.Kind == "" || s.Spec.Metadata.Name == "" {
+				return fmt.Errorf("revision %d has a spec without kind or name", revision.Number)
+			}
+			if s.Handler == "" {
+				return fmt.Errorf("revision %d has no handler for %v", revision.Number, keyOf(s.Spec))
+			}
+			specs = append(specs, s.Spec)
+		}
+		if _, err := orderByDependencies(specs); err != nil {
+			return fmt.Errorf("revision %d is invalid: %v", revision.Number, err)
+		}
+	}
+	return nil
+}
+
+// Import validates the archive and writes it into the snapshot.  If the snapshot keeps a history, the revisions are
+// committed again in order, with their authors and messages but timed at the import; otherwise only the last
+// revision is saved.  The snapshot must be empty unless overwrite is true.  The specs are read back once written, to check that the
+// snapshot has the specs of the archive.
+func Import(archive Archive, snapshot store.Snapshot, overwrite bool) error {
+	if err := archive.Validate(); err != nil {
+		return err
+	}
+	if len(archive.Revisions) == 0 {
+		return fmt.Errorf("no revisions in archive")
+	}
+
+	if !overwrite {
+		current := globalSpec{}
+		if err := current.load(snapshot); err != nil {
+			return err
+		}
+		if len(current.index) > 0 {
+			return fmt.Errorf("the store has specs already")
+		}
+	}
+
+	revisions := archive.Revisions
+	if _, is := snapshot.(store.Versioned); !is {
+		revisions = revisions[len(revisions)-1:]
+	}
+	for _, revision := range revisions {
+		stored := revision.globalSpec()
+		message := revision.Message
+		if message == "" {
+			message = fmt.Sprintf("Import revision %d", revision.Number)
+		}
+		if err := stored.commit(snapshot, revision.Author, message); err != nil {
+			return err
+		}
+	}
+
+	expected := archive.Revisions[len(archive.Revisions)-1].globalSpec()
+	written := globalSpec{}
+	if err := written.load(snapshot); err != nil {
+		return err
+	}
+	if written.fingerprint() != expected.fingerprint() {
+		return fmt.Errorf("the specs read back differ from the specs imported")
+	}
+	return nil
+}
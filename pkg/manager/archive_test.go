@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/mem"
+	"github.com/docker/infrakit/pkg/store/versioned"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	source := versioned.NewSnapshot(&memSnapshot{}, mem.NewStore("revision"), 0)
+
+	stored := globalSpec{}
+	stored.updateGroupSpec(group.Spec{ID: "workers", Properties: types.AnyValueMust(3)}, "group-stateless")
+	require.NoError(t, stored.commit(source, "alice", "Start workers"))
+	stored.updateGroupSpec(group.Spec{ID: "managers", Properties: types.AnyValueMust(1)}, "group-stateless")
+	require.NoError(t, stored.commit(source, "bob", "Start managers"))
+
+	archive, err := Export(source)
+	require.NoError(t, err)
+	require.Equal(t, ArchiveVersion, archive.Version)
+	require.Equal(t, 2, len(archive.Revisions))
+	require.Equal(t, "alice", archive.Revisions[0].Author)
+	require.Equal(t, 1, len(archive.Revisions[0].Specs))
+	require.Equal(t, 2, len(archive.Revisions[1].Specs))
+
+	// The archive is portable
+	buff, err := json.Marshal(archive)
+	require.NoError(t, err)
+	archive = Archive{}
+	require.NoError(t, json.Unmarshal(buff, &archive))
+
+	// Into a store with history
+	target := versioned.NewSnapshot(&memSnapshot{}, mem.NewStore("revision"), 0)
+	require.NoError(t, Import(archive, target, false))
+
+	revisions, err := target.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revisions))
+	require.Equal(t, "bob", revisions[1].Author)
+	require.Equal(t, "Start managers", revisions[1].Message)
+
+	imported := globalSpec{}
+	require.NoError(t, imported.load(target))
+	require.Equal(t, stored.fingerprint(), imported.fingerprint())
+
+	// The target must be empty
+	require.Error(t, Import(archive, target, false))
+	require.NoError(t, Import(archive, target, true))
+
+	// Into a store without history
+	plain := &memSnapshot{}
+	require.NoError(t, Import(archive, plain, false))
+	imported = globalSpec{}
+	require.NoError(t, imported.load(plain))
+	require.Equal(t, stored.fingerprint(), imported.fingerprint())
+
+	exported, err := Export(plain)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(exported.Revisions))
+	require.Equal(t, archive.Revisions[1].Specs, exported.Revisions[0].Specs)
+}
+
+func TestArchiveValidate(t *testing.T) {
+	spec := func(name string, depends ...string) ArchivedSpec {
+		s := ArchivedSpec{Handler: plugin.Name("group-stateless"), Spec: types.Spec{Kind: "group"}}
+		s.Spec.Metadata.Name = name
+		for _, d := range depends {
+			s.Spec.Depends = append(s.Spec.Depends, types.Dependency{Kind: "group", Name: d})
+		}
+		return s
+	}
+	archive := func(revisions ...ArchivedRevision) Archive {
+		return Archive{Version: ArchiveVersion, Revisions: revisions}
+	}
+	revision := func(number int, specs ...ArchivedSpec) ArchivedRevision {
+		return ArchivedRevision{Revision: store.Revision{Number: number}, Specs: specs}
+	}
+
+	require.NoError(t, archive(revision(1, spec("a")), revision(2, spec("a"), spec("b", "a"))).Validate())
+
+	require.Error(t, Archive{Version: ArchiveVersion + 1}.Validate())
+	require.Error(t, archive(revision(2, spec("a")), revision(1, spec("a"))).Validate())
+	require.Error(t, archive(revision(1, spec(""))).Validate())
+	require.Error(t, archive(revision(1, spec("a"), spec("a"))).Validate())
+	require.Error(t, archive(revision(1, spec("a", "b"), spec("b", "a"))).Validate())
+
+	noHandler := spec("a")
+	noHandler.Handler = ""
+	require.Error(t, archive(revision(1, noHandler)).Validate())
+
+	// Nothing to import
+	require.Error(t, Import(archive(), &memSnapshot{}, false))
+}
@@ -75,8 +75,10 @@ func (c *pController) Plan(operation controller.Operation,
 
 	plan = controller.Plan{}
 	object = objectFromSpec(spec)
-	if resp, cerr := c.plugin.CommitGroup(gSpec, true); cerr == nil {
-		plan.Message = []string{resp}
+	if resp, cerr := c.plugin.PlanCommit(gSpec); cerr == nil {
+		plan.Message = []string{resp.Explain}
+		plan.Changes = resp.Changes
+		plan.Instances = &resp.Instances
 	} else {
 		err = cerr
 	}
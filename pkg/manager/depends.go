@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/infrakit/pkg/controller"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/template"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// specKey identifies a spec by its kind and name, as referenced by the dependencies of other specs.
+type specKey struct {
+	kind string
+	name string
+}
+
+func (k specKey) String() string {
+	return k.kind + "/" + k.name
+}
+
+func keyOf(spec types.Spec) specKey {
+	return specKey{kind: spec.Kind, name: spec.Metadata.Name}
+}
+
+// controllerName returns the name of the controller that manages the object of the given kind and name.  This
+// follows how plugins are started from specs: a kind without a subtype is qualified by the name of the object.
+func controllerName(kind, name string) plugin.Name {
+	pn := plugin.Name(kind)
+	if lookup, sub := pn.GetLookupAndType(); sub == "" {
+		return plugin.NameFrom(lookup, name)
+	}
+	return pn
+}
+
+// orderByDependencies sorts the specs so that every spec comes after the specs it depends on.  Dependencies on
+// specs that are not in the input are assumed to exist already.  Error is returned if the dependencies form a
+// cycle.
+func orderByDependencies(specs []types.Spec) ([]types.Spec, error) {
+	byKey := map[specKey]types.Spec{}
+	keys := []specKey{}
+	for _, spec := range specs {
+		key := keyOf(spec)
+		if _, has := byKey[key]; has {
+			return nil, fmt.Errorf("duplicate spec %v", key)
+		}
+		byKey[key] = spec
+		keys = append(keys, key)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[specKey]int{}
+	ordered := []types.Spec{}
+	path := []specKey{}
+
+	var visit func(key specKey) error
+	visit = func(key specKey) error {
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := []string{}
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]string{path[i].String()}, cycle...)
+				if path[i] == key {
+					break
+				}
+			}
+			return fmt.Errorf("dependency cycle: %s -> %v", strings.Join(cycle, " -> "), key)
+		}
+
+		state[key] = visiting
+		path = append(path, key)
+
+		spec := byKey[key]
+		for _, d := range spec.Depends {
+			dep := specKey{kind: d.Kind, name: d.Name}
+			if _, has := byKey[dep]; !has {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[key] = visited
+		ordered = append(ordered, spec)
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := visit(key); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// bindDependencies returns the values bound by the dependencies of the spec, by variable name.  The values are
+// read with the pointers of each dependency from the object described by its controller.
+func bindDependencies(spec types.Spec,
+	lookup func(plugin.Name) (controller.Controller, error)) (map[string]interface{}, error) {
+
+	bound := map[string]interface{}{}
+	for _, d := range spec.Depends {
+		if len(d.Bind) == 0 {
+			continue
+		}
+
+		key := specKey{kind: d.Kind, name: d.Name}
+		c, err := lookup(controllerName(d.Kind, d.Name))
+		if err != nil {
+			return nil, fmt.Errorf("cannot find controller for %v: %v", key, err)
+		}
+
+		objects, err := c.Describe(&types.Metadata{Name: d.Name})
+		if err != nil {
+			return nil, fmt.Errorf("cannot describe %v: %v", key, err)
+		}
+		if len(objects) == 0 {
+			return nil, fmt.Errorf("no object found for %v", key)
+		}
+
+		var object interface{}
+		if err := types.AnyValueMust(objects[0]).Decode(&object); err != nil {
+			return nil, err
+		}
+
+		names := []string{}
+		for name := range d.Bind {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			pointer := d.Bind[name]
+			if pointer == nil {
+				return nil, fmt.Errorf("missing pointer for %s in dependency %v", name, key)
+			}
+			value := pointer.Get(object)
+			if value == nil {
+				return nil, fmt.Errorf("cannot bind %s: no value at %v in %v", name, pointer, key)
+			}
+			bound[name] = value
+		}
+	}
+	return bound, nil
+}
+
+// placeholder returns the name bound by the value if it is a placeholder, like @bind(addr)
+func placeholder(v interface{}) (string, bool) {
+	str, is := v.(string)
+	if !is || !strings.HasPrefix(str, "@bind(") || !strings.HasSuffix(str, ")") {
+		return "", false
+	}
+	return str[len("@bind(") : len(str)-1], true
+}
+
+// substitute returns the value with the placeholders replaced by the values bound, and true if any was replaced.
+func substitute(v interface{}, bound map[string]interface{}) (interface{}, bool, error) {
+	if name, is := placeholder(v); is {
+		value, has := bound[name]
+		if !has {
+			return nil, false, fmt.Errorf("no binding for %v", name)
+		}
+		return value, true, nil
+	}
+
+	replaced := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, e := range v {
+			value, changed, err := substitute(e, bound)
+			if err != nil {
+				return nil, false, err
+			}
+			out[k] = value
+			replaced = replaced || changed
+		}
+		return out, replaced, nil
+	case []interface{}:
+		out := []interface{}{}
+		for _, e := range v {
+			value, changed, err := substitute(e, bound)
+			if err != nil {
+				return nil, false, err
+			}
+			out = append(out, value)
+			replaced = replaced || changed
+		}
+		return out, replaced, nil
+	}
+	return v, false, nil
+}
+
+// resolveSpec returns the spec with the values bound from its dependencies injected.  In the properties, only the
+// values that are exactly a placeholder @bind(name) are replaced by the value bound to the name; the properties are
+// otherwise left as is, so the templates meant for the plugins (e.g. init scripts) are not evaluated here.  The
+// template of the spec, if any, is rendered with the bound values as variables (e.g. {{ var `addr` }}), and the
+// properties override the same fields of the rendered template.  Specs without placeholders or a template are
+// returned as is.
+func resolveSpec(spec types.Spec, bound map[string]interface{}) (types.Spec, error) {
+	var properties interface{}
+	replaced := false
+	if spec.Properties != nil {
+		if err := spec.Properties.Decode(&properties); err != nil {
+			return spec, err
+		}
+		p, changed, err := substitute(properties, bound)
+		if err != nil {
+			return spec, fmt.Errorf("cannot bind properties of %v: %v", keyOf(spec), err)
+		}
+		properties, replaced = p, changed
+	}
+
+	if spec.Template == nil && !replaced {
+		return spec, nil
+	}
+
+	if spec.Template != nil {
+		t, err := template.NewTemplate(spec.Template.String(), template.Options{})
+		if err != nil {
+			return spec, err
+		}
+		for name, value := range bound {
+			t.Global(name, value)
+		}
+		view, err := t.Render(nil)
+		if err != nil {
+			return spec, fmt.Errorf("cannot render template %v: %v", spec.Template, err)
+		}
+		var rendered interface{}
+		if err := types.AnyString(view).Decode(&rendered); err != nil {
+			return spec, fmt.Errorf("cannot render template %v: %v", spec.Template, err)
+		}
+		if properties == nil {
+			properties = rendered
+		} else {
+			properties = mergeProperties(rendered, properties)
+		}
+	}
+
+	any, err := types.AnyValue(properties)
+	if err != nil {
+		return spec, err
+	}
+	resolved := spec
+	resolved.Properties = any
+	return resolved, nil
+}
+
+// mergeProperties returns the base value with the fields of override replacing the same fields.  Objects are
+// merged field by field, while any other value of the override replaces the base.
+func mergeProperties(base, override interface{}) interface{} {
+	b, is := base.(map[string]interface{})
+	if !is {
+		return override
+	}
+	o, is := override.(map[string]interface{})
+	if !is {
+		return override
+	}
+
+	merged := map[string]interface{}{}
+	for k, v := range b {
+		merged[k] = v
+	}
+	for k, v := range o {
+		merged[k] = mergeProperties(merged[k], v)
+	}
+	return merged
+}
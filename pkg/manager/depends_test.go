@@ -0,0 +1,252 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/controller"
+	"github.com/docker/infrakit/pkg/plugin"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func specsFromYAML(t *testing.T, s string) []types.Spec {
+	specs := []types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(s)).Decode(&specs))
+	return specs
+}
+
+func names(specs []types.Spec) []string {
+	out := []string{}
+	for _, spec := range specs {
+		out = append(out, keyOf(spec).String())
+	}
+	return out
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	specs := specsFromYAML(t, `
+- kind: group
+  metadata:
+    name: workers
+  depends:
+    - kind: group
+      name: managers
+    - kind: ingress
+      name: lb
+- kind: ingress
+  metadata:
+    name: lb
+  depends:
+    - kind: resource
+      name: network
+- kind: group
+  metadata:
+    name: managers
+  depends:
+    - kind: ingress
+      name: lb
+`)
+
+	ordered, err := orderByDependencies(specs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ingress/lb", "group/managers", "group/workers"}, names(ordered))
+}
+
+func TestOrderByDependenciesCycle(t *testing.T) {
+	specs := specsFromYAML(t, `
+- kind: group
+  metadata:
+    name: a
+  depends:
+    - kind: group
+      name: b
+- kind: group
+  metadata:
+    name: b
+  depends:
+    - kind: group
+      name: c
+- kind: group
+  metadata:
+    name: c
+  depends:
+    - kind: group
+      name: b
+`)
+
+	_, err := orderByDependencies(specs)
+	require.Error(t, err)
+	require.Equal(t, "dependency cycle: group/b -> group/c -> group/b", err.Error())
+
+	_, err = orderByDependencies(append(specs, specs[0]))
+	require.Error(t, err)
+}
+
+func TestMergeProperties(t *testing.T) {
+	base := map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"x": 1, "y": 2},
+	}
+	override := map[string]interface{}{
+		"b": map[string]interface{}{"y": 3},
+		"c": "new",
+	}
+	require.Equal(t, map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"x": 1, "y": 3},
+		"c": "new",
+	}, mergeProperties(base, override))
+	require.Equal(t, override, mergeProperties(nil, override))
+}
+
+func TestEnforceWithDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-depends")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The template is rendered with the values bound as variables
+	templateFile := filepath.Join(dir, "workers.json")
+	require.NoError(t, ioutil.WriteFile(templateFile,
+		[]byte(`{ "Endpoint": "{{ var "addr" }}:{{ var "port" }}", "Size": 1 }`), 0644))
+
+	specs := specsFromYAML(t, `
+- kind: group
+  metadata:
+    name: workers
+  depends:
+    - kind: ingress
+      name: lb
+      bind:
+        addr: /state/Address
+        port: /state/Ports[0]
+  properties:
+    Address: '@bind(addr)'
+    Ports: [ '@bind(port)', 443 ]
+    Size: 3
+- kind: ingress
+  metadata:
+    name: lb
+  properties:
+    Ports: [ 80 ]
+`)
+	template, err := types.NewURL("file://" + templateFile)
+	require.NoError(t, err)
+	specs[0].Template = template
+
+	committed := []types.Spec{}
+	controllers := map[plugin.Name]*testing_controller.Controller{}
+	for _, name := range []plugin.Name{"group/workers", "ingress/lb"} {
+		controllers[name] = &testing_controller.Controller{
+			DoCommit: func(operation controller.Operation, spec types.Spec) (types.Object, error) {
+				require.Equal(t, controller.Operation(controller.Enforce), operation)
+				committed = append(committed, spec)
+				return types.Object{Spec: spec}, nil
+			},
+		}
+	}
+	controllers["ingress/lb"].DoDescribe = func(metadata *types.Metadata) ([]types.Object, error) {
+		require.Equal(t, "lb", metadata.Name)
+		return []types.Object{
+			{
+				Spec: specs[1],
+				State: types.AnyValueMust(map[string]interface{}{
+					"Address": "10.0.0.1",
+					"Ports":   []int{8080},
+				}),
+			},
+		}, nil
+	}
+
+	m := &manager{
+		snapshot: &memSnapshot{},
+		lookupController: func(name plugin.Name) (controller.Controller, error) {
+			if c, has := controllers[name]; has {
+				return c, nil
+			}
+			return nil, fmt.Errorf("not found: %v", name)
+		},
+	}
+	testStart(t, m)()
+	defer m.Stop()
+
+	require.NoError(t, m.Enforce(specs))
+	require.Equal(t, []string{"ingress/lb", "group/workers"}, names(committed))
+
+	properties := map[string]interface{}{}
+	require.NoError(t, committed[1].Properties.Decode(&properties))
+	require.Equal(t, "10.0.0.1:8080", properties["Endpoint"])
+	require.Equal(t, "10.0.0.1", properties["Address"])
+	require.Equal(t, []interface{}{float64(8080), float64(443)}, properties["Ports"])
+	require.Equal(t, float64(3), properties["Size"])
+
+	// Bindings that cannot be resolved fail the enforce
+	specs[0].Depends[0].Bind["missing"] = types.PointerFromString("/state/Missing")
+	committed = []types.Spec{}
+	err = m.Enforce(specs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot bind missing")
+	require.Equal(t, []string{"ingress/lb"}, names(committed))
+}
+
+func TestResolveSpecLeavesPluginTemplates(t *testing.T) {
+	specs := specsFromYAML(t, `
+- kind: group
+  metadata:
+    name: workers
+  depends:
+    - kind: ingress
+      name: lb
+      bind:
+        addr: /state/Address
+  properties:
+    Allocation:
+      Size: 3
+    Flavor:
+      Plugin: vanilla
+      Properties:
+        Init:
+          - echo {{ var "/cluster/name" }} > /etc/cluster
+          - 'echo "{{ include "setup.sh" }}"'
+`)
+	bound := map[string]interface{}{"addr": "10.0.0.1"}
+
+	// Without placeholders, the properties are not touched
+	resolved, err := resolveSpec(specs[0], bound)
+	require.NoError(t, err)
+	require.Equal(t, specs[0].Properties.Bytes(), resolved.Properties.Bytes())
+
+	// The placeholders are replaced, but not the templates of the plugins
+	properties := map[string]interface{}{}
+	require.NoError(t, specs[0].Properties.Decode(&properties))
+	properties["Address"] = "@bind(addr)"
+	specs[0].Properties = types.AnyValueMust(properties)
+
+	resolved, err = resolveSpec(specs[0], bound)
+	require.NoError(t, err)
+	init := struct {
+		Address string
+		Flavor  struct {
+			Properties struct {
+				Init []string
+			}
+		}
+	}{}
+	require.NoError(t, resolved.Properties.Decode(&init))
+	require.Equal(t, "10.0.0.1", init.Address)
+	require.Equal(t, []string{
+		`echo {{ var "/cluster/name" }} > /etc/cluster`,
+		`echo "{{ include "setup.sh" }}"`,
+	}, init.Flavor.Properties.Init)
+
+	// A placeholder without binding fails
+	properties["Port"] = "@bind(port)"
+	specs[0].Properties = types.AnyValueMust(properties)
+	_, err = resolveSpec(specs[0], bound)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no binding for port")
+}
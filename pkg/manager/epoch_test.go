@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/discovery/local"
+	"github.com/docker/infrakit/pkg/leader"
+	group_mock "github.com/docker/infrakit/pkg/mock/spi/group"
+	group_rpc "github.com/docker/infrakit/pkg/rpc/group"
+	"github.com/docker/infrakit/pkg/rpc/server"
+	"github.com/docker/infrakit/pkg/store/fenced"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleEpochRejected(t *testing.T) {
+	m := &manager{isLeader: true, epoch: 2}
+
+	run := func(epoch uint64) (bool, error) {
+		ran := false
+		done := make(chan error, 1)
+		m.runOp(backendOp{
+			name:      "test",
+			epoch:     epoch,
+			operation: func() error { ran = true; return nil },
+			done:      done,
+		})
+		return ran, <-done
+	}
+
+	ran, err := run(2)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	// Queued by an earlier leadership
+	ran, err = run(1)
+	require.Equal(t, leader.ErrStaleEpoch{Epoch: 1, Current: 2}, err)
+	require.False(t, ran)
+
+	// The lease has expired without being renewed
+	m.expires = time.Now().Add(-time.Second)
+	isLeader, err := m.IsLeader()
+	require.NoError(t, err)
+	require.False(t, isLeader)
+	require.Equal(t, uint64(0), m.Epoch())
+
+	ran, err = run(2)
+	require.Error(t, err)
+	require.False(t, ran)
+
+	m.expires = time.Now().Add(time.Minute)
+	require.Equal(t, uint64(2), m.Epoch())
+}
+
+func TestCommitOverFencedSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := testDiscoveryDir(t)
+	disc, err := local.NewPluginDiscoveryWithDir(dir)
+	require.NoError(t, err)
+
+	gm := group_mock.NewMockPlugin(ctrl)
+	gm.EXPECT().CommitGroup(gomock.Any(), false).Return("ok", nil).AnyTimes()
+	st, err := server.StartPluginAtPath(filepath.Join(dir, "group-stateless"), group_rpc.PluginServer(gm))
+	require.NoError(t, err)
+	defer st.Stop()
+
+	backend := &memSnapshot{}
+	var m Backend
+	snapshot := fenced.NewSnapshot(backend, func() uint64 { return m.Epoch() })
+
+	leaderChan := make(chan string)
+	defer close(leaderChan)
+	m = NewManager(disc, &testLeaderDetector{t: t, me: "m1", input: leaderChan}, nil, snapshot, "group-stateless")
+	m.Start()
+	defer m.Stop()
+	leaderChan <- "m1"
+
+	commit := func(properties string) error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := m.CommitGroup(testBuildGroupSpec("workers", properties), false)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "commit over a fenced snapshot does not return")
+		}
+		return nil
+	}
+
+	require.NoError(t, commit(`{"field1":"v1"}`))
+
+	stored := globalSpec{}
+	require.NoError(t, stored.load(snapshot))
+	spec, err := stored.getGroupSpec("workers")
+	require.NoError(t, err)
+	require.Equal(t, `{"field1":"v1"}`, spec.Properties.String())
+
+	// A successor saves the specs with a higher epoch
+	require.NoError(t, fenced.NewSnapshot(backend, func() uint64 { return 5 }).Save(stored.data))
+
+	err = commit(`{"field1":"v2"}`)
+	require.Error(t, err)
+	require.Equal(t, leader.ErrStaleEpoch{Epoch: 0, Current: 5}, err)
+}
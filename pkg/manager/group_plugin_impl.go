@@ -2,6 +2,7 @@ package manager
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/docker/infrakit/pkg/plugin"
 	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
@@ -10,7 +11,7 @@ import (
 	"github.com/docker/infrakit/pkg/types"
 )
 
-func (m *manager) updateConfig(spec group.Spec) error {
+func (m *manager) updateConfig(spec group.Spec, message string) error {
 	log.Debug("Updating config", "spec", spec)
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -27,7 +28,7 @@ func (m *manager) updateConfig(spec group.Spec) error {
 	defer log.Debug("Saved snapshot", "global", stored, "spec", spec)
 
 	stored.updateGroupSpec(spec, plugin.Name(m.backendName))
-	return stored.store(m.snapshot)
+	return m.commit(&stored, "", message)
 }
 
 func (m *manager) removeConfig(id group.ID) error {
@@ -46,184 +47,186 @@ func (m *manager) removeConfig(id group.ID) error {
 	defer log.Debug("Saved snapshot", "global", stored, "id", id)
 
 	stored.removeGroup(id)
-	return stored.store(m.snapshot)
+	return m.commit(&stored, "", fmt.Sprintf("Remove group %v", id))
 }
 
 // This implements/ overrides the Group Plugin interface to support single group-only operations
 func (m *manager) CommitGroup(grp group.Spec, pretend bool) (resp string, err error) {
-
-	resultChan := make(chan []interface{})
-
-	m.backendOps <- backendOp{
-		name: "commit",
-		operation: func() error {
-			log.Debug("Manager CommitGroup", "spec", grp, "V", debugV)
-
-			var txnResp string
-			var txnErr error
-
-			// Always send a response so we don't block forever
-			defer func() {
-				resultChan <- []interface{}{txnResp, txnErr}
-			}()
-
-			// We first update the user's desired state first
-			if !pretend {
-				if updateErr := m.updateConfig(grp); updateErr != nil {
-					log.Warn("Error updating", "err", updateErr)
-					txnErr = updateErr
-					txnResp = "Cannot update spec. Abort"
-					return txnErr
-				}
+	err = m.queue("commit", func() error {
+		log.Debug("Manager CommitGroup", "spec", grp, "V", debugV)
+
+		// We first update the user's desired state first
+		if !pretend {
+			if updateErr := m.updateConfig(grp, fmt.Sprintf("Commit group %v", grp.ID)); updateErr != nil {
+				log.Warn("Error updating", "err", updateErr)
+				resp = "Cannot update spec. Abort"
+				return updateErr
 			}
-
-			txnResp, txnErr = m.Plugin.CommitGroup(grp, pretend)
-			return txnErr
-		},
-	}
-
-	r := <-resultChan
-	if v, has := r[0].(string); has {
-		resp = v
-	}
-	if v, has := r[1].(error); has && v != nil {
-		err = v
-	}
+		}
+
+		var txnErr error
+		resp, txnErr = m.Plugin.CommitGroup(grp, pretend)
+		if txnErr == nil && !pretend && canRollback(grp) {
+			go m.watchRollback(m.Epoch(), grp)
+		}
+		return txnErr
+	})
 	return
 }
 
-// Serialized describe group
-func (m *manager) DescribeGroup(id group.ID) (desc group.Description, err error) {
-	log.Debug("Describe group", "id", id, "V", debugV)
-	resultChan := make(chan []interface{})
-
-	m.backendOps <- backendOp{
-		name: "describe",
-		operation: func() error {
-			log.Debug("Manager DescribeGroup", "id", id, "V", debugV)
+// rollbackPollInterval is how often the update of a group that may be rolled back is checked.
+var rollbackPollInterval = 5 * time.Second
 
-			var txnResp group.Description
-			var txnErr error
-
-			// Always send a response so we don't block forever
-			defer func() {
-				resultChan <- []interface{}{txnResp, txnErr}
-			}()
-
-			txnResp, txnErr = m.Plugin.DescribeGroup(id)
-			return txnErr
-		},
+// canRollback returns true if the update of the group may be rolled back by the group plugin.
+func canRollback(spec group.Spec) bool {
+	parsed, err := group_types.ParseProperties(spec)
+	if err != nil {
+		return false
 	}
+	return parsed.Update.AutoRollback || parsed.Update.Canary != nil
+}
 
-	r := <-resultChan
-	if v, has := r[0].(group.Description); has {
-		desc = v
+// watchRollback follows the update of a group started by the commit of the spec until it is done.  If the group
+// plugin rolls the update back, the spec restored by the plugin is saved in place of the spec committed, so that
+// the failed spec is not committed again by the next leader.  It stops once the leadership changes hands.
+func (m *manager) watchRollback(epoch uint64, spec group.Spec) {
+	parsed, err := group_types.ParseProperties(spec)
+	if err != nil {
+		return
 	}
-	if v, has := r[1].(error); has && v != nil {
-		err = v
+	hash := parsed.InstanceHash()
+
+	ticker := time.NewTicker(rollbackPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if m.checkEpoch(epoch) != nil {
+			return
+		}
+		desc, err := m.Plugin.DescribeGroup(spec.ID)
+		if err != nil {
+			return
+		}
+		if desc.Rollback != nil && desc.Rollback.From == hash {
+			if err := m.recordRollback(epoch, spec, *desc.Rollback); err != nil {
+				log.Warn("Cannot record rollback", "groupID", spec.ID, "err", err)
+			}
+			return
+		}
+		if desc.Converged {
+			return
+		}
 	}
-	return
 }
 
-// This implements/ overrides the Group Plugin interface to support single group-only operations
-func (m *manager) DestroyGroup(id group.ID) (err error) {
-
-	resultChan := make(chan []interface{})
-
-	m.backendOps <- backendOp{
-		name: "destroy",
-		operation: func() error {
-			log.Debug("Manager DestroyGroup", "groupID", id, "V", debugV)
-
-			var txnErr error
-
-			// Always send a response so we don't block forever
-			defer func() {
-				resultChan <- []interface{}{txnErr}
-			}()
-
-			// We first update the user's desired state first
-			if removeErr := m.removeConfig(id); removeErr != nil {
-				log.Warn("Error updating/ remove", "err", removeErr)
-				txnErr = removeErr
-				return txnErr
+// recordRollback saves the spec of the group restored by a rollback, unless the group has been committed again
+// since the spec that failed.
+func (m *manager) recordRollback(epoch uint64, failed group.Spec, rollback group.Rollback) error {
+	return m.queueAt(epoch, "recordRollback", func() error {
+		stored, err := m.loadGroupSpec(failed.ID)
+		if err != nil {
+			return err
+		}
+		if types.Fingerprint(stored.Properties) != types.Fingerprint(failed.Properties) {
+			return nil
+		}
+
+		specs, err := m.Plugin.InspectGroups()
+		if err != nil {
+			return err
+		}
+		for _, restored := range specs {
+			if restored.ID == failed.ID {
+				log.Warn("Recording rollback", "groupID", failed.ID, "reason", rollback.Reason)
+				return m.updateConfig(restored,
+					fmt.Sprintf("Roll back group %v after failed update: %v", failed.ID, rollback.Reason))
 			}
+		}
+		return nil
+	})
+}
 
-			txnErr = m.Plugin.DestroyGroup(id)
-			return txnErr
-		},
-	}
+// Serialized plan of a commit
+func (m *manager) PlanCommit(grp group.Spec) (plan group.Plan, err error) {
+	log.Debug("Plan commit", "spec", grp, "V", debugV)
+	err = m.queue("plan", func() error {
+		log.Debug("Manager PlanCommit", "spec", grp, "V", debugV)
 
-	r := <-resultChan
-	if v, has := r[0].(error); has && v != nil {
-		err = v
-	}
+		var txnErr error
+		plan, txnErr = m.Plugin.PlanCommit(grp)
+		return txnErr
+	})
 	return
 }
 
-// This implements/ overrides the Group Plugin interface to support single group-only operations
-func (m *manager) FreeGroup(id group.ID) (err error) {
-
-	resultChan := make(chan []interface{})
-
-	m.backendOps <- backendOp{
-		name: "free",
-		operation: func() error {
-			log.Debug("Manager FreeGroup", "groupID", id, "V", debugV)
-
-			var txnErr error
-
-			// Always send a response so we don't block forever
-			defer func() {
-				resultChan <- []interface{}{txnErr}
-			}()
-
-			// We first update the user's desired state first
-			if removeErr := m.removeConfig(id); removeErr != nil {
-				log.Warn("Error updating / remove", "err", removeErr)
-				txnErr = removeErr
-				return txnErr
-			}
-
-			txnErr = m.Plugin.FreeGroup(id)
-			return txnErr
-		},
-	}
+// Serialized describe group
+func (m *manager) DescribeGroup(id group.ID) (desc group.Description, err error) {
+	log.Debug("Describe group", "id", id, "V", debugV)
+	err = m.queue("describe", func() error {
+		log.Debug("Manager DescribeGroup", "id", id, "V", debugV)
 
-	r := <-resultChan
-	if v, has := r[0].(error); has && v != nil {
-		err = v
-	}
+		var txnErr error
+		desc, txnErr = m.Plugin.DescribeGroup(id)
+		return txnErr
+	})
 	return
 }
 
 // This implements/ overrides the Group Plugin interface to support single group-only operations
-func (m *manager) DestroyInstances(id group.ID, instances []instance.ID) (err error) {
-	log.Debug("manager.DestroyInstances", "id", id, "instances", instances, "V", debugV)
-	resultChan := make(chan []interface{})
-
-	m.backendOps <- backendOp{
-		name: "destroyInstances",
-		operation: func() error {
-			log.Debug("Manager DestroyInstances", "groupID", id, "instances", instances, "V", debugV)
+func (m *manager) DestroyGroup(id group.ID) error {
+	return m.queue("destroy", func() error {
+		log.Debug("Manager DestroyGroup", "groupID", id, "V", debugV)
+
+		// We first update the user's desired state first
+		if removeErr := m.removeConfig(id); removeErr != nil {
+			log.Warn("Error updating/ remove", "err", removeErr)
+			return removeErr
+		}
+
+		return m.Plugin.DestroyGroup(id)
+	})
+}
 
-			var txnErr error
+// This implements/ overrides the Group Plugin interface to support single group-only operations
+func (m *manager) FreeGroup(id group.ID) error {
+	return m.queue("free", func() error {
+		log.Debug("Manager FreeGroup", "groupID", id, "V", debugV)
+
+		// We first update the user's desired state first
+		if removeErr := m.removeConfig(id); removeErr != nil {
+			log.Warn("Error updating / remove", "err", removeErr)
+			return removeErr
+		}
+
+		return m.Plugin.FreeGroup(id)
+	})
+}
 
-			// Always send a response so we don't block forever
-			defer func() {
-				resultChan <- []interface{}{txnErr}
-			}()
+// This implements/ overrides the Group Plugin interface to support single group-only operations
+func (m *manager) DestroyInstances(id group.ID, instances []instance.ID) error {
+	log.Debug("manager.DestroyInstances", "id", id, "instances", instances, "V", debugV)
+	return m.queue("destroyInstances", func() error {
+		log.Debug("Manager DestroyInstances", "groupID", id, "instances", instances, "V", debugV)
+		return m.Plugin.DestroyInstances(id, instances)
+	})
+}
 
-			txnErr = m.Plugin.DestroyInstances(id, instances)
-			return txnErr
-		},
-	}
+// This implements/ overrides the Group Plugin interface to support single group-only operations
+func (m *manager) PromoteCanary(id group.ID) error {
+	log.Debug("manager.PromoteCanary", "id", id, "V", debugV)
+	return m.queue("promoteCanary", func() error {
+		log.Debug("Manager PromoteCanary", "groupID", id, "V", debugV)
+		return m.Plugin.PromoteCanary(id)
+	})
+}
 
-	r := <-resultChan
-	if v, has := r[0].(error); has && v != nil {
-		err = v
-	}
-	return
+// This implements/ overrides the Group Plugin interface to support single group-only operations
+func (m *manager) AbortCanary(id group.ID) error {
+	log.Debug("manager.AbortCanary", "id", id, "V", debugV)
+	return m.queue("abortCanary", func() error {
+		log.Debug("Manager AbortCanary", "groupID", id, "V", debugV)
+		return m.Plugin.AbortCanary(id)
+	})
 }
 
 func (m *manager) loadGroupSpec(id group.ID) (group.Spec, error) {
@@ -274,3 +277,68 @@ func (m *manager) Size(id group.ID) (size int, err error) {
 	}
 	return int(parsed.Allocation.Size), nil
 }
+
+// setPaused records in the stored spec of a group whether its updates are paused, so that the paused state
+// survives a change of leader.
+func (m *manager) setPaused(id group.ID, paused bool) error {
+	spec, err := m.loadGroupSpec(id)
+	if err != nil {
+		return err
+	}
+	parsed, err := group_types.ParseProperties(spec)
+	if err != nil {
+		return err
+	}
+	if parsed.Update.Paused == paused {
+		return nil
+	}
+	parsed.Update.Paused = paused
+	spec.Properties = types.AnyValueMust(parsed)
+
+	message := fmt.Sprintf("Resume updates of group %v", id)
+	if paused {
+		message = fmt.Sprintf("Pause updates of group %v", id)
+	}
+	return m.updateConfig(spec, message)
+}
+
+// This implements/ overrides the Group Plugin interface to support single group-only operations
+func (m *manager) PauseUpdate(id group.ID) error {
+	log.Debug("manager.PauseUpdate", "id", id, "V", debugV)
+	return m.queue("pauseUpdate", func() error {
+		log.Debug("Manager PauseUpdate", "groupID", id, "V", debugV)
+
+		if err := m.Plugin.PauseUpdate(id); err != nil {
+			return err
+		}
+		if err := m.setPaused(id, true); err != nil {
+			// Undo the pause, so the update does not stay paused without a record of it.
+			if resumeErr := m.Plugin.ResumeUpdate(id); resumeErr != nil {
+				log.Warn("Cannot resume the update", "groupID", id, "err", resumeErr)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// This implements/ overrides the Group Plugin interface to support single group-only operations
+func (m *manager) ResumeUpdate(id group.ID) error {
+	log.Debug("manager.ResumeUpdate", "id", id, "V", debugV)
+	return m.queue("resumeUpdate", func() error {
+		log.Debug("Manager ResumeUpdate", "groupID", id, "V", debugV)
+
+		// Resume before clearing the stored state, so an error of the plugin leaves both paused.
+		if err := m.Plugin.ResumeUpdate(id); err != nil {
+			return err
+		}
+		if err := m.setPaused(id, false); err != nil {
+			// Pause the update again, so the update does not run while the stored spec says it is paused.
+			if pauseErr := m.Plugin.PauseUpdate(id); pauseErr != nil {
+				log.Warn("Cannot pause the update", "groupID", id, "err", pauseErr)
+			}
+			return err
+		}
+		return nil
+	})
+}
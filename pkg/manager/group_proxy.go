@@ -50,6 +50,14 @@ func (c *lateBindGroup) CommitGroup(grp group.Spec, pretend bool) (resp string,
 	return
 }
 
+func (c *lateBindGroup) PlanCommit(grp group.Spec) (plan group.Plan, err error) {
+	err = c.do(func(p group.Plugin) error {
+		plan, err = p.PlanCommit(grp)
+		return err
+	})
+	return
+}
+
 func (c *lateBindGroup) FreeGroup(id group.ID) (err error) {
 	err = c.do(func(p group.Plugin) error {
 		err = p.FreeGroup(id)
@@ -105,3 +113,35 @@ func (c *lateBindGroup) SetSize(id group.ID, size int) (err error) {
 	})
 	return
 }
+
+func (c *lateBindGroup) PromoteCanary(id group.ID) (err error) {
+	err = c.do(func(p group.Plugin) error {
+		err = p.PromoteCanary(id)
+		return err
+	})
+	return
+}
+
+func (c *lateBindGroup) AbortCanary(id group.ID) (err error) {
+	err = c.do(func(p group.Plugin) error {
+		err = p.AbortCanary(id)
+		return err
+	})
+	return
+}
+
+func (c *lateBindGroup) PauseUpdate(id group.ID) (err error) {
+	err = c.do(func(p group.Plugin) error {
+		err = p.PauseUpdate(id)
+		return err
+	})
+	return
+}
+
+func (c *lateBindGroup) ResumeUpdate(id group.ID) (err error) {
+	err = c.do(func(p group.Plugin) error {
+		err = p.ResumeUpdate(id)
+		return err
+	})
+	return
+}
@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var errNoHistory = fmt.Errorf("no history of revisions kept by the store")
+
+// Revisions returns the revisions of the specs committed, oldest first.
+func (m *manager) Revisions() ([]store.Revision, error) {
+	versioned, is := m.snapshot.(store.Versioned)
+	if !is {
+		return nil, errNoHistory
+	}
+	return versioned.Revisions()
+}
+
+// InspectRevision returns the specs committed in the given revision.
+func (m *manager) InspectRevision(number int) ([]types.Spec, error) {
+	stored := globalSpec{}
+	if err := stored.loadRevision(m.snapshot, number); err != nil {
+		return nil, err
+	}
+	return stored.specs(), nil
+}
+
+// Rollback commits the specs of a previous revision as a new revision.  Groups added since the revision are
+// pruned according to the prune mode of the options, while other specs added since are left as is.
+func (m *manager) Rollback(number int, options CommitOptions) (GroupChanges, error) {
+	target := globalSpec{}
+	if err := target.loadRevision(m.snapshot, number); err != nil {
+		return GroupChanges{}, err
+	}
+
+	groups := []group.Spec{}
+	others := []types.Spec{}
+	for _, spec := range target.specs() {
+		if spec.Kind != keyFromGroupID("").Kind {
+			others = append(others, spec)
+			continue
+		}
+		gspec, err := target.getGroupSpec(group.ID(spec.Metadata.Name))
+		if err != nil {
+			return GroupChanges{}, err
+		}
+		groups = append(groups, gspec)
+	}
+
+	if options.Message == "" {
+		options.Message = fmt.Sprintf("Rollback to revision %d", number)
+	}
+	return m.commitState(groups, others, options)
+}
@@ -0,0 +1,196 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store/mem"
+	"github.com/docker/infrakit/pkg/store/versioned"
+	group_test "github.com/docker/infrakit/pkg/testing/group"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevisionsAndRollback(t *testing.T) {
+	committed := []string{}
+	destroyed := []group.ID{}
+
+	ops := make(chan backendOp)
+	defer close(ops)
+	go func() {
+		for op := range ops {
+			op.done <- op.operation()
+		}
+	}()
+
+	m := &manager{
+		Plugin: &group_test.Plugin{
+			DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+				committed = append(committed, fmt.Sprintf("%v:%v", spec.ID, spec.Properties.String()))
+				return "ok", nil
+			},
+			DoDestroyGroup: func(id group.ID) error {
+				destroyed = append(destroyed, id)
+				return nil
+			},
+		},
+		snapshot:    versioned.NewSnapshot(&memSnapshot{}, mem.NewStore("revision"), 0),
+		isLeader:    true,
+		backendName: "group-stateless",
+		backendOps:  ops,
+	}
+
+	_, err := m.CommitGroups([]group.Spec{
+		{ID: "workers", Properties: types.AnyValueMust(3)},
+	}, CommitOptions{Author: "alice", Message: "Start workers"})
+	require.NoError(t, err)
+
+	changes, err := m.CommitGroups([]group.Spec{
+		{ID: "workers", Properties: types.AnyValueMust(5)},
+		{ID: "managers", Properties: types.AnyValueMust(3)},
+	}, CommitOptions{})
+	require.NoError(t, err)
+	require.Equal(t, GroupChanges{Add: []group.ID{"managers"}, Change: []group.ID{"workers"}}, changes)
+	require.Equal(t, []string{"workers:3", "workers:5", "managers:3"}, committed)
+
+	// Groups not changed are not committed again, and no revision is added
+	committed = []string{}
+	changes, err = m.CommitGroups([]group.Spec{
+		{ID: "workers", Properties: types.AnyValueMust(5)},
+		{ID: "managers", Properties: types.AnyValueMust(3)},
+	}, CommitOptions{})
+	require.NoError(t, err)
+	require.True(t, changes.Empty())
+	require.Empty(t, committed)
+
+	revisions, err := m.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revisions))
+	require.Equal(t, 1, revisions[0].Number)
+	require.Equal(t, "alice", revisions[0].Author)
+	require.Equal(t, "Start workers", revisions[0].Message)
+	require.Equal(t, "Commit groups workers, managers", revisions[1].Message)
+
+	specs, err := m.InspectRevision(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(specs))
+	require.Equal(t, "workers", specs[0].Metadata.Name)
+
+	_, err = m.InspectRevision(5)
+	require.Error(t, err)
+
+	committed = []string{}
+	changes, err = m.Rollback(1, CommitOptions{Prune: PruneDestroy, Pretend: true})
+	require.NoError(t, err)
+	require.Equal(t, GroupChanges{
+		Change: []group.ID{"workers"},
+		Remove: []group.ID{"managers"},
+		Prune:  PruneDestroy,
+	}, changes)
+	require.Empty(t, committed)
+
+	_, err = m.Rollback(1, CommitOptions{Prune: PruneDestroy, Author: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"workers:3"}, committed)
+	require.Equal(t, []group.ID{"managers"}, destroyed)
+
+	revisions, err = m.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions))
+	require.Equal(t, "bob", revisions[2].Author)
+	require.Equal(t, "Rollback to revision 1", revisions[2].Message)
+
+	stored := globalSpec{}
+	require.NoError(t, stored.load(m.snapshot))
+	require.Equal(t, 1, len(stored.specs()))
+
+	// Without history
+	m.snapshot = &memSnapshot{}
+	_, err = m.Revisions()
+	require.Error(t, err)
+	_, err = m.Rollback(1, CommitOptions{})
+	require.Error(t, err)
+}
+
+func TestRecordRollback(t *testing.T) {
+	rollbackPollInterval = 10 * time.Millisecond
+
+	spec := func(image string) group.Spec {
+		return group.Spec{
+			ID: "workers",
+			Properties: types.AnyValueMust(map[string]interface{}{
+				"Allocation": map[string]interface{}{"Size": 3},
+				"Instance":   map[string]interface{}{"Plugin": "simulator", "Properties": map[string]string{"Image": image}},
+				"Update":     map[string]interface{}{"AutoRollback": true},
+			}),
+		}
+	}
+	hash := func(spec group.Spec) string {
+		parsed, err := group_types.ParseProperties(spec)
+		require.NoError(t, err)
+		return parsed.InstanceHash()
+	}
+
+	current := group.Spec{}
+	failed := ""
+	lock := sync.Mutex{}
+	m := &manager{
+		Plugin: &group_test.Plugin{
+			DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				if hash(spec) == failed {
+					return "ok", nil // the plugin keeps the previous spec, as when rolling back
+				}
+				current = spec
+				return "ok", nil
+			},
+			DoDescribeGroup: func(id group.ID) (group.Description, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				if failed == "" {
+					return group.Description{Converged: true}, nil
+				}
+				return group.Description{Rollback: &group.Rollback{From: failed, Reason: "unhealthy"}}, nil
+			},
+			DoInspectGroups: func() ([]group.Spec, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				return []group.Spec{current}, nil
+			},
+		},
+		snapshot:    versioned.NewSnapshot(&memSnapshot{}, mem.NewStore("revision"), 0),
+		backendName: "group-stateless",
+	}
+	testStart(t, m)()
+	defer m.Stop()
+
+	_, err := m.CommitGroup(spec("v1"), false)
+	require.NoError(t, err)
+
+	lock.Lock()
+	failed = hash(spec("v2"))
+	lock.Unlock()
+	_, err = m.CommitGroup(spec("v2"), false)
+	require.NoError(t, err)
+
+	// The spec restored by the rollback is saved in place of the spec that failed
+	for i := 0; ; i++ {
+		stored, err := m.loadGroupSpec("workers")
+		require.NoError(t, err)
+		if hash(stored) == hash(spec("v1")) {
+			break
+		}
+		require.True(t, i < 500, "rollback not recorded")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	revisions, err := m.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions))
+	require.Equal(t, "Roll back group workers after failed update: unhealthy", revisions[2].Message)
+}
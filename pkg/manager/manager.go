@@ -3,13 +3,16 @@ package manager
 import (
 	"fmt"
 	"net/url"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/infrakit/pkg/controller"
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/leader"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/plugin"
+	controller_rpc "github.com/docker/infrakit/pkg/rpc/controller"
 	rpc "github.com/docker/infrakit/pkg/rpc/group"
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/spi/group"
@@ -26,7 +29,7 @@ var (
 	// InterfaceSpec is the current name and version of the Instance API.
 	InterfaceSpec = spi.InterfaceSpec{
 		Name:    "Manager",
-		Version: "0.1.0",
+		Version: "0.2.0",
 	}
 )
 
@@ -51,6 +54,19 @@ type Manager interface {
 
 	// Terminate destroys all resources associated with the specs
 	Terminate(specs []types.Spec) error
+
+	// CommitGroups commits the group specs as the desired state of all groups, pruning the groups that are
+	// missing according to the prune mode.  The changes are returned without being applied if pretending.
+	CommitGroups(specs []group.Spec, options CommitOptions) (GroupChanges, error)
+
+	// Revisions returns the revisions of the specs committed, oldest first.
+	Revisions() ([]store.Revision, error)
+
+	// InspectRevision returns the specs committed in the given revision.
+	InspectRevision(number int) ([]types.Spec, error)
+
+	// Rollback commits the specs of a previous revision as a new revision.
+	Rollback(number int, options CommitOptions) (GroupChanges, error)
 }
 
 // Backend is the admin / server interface
@@ -62,6 +78,10 @@ type Backend interface {
 
 	Manager
 
+	// Epoch returns the epoch of the leadership held, or 0 if not the leader.  It is the fencing token of the
+	// writes made while leading.
+	Epoch() uint64
+
 	Start() (<-chan struct{}, error)
 	Stop()
 }
@@ -76,18 +96,37 @@ type manager struct {
 	leader      leader.Detector
 	leaderStore leader.Store
 	snapshot    store.Snapshot
-	isLeader    bool
 	lock        sync.Mutex
-	stop        chan struct{}
-	running     chan struct{}
+
+	// leadership guards the state of the leadership.  It is not the lock, so the epoch can be read by the writes
+	// to the snapshot made while holding the lock, such as by a fenced snapshot.
+	leadership sync.Mutex
+	isLeader   bool
+	epoch      uint64
+	expires    time.Time
+
+	stop    chan struct{}
+	running chan struct{}
 
 	backendName string
 	backendOps  chan<- backendOp
+
+	// known is the fingerprint of the specs last loaded or committed by this manager, to tell the changes made
+	// out of band
+	known string
+
+	// unwatch stops watching the snapshot for changes.  It is only used by the work queue.
+	unwatch chan<- struct{}
+
+	// lookupController returns the controller of the given name, for committing specs
+	lookupController func(plugin.Name) (controller.Controller, error)
 }
 
 type backendOp struct {
 	name      string
+	epoch     uint64
 	operation func() error
+	done      chan<- error
 }
 
 // NewManager returns the manager which depends on other services to coordinate and manage
@@ -98,7 +137,7 @@ func NewManager(plugins discovery.Plugins,
 	snapshot store.Snapshot,
 	backendName string) Backend {
 
-	return &manager{
+	m := &manager{
 		// "base class" is the stateless backend group plugin
 		Plugin: &lateBindGroup{
 			finder: func() (group.Plugin, error) {
@@ -115,6 +154,37 @@ func NewManager(plugins discovery.Plugins,
 		snapshot:    snapshot,
 		backendName: backendName,
 	}
+	m.lookupController = m.findController
+	return m
+}
+
+// findController returns the controller of the given name.  Groups are routed through the controllers of the
+// manager so that their specs are persisted and serialized with the other group operations.  Other controllers
+// are found by discovery.
+func (m *manager) findController(name plugin.Name) (controller.Controller, error) {
+	_, sub := name.GetLookupAndType()
+	if m.isGroupController(name) {
+		controllers, err := m.Controllers()
+		if err != nil {
+			return nil, err
+		}
+		if c, has := controllers[sub]; has {
+			return c, nil
+		}
+		return controllers["."], nil
+	}
+
+	endpoint, err := m.plugins.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	return controller_rpc.NewClient(name, endpoint.Address)
+}
+
+// isGroupController returns true if the controller of the given name is one of the groups of the manager.
+func (m *manager) isGroupController(name plugin.Name) bool {
+	lookup, _ := name.GetLookupAndType()
+	return lookup == keyFromGroupID("").Kind || lookup == m.backendName
 }
 
 // return true only if the current call caused an allocation of the running channel.
@@ -129,9 +199,76 @@ func (m *manager) initRunning() bool {
 	return false
 }
 
-// IsLeader returns leader status.  False if not or unknown.
+// IsLeader returns leader status.  False if not or unknown, or if the lease of the leadership has expired
+// without being renewed.
 func (m *manager) IsLeader() (bool, error) {
-	return m.isLeader, nil
+	m.leadership.Lock()
+	defer m.leadership.Unlock()
+	return m.leading(), nil
+}
+
+// leading returns true if this is the leader.  The caller must hold the leadership lock.
+func (m *manager) leading() bool {
+	return m.isLeader && (m.expires.IsZero() || time.Now().Before(m.expires))
+}
+
+// Epoch returns the epoch of the leadership held, or 0 if not the leader.
+func (m *manager) Epoch() uint64 {
+	m.leadership.Lock()
+	defer m.leadership.Unlock()
+	if !m.leading() {
+		return 0
+	}
+	return m.epoch
+}
+
+// queue queues the operation to be run in the work queue, serialized with the other operations and the leadership
+// changes, and waits for its result.  The operation carries the epoch of the leadership at the time it is queued,
+// so it is rejected if the leadership has changed hands by the time it runs.
+func (m *manager) queue(name string, operation func() error) error {
+	return m.queueAt(m.Epoch(), name, operation)
+}
+
+// queueAt queues the operation as a step of a longer operation started in the given epoch, so that none of its
+// steps run once the leadership has changed hands.
+func (m *manager) queueAt(epoch uint64, name string, operation func() error) error {
+	done := make(chan error, 1)
+	select {
+	case m.backendOps <- backendOp{
+		name:      name,
+		epoch:     epoch,
+		operation: operation,
+		done:      done,
+	}:
+	case <-m.running:
+		return fmt.Errorf("manager stopped")
+	}
+	return <-done
+}
+
+// runOp runs the operation and sends its result, unless the leadership has been lost or has changed hands since
+// the operation was queued.
+func (m *manager) runOp(op backendOp) {
+	log.Debug("Backend operation", "op", op.name, "epoch", op.epoch, "V", debugV)
+	if err := m.checkEpoch(op.epoch); err != nil {
+		log.Warn("Rejecting backend operation", "op", op.name, "err", err)
+		op.done <- err
+		return
+	}
+	op.done <- op.operation()
+}
+
+// checkEpoch returns an error if the operation of the given epoch must not run.
+func (m *manager) checkEpoch(epoch uint64) error {
+	m.leadership.Lock()
+	defer m.leadership.Unlock()
+	if !m.leading() {
+		return fmt.Errorf("not the leader")
+	}
+	if epoch != m.epoch {
+		return leader.ErrStaleEpoch{Epoch: epoch, Current: m.epoch}
+	}
+	return nil
 }
 
 // LeaderLocation returns the location of the leader
@@ -143,27 +280,189 @@ func (m *manager) LeaderLocation() (*url.URL, error) {
 	return m.leaderStore.GetLocation()
 }
 
-// Enforce enforces infrastructure state to match that of the specs
+// Enforce enforces infrastructure state to match that of the specs.  The specs are saved in the snapshot and then
+// committed to their controllers in the order of their dependencies, with the values bound from each dependency
+// injected into the dependents.  Each step is run in the work queue, and rejected once the leadership has changed
+// hands since the call.
 func (m *manager) Enforce(specs []types.Spec) error {
+	epoch, err := m.leaderEpoch()
+	if err != nil {
+		return err
+	}
 
-	buff, err := types.AnyValueMust(specs).MarshalYAML()
+	ordered, err := orderByDependencies(specs)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(string(buff))
+	// We first update the user's desired state
+	if err := m.queueAt(epoch, "enforce", func() error {
+		return m.updateSpecs("", enforceMessage(ordered), func(stored *globalSpec) {
+			for _, spec := range ordered {
+				stored.updateSpec(spec, controllerName(spec.Kind, spec.Metadata.Name))
+			}
+		})
+	}); err != nil {
+		return err
+	}
+
+	return m.enforce(epoch, ordered)
+}
+
+func enforceMessage(specs []types.Spec) string {
+	keys := []string{}
+	for _, spec := range specs {
+		keys = append(keys, keyOf(spec).String())
+	}
+	return "Enforce " + strings.Join(keys, ", ")
+}
+
+// enforce commits the specs in order.  The dependencies are bound outside of the work queue, since they may be
+// described by the groups of the manager, whose operations are queued themselves.
+func (m *manager) enforce(epoch uint64, ordered []types.Spec) error {
+	for _, spec := range ordered {
+		bound, err := bindDependencies(spec, m.lookupController)
+		if err != nil {
+			return fmt.Errorf("cannot resolve dependencies of %v: %v", keyOf(spec), err)
+		}
+
+		resolved, err := resolveSpec(spec, bound)
+		if err != nil {
+			return err
+		}
 
+		name := controllerName(spec.Kind, spec.Metadata.Name)
+		c, err := m.lookupController(name)
+		if err != nil {
+			return fmt.Errorf("cannot find controller for %v: %v", keyOf(spec), err)
+		}
+
+		log.Info("Enforcing", "kind", spec.Kind, "name", spec.Metadata.Name, "controller", name)
+		if err := m.commitAt(epoch, name, func() error {
+			_, err := c.Commit(controller.Enforce, resolved)
+			return err
+		}); err != nil {
+			return fmt.Errorf("cannot enforce %v: %v", keyOf(spec), err)
+		}
+	}
 	return nil
 }
 
-// Inspect returns the current state of the infrastructure
+// commitAt runs the commit to the controller of the given name in the work queue, unless the controller is one of
+// the groups of the manager, whose operations are queued themselves.  The commits to the groups are then only
+// checked against the epoch.
+func (m *manager) commitAt(epoch uint64, name plugin.Name, commit func() error) error {
+	if m.isGroupController(name) {
+		if err := m.checkEpoch(epoch); err != nil {
+			return err
+		}
+		return commit()
+	}
+	return m.queueAt(epoch, "commit "+name.String(), commit)
+}
+
+// Inspect returns the current state of the infrastructure, as described by the controllers of the specs
+// in the snapshot.
 func (m *manager) Inspect() ([]types.Object, error) {
-	return nil, nil
+	stored := globalSpec{}
+	if err := stored.load(m.snapshot); err != nil {
+		return nil, err
+	}
+
+	ordered, err := orderByDependencies(stored.specs())
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []types.Object{}
+	for _, spec := range ordered {
+		c, err := m.lookupController(controllerName(spec.Kind, spec.Metadata.Name))
+		if err != nil {
+			return nil, fmt.Errorf("cannot find controller for %v: %v", keyOf(spec), err)
+		}
+		described, err := c.Describe(&types.Metadata{Name: spec.Metadata.Name})
+		if err != nil {
+			return nil, fmt.Errorf("cannot describe %v: %v", keyOf(spec), err)
+		}
+		objects = append(objects, described...)
+	}
+	return objects, nil
 }
 
-// Terminate destroys all resources associated with the specs
+// Terminate destroys all resources associated with the specs.  The specs are destroyed in the reverse order of
+// their dependencies, so that no object is destroyed before the objects that depend on it.  As in Enforce, each
+// step is run in the work queue, and rejected once the leadership has changed hands since the call.
 func (m *manager) Terminate(specs []types.Spec) error {
-	return fmt.Errorf("not implemented")
+	epoch, err := m.leaderEpoch()
+	if err != nil {
+		return err
+	}
+
+	ordered, err := orderByDependencies(specs)
+	if err != nil {
+		return err
+	}
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		spec := ordered[i]
+
+		name := controllerName(spec.Kind, spec.Metadata.Name)
+		c, err := m.lookupController(name)
+		if err != nil {
+			return fmt.Errorf("cannot find controller for %v: %v", keyOf(spec), err)
+		}
+
+		log.Info("Terminating", "kind", spec.Kind, "name", spec.Metadata.Name, "controller", name)
+		if err := m.commitAt(epoch, name, func() error {
+			_, err := c.Commit(controller.Destroy, spec)
+			return err
+		}); err != nil {
+			return fmt.Errorf("cannot terminate %v: %v", keyOf(spec), err)
+		}
+
+		if err := m.queueAt(epoch, "terminate", func() error {
+			return m.updateSpecs("", fmt.Sprintf("Terminate %v", keyOf(spec)), func(stored *globalSpec) {
+				stored.removeSpec(spec.Kind, spec.Metadata)
+			})
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leaderEpoch returns the epoch of the leadership, or an error if not the leader.
+func (m *manager) leaderEpoch() (uint64, error) {
+	m.leadership.Lock()
+	defer m.leadership.Unlock()
+	if !m.leading() {
+		return 0, fmt.Errorf("not the leader")
+	}
+	return m.epoch, nil
+}
+
+// updateSpecs loads the specs in the snapshot, applies the update and commits the result with the author
+// and message.
+func (m *manager) updateSpecs(author, message string, update func(*globalSpec)) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stored := globalSpec{}
+	if err := stored.load(m.snapshot); err != nil {
+		return err
+	}
+	update(&stored)
+	return m.commit(&stored, author, message)
+}
+
+// commit commits the specs and records them as known, so that the change is not taken for a change made out of
+// band.  The caller must hold the lock.
+func (m *manager) commit(stored *globalSpec, author, message string) error {
+	if err := stored.commit(m.snapshot, author, message); err != nil {
+		return err
+	}
+	m.known = stored.fingerprint()
+	return nil
 }
 
 // Start starts the manager.  It does not block. Instead read from the returned channel to block.
@@ -197,14 +496,12 @@ func (m *manager) Start() (<-chan struct{}, error) {
 			select {
 
 			case op := <-backendOps:
-				log.Debug("Backend operation", "op", op, "V", debugV)
-				if m.isLeader {
-					op.operation()
-				}
+				m.runOp(op)
 
 			case <-stopWorkQueue:
 
 				log.Info("Stopping work queue.")
+				m.stopWatchingSpecs()
 				close(m.running)
 				log.Info("Manager stopped.")
 				return
@@ -251,9 +548,10 @@ func (m *manager) Start() (<-chan struct{}, error) {
 				// This here handles possible duplicated events about leadership and fires only when there
 				// is a change.
 
-				m.lock.Lock()
+				m.leadership.Lock()
 
 				current := m.isLeader
+				epoch := m.epoch
 
 				if evt.Status == leader.Unknown {
 					log.Warn("Leadership status is uncertain", "err", evt.Error)
@@ -270,9 +568,18 @@ func (m *manager) Start() (<-chan struct{}, error) {
 				} else {
 					m.isLeader = evt.Status == leader.Leader
 				}
+				if m.isLeader {
+					m.epoch = evt.Epoch
+					m.expires = evt.Expires
+				}
 				next := m.isLeader
 
-				m.lock.Unlock()
+				if current && next && epoch != m.epoch {
+					// The lease lapsed and was acquired again.  Operations queued before are stale.
+					log.Warn("Leadership epoch changed", "epoch", epoch, "next", m.epoch)
+				}
+
+				m.leadership.Unlock()
 
 				if current != next {
 					notify <- next
@@ -320,21 +627,110 @@ func (m *manager) getCurrentState() (globalSpec, error) {
 func (m *manager) onAssumeLeadership() error {
 	log.Info("Assuming leadership")
 
+	// Watch before loading, so that no change made after the load is missed
+	m.watchSpecs()
+
 	// load the config
 	config := &globalSpec{}
 	// load the latest version -- assumption here is that it's been persisted already.
 	log.Info("Loading snapshot")
+	m.lock.Lock()
 	err := config.load(m.snapshot)
+	if err == nil {
+		m.known = config.fingerprint()
+	}
+	m.lock.Unlock()
 	log.Info("Loaded snapshot", "err", err)
 	if err != nil {
 		log.Warn("Error loading config", "err", err)
 		return err
 	}
-	return m.doCommitGroups(*config)
+
+	// Encrypt the specs again if the keys of the snapshot have changed, so the old keys can be removed
+	m.lock.Lock()
+	err = store.Rotate(m.snapshot)
+	m.lock.Unlock()
+	if err != nil {
+		log.Warn("Error encrypting the specs with the current key", "err", err)
+	}
+	return m.applySpecs(*config)
+}
+
+// applySpecs commits the groups and the other specs loaded from the snapshot.
+func (m *manager) applySpecs(config globalSpec) error {
+	epoch := m.Epoch()
+	if err := m.doCommitGroups(config); err != nil {
+		return err
+	}
+
+	// The other specs are committed outside of the work queue, since resolving their dependencies may
+	// require operations on the groups.
+	go func() {
+		if err := m.doCommitSpecs(epoch, config); err != nil {
+			log.Warn("Error committing specs", "err", err)
+		}
+	}()
+	return nil
+}
+
+// watchSpecs watches the snapshot, if it can be watched, to apply the specs changed out of band, such as by an
+// edit of the file of the specs, without a restart.  It is only called by the work queue.
+func (m *manager) watchSpecs() {
+	if m.unwatch != nil {
+		return
+	}
+	if _, is := m.snapshot.(store.Watchable); !is {
+		return
+	}
+	events, done, err := store.WatchSnapshot(m.snapshot)
+	if err != nil {
+		log.Warn("Cannot watch specs", "err", err)
+		return
+	}
+	m.unwatch = done
+
+	go func() {
+		for range events {
+			if err := m.queue("reload", m.reloadSpecs); err != nil {
+				log.Warn("Error reloading specs", "err", err)
+			}
+		}
+	}()
+}
+
+// stopWatchingSpecs stops watching the snapshot.  It is only called by the work queue.
+func (m *manager) stopWatchingSpecs() {
+	if m.unwatch != nil {
+		close(m.unwatch)
+		m.unwatch = nil
+	}
+}
+
+// reloadSpecs applies the specs in the snapshot if they are not the specs last loaded or committed by this
+// manager.
+func (m *manager) reloadSpecs() error {
+	m.lock.Lock()
+	config := globalSpec{}
+	err := config.load(m.snapshot)
+	changed := false
+	if err == nil {
+		fingerprint := config.fingerprint()
+		changed = fingerprint != m.known
+		m.known = fingerprint
+	}
+	m.lock.Unlock()
+
+	if err != nil || !changed {
+		return err
+	}
+	log.Info("Specs changed out of band, applying")
+	return m.applySpecs(config)
 }
 
 func (m *manager) onLostLeadership() error {
 	log.Info("Lost leadership")
+	m.stopWatchingSpecs()
+
 	config, err := m.getCurrentState()
 	if err != nil {
 		return err
@@ -374,6 +770,22 @@ func (m *manager) doCommitGroups(config globalSpec) error {
 		})
 }
 
+// doCommitSpecs enforces the specs of kinds other than groups, which are committed by doCommitGroups.
+func (m *manager) doCommitSpecs(epoch uint64, config globalSpec) error {
+	specs := []types.Spec{}
+	for _, spec := range config.specs() {
+		if spec.Kind != keyFromGroupID("").Kind {
+			specs = append(specs, spec)
+		}
+	}
+
+	ordered, err := orderByDependencies(specs)
+	if err != nil {
+		return err
+	}
+	return m.enforce(epoch, ordered)
+}
+
 func (m *manager) doFreeGroups(config globalSpec) error {
 	log.Info("Freeing groups")
 	return m.execPlugins(config,
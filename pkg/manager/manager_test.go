@@ -1,20 +1,31 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/docker/infrakit/pkg/controller"
 	"github.com/docker/infrakit/pkg/discovery/local"
 	"github.com/docker/infrakit/pkg/leader"
 	group_mock "github.com/docker/infrakit/pkg/mock/spi/group"
 	store_mock "github.com/docker/infrakit/pkg/mock/store"
 	"github.com/docker/infrakit/pkg/plugin"
+	group_plugin "github.com/docker/infrakit/pkg/plugin/group"
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
 	group_rpc "github.com/docker/infrakit/pkg/rpc/group"
 	"github.com/docker/infrakit/pkg/rpc/server"
+	"github.com/docker/infrakit/pkg/spi/flavor"
 	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/store/file"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_flavor "github.com/docker/infrakit/pkg/testing/flavor"
+	testing_instance "github.com/docker/infrakit/pkg/testing/instance"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -84,6 +95,29 @@ func testEnsemble(t *testing.T,
 	return m, st
 }
 
+// testStart starts the work queue of the manager, with no plugins discovered, and returns the func that makes the
+// manager the leader.
+func testStart(t *testing.T, m *manager) func() {
+	disc, err := local.NewPluginDiscoveryWithDir(testDiscoveryDir(t))
+	require.NoError(t, err)
+
+	leaderChan := make(chan string)
+	m.plugins = disc
+	m.leader = &testLeaderDetector{t: t, me: "m", input: leaderChan}
+	_, err = m.Start()
+	require.NoError(t, err)
+
+	return func() {
+		leaderChan <- "m"
+		for {
+			if isLeader, _ := m.IsLeader(); isLeader {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
 func testSetLeader(t *testing.T, c []chan string, l string) {
 	for _, cc := range c {
 		cc <- l
@@ -319,3 +353,419 @@ func TestChangeLeadership(t *testing.T) {
 
 	testCloseAll(leaderChans)
 }
+
+func TestPausedUpdateSurvivesLeaderChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gs := testBuildGroupSpec("workers", `
+{
+   "Allocation" : { "Size" : 3 }
+}
+`)
+	global := testBuildGlobalSpec(t, gs)
+
+	// Both managers share the same store
+	var lock sync.Mutex
+	stored := global.data
+	configStore := func(s *store_mock.MockSnapshot) {
+		s.EXPECT().Load(gomock.Any()).Do(
+			func(o interface{}) error {
+				lock.Lock()
+				defer lock.Unlock()
+				*o.(*[]persisted) = stored
+				return nil
+			},
+		).Return(nil).AnyTimes()
+		s.EXPECT().Save(gomock.Any()).Do(
+			func(o interface{}) error {
+				lock.Lock()
+				defer lock.Unlock()
+				stored = o.([]persisted)
+				return nil
+			},
+		).Return(nil).AnyTimes()
+	}
+
+	leaderChans := []chan string{make(chan string), make(chan string)}
+	checkpoint1 := make(chan struct{})
+	checkpoint2 := make(chan struct{})
+	checkpoint3 := make(chan struct{})
+
+	manager1, stoppable1 := testEnsemble(t, testDiscoveryDir(t), "m1", leaderChans[0], ctrl,
+		configStore,
+		func(g *group_mock.MockPlugin) {
+			g.EXPECT().CommitGroup(gomock.Any(), false).Do(
+				func(spec group.Spec, pretend bool) (string, error) {
+					defer close(checkpoint1)
+					return "ok", nil
+				},
+			).Return("ok", nil)
+
+			g.EXPECT().PauseUpdate(gomock.Eq(gs.ID)).Return(nil)
+
+			g.EXPECT().InspectGroups().Return([]group.Spec{gs}, nil)
+			g.EXPECT().FreeGroup(gomock.Eq(gs.ID)).Do(
+				func(id group.ID) error {
+					defer close(checkpoint3)
+					return nil
+				},
+			).Return(nil)
+		})
+	manager2, stoppable2 := testEnsemble(t, testDiscoveryDir(t), "m2", leaderChans[1], ctrl,
+		configStore,
+		func(g *group_mock.MockPlugin) {
+			g.EXPECT().CommitGroup(gomock.Any(), false).Do(
+				func(spec group.Spec, pretend bool) (string, error) {
+
+					defer close(checkpoint2)
+
+					require.Equal(t, gs.ID, spec.ID)
+					parsed, err := group_types.ParseProperties(spec)
+					require.NoError(t, err)
+					require.True(t, parsed.Update.Paused)
+					require.Equal(t, uint(3), parsed.Allocation.Size)
+					return "ok", nil
+				},
+			).Return("ok", nil)
+		})
+
+	manager1.Start()
+	manager2.Start()
+
+	testSetLeader(t, leaderChans, "m1")
+
+	<-checkpoint1
+
+	require.NoError(t, manager1.PauseUpdate(gs.ID))
+
+	testSetLeader(t, leaderChans, "m2")
+
+	<-checkpoint2
+	<-checkpoint3
+
+	manager1.Stop()
+	manager2.Stop()
+
+	stoppable1.Stop()
+	stoppable2.Stop()
+
+	testCloseAll(leaderChans)
+}
+
+// testInstances is an instance plugin that keeps the instances in memory.
+func testInstances(specs ...instance.Spec) *testing_instance.Plugin {
+	var lock sync.Mutex
+	instances := map[instance.ID]instance.Spec{}
+	next := 0
+	provision := func(spec instance.Spec) (*instance.ID, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		next++
+		id := instance.ID(fmt.Sprintf("instance-%d", next))
+		instances[id] = spec
+		return &id, nil
+	}
+	for _, spec := range specs {
+		provision(spec)
+	}
+	return &testing_instance.Plugin{
+		DoValidate:  func(req *types.Any) error { return nil },
+		DoProvision: provision,
+		DoLabel:     func(id instance.ID, labels map[string]string) error { return nil },
+		DoDestroy: func(id instance.ID, context instance.Context) error {
+			lock.Lock()
+			defer lock.Unlock()
+			delete(instances, id)
+			return nil
+		},
+		DoDescribeInstances: func(tags map[string]string, details bool) ([]instance.Description, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			described := []instance.Description{}
+		instances:
+			for id, spec := range instances {
+				for k, v := range tags {
+					if spec.Tags[k] != v {
+						continue instances
+					}
+				}
+				described = append(described, instance.Description{ID: id, Tags: spec.Tags})
+			}
+			return described, nil
+		},
+	}
+}
+
+func TestPausedUpdateResumedOnNewLeader(t *testing.T) {
+	properties := func(data string, paused bool) string {
+		return fmt.Sprintf(`{
+  "Allocation" : { "Size" : 2 },
+  "Instance" : { "Plugin" : "instance", "Properties" : { "data" : "%s" } },
+  "Flavor" : { "Plugin" : "flavor" },
+  "Update" : { "Paused" : %v }
+}`, data, paused)
+	}
+	hash := func(spec group.Spec) string {
+		parsed, err := group_types.ParseProperties(spec)
+		require.NoError(t, err)
+		return parsed.InstanceHash()
+	}
+	old := testBuildGroupSpec("workers", properties("v1", false))
+	gs := testBuildGroupSpec("workers", properties("v2", true))
+
+	// The previous leader paused the update of the group to v2, having replaced none of the instances of v1.
+	tags := map[string]string{"infrakit.group": "workers", "infrakit.config_sha": hash(old)}
+	instances := testInstances(instance.Spec{Tags: tags}, instance.Spec{Tags: tags})
+	snap := &memSnapshot{}
+	require.NoError(t, snap.Save(testBuildGlobalSpec(t, gs).data))
+
+	// The new leader starts with a fresh group plugin
+	grp := group_plugin.NewGroupPlugin(
+		func(plugin.Name) (instance.Plugin, error) { return instances, nil },
+		func(plugin.Name) (flavor.Plugin, error) {
+			return &testing_flavor.Plugin{
+				DoValidate: func(*types.Any, group_types.AllocationMethod) error { return nil },
+				DoPrepare: func(_ *types.Any, spec instance.Spec, _ group_types.AllocationMethod,
+					_ group_types.Index) (instance.Spec, error) {
+					return spec, nil
+				},
+				DoHealthy: func(*types.Any, instance.Description) (flavor.Health, error) {
+					return flavor.Healthy, nil
+				},
+				DoDrain: func(*types.Any, instance.Description) error { return nil },
+			}, nil
+		},
+		10*time.Millisecond, 0)
+
+	dir := testDiscoveryDir(t)
+	disc, err := local.NewPluginDiscoveryWithDir(dir)
+	require.NoError(t, err)
+	st, err := server.StartPluginAtPath(filepath.Join(dir, "group-stateless"), group_rpc.PluginServer(grp))
+	require.NoError(t, err)
+
+	leaderChan := make(chan string)
+	m := NewManager(disc, &testLeaderDetector{t: t, me: "m2", input: leaderChan}, nil, snap, "group-stateless")
+	m.Start()
+	leaderChan <- "m2"
+
+	describe := func(done func(group.Description) bool) group.Description {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			desc, err := m.DescribeGroup(gs.ID)
+			if err == nil && done(desc) {
+				return desc
+			}
+			require.True(t, time.Now().Before(deadline), "group not described as expected: %v", err)
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	desc := describe(func(desc group.Description) bool {
+		return desc.Update != nil && desc.Update.Phase == group.UpdatePaused
+	})
+	require.Equal(t, 0, desc.Update.Replaced)
+	time.Sleep(100 * time.Millisecond)
+	described, err := instances.DescribeInstances(map[string]string{"infrakit.group": "workers"}, false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(described))
+	for _, inst := range described {
+		require.Equal(t, hash(old), inst.Tags["infrakit.config_sha"])
+	}
+
+	require.NoError(t, m.ResumeUpdate(gs.ID))
+
+	describe(func(desc group.Description) bool { return desc.Converged })
+	described, err = instances.DescribeInstances(map[string]string{"infrakit.group": "workers"}, false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(described))
+	for _, inst := range described {
+		require.Equal(t, hash(gs), inst.Tags["infrakit.config_sha"])
+	}
+
+	// The resume is stored, so the update is not paused again by a later change of leader
+	global := globalSpec{}
+	require.NoError(t, global.load(snap))
+	stored, err := global.getGroupSpec(gs.ID)
+	require.NoError(t, err)
+	parsed, err := group_types.ParseProperties(stored)
+	require.NoError(t, err)
+	require.False(t, parsed.Update.Paused)
+
+	m.Stop()
+	st.Stop()
+	close(leaderChan)
+}
+
+type memSnapshot struct {
+	buff []byte
+}
+
+func (s *memSnapshot) Save(obj interface{}) (err error) {
+	s.buff, err = json.Marshal(obj)
+	return
+}
+
+func (s *memSnapshot) Load(output interface{}) error {
+	if s.buff == nil {
+		return nil
+	}
+	return json.Unmarshal(s.buff, output)
+}
+
+func (s *memSnapshot) Close() error {
+	return nil
+}
+
+func TestEnforceInspectTerminate(t *testing.T) {
+	specs := specsFromYAML(t, `
+- kind: enrollment
+  metadata:
+    name: workers
+  depends:
+    - kind: resource
+      name: network
+  properties:
+    List: group/workers
+- kind: resource
+  metadata:
+    name: network
+  properties:
+    CIDR: 10.0.0.0/16
+`)
+
+	committed := []string{}
+	objects := map[string]types.Object{}
+	controllers := map[plugin.Name]*testing_controller.Controller{}
+	for _, spec := range specs {
+		spec := spec
+		name := controllerName(spec.Kind, spec.Metadata.Name)
+		controllers[name] = &testing_controller.Controller{
+			DoCommit: func(operation controller.Operation, spec types.Spec) (types.Object, error) {
+				committed = append(committed, fmt.Sprintf("%v %v", operation, keyOf(spec)))
+				object := types.Object{Spec: spec, State: types.AnyValueMust("ok")}
+				objects[keyOf(spec).String()] = object
+				return object, nil
+			},
+			DoDescribe: func(metadata *types.Metadata) ([]types.Object, error) {
+				require.Equal(t, spec.Metadata.Name, metadata.Name)
+				return []types.Object{objects[keyOf(spec).String()]}, nil
+			},
+		}
+	}
+
+	snapshot := &memSnapshot{}
+	m := &manager{
+		snapshot: snapshot,
+		lookupController: func(name plugin.Name) (controller.Controller, error) {
+			if c, has := controllers[name]; has {
+				return c, nil
+			}
+			return nil, fmt.Errorf("not found: %v", name)
+		},
+	}
+	lead := testStart(t, m)
+	defer m.Stop()
+
+	// Only the leader enforces specs
+	require.Error(t, m.Enforce(specs))
+	require.Empty(t, committed)
+
+	lead()
+	require.NoError(t, m.Enforce(specs))
+	require.Equal(t, []string{
+		fmt.Sprintf("%v resource/network", controller.Enforce),
+		fmt.Sprintf("%v enrollment/workers", controller.Enforce),
+	}, committed)
+
+	stored := globalSpec{}
+	require.NoError(t, stored.load(snapshot))
+	require.Equal(t, 2, len(stored.specs()))
+	require.Equal(t, plugin.Name("resource/network"), stored.index[key{Kind: "resource", Name: "network"}].Handler)
+
+	inspected, err := m.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(inspected))
+	require.Equal(t, "network", inspected[0].Spec.Metadata.Name)
+	require.Equal(t, "workers", inspected[1].Spec.Metadata.Name)
+	state := ""
+	require.NoError(t, inspected[1].State.Decode(&state))
+	require.Equal(t, "ok", state)
+
+	committed = []string{}
+	require.NoError(t, m.Terminate(specs))
+	require.Equal(t, []string{
+		fmt.Sprintf("%v enrollment/workers", controller.Destroy),
+		fmt.Sprintf("%v resource/network", controller.Destroy),
+	}, committed)
+
+	stored = globalSpec{}
+	require.NoError(t, stored.load(snapshot))
+	require.Empty(t, stored.specs())
+
+	inspected, err = m.Inspect()
+	require.NoError(t, err)
+	require.Empty(t, inspected)
+}
+
+func TestApplySpecsChangedOutOfBand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	specDir := testDiscoveryDir(t)
+	snap, err := file.NewSnapshot(specDir, "specs.json")
+	require.NoError(t, err)
+	require.NoError(t, snap.Save(testBuildGlobalSpec(t, testBuildGroupSpec("workers", `{"field1":"v1"}`)).data))
+
+	dir := testDiscoveryDir(t)
+	disc, err := local.NewPluginDiscoveryWithDir(dir)
+	require.NoError(t, err)
+
+	commits := make(chan group.Spec, 10)
+	gm := group_mock.NewMockPlugin(ctrl)
+	gm.EXPECT().CommitGroup(gomock.Any(), false).Do(
+		func(spec group.Spec, pretend bool) (string, error) {
+			commits <- spec
+			return "ok", nil
+		},
+	).Return("ok", nil).AnyTimes()
+	st, err := server.StartPluginAtPath(filepath.Join(dir, "group-stateless"), group_rpc.PluginServer(gm))
+	require.NoError(t, err)
+
+	leaderChan := make(chan string)
+	m := NewManager(disc, &testLeaderDetector{t: t, me: "m1", input: leaderChan}, nil, snap, "group-stateless")
+	m.Start()
+
+	committed := func() group.Spec {
+		select {
+		case spec := <-commits:
+			return spec
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "no commit")
+		}
+		return group.Spec{}
+	}
+
+	leaderChan <- "m1"
+	require.Equal(t, `{"field1":"v1"}`, committed().Properties.String())
+
+	// The specs are edited by another process
+	edit, err := file.NewSnapshot(specDir, "specs.json")
+	require.NoError(t, err)
+	require.NoError(t, edit.Save(testBuildGlobalSpec(t, testBuildGroupSpec("workers", `{"field1":"v2"}`)).data))
+	require.Equal(t, `{"field1":"v2"}`, committed().Properties.String())
+
+	// The commits of the manager itself are not applied again
+	_, err = m.CommitGroup(testBuildGroupSpec("workers", `{"field1":"v3"}`), false)
+	require.NoError(t, err)
+	require.Equal(t, `{"field1":"v3"}`, committed().Properties.String())
+	select {
+	case spec := <-commits:
+		require.Fail(t, "unexpected commit", "spec", spec)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	m.Stop()
+	st.Stop()
+	close(leaderChan)
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// PruneMode is how the groups missing from a committed set of group specs are removed.
+type PruneMode string
+
+const (
+	// PruneNone leaves the groups missing from the committed specs running.
+	PruneNone PruneMode = ""
+
+	// PruneFree frees the groups missing from the committed specs, leaving their instances running.
+	PruneFree PruneMode = "free"
+
+	// PruneDestroy destroys the groups missing from the committed specs, along with their instances.
+	PruneDestroy PruneMode = "destroy"
+)
+
+// Validate returns an error if the prune mode is not known.
+func (p PruneMode) Validate() error {
+	switch p {
+	case PruneNone, PruneFree, PruneDestroy:
+		return nil
+	}
+	return fmt.Errorf("unknown prune mode: %s", p)
+}
+
+// GroupChanges is the plan for reconciling the stored group specs with a committed set of group specs.
+type GroupChanges struct {
+	// Add are the groups that are not stored yet.
+	Add []group.ID
+
+	// Change are the stored groups whose specs are different.
+	Change []group.ID
+
+	// Remove are the stored groups that are missing from the committed specs.  These are only removed
+	// when pruning.
+	Remove []group.ID
+
+	// Prune is how the removed groups are removed.
+	Prune PruneMode
+}
+
+// Empty returns true if there are no changes.
+func (c GroupChanges) Empty() bool {
+	return len(c.Add) == 0 && len(c.Change) == 0 && len(c.Remove) == 0
+}
+
+// diffGroups compares the group specs with the groups in the stored config.  Removals are included only if
+// the groups are pruned.
+func diffGroups(stored globalSpec, specs []group.Spec, prune PruneMode) (GroupChanges, error) {
+	changes := GroupChanges{Prune: prune}
+
+	committed := map[group.ID]bool{}
+	for _, spec := range specs {
+		if committed[spec.ID] {
+			return changes, fmt.Errorf("duplicate group %v", spec.ID)
+		}
+		committed[spec.ID] = true
+
+		current, err := stored.getGroupSpec(spec.ID)
+		switch {
+		case err != nil:
+			changes.Add = append(changes.Add, spec.ID)
+		case types.Fingerprint(current.Properties) != types.Fingerprint(spec.Properties):
+			changes.Change = append(changes.Change, spec.ID)
+		}
+	}
+
+	if prune != PruneNone {
+		for k := range stored.index {
+			if k.Kind != keyFromGroupID("").Kind {
+				continue
+			}
+			if id := group.ID(k.Name); !committed[id] {
+				changes.Remove = append(changes.Remove, id)
+			}
+		}
+		sort.Slice(changes.Remove, func(i, j int) bool { return changes.Remove[i] < changes.Remove[j] })
+	}
+	return changes, nil
+}
+
+// CommitOptions are the options for committing the desired state of the groups.
+type CommitOptions struct {
+	// Prune is how the groups missing from the committed specs are removed.
+	Prune PruneMode
+
+	// Pretend is true to only return the changes, without applying them.
+	Pretend bool
+
+	// Author is who made the change, recorded in the revision of the commit.
+	Author string
+
+	// Message describes the change, recorded in the revision of the commit.
+	Message string
+}
+
+// CommitGroups commits the group specs as the desired state of all the groups of the manager.  The groups that
+// are new or changed are committed.  Groups missing from the specs are freed or destroyed according to the prune
+// mode, or left running if not pruning.  The changes are returned without being applied if pretending.
+func (m *manager) CommitGroups(specs []group.Spec, options CommitOptions) (GroupChanges, error) {
+	if options.Message == "" {
+		ids := []string{}
+		for _, spec := range specs {
+			ids = append(ids, string(spec.ID))
+		}
+		options.Message = "Commit groups " + strings.Join(ids, ", ")
+	}
+	return m.commitState(specs, nil, options)
+}
+
+// commitState saves the group specs and other specs as one revision and then applies the changes.  The specs
+// missing from the input are left in the snapshot, except for the groups pruned.  Only the groups added or changed
+// are committed to the group plugin, and the groups are applied without saving the specs again.
+func (m *manager) commitState(groups []group.Spec, others []types.Spec, options CommitOptions) (GroupChanges, error) {
+	if err := options.Prune.Validate(); err != nil {
+		return GroupChanges{}, err
+	}
+	epoch, err := m.leaderEpoch()
+	if err != nil {
+		return GroupChanges{}, err
+	}
+
+	ordered, err := orderByDependencies(others)
+	if err != nil {
+		return GroupChanges{}, err
+	}
+
+	// We first update the user's desired state, as a single revision.  The changes are found in the same
+	// operation so that no other commit is made in between.
+	changes := GroupChanges{}
+	if err := m.queueAt(epoch, "commitState", func() error {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		stored := globalSpec{}
+		if err := stored.load(m.snapshot); err != nil {
+			return err
+		}
+
+		var err error
+		changes, err = diffGroups(stored, groups, options.Prune)
+		if err != nil || options.Pretend {
+			return err
+		}
+
+		for _, spec := range groups {
+			stored.updateGroupSpec(spec, plugin.Name(m.backendName))
+		}
+		for _, id := range changes.Remove {
+			stored.removeGroup(id)
+		}
+		for _, spec := range ordered {
+			stored.updateSpec(spec, controllerName(spec.Kind, spec.Metadata.Name))
+		}
+		return m.commit(&stored, options.Author, options.Message)
+	}); err != nil || options.Pretend {
+		return changes, err
+	}
+
+	changed := map[group.ID]bool{}
+	for _, id := range append(changes.Add, changes.Change...) {
+		changed[id] = true
+	}
+	for _, spec := range groups {
+		if !changed[spec.ID] {
+			continue
+		}
+		spec := spec
+		log.Info("Committing group", "groupID", spec.ID)
+		if err := m.queueAt(epoch, "commit", func() error {
+			_, err := m.Plugin.CommitGroup(spec, false)
+			return err
+		}); err != nil {
+			return changes, fmt.Errorf("cannot commit group %v: %v", spec.ID, err)
+		}
+	}
+
+	for _, id := range changes.Remove {
+		id := id
+		log.Info("Pruning group", "groupID", id, "prune", options.Prune)
+		if err := m.queueAt(epoch, "prune", func() error {
+			if options.Prune == PruneDestroy {
+				return m.Plugin.DestroyGroup(id)
+			}
+			return m.Plugin.FreeGroup(id)
+		}); err != nil {
+			return changes, fmt.Errorf("cannot prune group %v: %v", id, err)
+		}
+	}
+
+	return changes, m.enforce(epoch, ordered)
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffGroups(t *testing.T) {
+	stored := globalSpec{}
+	for _, id := range []group.ID{"managers", "workers", "old"} {
+		stored.updateGroupSpec(group.Spec{
+			ID:         id,
+			Properties: types.AnyValueMust(map[string]interface{}{"size": 3}),
+		}, plugin.Name("group-stateless"))
+	}
+	stored.updateSpec(types.Spec{Kind: "ingress", Metadata: types.Metadata{Name: "lb"}}, "ingress/lb")
+
+	specs := []group.Spec{
+		{ID: "managers", Properties: types.AnyValueMust(map[string]interface{}{"size": 3})},
+		{ID: "workers", Properties: types.AnyValueMust(map[string]interface{}{"size": 5})},
+		{ID: "new", Properties: types.AnyValueMust(map[string]interface{}{"size": 1})},
+	}
+
+	changes, err := diffGroups(stored, specs, PruneNone)
+	require.NoError(t, err)
+	require.Equal(t, GroupChanges{
+		Add:    []group.ID{"new"},
+		Change: []group.ID{"workers"},
+	}, changes)
+
+	changes, err = diffGroups(stored, specs, PruneDestroy)
+	require.NoError(t, err)
+	require.Equal(t, GroupChanges{
+		Add:    []group.ID{"new"},
+		Change: []group.ID{"workers"},
+		Remove: []group.ID{"old"},
+		Prune:  PruneDestroy,
+	}, changes)
+	require.False(t, changes.Empty())
+
+	changes, err = diffGroups(stored, specs[:1], PruneFree)
+	require.NoError(t, err)
+	require.Equal(t, []group.ID{"old", "workers"}, changes.Remove)
+
+	_, err = diffGroups(stored, append(specs, specs[0]), PruneFree)
+	require.Error(t, err)
+}
+
+func TestCommitGroupsPretend(t *testing.T) {
+	snapshot := &memSnapshot{}
+	stored := globalSpec{}
+	stored.updateGroupSpec(group.Spec{
+		ID:         "old",
+		Properties: types.AnyValueMust(map[string]interface{}{"size": 3}),
+	}, plugin.Name("group-stateless"))
+	require.NoError(t, stored.store(snapshot))
+
+	m := &manager{snapshot: snapshot}
+	lead := testStart(t, m)
+	defer m.Stop()
+
+	specs := []group.Spec{
+		{ID: "workers", Properties: types.AnyValueMust(map[string]interface{}{"size": 5})},
+	}
+
+	_, err := m.CommitGroups(specs, CommitOptions{Prune: PruneDestroy, Pretend: true})
+	require.Error(t, err) // not the leader
+
+	lead()
+	_, err = m.CommitGroups(specs, CommitOptions{Prune: PruneMode("delete"), Pretend: true})
+	require.Error(t, err)
+
+	changes, err := m.CommitGroups(specs, CommitOptions{Prune: PruneDestroy, Pretend: true})
+	require.NoError(t, err)
+	require.Equal(t, GroupChanges{
+		Add:    []group.ID{"workers"},
+		Remove: []group.ID{"old"},
+		Prune:  PruneDestroy,
+	}, changes)
+
+	// Nothing is changed when pretending
+	after := globalSpec{}
+	require.NoError(t, after.load(snapshot))
+	require.Equal(t, 1, len(after.specs()))
+}
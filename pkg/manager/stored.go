@@ -1,7 +1,9 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/spi/group"
@@ -41,13 +43,57 @@ func (g *globalSpec) visit(f func(key, record) error) error {
 	return nil
 }
 
+// specs returns the specs sorted by kind and name.
+func (g *globalSpec) specs() []types.Spec {
+	specs := []types.Spec{}
+	for _, p := range g.sorted() {
+		specs = append(specs, p.Record.Spec)
+	}
+	return specs
+}
+
 func (g *globalSpec) store(store store.Snapshot) error {
+	g.data = g.sorted()
+	return store.Save(g.data)
+}
+
+// commit saves the specs like store, as a new revision with the author and message if the snapshot keeps
+// a history.
+func (g *globalSpec) commit(snapshot store.Snapshot, author, message string) error {
+	versioned, is := snapshot.(store.Versioned)
+	if !is {
+		return g.store(snapshot)
+	}
+	g.data = g.sorted()
+	revision, err := versioned.Commit(g.data, author, message)
+	if err == nil {
+		log.Debug("Committed revision", "revision", revision.Number, "message", message, "V", debugV)
+	}
+	return err
+}
+
+// fingerprint returns the specs encoded the same way whatever their formatting, to tell whether they have changed.
+func (g *globalSpec) fingerprint() string {
+	buff, err := json.Marshal(g.sorted())
+	if err != nil {
+		return ""
+	}
+	return string(buff)
+}
+
+// sorted returns the records sorted by key, so that the same specs are always saved the same way.
+func (g *globalSpec) sorted() []persisted {
 	data := []persisted{}
 	for k, v := range g.index {
 		data = append(data, persisted{Key: k, Record: v})
 	}
-	g.data = data
-	return store.Save(g.data)
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Key.Kind == data[j].Key.Kind {
+			return data[i].Key.Name < data[j].Key.Name
+		}
+		return data[i].Key.Kind < data[j].Key.Kind
+	})
+	return data
 }
 
 func (g *globalSpec) load(store store.Snapshot) error {
@@ -56,11 +102,29 @@ func (g *globalSpec) load(store store.Snapshot) error {
 	if err != nil {
 		return err
 	}
+	g.reindex()
+	return nil
+}
+
+// loadRevision loads the specs of a revision from the history of the snapshot.
+func (g *globalSpec) loadRevision(snapshot store.Snapshot, number int) error {
+	versioned, is := snapshot.(store.Versioned)
+	if !is {
+		return errNoHistory
+	}
+	g.data = []persisted{}
+	if err := versioned.LoadRevision(number, &g.data); err != nil {
+		return err
+	}
+	g.reindex()
+	return nil
+}
+
+func (g *globalSpec) reindex() {
 	g.index = map[key]record{}
 	for _, p := range g.data {
 		g.index[p.Key] = p.Record
 	}
-	return nil
 }
 
 func (g *globalSpec) updateSpec(spec types.Spec, handler plugin.Name) {
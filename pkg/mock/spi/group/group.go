@@ -30,6 +30,16 @@ func (_m *MockPlugin) EXPECT() *_MockPluginRecorder {
 	return _m.recorder
 }
 
+func (_m *MockPlugin) AbortCanary(_param0 group.ID) error {
+	ret := _m.ctrl.Call(_m, "AbortCanary", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockPluginRecorder) AbortCanary(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "AbortCanary", arg0)
+}
+
 func (_m *MockPlugin) CommitGroup(_param0 group.Spec, _param1 bool) (string, error) {
 	ret := _m.ctrl.Call(_m, "CommitGroup", _param0, _param1)
 	ret0, _ := ret[0].(string)
@@ -93,6 +103,47 @@ func (_mr *_MockPluginRecorder) InspectGroups() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "InspectGroups")
 }
 
+func (_m *MockPlugin) PauseUpdate(_param0 group.ID) error {
+	ret := _m.ctrl.Call(_m, "PauseUpdate", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockPluginRecorder) PauseUpdate(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PauseUpdate", arg0)
+}
+
+func (_m *MockPlugin) PlanCommit(_param0 group.Spec) (group.Plan, error) {
+	ret := _m.ctrl.Call(_m, "PlanCommit", _param0)
+	ret0, _ := ret[0].(group.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPluginRecorder) PlanCommit(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PlanCommit", arg0)
+}
+
+func (_m *MockPlugin) PromoteCanary(_param0 group.ID) error {
+	ret := _m.ctrl.Call(_m, "PromoteCanary", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockPluginRecorder) PromoteCanary(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PromoteCanary", arg0)
+}
+
+func (_m *MockPlugin) ResumeUpdate(_param0 group.ID) error {
+	ret := _m.ctrl.Call(_m, "ResumeUpdate", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockPluginRecorder) ResumeUpdate(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ResumeUpdate", arg0)
+}
+
 func (_m *MockPlugin) SetSize(_param0 group.ID, _param1 int) error {
 	ret := _m.ctrl.Call(_m, "SetSize", _param0, _param1)
 	ret0, _ := ret[0].(error)
@@ -0,0 +1,192 @@
+package group
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+)
+
+// AllocationStrategy maintains the instances of a group according to the group's allocation method.  Strategies
+// register themselves with RegisterAllocationStrategy, from this package or another, and each group is supervised
+// by the one strategy that handles its allocation method.
+type AllocationStrategy interface {
+	// Name identifies the strategy in update plans and logs.
+	Name() string
+
+	// Handles returns true if the strategy maintains groups with the allocation method.
+	Handles(allocation group_types.AllocationMethod) bool
+
+	// Validate checks that a group may be maintained by the strategy.
+	Validate(config group_types.Spec) error
+
+	// NewSupervisor creates a supervisor maintaining the allocation of a group.
+	NewSupervisor(id group.ID, scaled Scaled, config group_types.Spec,
+		pollInterval time.Duration, maxParallelNum uint) Supervisor
+
+	// Allocated returns true if the instances fulfill the allocation method.  Instances that the strategy does not
+	// maintain are ignored.
+	Allocated(allocation group_types.AllocationMethod, instances []instance.Description) bool
+
+	// Unmaintained returns the instances that the strategy does not maintain.  These are removed when a group
+	// switches to the strategy.
+	Unmaintained(allocation group_types.AllocationMethod, instances []instance.Description) []instance.Description
+}
+
+var (
+	allocationStrategies     = []AllocationStrategy{}
+	allocationStrategiesLock sync.Mutex
+)
+
+// RegisterAllocationStrategy makes an allocation strategy available to the group plugin.  It is meant to be called
+// from an init func, before any group is committed.
+func RegisterAllocationStrategy(strategy AllocationStrategy) {
+	allocationStrategiesLock.Lock()
+	defer allocationStrategiesLock.Unlock()
+
+	allocationStrategies = append(allocationStrategies, strategy)
+}
+
+// allocationStrategyFor returns the strategy handling an allocation method.  Error is returned unless exactly one
+// strategy handles the allocation method.
+func allocationStrategyFor(allocation group_types.AllocationMethod) (AllocationStrategy, error) {
+	allocationStrategiesLock.Lock()
+	defer allocationStrategiesLock.Unlock()
+
+	var found AllocationStrategy
+	for _, strategy := range allocationStrategies {
+		if !strategy.Handles(allocation) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("Allocation is ambiguous, handled by both %s and %s", found.Name(), strategy.Name())
+		}
+		found = strategy
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("No allocation strategy handles %v", allocation)
+	}
+	return found, nil
+}
+
+// allocationChange is an update that moves a group to a different allocation strategy.  The supervisor of the group
+// is replaced with one for the new strategy.  Once the new supervisor has allocated the group, instances that it does
+// not maintain are removed one at a time and any instances with an outdated configuration are rolled.
+type allocationChange struct {
+	*rollingupdate
+	context    *groupContext
+	strategy   AllocationStrategy
+	supervisor Supervisor
+}
+
+func newAllocationChange(context *groupContext, settings, newSettings groupSettings,
+	supervisor Supervisor) *allocationChange {
+
+	from := context.currentStrategy()
+	to := newSettings.strategy
+
+	desc := fmt.Sprintf("Switching the allocation strategy from %s to %s", from.Name(), to.Name())
+	if settings.config.InstanceHash() != newSettings.config.InstanceHash() {
+		count := groupSize(newSettings.config)
+		desc += fmt.Sprintf(", then performing a rolling update on %d instances%s",
+			count,
+			explainBatches(count, newSettings.config.Update))
+	}
+
+	return &allocationChange{
+		rollingupdate: &rollingupdate{
+			desc:       desc,
+			scaled:     context.scaled,
+			updatingTo: newSettings,
+			promote:    make(chan bool, 1),
+			abort:      make(chan bool, 1),
+			paused:     newSettings.config.Update.Paused,
+			resume:     make(chan bool, 1),
+			stop:       make(chan bool),
+		},
+		context:    context,
+		strategy:   to,
+		supervisor: supervisor,
+	}
+}
+
+func (c *allocationChange) Run(pollInterval time.Duration) error {
+	select {
+	case <-c.stop:
+		return errUpdateHalted
+	default:
+	}
+
+	log.Info("Switching allocation strategy", "groupID", c.supervisor.ID(), "strategy", c.strategy.Name())
+	c.context.replaceSupervisor(c.strategy, c.supervisor)
+
+	if err := c.awaitAllocation(pollInterval); err != nil {
+		return err
+	}
+
+	if err := c.removeUnmaintained(); err != nil {
+		return err
+	}
+
+	return c.rollingupdate.Run(pollInterval)
+}
+
+// removeUnmaintained drains and destroys the instances that the new strategy does not maintain, one at a time.
+func (c *allocationChange) removeUnmaintained() error {
+	instances, err := labelAndList(c.scaled)
+	if err != nil {
+		return err
+	}
+
+	unmaintained := c.strategy.Unmaintained(c.updatingTo.config.Allocation, instances)
+	sort.Sort(sortByID(unmaintained))
+
+	for _, inst := range unmaintained {
+		if err := c.waitWhilePaused(); err != nil {
+			return err
+		}
+
+		select {
+		case <-c.stop:
+			return errUpdateHalted
+		default:
+		}
+
+		log.Info("Removing instance not maintained by the allocation strategy", "id", inst.ID)
+		if err := c.scaled.Destroy(inst, instance.Termination); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// awaitAllocation blocks until the instances of the group fulfill the new allocation method.
+func (c *allocationChange) awaitAllocation(pollInterval time.Duration) error {
+	allocation := c.updatingTo.config.Allocation
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			instances, err := labelAndList(c.scaled)
+			if err != nil {
+				return err
+			}
+
+			if c.strategy.Allocated(allocation, instances) {
+				return nil
+			}
+
+			log.Info("Waiting for the group to be allocated", "groupID", c.supervisor.ID())
+
+		case <-c.stop:
+			return errUpdateHalted
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocationStrategyFor(t *testing.T) {
+	strategy, err := allocationStrategyFor(types.AllocationMethod{Size: 3})
+	require.NoError(t, err)
+	require.Equal(t, sizeAllocation{}, strategy)
+
+	strategy, err = allocationStrategyFor(types.AllocationMethod{LogicalIDs: []instance.LogicalID{"a"}})
+	require.NoError(t, err)
+	require.Equal(t, logicalIDAllocation{}, strategy)
+
+	strategy, err = allocationStrategyFor(types.AllocationMethod{Size: 1, Pool: []instance.LogicalID{"a", "b"}})
+	require.NoError(t, err)
+	require.Equal(t, poolAllocation{}, strategy)
+
+	_, err = allocationStrategyFor(types.AllocationMethod{})
+	require.Error(t, err)
+}
+
+func TestPoolAllocation(t *testing.T) {
+	pool := []instance.LogicalID{"one", "two", "three"}
+
+	require.NoError(t, poolAllocation{}.Validate(types.Spec{Allocation: types.AllocationMethod{Size: 3, Pool: pool}}))
+	require.Error(t, poolAllocation{}.Validate(types.Spec{Allocation: types.AllocationMethod{Size: 4, Pool: pool}}))
+	require.Error(t, poolAllocation{}.Validate(types.Spec{
+		Allocation: types.AllocationMethod{Size: 3, Pool: pool},
+		Update:     types.UpdatePolicy{MaxSurge: 1},
+	}))
+	require.Error(t, poolAllocation{}.Validate(types.Spec{
+		Allocation: types.AllocationMethod{Size: 1, Pool: []instance.LogicalID{"one", "one"}},
+	}))
+
+	one := instance.LogicalID("one")
+	two := instance.LogicalID("two")
+	other := instance.LogicalID("other")
+	instances := []instance.Description{
+		{ID: "a", LogicalID: &one},
+		{ID: "b", LogicalID: &one},
+		{ID: "c", LogicalID: &other},
+		{ID: "d"},
+		{ID: "e", LogicalID: &two},
+	}
+
+	maintained, others, free := partitionByPool(pool, instances)
+	require.Equal(t, []instance.Description{instances[0], instances[4]}, maintained)
+	require.Equal(t, []instance.Description{instances[1], instances[2], instances[3]}, others)
+	require.Equal(t, []instance.LogicalID{"three"}, free)
+
+	allocation := types.AllocationMethod{Size: 2, Pool: pool}
+	require.True(t, poolAllocation{}.Allocated(allocation, instances))
+	require.False(t, poolAllocation{}.Allocated(types.AllocationMethod{Size: 3, Pool: pool}, instances))
+	require.Equal(t, others, poolAllocation{}.Unmaintained(allocation, instances))
+
+	// All the instances are maintained without a pool
+	maintained, others, free = partitionByPool(nil, instances)
+	require.Equal(t, instances, maintained)
+	require.Empty(t, others)
+	require.Nil(t, free)
+}
+
+func TestAllocated(t *testing.T) {
+	one := instance.LogicalID("one")
+	two := instance.LogicalID("two")
+	instances := []instance.Description{{ID: "a", LogicalID: &one}, {ID: "b", LogicalID: &two}}
+
+	require.True(t, sizeAllocation{}.Allocated(types.AllocationMethod{Size: 2}, instances))
+	require.False(t, sizeAllocation{}.Allocated(types.AllocationMethod{Size: 3}, instances))
+
+	ids := types.AllocationMethod{LogicalIDs: []instance.LogicalID{one, two}}
+	require.True(t, logicalIDAllocation{}.Allocated(ids, instances))
+	require.False(t, logicalIDAllocation{}.Allocated(ids, instances[:1]))
+	three := instance.LogicalID("three")
+	unknown := instance.Description{ID: "c", LogicalID: &three}
+	require.False(t, logicalIDAllocation{}.Allocated(ids, append(instances, unknown)))
+
+	// Instances without logical IDs are not maintained by the quorum.
+	unmaintained := instance.Description{ID: "d"}
+	require.True(t, logicalIDAllocation{}.Allocated(ids, append(instances, unmaintained)))
+	require.Equal(t, []instance.Description{unmaintained},
+		logicalIDAllocation{}.Unmaintained(ids, append(instances, unmaintained)))
+	require.Nil(t, sizeAllocation{}.Unmaintained(types.AllocationMethod{Size: 3}, append(instances, unmaintained)))
+}
@@ -10,6 +10,7 @@ import (
 	logutil "github.com/docker/infrakit/pkg/log"
 	plugin_base "github.com/docker/infrakit/pkg/plugin"
 	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/flavor"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
@@ -44,6 +45,7 @@ func NewGroupPlugin(
 		pollInterval:    pollInterval,
 		maxParallelNum:  maxParallelNum,
 		groups:          groups{byID: map[group.ID]*groupContext{}},
+		events:          newHealthEvents(),
 	}
 }
 
@@ -54,6 +56,7 @@ type plugin struct {
 	maxParallelNum  uint
 	lock            sync.Mutex
 	groups          groups
+	events          *healthEvents
 }
 
 func (p *plugin) CommitGroup(config group.Spec, pretend bool) (string, error) {
@@ -78,23 +81,16 @@ func (p *plugin) CommitGroup(config group.Spec, pretend bool) (string, error) {
 		// not be much work, and will make this routine easier to follow.
 
 		// TODO(wfarner): Don't hold the lock - this is a blocking operation.
-		updatePlan, err := context.supervisor.PlanUpdate(context.scaled, context.settings, settings)
+		updatePlan, err := p.planUpdate(context, context.settings, settings)
 		if err != nil {
 			return "unable to fulfill request", err
 		}
 
 		if !pretend {
-			context.setUpdate(updatePlan)
-			context.changeSettings(settings)
-			go func() {
-				log.Info("Executing update plan", "groupID", config.ID, "plan", updatePlan.Explain())
-				if err := updatePlan.Run(p.pollInterval); err != nil {
-					log.Error("Update failed", "groupID", config.ID, "err", err)
-				} else {
-					log.Info("Convergence", "groupID", config.ID)
-				}
-				context.setUpdate(nil)
-			}()
+			context.setUpdate(updatePlan, settings.config.InstanceHash())
+			context.setRollback(nil)
+			previous := context.changeSettings(settings)
+			go p.runUpdate(config.ID, context, updatePlan, settings, &previous)
 		}
 
 		return updatePlan.Explain(), nil
@@ -105,22 +101,125 @@ func (p *plugin) CommitGroup(config group.Spec, pretend bool) (string, error) {
 		memberTags: map[string]string{groupTag: string(config.ID)},
 	}
 
-	var supervisor Supervisor
-	if settings.config.Allocation.Size != 0 {
-		supervisor = NewScalingGroup(config.ID, scaled, settings.config.Allocation.Size, p.pollInterval, p.maxParallelNum)
-	} else if len(settings.config.Allocation.LogicalIDs) > 0 {
-		supervisor = NewQuorum(config.ID, scaled, settings.config.Allocation.LogicalIDs, p.pollInterval)
-	} else {
-		panic("Invalid empty allocation method")
-	}
+	supervisor := settings.strategy.NewSupervisor(config.ID, scaled, settings.config, p.pollInterval, p.maxParallelNum)
 
 	scaled.supervisor = supervisor
+	desc := fmt.Sprintf("Managing %d instances", supervisor.Size())
 	if !pretend {
-		p.groups.put(config.ID, &groupContext{supervisor: supervisor, scaled: scaled, settings: settings})
+		now := time.Now()
+		context := &groupContext{
+			supervisor: supervisor,
+			strategy:   settings.strategy,
+			scaled:     scaled,
+			settings:   settings,
+		}
+		context.record(group.Commit{
+			Hash:     settings.config.InstanceHash(),
+			Plan:     desc,
+			Phase:    group.UpdateCompleted,
+			Started:  now,
+			Finished: now,
+		})
+		context.health = newHealthMonitor(config.ID, context, p.events, p.pollInterval)
+		p.groups.put(config.ID, context)
+
+		// The group may have been updating when it was last managed, as by the plugin of another leader: the
+		// instances not of the spec are rolled, and the update is held if paused in the spec.
+		updatePlan, err := supervisor.PlanUpdate(scaled, settings, settings)
+		switch {
+		case err != nil:
+			log.Warn("Cannot plan the update of the instances not of the spec", "groupID", config.ID, "err", err)
+		case !isNoop(updatePlan):
+			desc = fmt.Sprintf("%s, resuming the update: %s", desc, updatePlan.Explain())
+			context.setUpdate(updatePlan, settings.config.InstanceHash())
+			go p.runUpdate(config.ID, context, updatePlan, settings, nil)
+		}
+
 		go supervisor.Run()
+		go context.health.Run()
+	}
+
+	return desc, nil
+}
+
+func isNoop(plan updatePlan) bool {
+	switch plan.(type) {
+	case noopUpdate, *noopUpdate:
+		return true
+	}
+	return false
+}
+
+// runUpdate runs the update plan of the group.  A failed update is rolled back to the previous settings, if any,
+// when aborted as a canary or if the spec asks for it.
+func (p *plugin) runUpdate(id group.ID, context *groupContext, updatePlan updatePlan, settings groupSettings,
+	previous *groupSettings) {
+
+	log.Info("Executing update plan", "groupID", id, "plan", updatePlan.Explain())
+	if err := updatePlan.Run(p.pollInterval); err != nil {
+		log.Error("Update failed", "groupID", id, "err", err)
+		if previous != nil && (err == errCanaryAborted ||
+			(err != errUpdateHalted && settings.config.Update.AutoRollback)) {
+			p.rollback(id, context, updatePlan, settings, *previous, err)
+			return
+		}
+		context.finishUpdate(updatePlan, err)
+		return
+	}
+	log.Info("Convergence", "groupID", id)
+	context.finishUpdate(updatePlan, nil)
+}
+
+// planUpdate plans the update of a group to new settings.  Updates within the allocation strategy of the group are
+// planned by its supervisor, while a change of strategy replaces the supervisor.
+func (p *plugin) planUpdate(context *groupContext, settings, newSettings groupSettings) (updatePlan, error) {
+	if context.currentStrategy().Name() == newSettings.strategy.Name() {
+		return context.currentSupervisor().PlanUpdate(context.scaled, settings, newSettings)
+	}
+
+	supervisor := newSettings.strategy.NewSupervisor(context.currentSupervisor().ID(), context.scaled,
+		newSettings.config, p.pollInterval, p.maxParallelNum)
+	return newAllocationChange(context, settings, newSettings, supervisor), nil
+}
+
+// rollback reverts a group to its previous settings after an update has failed.  Nothing is done if the failed
+// update has since been superseded, for example by another commit.
+func (p *plugin) rollback(id group.ID, context *groupContext, failed updatePlan,
+	current, previous groupSettings, cause error) {
+
+	p.lock.Lock()
+
+	plan, err := p.planUpdate(context, current, previous)
+	if err != nil {
+		p.lock.Unlock()
+		log.Error("Unable to plan rollback", "groupID", id, "err", err)
+		context.finishUpdate(failed, cause)
+		return
+	}
+
+	if !context.rollbackUpdate(failed, cause, plan, previous.config.InstanceHash()) {
+		p.lock.Unlock()
+		log.Info("Update superseded, not rolling back", "groupID", id)
+		return
 	}
 
-	return fmt.Sprintf("Managing %d instances", supervisor.Size()), nil
+	context.setRollback(&group.Rollback{
+		From:   current.config.InstanceHash(),
+		To:     previous.config.InstanceHash(),
+		Reason: cause.Error(),
+		Time:   time.Now(),
+	})
+	context.changeSettings(previous)
+	p.lock.Unlock()
+
+	log.Warn("Rolling back update", "groupID", id, "plan", plan.Explain(), "cause", cause)
+	err = plan.Run(p.pollInterval)
+	if err != nil {
+		log.Error("Rollback failed", "groupID", id, "err", err)
+	} else {
+		log.Info("Rollback complete", "groupID", id)
+	}
+	context.finishUpdate(plan, err)
 }
 
 func (p *plugin) doFree(id group.ID) (*groupContext, error) {
@@ -133,7 +232,8 @@ func (p *plugin) doFree(id group.ID) (*groupContext, error) {
 	}
 
 	grp.stopUpdating()
-	grp.supervisor.Stop()
+	grp.health.Stop()
+	grp.currentSupervisor().Stop()
 	p.groups.del(id)
 
 	log.Info("Ignored", "groupID", id)
@@ -149,8 +249,6 @@ func (p *plugin) DescribeGroup(id group.ID) (group.Description, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	// TODO(wfarner): Include details about any in-flight updates.
-
 	context, exists := p.groups.get(id)
 	if !exists {
 		return group.Description{}, fmt.Errorf("Group '%s' is not being watched", id)
@@ -161,7 +259,13 @@ func (p *plugin) DescribeGroup(id group.ID) (group.Description, error) {
 		return group.Description{}, err
 	}
 
-	return group.Description{Instances: instances, Converged: !context.updating()}, nil
+	return group.Description{
+		Instances: instances,
+		Converged: !context.updating(),
+		Update:    context.describeUpdate(),
+		Rollback:  context.lastRollback(),
+		History:   context.commitHistory(),
+	}, nil
 }
 
 func (p *plugin) DestroyGroup(gid group.ID) error {
@@ -288,6 +392,80 @@ func (p *plugin) DestroyInstances(gid group.ID, toDestroy []instance.ID) error {
 	return p.SetSize(gid, sizeSpec-len(toDestroy)) // this will commit the change and watch again
 }
 
+func (p *plugin) canary(gid group.ID) (canaryUpdate, error) {
+	context, exists := p.groups.get(gid)
+	if !exists {
+		return nil, fmt.Errorf("Group '%s' is not being watched", gid)
+	}
+
+	if c, is := context.currentUpdate().(canaryUpdate); is {
+		return c, nil
+	}
+	return nil, errNoCanary
+}
+
+func (p *plugin) PromoteCanary(gid group.ID) error {
+	c, err := p.canary(gid)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Promoting canary", "groupID", gid)
+	return c.Promote()
+}
+
+func (p *plugin) AbortCanary(gid group.ID) error {
+	c, err := p.canary(gid)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Aborting canary", "groupID", gid)
+	return c.Abort()
+}
+
+func (p *plugin) pausable(gid group.ID) (pausableUpdate, error) {
+	context, exists := p.groups.get(gid)
+	if !exists {
+		return nil, fmt.Errorf("Group '%s' is not being watched", gid)
+	}
+
+	if u, is := context.currentUpdate().(pausableUpdate); is {
+		return u, nil
+	}
+	return nil, errNoUpdate
+}
+
+func (p *plugin) PauseUpdate(gid group.ID) error {
+	u, err := p.pausable(gid)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Pausing update", "groupID", gid)
+	return u.Pause()
+}
+
+func (p *plugin) ResumeUpdate(gid group.ID) error {
+	u, err := p.pausable(gid)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Resuming update", "groupID", gid)
+	return u.Resume()
+}
+
+// List returns the topics of the events published by the group plugin.
+func (p *plugin) List(topic types.Path) ([]string, error) {
+	return p.events.list(topic), nil
+}
+
+// PublishOn sets the channel to publish the replacements of unhealthy instances on.
+func (p *plugin) PublishOn(c chan<- *event.Event) {
+	p.events.publishOn(c)
+}
+
 func (p *plugin) InspectGroups() ([]group.Spec, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -309,6 +487,33 @@ type updatePlan interface {
 	Explain() string
 	Run(pollInterval time.Duration) error
 	Stop()
+	Progress() updateProgress
+}
+
+// updateProgress is a snapshot of the progress of an update plan.
+type updateProgress struct {
+	phase     group.UpdatePhase
+	replaced  int
+	remaining int
+	lastError string
+}
+
+// canaryUpdate is implemented by update plans that may hold in a canary phase.
+type canaryUpdate interface {
+	// Promote ends the canary phase, continuing the update.
+	Promote() error
+
+	// Abort ends the canary phase, failing the update.
+	Abort() error
+}
+
+// pausableUpdate is implemented by update plans that may be paused and resumed.
+type pausableUpdate interface {
+	// Pause holds the update before it replaces any more instances.
+	Pause() error
+
+	// Resume continues a paused update.
+	Resume() error
 }
 
 type noopUpdate struct {
@@ -325,6 +530,10 @@ func (n noopUpdate) Run(_ time.Duration) error {
 func (n noopUpdate) Stop() {
 }
 
+func (n noopUpdate) Progress() updateProgress {
+	return updateProgress{}
+}
+
 func (p *plugin) validate(config group.Spec) (groupSettings, error) {
 
 	noSettings := groupSettings{}
@@ -349,6 +558,15 @@ func (p *plugin) validate(config group.Spec) (groupSettings, error) {
 		return noSettings, errors.New("Only one Allocation method may be used")
 	}
 
+	strategy, err := allocationStrategyFor(parsed.Allocation)
+	if err != nil {
+		return noSettings, err
+	}
+
+	if err := strategy.Validate(parsed); err != nil {
+		return noSettings, err
+	}
+
 	flavorPlugin, err := p.flavorPlugins(parsed.Flavor.Plugin)
 	if err != nil {
 		return noSettings, fmt.Errorf("Failed to find Flavor plugin '%s':%v", parsed.Flavor.Plugin, err)
@@ -370,6 +588,7 @@ func (p *plugin) validate(config group.Spec) (groupSettings, error) {
 	return groupSettings{
 		instancePlugin: instancePlugin,
 		flavorPlugin:   flavorPlugin,
+		strategy:       strategy,
 		config:         parsed,
 	}, nil
 }
@@ -0,0 +1,224 @@
+package group
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/flavor"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	healthEventType = event.Type("health")
+
+	// topicReplaced is the topic of events for unhealthy instances that have been replaced.
+	topicReplaced = "replaced"
+
+	// topicError is the topic of events for unhealthy instances that could not be replaced.
+	topicError = "error"
+)
+
+// Replacement is the data of the events published when an unhealthy instance is replaced.
+type Replacement struct {
+	// Group is the group of the instance.
+	Group group.ID
+
+	// Instance is the instance that was replaced.
+	Instance instance.Description
+
+	// Failures is the number of consecutive health checks the instance failed.
+	Failures uint
+
+	// Error is set if the instance could not be destroyed.
+	Error string `json:",omitempty"`
+}
+
+// healthEvents publishes the events of the group plugin.  Events are dropped until a channel is set with PublishOn.
+type healthEvents struct {
+	topics map[string]interface{}
+	events chan<- *event.Event
+	lock   sync.Mutex
+}
+
+func newHealthEvents() *healthEvents {
+	h := &healthEvents{topics: map[string]interface{}{}}
+	for _, topic := range types.PathFromStrings(topicReplaced, topicError) {
+		types.Put(topic, h.getEndpoint, h.topics)
+	}
+	return h
+}
+
+func (h *healthEvents) getEndpoint() interface{} {
+	return "redirect to endpoint (not implemented)"
+}
+
+func (h *healthEvents) list(topic types.Path) []string {
+	return types.List(topic, h.topics)
+}
+
+func (h *healthEvents) publishOn(c chan<- *event.Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.events = c
+}
+
+func (h *healthEvents) publish(topic string, replacement Replacement) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.events == nil {
+		return
+	}
+
+	// The event is dropped rather than blocking the health checks of the group if it cannot be published
+	select {
+	case h.events <- event.Event{
+		Type: healthEventType,
+		ID:   string(replacement.Instance.ID),
+	}.Init().Now().WithTopic(topic).WithDataMust(replacement):
+	default:
+		log.Warn("Dropping event, not received", "topic", topic, "groupID", replacement.Group,
+			"id", replacement.Instance.ID)
+	}
+}
+
+// healthMonitor replaces the instances of a group that fail their health checks, according to the health policy of
+// the group.  Instances are destroyed after being drained, and the supervisor of the group creates their
+// replacements.
+type healthMonitor struct {
+	id           group.ID
+	context      *groupContext
+	events       *healthEvents
+	pollInterval time.Duration
+	// seen records when each instance was first seen, for the grace period.
+	seen map[instance.ID]time.Time
+	// failures counts the consecutive failed health checks of each instance.
+	failures map[instance.ID]uint
+	stop     chan bool
+}
+
+func newHealthMonitor(id group.ID, context *groupContext, events *healthEvents,
+	pollInterval time.Duration) *healthMonitor {
+
+	return &healthMonitor{
+		id:           id,
+		context:      context,
+		events:       events,
+		pollInterval: pollInterval,
+		seen:         map[instance.ID]time.Time{},
+		failures:     map[instance.ID]uint{},
+		stop:         make(chan bool),
+	}
+}
+
+func (h *healthMonitor) Run() {
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.check()
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+func (h *healthMonitor) Stop() {
+	close(h.stop)
+}
+
+// check performs one round of health checks, replacing the instances that have failed too many of them.
+func (h *healthMonitor) check() {
+	policy := h.context.scaled.latestSettings().config.Health
+	if policy == nil || h.context.updating() {
+		// Instances are left to the update while one is in progress, and checked from scratch afterwards.
+		h.seen = map[instance.ID]time.Time{}
+		h.failures = map[instance.ID]uint{}
+		return
+	}
+
+	instances, err := labelAndList(h.context.scaled)
+	if err != nil {
+		log.Error("Failed to list group instances", "groupID", h.id, "err", err)
+		return
+	}
+
+	now := time.Now()
+	present := map[instance.ID]bool{}
+	unhealthy := []instance.Description{}
+	for _, inst := range instances {
+		present[inst.ID] = true
+
+		first, seen := h.seen[inst.ID]
+		if !seen {
+			h.seen[inst.ID] = now
+			first = now
+		}
+		if now.Sub(first) < policy.GracePeriod.Duration() {
+			continue
+		}
+
+		switch h.context.scaled.Health(inst) {
+		case flavor.Healthy:
+			delete(h.failures, inst.ID)
+		case flavor.Unhealthy:
+			h.failures[inst.ID]++
+			if h.failures[inst.ID] >= policy.Threshold() {
+				unhealthy = append(unhealthy, inst)
+			}
+		}
+	}
+
+	for id := range h.seen {
+		if !present[id] {
+			delete(h.seen, id)
+			delete(h.failures, id)
+		}
+	}
+
+	if len(unhealthy) == 0 {
+		return
+	}
+
+	// Instances destroyed in earlier rounds that have not been replaced yet leave the group short of its size, and
+	// count against the limit of concurrent replacements.  The instances not maintained by the allocation strategy,
+	// such as the instances without a logical ID in a quorum, do not count towards the size.
+	allocation := h.context.scaled.latestSettings().config.Allocation
+	maintained := len(instances) - len(h.context.currentStrategy().Unmaintained(allocation, instances))
+	limit := int(policy.MaxReplacements()) - (int(h.context.currentSupervisor().Size()) - maintained)
+	if limit <= 0 {
+		log.Info("Waiting for replacements before replacing more unhealthy instances", "groupID", h.id)
+		return
+	}
+
+	sort.Sort(sortByID(unhealthy))
+	if len(unhealthy) > limit {
+		unhealthy = unhealthy[:limit]
+	}
+
+	for _, inst := range unhealthy {
+		replacement := Replacement{
+			Group:    h.id,
+			Instance: inst,
+			Failures: h.failures[inst.ID],
+		}
+
+		log.Warn("Replacing unhealthy instance", "groupID", h.id, "id", inst.ID, "failures", replacement.Failures)
+		if err := h.context.scaled.Destroy(inst, instance.Termination); err != nil {
+			replacement.Error = err.Error()
+			h.events.publish(topicError, replacement)
+			continue
+		}
+
+		delete(h.seen, inst.ID)
+		delete(h.failures, inst.ID)
+		h.events.publish(topicReplaced, replacement)
+	}
+}
@@ -0,0 +1,223 @@
+package group
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	plugin_base "github.com/docker/infrakit/pkg/plugin"
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/flavor"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func withHealthPolicy(properties *types.Any, policy *group_types.HealthPolicy) *types.Any {
+	spec := group_types.Spec{}
+	if err := properties.Decode(&spec); err != nil {
+		panic(err)
+	}
+	spec.Health = policy
+	return types.AnyValueMust(spec)
+}
+
+func sortedInstanceIDs(plugin *testplugin) []instance.ID {
+	ids := []instance.ID{}
+	for id := range plugin.instancesCopy() {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestReplaceUnhealthyInstance(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	sick := sortedInstanceIDs(plugin)[1]
+
+	drained := []instance.ID{}
+	drainLock := sync.Mutex{}
+	flavorPlugin := testFlavor{
+		healthy: func(flavorProperties *types.Any, inst instance.Description) (flavor.Health, error) {
+			if inst.ID == sick {
+				return flavor.Unhealthy, nil
+			}
+			return flavor.Healthy, nil
+		},
+		drain: func(flavorProperties *types.Any, inst instance.Description) error {
+			drainLock.Lock()
+			defer drainLock.Unlock()
+
+			drained = append(drained, inst.ID)
+			return nil
+		},
+	}
+	flavorLookup := func(_ plugin_base.Name) (flavor.Plugin, error) {
+		return &flavorPlugin, nil
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorLookup, 1*time.Millisecond, 0)
+
+	events := make(chan *event.Event, 10)
+	grp.(event.Publisher).PublishOn(events)
+
+	policy := group_types.HealthPolicy{UnhealthyThreshold: 3}
+	spec := group.Spec{ID: id, Properties: withHealthPolicy(minionProperties(3, "data", "init"), &policy)}
+	_, err := grp.CommitGroup(spec, false)
+	require.NoError(t, err)
+
+	select {
+	case e := <-events:
+		require.Equal(t, types.PathFromString(topicReplaced), e.Topic)
+		require.Equal(t, string(sick), e.ID)
+
+		replacement := Replacement{}
+		require.NoError(t, e.Data.Decode(&replacement))
+		require.Equal(t, id, replacement.Group)
+		require.Equal(t, sick, replacement.Instance.ID)
+		require.Equal(t, uint(3), replacement.Failures)
+
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Unhealthy instance was not replaced")
+	}
+
+	// The supervisor creates a replacement for the instance.
+	for {
+		instances := plugin.instancesCopy()
+		_, exists := instances[sick]
+		require.False(t, exists)
+		if len(instances) == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	drainLock.Lock()
+	require.Equal(t, []instance.ID{sick}, drained)
+	drainLock.Unlock()
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestHealthPolicy(t *testing.T) {
+	instances := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+
+	flavorPlugin := testFlavor{
+		healthy: func(flavorProperties *types.Any, inst instance.Description) (flavor.Health, error) {
+			return flavor.Unhealthy, nil
+		},
+	}
+	flavorLookup := func(_ plugin_base.Name) (flavor.Plugin, error) {
+		return &flavorPlugin, nil
+	}
+
+	// The poll interval is long enough that health checks are only performed by the test.
+	grp := NewGroupPlugin(pluginLookup(pluginName, instances), flavorLookup, 1*time.Hour, 0).(*plugin)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	context, exists := grp.groups.get(id)
+	require.True(t, exists)
+
+	// Without a health policy, unhealthy instances are left alone.
+	context.health.check()
+	require.Equal(t, 3, len(instances.instancesCopy()))
+
+	commit := func(policy group_types.HealthPolicy) {
+		_, err := grp.CommitGroup(group.Spec{
+			ID:         id,
+			Properties: withHealthPolicy(minionProperties(3, "data", "init"), &policy),
+		}, false)
+		require.NoError(t, err)
+		awaitGroupConvergence(t, grp)
+	}
+
+	// Instances are not checked during the grace period.
+	commit(group_types.HealthPolicy{GracePeriod: types.FromDuration(1 * time.Hour)})
+	context.health.check()
+	context.health.check()
+	require.Equal(t, 3, len(instances.instancesCopy()))
+
+	// Instances are replaced once they fail enough consecutive checks, a limited number at a time.
+	commit(group_types.HealthPolicy{UnhealthyThreshold: 2, MaxConcurrentReplacements: 2})
+	context.health.check()
+	require.Equal(t, 3, len(instances.instancesCopy()))
+	context.health.check()
+	require.Equal(t, 1, len(instances.instancesCopy()))
+
+	// No more instances are replaced until the replacements have been created.
+	context.health.check()
+	context.health.check()
+	require.Equal(t, 1, len(instances.instancesCopy()))
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestReplaceUnhealthyQuorumMembers(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(leaders, &leaderIDs[0]),
+		newFakeInstance(leaders, &leaderIDs[1]),
+		newFakeInstance(leaders, &leaderIDs[2]),
+	)
+	sick := map[instance.ID]bool{}
+	for instID, inst := range plugin.instancesCopy() {
+		if *inst.LogicalID != leaderIDs[2] {
+			sick[instID] = true
+		}
+	}
+
+	flavorPlugin := testFlavor{
+		healthy: func(flavorProperties *types.Any, inst instance.Description) (flavor.Health, error) {
+			if sick[inst.ID] {
+				return flavor.Unhealthy, nil
+			}
+			return flavor.Healthy, nil
+		},
+	}
+	flavorLookup := func(_ plugin_base.Name) (flavor.Plugin, error) {
+		return &flavorPlugin, nil
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorLookup, 1*time.Millisecond, 0)
+
+	// The events are not received, which does not hold up the replacements.
+	grp.(event.Publisher).PublishOn(make(chan *event.Event))
+
+	_, err := grp.CommitGroup(group.Spec{
+		ID:         id,
+		Properties: withHealthPolicy(leaderProperties(leaderIDs, "data"), &group_types.HealthPolicy{}),
+	}, false)
+	require.NoError(t, err)
+
+	// The members are replaced one at a time, each keeping its logical ID.
+	for {
+		instances := plugin.instancesCopy()
+		require.True(t, len(instances) >= 2)
+
+		replaced := true
+		for instID := range instances {
+			if sick[instID] {
+				replaced = false
+			}
+		}
+		if replaced && len(instances) == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	awaitLogicalIDs(t, plugin, leaderIDs)
+
+	require.NoError(t, grp.FreeGroup(id))
+}
@@ -3,6 +3,8 @@ package group
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -216,6 +218,69 @@ func TestRollingUpdate(t *testing.T) {
 	require.NoError(t, grp.FreeGroup(id))
 }
 
+func withUpdatePolicy(properties *types.Any, policy group_types.UpdatePolicy) *types.Any {
+	spec := group_types.Spec{}
+	if err := properties.Decode(&spec); err != nil {
+		panic(err)
+	}
+	spec.Update = policy
+	return types.AnyValueMust(spec)
+}
+
+func TestRollingUpdateInBatches(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			MaxSurge:       1,
+			MaxUnavailable: 1,
+		}),
+	}
+
+	desc, err := grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+	require.Equal(t, "Performing a rolling update on 3 instances in 2 batches (2, 1), surge of 1, at most 1 unavailable",
+		desc)
+
+	awaitGroupConvergence(t, grp)
+
+	// The surge instance is removed once the update is complete.
+	var instances []instance.Description
+	for {
+		instances, err = plugin.DescribeInstances(memberTags(updated.ID), false)
+		require.NoError(t, err)
+		if len(instances) == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, i := range instances {
+		require.Equal(t, provisionTags(updated), i.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestSurgeWithLogicalIDs(t *testing.T) {
+	plugin := newTestInstancePlugin()
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(group.Spec{
+		ID:         id,
+		Properties: withUpdatePolicy(leaderProperties(leaderIDs, "data"), group_types.UpdatePolicy{MaxSurge: 1}),
+	}, true)
+	require.Error(t, err)
+}
+
 func TestRollAndAdjustScale(t *testing.T) {
 	plugin := newTestInstancePlugin(
 		newFakeInstance(minions, nil),
@@ -388,6 +453,143 @@ func TestSuperviseQuorum(t *testing.T) {
 	require.NoError(t, grp.FreeGroup(id))
 }
 
+func awaitLogicalIDs(t *testing.T, plugin *testplugin, expected []instance.LogicalID) {
+	for {
+		actual := []string{}
+		for _, inst := range plugin.instancesCopy() {
+			actual = append(actual, string(*inst.LogicalID))
+		}
+		sort.Strings(actual)
+
+		wanted := []string{}
+		for _, id := range expected {
+			wanted = append(wanted, string(id))
+		}
+		sort.Strings(wanted)
+
+		if reflect.DeepEqual(wanted, actual) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQuorumLogicalIDChanges(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(leaders, &leaderIDs[0]),
+		newFakeInstance(leaders, &leaderIDs[1]),
+		newFakeInstance(leaders, &leaderIDs[2]),
+	)
+
+	var lock sync.Mutex
+	drained := []instance.LogicalID{}
+	sizeWhenDrained := []int{}
+	flavorPlugin := testFlavor{
+		drain: func(flavorProperties *types.Any, inst instance.Description) error {
+			lock.Lock()
+			defer lock.Unlock()
+			drained = append(drained, *inst.LogicalID)
+			sizeWhenDrained = append(sizeWhenDrained, len(plugin.instancesCopy()))
+			return nil
+		},
+	}
+	flavorLookup := func(_ plugin_base.Name) (flavor.Plugin, error) {
+		return &flavorPlugin, nil
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(leaders, false)
+	require.NoError(t, err)
+
+	grown := append(append([]instance.LogicalID{}, leaderIDs...), "192.168.0.7", "192.168.0.8")
+	grow := group.Spec{ID: id, Properties: leaderProperties(grown, "data")}
+
+	desc, err := grp.CommitGroup(grow, false)
+	require.NoError(t, err)
+	require.Equal(t,
+		"Changing the quorum members by adding [192.168.0.7 192.168.0.8], one member at a time", desc)
+
+	awaitGroupConvergence(t, grp)
+	awaitLogicalIDs(t, plugin, grown)
+
+	size, err := grp.Size(id)
+	require.NoError(t, err)
+	require.Equal(t, 5, size)
+
+	// Shrink to a different set of members while also changing the instance configuration.
+	shrunk := []instance.LogicalID{"192.168.0.6", "192.168.0.7", "192.168.0.8"}
+	shrink := group.Spec{ID: id, Properties: leaderProperties(shrunk, "data2")}
+
+	desc, err = grp.CommitGroup(shrink, false)
+	require.NoError(t, err)
+	require.Equal(t,
+		"Changing the quorum members by removing [192.168.0.4 192.168.0.5], one member at a time, "+
+			"then performing a rolling update on 3 instances", desc)
+
+	awaitGroupConvergence(t, grp)
+	awaitLogicalIDs(t, plugin, shrunk)
+
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(shrink), inst.Tags)
+	}
+
+	// Members are drained and removed one at a time before the remaining members are rolled.
+	lock.Lock()
+	require.Equal(t, []instance.LogicalID{"192.168.0.4", "192.168.0.5"}, drained[:2])
+	require.Equal(t, []int{5, 4}, sizeWhenDrained[:2])
+	require.Equal(t, 5, len(drained))
+	lock.Unlock()
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestSwitchAllocationStrategy(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	quorum := group.Spec{ID: id, Properties: leaderProperties(leaderIDs, "data")}
+
+	desc, err := grp.CommitGroup(quorum, true)
+	require.NoError(t, err)
+	require.Equal(t, "Switching the allocation strategy from size to logical IDs, "+
+		"then performing a rolling update on 3 instances", desc)
+
+	_, err = grp.CommitGroup(quorum, false)
+	require.NoError(t, err)
+
+	awaitGroupConvergence(t, grp)
+	awaitLogicalIDs(t, plugin, leaderIDs)
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(quorum), inst.Tags)
+	}
+
+	scaled := group.Spec{ID: id, Properties: minionProperties(2, "data", "init")}
+
+	desc, err = grp.CommitGroup(scaled, false)
+	require.NoError(t, err)
+	require.Equal(t, "Switching the allocation strategy from logical IDs to size, "+
+		"then performing a rolling update on 2 instances", desc)
+
+	awaitGroupConvergence(t, grp)
+
+	instances, err := plugin.DescribeInstances(memberTags(id), false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(instances))
+	for _, inst := range instances {
+		require.Equal(t, provisionTags(scaled), inst.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
 func TestUpdateCompletes(t *testing.T) {
 	// Tests that a completed update clears the 'update in progress state', allowing another update to commence.
 
@@ -562,6 +764,522 @@ func TestUpdateFailsWhenInstanceIsUnhealthy(t *testing.T) {
 	require.NoError(t, grp.FreeGroup(id))
 }
 
+func TestUnhealthyThreshold(t *testing.T) {
+
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+
+	flavorPlugin := testFlavor{
+		healthy: func(flavorProperties *types.Any, inst instance.Description) (flavor.Health, error) {
+			if strings.Contains(flavorProperties.String(), "bad update") {
+				return flavor.Unhealthy, nil
+			}
+			return flavor.Healthy, nil
+		},
+	}
+	flavorLookup := func(_ plugin_base.Name) (flavor.Plugin, error) {
+		return &flavorPlugin, nil
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data", "bad update"), group_types.UpdatePolicy{
+			UnhealthyThreshold: 1,
+		}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	awaitGroupConvergence(t, grp)
+
+	// The first unhealthy instance is tolerated, the second fails the update.
+	badUpdateInstances := 0
+	for _, inst := range plugin.instancesCopy() {
+		if inst.Init == "bad update" {
+			badUpdateInstances++
+		}
+	}
+
+	require.Equal(t, 2, badUpdateInstances)
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestAutoRollback(t *testing.T) {
+
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+
+	flavorPlugin := testFlavor{
+		healthy: func(flavorProperties *types.Any, inst instance.Description) (flavor.Health, error) {
+			if strings.Contains(flavorProperties.String(), "bad update") {
+				return flavor.Unhealthy, nil
+			}
+			return flavor.Healthy, nil
+		},
+	}
+	flavorLookup := func(_ plugin_base.Name) (flavor.Plugin, error) {
+		return &flavorPlugin, nil
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data", "bad update"), group_types.UpdatePolicy{
+			AutoRollback: true,
+		}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	var desc group.Description
+	for {
+		desc, err = grp.DescribeGroup(id)
+		require.NoError(t, err)
+		if desc.Rollback != nil && desc.Converged {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	original := group_types.MustParse(group_types.ParseProperties(minions))
+	require.Equal(t, original.InstanceHash(), desc.Rollback.To)
+	require.Equal(t, group_types.MustParse(group_types.ParseProperties(updated)).InstanceHash(), desc.Rollback.From)
+	require.Contains(t, desc.Rollback.Reason, "is unhealthy")
+
+	// All instances are restored to the original configuration.
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(minions), inst.Tags)
+	}
+
+	specs, err := grp.InspectGroups()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(specs))
+	require.Equal(t, original.InstanceHash(), group_types.MustParse(group_types.ParseProperties(specs[0])).InstanceHash())
+	require.False(t, group_types.MustParse(group_types.ParseProperties(specs[0])).Update.AutoRollback)
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func awaitUpdatedInstances(t *testing.T, plugin *testplugin, spec group.Spec, count int) {
+	for {
+		instances, err := plugin.DescribeInstances(provisionTags(spec), false)
+		require.NoError(t, err)
+		if len(instances) >= count {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCanarySoak(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			Canary: &group_types.CanaryPolicy{Count: 1, Soak: types.FromDuration(10 * time.Millisecond)},
+		}),
+	}
+
+	desc, err := grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+	require.Equal(t, "Performing a rolling update on 3 instances after a canary of 1 instances healthy for 10ms", desc)
+
+	awaitGroupConvergence(t, grp)
+
+	instances, err := plugin.DescribeInstances(memberTags(updated.ID), false)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(instances))
+	for _, i := range instances {
+		require.Equal(t, provisionTags(updated), i.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestCanaryPauseBetweenBatches(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			Canary:              &group_types.CanaryPolicy{Count: 1, Manual: true},
+			PauseBetweenBatches: types.FromDuration(200 * time.Millisecond),
+		}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	awaitUpdatedInstances(t, plugin, updated, 1)
+	require.NoError(t, grp.PromoteCanary(id))
+
+	// The batch after the canary waits for the pause too, once the canary is promoted.
+	var desc group.Description
+	for {
+		desc, err = grp.DescribeGroup(id)
+		require.NoError(t, err)
+		if desc.Update != nil && desc.Update.Phase == group.UpdatePausing {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.Equal(t, 1, desc.Update.Replaced)
+	instances, err := plugin.DescribeInstances(provisionTags(updated), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(instances))
+
+	awaitGroupConvergence(t, grp)
+
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(updated), inst.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestCanaryPromote(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	require.Equal(t, errNoCanary, grp.PromoteCanary(id))
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			Canary: &group_types.CanaryPolicy{Count: 1, Manual: true},
+		}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	awaitUpdatedInstances(t, plugin, updated, 1)
+
+	// The update holds after the canary until it is promoted.
+	time.Sleep(50 * time.Millisecond)
+	instances, err := plugin.DescribeInstances(provisionTags(updated), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(instances))
+
+	desc, err := grp.DescribeGroup(id)
+	require.NoError(t, err)
+	require.False(t, desc.Converged)
+
+	require.NoError(t, grp.PromoteCanary(id))
+
+	awaitGroupConvergence(t, grp)
+
+	instances, err = plugin.DescribeInstances(memberTags(updated.ID), false)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(instances))
+	for _, i := range instances {
+		require.Equal(t, provisionTags(updated), i.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestCanaryAbort(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			Canary: &group_types.CanaryPolicy{Count: 1, Manual: true},
+		}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	awaitUpdatedInstances(t, plugin, updated, 1)
+
+	require.NoError(t, grp.AbortCanary(id))
+
+	var desc group.Description
+	for {
+		desc, err = grp.DescribeGroup(id)
+		require.NoError(t, err)
+		if desc.Rollback != nil && desc.Converged {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, errCanaryAborted.Error(), desc.Rollback.Reason)
+
+	// The aborted update and its rollback are both recorded.
+	require.Equal(t, 3, len(desc.History))
+	require.Equal(t, group.UpdateFailed, desc.History[1].Phase)
+	require.Equal(t, errCanaryAborted.Error(), desc.History[1].Error)
+	require.Equal(t, group.UpdateCompleted, desc.History[2].Phase)
+	require.True(t, desc.History[2].Rollback)
+	require.Equal(t, desc.Rollback.To, desc.History[2].Hash)
+
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(minions), inst.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestUpdateProgressAndHistory(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	desc, err := grp.DescribeGroup(id)
+	require.NoError(t, err)
+	require.Nil(t, desc.Update)
+	require.Equal(t, 1, len(desc.History))
+	require.Equal(t, group.UpdateCompleted, desc.History[0].Phase)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			Canary: &group_types.CanaryPolicy{Count: 1, Manual: true},
+		}),
+	}
+
+	plan, err := grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	awaitUpdatedInstances(t, plugin, updated, 1)
+
+	desc, err = grp.DescribeGroup(id)
+	require.NoError(t, err)
+	require.NotNil(t, desc.Update)
+	require.Equal(t, plan, desc.Update.Plan)
+	require.Equal(t, group.UpdateCanary, desc.Update.Phase)
+	require.Equal(t, 1, desc.Update.Replaced)
+	require.Equal(t, 2, desc.Update.Remaining)
+	require.False(t, desc.Update.Started.IsZero())
+
+	require.NoError(t, grp.PromoteCanary(id))
+
+	awaitGroupConvergence(t, grp)
+
+	desc, err = grp.DescribeGroup(id)
+	require.NoError(t, err)
+	require.Nil(t, desc.Update)
+	require.Equal(t, 2, len(desc.History))
+	require.Equal(t, group_types.MustParse(group_types.ParseProperties(updated)).InstanceHash(), desc.History[1].Hash)
+	require.Equal(t, plan, desc.History[1].Plan)
+	require.Equal(t, group.UpdateCompleted, desc.History[1].Phase)
+
+	// Only the most recent commits are retained.
+	for i := 0; i < historyLimit; i++ {
+		_, err = grp.CommitGroup(updated, false)
+		require.NoError(t, err)
+	}
+	awaitGroupConvergence(t, grp)
+
+	desc, err = grp.DescribeGroup(id)
+	require.NoError(t, err)
+	require.Equal(t, historyLimit, len(desc.History))
+	require.Equal(t, "Noop", desc.History[0].Plan)
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestCommitPausedUpdate(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	require.Equal(t, errNoUpdate, grp.PauseUpdate(id))
+
+	updated := group.Spec{
+		ID:         id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{Paused: true}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	var desc group.Description
+	for {
+		desc, err = grp.DescribeGroup(id)
+		require.NoError(t, err)
+		if desc.Update != nil && desc.Update.Phase == group.UpdatePaused {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 0, desc.Update.Replaced)
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(minions), inst.Tags)
+	}
+
+	require.NoError(t, grp.ResumeUpdate(id))
+	require.Equal(t, errNotPaused, grp.ResumeUpdate(id))
+
+	awaitGroupConvergence(t, grp)
+
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(updated), inst.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestCommitPausedUpdateOnNewPlugin(t *testing.T) {
+	// The instances of the old config are left by the plugin of the previous leader.
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	updated := group.Spec{
+		ID:         id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{Paused: true}),
+	}
+
+	desc, err := grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+	require.Contains(t, desc, "resuming the update")
+
+	var described group.Description
+	for {
+		described, err = grp.DescribeGroup(id)
+		require.NoError(t, err)
+		if described.Update != nil && described.Update.Phase == group.UpdatePaused {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 0, described.Update.Replaced)
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(minions), inst.Tags)
+	}
+
+	require.NoError(t, grp.ResumeUpdate(id))
+
+	awaitGroupConvergence(t, grp)
+
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(updated), inst.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestPauseAndResumeUpdate(t *testing.T) {
+	plugin := newTestInstancePlugin(
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+		newFakeInstance(minions, nil),
+	)
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	_, err := grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{
+		ID: id,
+		Properties: withUpdatePolicy(minionProperties(3, "data2", "flavor2"), group_types.UpdatePolicy{
+			Canary: &group_types.CanaryPolicy{Count: 1, Manual: true},
+		}),
+	}
+
+	_, err = grp.CommitGroup(updated, false)
+	require.NoError(t, err)
+
+	awaitUpdatedInstances(t, plugin, updated, 1)
+
+	// Pause while the canary is held, so the update stops again once the canary is promoted.
+	require.NoError(t, grp.PauseUpdate(id))
+	require.NoError(t, grp.PromoteCanary(id))
+
+	var desc group.Description
+	for {
+		desc, err = grp.DescribeGroup(id)
+		require.NoError(t, err)
+		if desc.Update.Phase == group.UpdatePaused {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 1, desc.Update.Replaced)
+
+	time.Sleep(50 * time.Millisecond)
+	instances, err := plugin.DescribeInstances(provisionTags(updated), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(instances))
+
+	require.NoError(t, grp.ResumeUpdate(id))
+
+	awaitGroupConvergence(t, grp)
+
+	for _, inst := range plugin.instancesCopy() {
+		require.Equal(t, provisionTags(updated), inst.Tags)
+	}
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
 func TestNoSideEffectsFromPretendCommit(t *testing.T) {
 	// Tests that internal state is not modified by a GroupCommit with Pretend=true.
 
@@ -588,3 +1306,47 @@ func TestNoSideEffectsFromPretendCommit(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "Managing 3 instances", desc)
 }
+
+func withPool(properties *types.Any, size uint, pool ...instance.LogicalID) *types.Any {
+	spec := group_types.Spec{}
+	if err := properties.Decode(&spec); err != nil {
+		panic(err)
+	}
+	spec.Allocation = group_types.AllocationMethod{Size: size, Pool: pool}
+	return types.AnyValueMust(spec)
+}
+
+func TestPoolGroup(t *testing.T) {
+	plugin := newTestInstancePlugin()
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	pooled := group.Spec{ID: id, Properties: withPool(minionProperties(0, "data", "init"), 2, "a", "b", "c")}
+	_, err := grp.CommitGroup(pooled, false)
+	require.NoError(t, err)
+	awaitLogicalIDs(t, plugin, []instance.LogicalID{"a", "b"})
+
+	// The logical ID of an instance lost is given to its replacement
+	for instID, inst := range plugin.instancesCopy() {
+		if *inst.LogicalID == "a" {
+			require.NoError(t, plugin.Destroy(instID, instance.Termination))
+		}
+	}
+	awaitLogicalIDs(t, plugin, []instance.LogicalID{"a", "b"})
+
+	require.NoError(t, grp.SetSize(id, 3))
+	awaitLogicalIDs(t, plugin, []instance.LogicalID{"a", "b", "c"})
+
+	_, err = grp.CommitGroup(group.Spec{ID: id, Properties: withPool(minionProperties(0, "data", "init"), 4, "a", "b", "c")},
+		true)
+	require.Error(t, err)
+
+	// The instances given logical IDs no longer in the pool are replaced
+	pooled = group.Spec{ID: id, Properties: withPool(minionProperties(0, "data", "init"), 3, "b", "d", "e", "c")}
+	desc, err := grp.CommitGroup(pooled, false)
+	require.NoError(t, err)
+	require.Equal(t, "Changing the pool of logical IDs to [b d e c]", desc)
+	awaitLogicalIDs(t, plugin, []instance.LogicalID{"b", "c", "d"})
+	awaitGroupConvergence(t, grp)
+
+	require.NoError(t, grp.FreeGroup(id))
+}
@@ -0,0 +1,152 @@
+package group
+
+import (
+	"fmt"
+	"sort"
+
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// PlanCommit details the plan of CommitGroup with the same spec, without making any changes.
+func (p *plugin) PlanCommit(config group.Spec) (group.Plan, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	settings, err := p.validate(config)
+	if err != nil {
+		return group.Plan{}, err
+	}
+
+	plan := group.Plan{}
+
+	var current *group_types.Spec
+	var instances []instance.Description
+
+	if context, exists := p.groups.get(config.ID); exists {
+		update, err := p.planUpdate(context, context.settings, settings)
+		if err != nil {
+			return plan, err
+		}
+		plan.Explain = update.Explain()
+		current = &context.settings.config
+		instances, err = context.scaled.List()
+		if err != nil {
+			return plan, err
+		}
+	} else {
+		plan.Explain = fmt.Sprintf("Managing %d instances", groupSize(settings.config))
+
+		// The group may have instances already, for example when the group was freed earlier.
+		scaled := &scaledGroup{
+			settings:   settings,
+			memberTags: map[string]string{groupTag: string(config.ID)},
+		}
+		instances, err = scaled.List()
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	plan.Changes, err = diffInstanceAndFlavor(current, settings.config)
+	if err != nil {
+		return plan, err
+	}
+
+	hash := settings.config.InstanceHash()
+	if current != nil {
+		hash = current.InstanceHash()
+	}
+	plan.Instances = planInstances(instances, hash, settings)
+	return plan, nil
+}
+
+// diffInstanceAndFlavor returns the field-level changes of the instance and flavor configurations of a group.  The
+// current configuration is nil for a group not committed yet.
+func diffInstanceAndFlavor(current *group_types.Spec, config group_types.Spec) ([]types.Change, error) {
+	sections := func(spec group_types.Spec) *types.Any {
+		return types.AnyValueMust(map[string]interface{}{
+			"Instance": spec.Instance,
+			"Flavor":   spec.Flavor,
+		})
+	}
+	var from *types.Any
+	if current != nil {
+		from = sections(*current)
+	}
+	return types.Diff(from, sections(config))
+}
+
+// planInstances determines which of the instances of a group are destroyed, created or left alone to reach the new
+// settings.  Instances pending a label are counted as having the current configuration, given by hash.  Where the
+// supervisors choose among the instances, as when a scaling group shrinks, the instances are chosen the same way.
+func planInstances(instances []instance.Description, hash string, settings groupSettings) instance.Plan {
+	plan := instance.Plan{}
+
+	sorted := make([]instance.Description, len(instances))
+	copy(sorted, instances)
+	sort.Sort(sortByID(sorted))
+
+	desiredHash := settings.config.InstanceHash()
+	desired := func(inst instance.Description) bool {
+		if instanceNeedsLabel(inst) {
+			return hash == desiredHash
+		}
+		return inst.Tags[configTag] == desiredHash
+	}
+
+	unmaintained := map[instance.ID]bool{}
+	for _, inst := range settings.strategy.Unmaintained(settings.config.Allocation, sorted) {
+		unmaintained[inst.ID] = true
+	}
+
+	maintained := []instance.Description{}
+	for _, inst := range sorted {
+		if unmaintained[inst.ID] {
+			plan.Destroy = append(plan.Destroy, inst.ID)
+			continue
+		}
+		maintained = append(maintained, inst)
+	}
+
+	if logicalIDs := settings.config.Allocation.LogicalIDs; len(logicalIDs) > 0 {
+		wanted := map[instance.LogicalID]bool{}
+		for _, id := range logicalIDs {
+			wanted[id] = true
+		}
+
+		kept := map[instance.LogicalID]bool{}
+		for _, inst := range maintained {
+			if inst.LogicalID != nil {
+				if id := *inst.LogicalID; wanted[id] && !kept[id] && desired(inst) {
+					kept[id] = true
+					plan.Keep = append(plan.Keep, inst.ID)
+					continue
+				}
+			}
+			plan.Destroy = append(plan.Destroy, inst.ID)
+		}
+
+		for _, id := range logicalIDs {
+			if !kept[id] {
+				plan.LogicalIDs = append(plan.LogicalIDs, id)
+			}
+		}
+		plan.Create = len(plan.LogicalIDs)
+		return plan
+	}
+
+	// A scaling group removes its excess instances in the order of their IDs.
+	excess := len(maintained) - int(settings.config.Allocation.Size)
+	for i, inst := range maintained {
+		if i < excess || !desired(inst) {
+			plan.Destroy = append(plan.Destroy, inst.ID)
+			continue
+		}
+		plan.Keep = append(plan.Keep, inst.ID)
+	}
+	plan.Create = int(settings.config.Allocation.Size) - len(plan.Keep)
+	return plan
+}
@@ -0,0 +1,82 @@
+package group
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanCommit(t *testing.T) {
+	plugin := newTestInstancePlugin()
+	ids := []instance.ID{
+		plugin.addInstance(newFakeInstance(minions, nil)),
+		plugin.addInstance(newFakeInstance(minions, nil)),
+		plugin.addInstance(newFakeInstance(minions, nil)),
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	plan, err := grp.PlanCommit(minions)
+	require.NoError(t, err)
+	require.Equal(t, "Managing 3 instances", plan.Explain)
+	require.Equal(t, 2, len(plan.Changes))
+	require.Equal(t, "Flavor", plan.Changes[0].Path)
+	require.Equal(t, "Instance", plan.Changes[1].Path)
+	require.Nil(t, plan.Changes[1].Old)
+	require.Equal(t, instance.Plan{Keep: ids}, plan.Instances)
+
+	_, err = grp.CommitGroup(minions, false)
+	require.NoError(t, err)
+
+	updated := group.Spec{ID: id, Properties: minionProperties(2, "data2", "init")}
+
+	plan, err = grp.PlanCommit(updated)
+	require.NoError(t, err)
+	require.Equal(t, "Terminating 1 instances to reduce the group size to 2,"+
+		" then performing a rolling update on 2 instances", plan.Explain)
+	require.Equal(t, 1, len(plan.Changes))
+	require.Equal(t, "Instance/Properties/OpaqueValue", plan.Changes[0].Path)
+	require.Equal(t, `"data"`, plan.Changes[0].Old.String())
+	require.Equal(t, `"data2"`, plan.Changes[0].New.String())
+	require.Equal(t, instance.Plan{Destroy: ids, Create: 2}, plan.Instances)
+
+	// Only the size changes
+	plan, err = grp.PlanCommit(group.Spec{ID: id, Properties: minionProperties(2, "data", "init")})
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes)
+	require.Equal(t, instance.Plan{Destroy: ids[:1], Keep: ids[1:]}, plan.Instances)
+
+	// Planning has no side effects
+	desc, err := grp.DescribeGroup(id)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(desc.Instances))
+	require.Equal(t, 3, len(plugin.instancesCopy()))
+
+	require.NoError(t, grp.FreeGroup(id))
+}
+
+func TestPlanCommitQuorum(t *testing.T) {
+	plugin := newTestInstancePlugin()
+	ids := []instance.ID{
+		plugin.addInstance(newFakeInstance(leaders, &leaderIDs[0])),
+		plugin.addInstance(newFakeInstance(leaders, &leaderIDs[1])),
+		plugin.addInstance(newFakeInstance(leaders, nil)),
+	}
+
+	grp := NewGroupPlugin(pluginLookup(pluginName, plugin), flavorPluginLookup, 1*time.Millisecond, 0)
+
+	plan, err := grp.PlanCommit(group.Spec{ID: id, Properties: leaderProperties(leaderIDs[1:], "data")})
+	require.NoError(t, err)
+	require.Equal(t, instance.Plan{
+		Destroy:    []instance.ID{ids[2], ids[0]},
+		Create:     1,
+		LogicalIDs: leaderIDs[2:],
+		Keep:       ids[1:2],
+	}, plan.Instances)
+
+	_, err = grp.PlanCommit(group.Spec{ID: id})
+	require.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package group
+
+import (
+	"fmt"
+	"time"
+
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+)
+
+func init() {
+	RegisterAllocationStrategy(poolAllocation{})
+}
+
+// poolAllocation maintains a number of instances, each given one of a pool of logical IDs.  Unlike a quorum, the
+// instances are interchangeable and the group is sized freely within the pool.
+type poolAllocation struct{}
+
+func (poolAllocation) Name() string {
+	return "logical ID pool"
+}
+
+func (poolAllocation) Handles(allocation group_types.AllocationMethod) bool {
+	return allocation.Size > 0 && len(allocation.Pool) > 0 && len(allocation.LogicalIDs) == 0
+}
+
+func (poolAllocation) Validate(config group_types.Spec) error {
+	seen := map[instance.LogicalID]bool{}
+	for _, id := range config.Allocation.Pool {
+		if seen[id] {
+			return fmt.Errorf("Logical ID %v is in the pool more than once", id)
+		}
+		seen[id] = true
+	}
+
+	if need := config.Allocation.Size + config.Update.MaxSurge; uint(len(config.Allocation.Pool)) < need {
+		return fmt.Errorf("Pool has %d logical IDs, less than the %d instances of the group",
+			len(config.Allocation.Pool), need)
+	}
+	return nil
+}
+
+func (poolAllocation) NewSupervisor(id group.ID, scaled Scaled, config group_types.Spec,
+	pollInterval time.Duration, maxParallelNum uint) Supervisor {
+
+	return NewPoolGroup(id, scaled, config.Allocation.Size, config.Allocation.Pool, pollInterval, maxParallelNum)
+}
+
+func (poolAllocation) Allocated(allocation group_types.AllocationMethod, instances []instance.Description) bool {
+	maintained, _, _ := partitionByPool(allocation.Pool, instances)
+	return len(maintained) == int(allocation.Size)
+}
+
+// Unmaintained returns the instances without a logical ID of the pool, or with the logical ID of another instance.
+func (poolAllocation) Unmaintained(allocation group_types.AllocationMethod,
+	instances []instance.Description) []instance.Description {
+
+	_, others, _ := partitionByPool(allocation.Pool, instances)
+	return others
+}
+
+// NewPoolGroup creates a supervisor that maintains a number of instances on a provisioner, giving each instance a
+// logical ID of the pool that no other instance has.
+func NewPoolGroup(id group.ID, scaled Scaled, size uint, pool []instance.LogicalID, pollInterval time.Duration,
+	maxParallelNum uint) Supervisor {
+
+	s := NewScalingGroup(id, scaled, size, pollInterval, maxParallelNum).(*scaler)
+	s.pool = pool
+	return s
+}
+
+// partitionByPool splits the instances into those given a logical ID of the pool, one per logical ID, and the
+// others, and returns the logical IDs of the pool given to none of the instances in the order of the pool.  All the
+// instances are maintained if the pool is nil.
+func partitionByPool(pool []instance.LogicalID,
+	instances []instance.Description) (maintained, others []instance.Description, free []instance.LogicalID) {
+
+	maintained = []instance.Description{}
+	others = []instance.Description{}
+	if pool == nil {
+		return append(maintained, instances...), others, nil
+	}
+
+	given := map[instance.LogicalID]bool{}
+	for _, id := range pool {
+		given[id] = false
+	}
+	for _, inst := range instances {
+		if inst.LogicalID == nil {
+			others = append(others, inst)
+			continue
+		}
+		if taken, in := given[*inst.LogicalID]; !in || taken {
+			others = append(others, inst)
+			continue
+		}
+		given[*inst.LogicalID] = true
+		maintained = append(maintained, inst)
+	}
+
+	free = []instance.LogicalID{}
+	for _, id := range pool {
+		if !given[id] {
+			free = append(free, id)
+		}
+	}
+	return
+}
@@ -3,24 +3,84 @@ package group
 import (
 	"errors"
 	"fmt"
-	"reflect"
+	"strings"
 	"sync"
 	"time"
 
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
+	"github.com/docker/infrakit/pkg/spi/flavor"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 )
 
-// TODO(wfarner): Converge this implementation with scaler.go, they share a lot of behavior.
-
 type quorum struct {
 	id           group.ID
 	scaled       Scaled
 	LogicalIDs   []instance.LogicalID
 	pollInterval time.Duration
+	lock         sync.Mutex
 	stop         chan bool
 }
 
+func init() {
+	RegisterAllocationStrategy(logicalIDAllocation{})
+}
+
+// logicalIDAllocation maintains one instance for each of a set of logical IDs, for example the members of a quorum.
+type logicalIDAllocation struct{}
+
+func (logicalIDAllocation) Name() string {
+	return "logical IDs"
+}
+
+func (logicalIDAllocation) Handles(allocation group_types.AllocationMethod) bool {
+	return len(allocation.LogicalIDs) > 0
+}
+
+func (logicalIDAllocation) Validate(config group_types.Spec) error {
+	if config.Update.MaxSurge > 0 {
+		return errors.New("MaxSurge may not be used with LogicalIDs")
+	}
+	return nil
+}
+
+func (logicalIDAllocation) NewSupervisor(id group.ID, scaled Scaled, config group_types.Spec,
+	pollInterval time.Duration, maxParallelNum uint) Supervisor {
+
+	return NewQuorum(id, scaled, config.Allocation.LogicalIDs, pollInterval)
+}
+
+func (logicalIDAllocation) Allocated(allocation group_types.AllocationMethod, instances []instance.Description) bool {
+	remaining := map[instance.LogicalID]bool{}
+	for _, id := range allocation.LogicalIDs {
+		remaining[id] = true
+	}
+
+	for _, inst := range instances {
+		if inst.LogicalID == nil {
+			continue
+		}
+		if !remaining[*inst.LogicalID] {
+			return false
+		}
+		delete(remaining, *inst.LogicalID)
+	}
+	return len(remaining) == 0
+}
+
+// Unmaintained returns the instances without a logical ID, which are ignored by the quorum supervisor.
+func (logicalIDAllocation) Unmaintained(allocation group_types.AllocationMethod,
+	instances []instance.Description) []instance.Description {
+
+	unmaintained := []instance.Description{}
+	for _, inst := range instances {
+		if inst.LogicalID == nil {
+			unmaintained = append(unmaintained, inst)
+		}
+	}
+	return unmaintained
+}
+
 // NewQuorum creates a supervisor for a group of instances operating in a quorum.
 func NewQuorum(id group.ID, scaled Scaled, logicalIDs []instance.LogicalID, pollInterval time.Duration) Supervisor {
 	return &quorum{
@@ -32,25 +92,211 @@ func NewQuorum(id group.ID, scaled Scaled, logicalIDs []instance.LogicalID, poll
 	}
 }
 
-func (q *quorum) PlanUpdate(scaled Scaled, settings groupSettings, newSettings groupSettings) (updatePlan, error) {
+// diffLogicalIDs returns the logical IDs that are in to but not in from, and those in from but not in to.
+func diffLogicalIDs(from, to []instance.LogicalID) (added, removed []instance.LogicalID) {
+	contains := func(ids []instance.LogicalID, id instance.LogicalID) bool {
+		for _, i := range ids {
+			if i == id {
+				return true
+			}
+		}
+		return false
+	}
 
-	if !reflect.DeepEqual(settings.config.Allocation.LogicalIDs, newSettings.config.Allocation.LogicalIDs) {
-		return nil, errors.New("Logical ID changes to a quorum is not currently supported")
+	for _, id := range to {
+		if !contains(from, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range from {
+		if !contains(to, id) {
+			removed = append(removed, id)
+		}
 	}
+	return
+}
 
-	if settings.config.InstanceHash() == newSettings.config.InstanceHash() {
+func (q *quorum) PlanUpdate(scaled Scaled, settings groupSettings, newSettings groupSettings) (updatePlan, error) {
+
+	added, removed := diffLogicalIDs(settings.config.Allocation.LogicalIDs, newSettings.config.Allocation.LogicalIDs)
+	rolling := settings.config.InstanceHash() != newSettings.config.InstanceHash()
+
+	if !rolling && len(added) == 0 && len(removed) == 0 {
 		// This is a no-op update because the instance configuration is unchanged
 		return &noopUpdate{}, nil
 	}
 
-	return &rollingupdate{
+	plan := &rollingupdate{
 		desc: fmt.Sprintf(
-			"Performing a rolling update on %d instances",
-			len(settings.config.Allocation.LogicalIDs)),
+			"Performing a rolling update on %d instances%s",
+			len(settings.config.Allocation.LogicalIDs),
+			explainBatches(len(settings.config.Allocation.LogicalIDs), newSettings.config.Update)),
 		scaled:     scaled,
 		updatingTo: newSettings,
+		promote:    make(chan bool, 1),
+		abort:      make(chan bool, 1),
+		paused:     newSettings.config.Update.Paused,
+		resume:     make(chan bool, 1),
 		stop:       make(chan bool),
-	}, nil
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		return plan, nil
+	}
+
+	changes := []string{}
+	if len(added) > 0 {
+		changes = append(changes, fmt.Sprintf("adding %v", added))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, fmt.Sprintf("removing %v", removed))
+	}
+	desc := fmt.Sprintf("Changing the quorum members by %s, one member at a time", strings.Join(changes, " and "))
+	if rolling {
+		desc += fmt.Sprintf(", then performing a rolling update on %d instances%s",
+			len(newSettings.config.Allocation.LogicalIDs),
+			explainBatches(len(newSettings.config.Allocation.LogicalIDs), newSettings.config.Update))
+	}
+	plan.desc = desc
+
+	return &quorumUpdatePlan{rollingupdate: plan, quorum: q}, nil
+}
+
+// quorumUpdatePlan changes the members of a quorum one at a time, waiting for all members to be healthy after each
+// change, and then rolls any members that do not match the desired configuration.
+type quorumUpdatePlan struct {
+	*rollingupdate
+	quorum *quorum
+}
+
+// Run adds the new members before removing the old ones, so that the quorum never shrinks below the smaller of its
+// previous and desired sizes.  The changes are computed from the members currently supervised, which allows an
+// interrupted update to be picked up by a later one.
+func (p *quorumUpdatePlan) Run(pollInterval time.Duration) error {
+
+	current := p.quorum.logicalIDs()
+	target := p.updatingTo.config.Allocation.LogicalIDs
+	added, removed := diffLogicalIDs(current, target)
+
+	if len(added) > 0 || len(removed) > 0 {
+		p.track(func(progress *updateProgress) {
+			progress.phase = group.UpdateRolling
+			progress.remaining = len(added) + len(removed)
+		})
+
+		// Never change the members of a quorum that is not healthy to begin with.
+		if err := p.awaitQuorum(pollInterval); err != nil {
+			return err
+		}
+
+		members := append([]instance.LogicalID{}, current...)
+		step := func(change []instance.LogicalID) error {
+			if err := p.waitWhilePaused(); err != nil {
+				return err
+			}
+
+			p.quorum.setLogicalIDs(change)
+			if err := p.awaitQuorum(pollInterval); err != nil {
+				return err
+			}
+
+			p.track(func(progress *updateProgress) {
+				progress.replaced++
+				progress.remaining--
+			})
+			members = change
+			return nil
+		}
+
+		for _, id := range added {
+			log.Info("Adding quorum member", "groupID", p.quorum.ID(), "logicalID", id)
+			if err := step(append(append([]instance.LogicalID{}, members...), id)); err != nil {
+				return err
+			}
+		}
+
+		for _, id := range removed {
+			log.Info("Removing quorum member", "groupID", p.quorum.ID(), "logicalID", id)
+			remaining := []instance.LogicalID{}
+			for _, member := range members {
+				if member != id {
+					remaining = append(remaining, member)
+				}
+			}
+			if err := step(remaining); err != nil {
+				return err
+			}
+		}
+
+		p.quorum.setLogicalIDs(target)
+	}
+
+	return p.rollingupdate.Run(pollInterval)
+}
+
+// awaitQuorum blocks until there is a healthy instance for every member of the quorum, and no instance remains for
+// members that were removed.  Instances are added and removed by the quorum supervisor, which drains instances
+// before destroying them.
+func (p *quorumUpdatePlan) awaitQuorum(pollInterval time.Duration) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			instances, err := labelAndList(p.scaled)
+			if err != nil {
+				return err
+			}
+
+			byLogicalID := map[instance.LogicalID]instance.Description{}
+			for _, inst := range instances {
+				if inst.LogicalID != nil {
+					byLogicalID[*inst.LogicalID] = inst
+				}
+			}
+
+			ready := true
+			for _, id := range p.quorum.logicalIDs() {
+				inst, has := byLogicalID[id]
+				if !has {
+					ready = false
+					continue
+				}
+				delete(byLogicalID, id)
+
+				switch p.scaled.Health(inst) {
+				case flavor.Healthy:
+				case flavor.Unhealthy:
+					return fmt.Errorf("Instance %s is unhealthy", inst.ID)
+				default:
+					ready = false
+				}
+			}
+
+			if ready && len(byLogicalID) == 0 {
+				return nil
+			}
+
+			log.Info("Waiting for quorum members", "groupID", p.quorum.ID())
+
+		case <-p.stop:
+			return errUpdateHalted
+		}
+	}
+}
+
+func (q *quorum) logicalIDs() []instance.LogicalID {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.LogicalIDs
+}
+
+func (q *quorum) setLogicalIDs(logicalIDs []instance.LogicalID) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.LogicalIDs = logicalIDs
 }
 
 func (q *quorum) Stop() {
@@ -78,7 +324,7 @@ func (q *quorum) ID() group.ID {
 }
 
 func (q *quorum) Size() uint {
-	return uint(len(q.LogicalIDs))
+	return uint(len(q.logicalIDs()))
 }
 
 func (q *quorum) converge() {
@@ -90,6 +336,8 @@ func (q *quorum) converge() {
 
 	log.Debug("Found existing instances", "groupID", q.ID(), "descriptions", descriptions, "V", debugV)
 
+	logicalIDs := q.logicalIDs()
+
 	unknownIPs := []instance.Description{}
 	for _, description := range descriptions {
 		if description.LogicalID == nil {
@@ -98,7 +346,7 @@ func (q *quorum) converge() {
 		}
 
 		matched := false
-		for _, expectedID := range q.LogicalIDs {
+		for _, expectedID := range logicalIDs {
 			if expectedID == *description.LogicalID {
 				matched = true
 			}
@@ -122,7 +370,7 @@ func (q *quorum) converge() {
 	}
 
 	missingIDs := []instance.LogicalID{}
-	for _, expectedID := range q.LogicalIDs {
+	for _, expectedID := range logicalIDs {
 		matched := false
 		for _, description := range descriptions {
 			if description.LogicalID == nil {
@@ -1,6 +1,7 @@
 package group
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -151,8 +152,13 @@ func TestQuorumPlanUpdateLogicalIDChange(t *testing.T) {
 		},
 	}
 	quorum := NewQuorum(groupID, scaled, logicalIDs, 1*time.Millisecond)
-	_, err := quorum.PlanUpdate(scaled, settingsOld, settingsNew)
-	require.Error(t, err)
+	plan, err := quorum.PlanUpdate(scaled, settingsOld, settingsNew)
+	require.NoError(t, err)
+	require.IsType(t, &quorumUpdatePlan{}, plan)
+	require.Equal(t,
+		fmt.Sprintf("Changing the quorum members by adding [%v] and removing [%v], one member at a time",
+			*b.LogicalID, *a.LogicalID),
+		plan.Explain())
 }
 
 func TestQuorumPlanUpdateRollingUpdate(t *testing.T) {
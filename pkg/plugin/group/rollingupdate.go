@@ -4,12 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
 	"github.com/docker/infrakit/pkg/spi/flavor"
+	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 )
 
+var (
+	errUpdateHalted  = errors.New("Update halted by user")
+	errCanaryAborted = errors.New("Canary aborted by user")
+	errNoCanary      = errors.New("No canary in progress")
+	errNoUpdate      = errors.New("No update in progress")
+	errNotPaused     = errors.New("Update is not paused")
+)
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -35,23 +47,126 @@ func desiredAndUndesiredInstances(
 	return desired, undesired
 }
 
+// groupSize returns the number of instances a group is configured to maintain.
+func groupSize(config group_types.Spec) int {
+	if n := len(config.Allocation.LogicalIDs); n > 0 {
+		return n
+	}
+	return int(config.Allocation.Size)
+}
+
+// canaryCount returns the number of instances replaced in the canary phase of an update of count instances.  There
+// is no canary phase if the canary would cover the entire update.
+func canaryCount(count int, policy group_types.UpdatePolicy) int {
+	if policy.Canary == nil || int(policy.Canary.Count) >= count {
+		return 0
+	}
+	return int(policy.Canary.Count)
+}
+
+// explainBatches describes how a rolling update of count instances is broken down under the given policy.
+// The default policy yields an empty description.
+func explainBatches(count int, policy group_types.UpdatePolicy) string {
+	if policy.Paused {
+		return explainSteps(count, policy) + ", paused until resumed"
+	}
+	return explainSteps(count, policy)
+}
+
+func explainSteps(count int, policy group_types.UpdatePolicy) string {
+	canary := ""
+	if canaries := canaryCount(count, policy); canaries > 0 {
+		canary = fmt.Sprintf(" after a canary of %d instances", canaries)
+		if d := policy.Canary.Soak.Duration(); d > 0 {
+			canary += fmt.Sprintf(" healthy for %v", d)
+		}
+		if policy.Canary.Manual {
+			canary += ", held until promoted"
+		}
+		count -= canaries
+	}
+
+	details := []string{}
+	if policy.MaxSurge > 0 {
+		details = append(details, fmt.Sprintf("surge of %d", policy.MaxSurge))
+	}
+	if policy.MaxUnavailable > 0 {
+		details = append(details, fmt.Sprintf("at most %d unavailable", policy.MaxUnavailable))
+	}
+	if d := policy.PauseBetweenBatches.Duration(); d > 0 {
+		details = append(details, fmt.Sprintf("pausing %v between batches", d))
+	}
+	if d := policy.MinHealthyDuration.Duration(); d > 0 {
+		details = append(details, fmt.Sprintf("healthy for %v before continuing", d))
+	}
+
+	batch := int(policy.Batch())
+	if count == 0 || (batch == 1 && len(details) == 0) {
+		return canary
+	}
+
+	sizes := []string{}
+	for remaining := count; remaining > 0; remaining -= batch {
+		sizes = append(sizes, fmt.Sprintf("%d", minInt(batch, remaining)))
+	}
+
+	desc := fmt.Sprintf(" in %d batches (%s)", len(sizes), strings.Join(sizes, ", "))
+	if len(details) > 0 {
+		desc += ", " + strings.Join(details, ", ")
+	}
+	return desc + canary
+}
+
 type rollingupdate struct {
 	desc       string
 	scaled     Scaled
 	updatingTo groupSettings
-	stop       chan bool
+	// surge is the number of instances the scaler is allowed to create above the target size during the update.
+	surge uint
+	// unhealthy records the updated instances that have been reported unhealthy.
+	unhealthy map[instance.ID]bool
+	// canary is true while the update is in its canary phase, which ends when promote or abort is signalled.
+	canary  bool
+	promote chan bool
+	abort   chan bool
+	// paused holds the update before it destroys any more instances, until resume is signalled.
+	paused   bool
+	resume   chan bool
+	progress updateProgress
+	lock     sync.Mutex
+	stop     chan bool
 }
 
-func (r rollingupdate) Explain() string {
+func (r *rollingupdate) Explain() string {
 	return r.desc
 }
 
+// Progress reports the phase of the update and the number of instances replaced so far.
+func (r *rollingupdate) Progress() updateProgress {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.progress
+}
+
+// track applies a change to the progress of the update.
+func (r *rollingupdate) track(change func(*updateProgress)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	change(&r.progress)
+}
+
 func (r *rollingupdate) waitUntilQuiesced(pollInterval time.Duration, expectedNewInstances int) error {
 	// Block until the expected number of instances in the desired state are ready.  Updates are unconcerned with
 	// the health of instances in the undesired state.  This allows a user to dig out of a hole where the original
 	// state of the group is bad, and instances are not reporting as healthy.
 
+	minHealthy := r.updatingTo.config.Update.MinHealthyDuration.Duration()
+	var healthySince time.Time
+
 	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
@@ -80,11 +195,15 @@ func (r *rollingupdate) waitUntilQuiesced(pollInterval time.Duration, expectedNe
 			//   - the update will continue indefinitely if one or more instances are in the
 			//     flavor.UnknownHealth state.  Operators must stop the update and diagnose the cause.
 			//
-			//   - the update is stopped immediately if any instance enters the flavor.Unhealthy state.
+			//   - the update is stopped immediately if more instances than allowed by the update policy enter the
+			//     flavor.Unhealthy state.
 			//
 			//   - the update will proceed with other instances immediately when the currently-expected
 			//     number of instances are observed in the flavor.Healthy state.
 			//
+			//   - up to UnhealthyThreshold updated instances may be reported unhealthy without failing the
+			//     update.  These instances count towards the expected number of instances.
+			//
 			numHealthy := 0
 			for _, inst := range matching {
 				// TODO(wfarner): More careful thought is needed with respect to blocking and timeouts
@@ -94,24 +213,200 @@ func (r *rollingupdate) waitUntilQuiesced(pollInterval time.Duration, expectedNe
 				case flavor.Healthy:
 					numHealthy++
 				case flavor.Unhealthy:
-					return fmt.Errorf("Instance %s is unhealthy", inst.ID)
+					if r.unhealthy == nil {
+						r.unhealthy = map[instance.ID]bool{}
+					}
+					r.unhealthy[inst.ID] = true
+					if len(r.unhealthy) > int(r.updatingTo.config.Update.UnhealthyThreshold) {
+						return fmt.Errorf("Instance %s is unhealthy", inst.ID)
+					}
+					log.Warn("Tolerating unhealthy instance", "id", inst.ID, "unhealthy", len(r.unhealthy))
+					r.track(func(p *updateProgress) {
+						p.lastError = fmt.Sprintf("Instance %s is unhealthy", inst.ID)
+					})
+					numHealthy++
 				}
 			}
 
 			if numHealthy >= int(expectedNewInstances) {
-				return nil
+				// Instances must remain healthy for the duration required by the update policy.
+				if healthySince.IsZero() {
+					healthySince = time.Now()
+				}
+				if time.Since(healthySince) >= minHealthy {
+					return nil
+				}
+			} else {
+				healthySince = time.Time{}
 			}
 
 			log.Info("Waiting for scaler to quiesce")
 
+		case <-r.abort:
+			return errCanaryAborted
+
+		case <-r.stop:
+			return errUpdateHalted
+		}
+	}
+}
+
+// soakCanaries holds the update while the canary instances are checked for health.  The canary phase ends once the
+// soak period elapses without a canary reporting unhealthy, or when the canary is explicitly promoted or aborted.
+func (r *rollingupdate) soakCanaries(pollInterval time.Duration) error {
+	defer r.setCanary(false)
+
+	policy := r.updatingTo.config.Update.Canary
+	log.Info("Soaking canary instances", "soak", policy.Soak, "manual", policy.Manual)
+
+	deadline := time.Now().Add(policy.Soak.Duration())
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			instances, err := labelAndList(r.scaled)
+			if err != nil {
+				return err
+			}
+
+			canaries, _ := desiredAndUndesiredInstances(instances, r.updatingTo)
+			for _, inst := range canaries {
+				if r.scaled.Health(inst) == flavor.Unhealthy {
+					return fmt.Errorf("Canary instance %s is unhealthy", inst.ID)
+				}
+			}
+
+			if !policy.Manual && !time.Now().Before(deadline) {
+				log.Info("Canary instances are healthy, continuing update")
+				return nil
+			}
+
+		case <-r.promote:
+			log.Info("Canary promoted, continuing update")
+			return nil
+
+		case <-r.abort:
+			return errCanaryAborted
+
+		case <-r.stop:
+			return errUpdateHalted
+		}
+	}
+}
+
+func (r *rollingupdate) setCanary(canary bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.canary = canary
+}
+
+func (r *rollingupdate) signalCanary(signal chan bool) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.canary {
+		return errNoCanary
+	}
+
+	select {
+	case signal <- true:
+	default:
+		// A signal is already pending.
+	}
+	return nil
+}
+
+// Promote ends the canary phase of the update, continuing with the rest of the group.
+func (r *rollingupdate) Promote() error {
+	return r.signalCanary(r.promote)
+}
+
+// Abort ends the canary phase of the update, failing the update.
+func (r *rollingupdate) Abort() error {
+	return r.signalCanary(r.abort)
+}
+
+// Pause holds the update before it replaces any more instances.
+func (r *rollingupdate) Pause() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.paused = true
+	return nil
+}
+
+// Resume continues a paused update.
+func (r *rollingupdate) Resume() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.paused {
+		return errNotPaused
+	}
+	r.paused = false
+
+	select {
+	case r.resume <- true:
+	default:
+		// A signal is already pending.
+	}
+	return nil
+}
+
+func (r *rollingupdate) isPaused() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.paused
+}
+
+// waitWhilePaused blocks until the update is resumed, returning an error if the update is stopped in the meantime.
+func (r *rollingupdate) waitWhilePaused() error {
+	if !r.isPaused() {
+		return nil
+	}
+
+	log.Info("Update paused")
+	phase := r.Progress().phase
+	r.track(func(p *updateProgress) { p.phase = group.UpdatePaused })
+	defer r.track(func(p *updateProgress) { p.phase = phase })
+
+	for r.isPaused() {
+		select {
+		case <-r.resume:
 		case <-r.stop:
-			ticker.Stop()
-			return errors.New("Update halted by user")
+			return errUpdateHalted
 		}
 	}
+
+	log.Info("Update resumed")
+	return nil
 }
 
-// Run identifies instances not matching the desired state and destroys them one at a time until all instances in the
+// pause blocks for the given duration, returning an error if the update is stopped in the meantime.
+func (r *rollingupdate) pause(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	log.Info("Pausing between batches", "duration", d)
+	r.track(func(p *updateProgress) { p.phase = group.UpdatePausing })
+	defer r.track(func(p *updateProgress) { p.phase = group.UpdateRolling })
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-r.stop:
+		return errUpdateHalted
+	}
+}
+
+// Run identifies instances not matching the desired state and destroys them in batches until all instances in the
 // group match the desired state, with the desired number of instances.
 // TODO(wfarner): Make this routine more resilient to transient errors.
 func (r *rollingupdate) Run(pollInterval time.Duration) error {
@@ -121,13 +416,28 @@ func (r *rollingupdate) Run(pollInterval time.Duration) error {
 		return err
 	}
 
-	desired, _ := desiredAndUndesiredInstances(instances, r.updatingTo)
-	expectedNewInstances := len(desired)
+	policy := r.updatingTo.config.Update
+	batch := int(policy.Batch())
 
-	for {
-		err := r.waitUntilQuiesced(
-			pollInterval,
-			minInt(expectedNewInstances, int(r.updatingTo.config.Allocation.Size)))
+	// Surge instances are created by the scaler with the new configuration as soon as its size is raised.
+	desired, undesired := desiredAndUndesiredInstances(instances, r.updatingTo)
+	expectedNewInstances := len(desired) + int(r.surge)
+	maxInstances := groupSize(r.updatingTo.config) + int(r.surge)
+
+	canaries := canaryCount(len(undesired), policy)
+	phase := group.UpdateRolling
+	if canaries > 0 {
+		phase = group.UpdateCanary
+		r.setCanary(true)
+		defer r.setCanary(false)
+	}
+	r.track(func(p *updateProgress) {
+		p.phase = phase
+		p.remaining = len(undesired)
+	})
+
+	for batches := 0; ; batches++ {
+		err := r.waitUntilQuiesced(pollInterval, minInt(expectedNewInstances, maxInstances))
 		if err != nil {
 			return err
 		}
@@ -139,20 +449,69 @@ func (r *rollingupdate) Run(pollInterval time.Duration) error {
 		}
 
 		_, undesiredInstances := desiredAndUndesiredInstances(instances, r.updatingTo)
+		r.track(func(p *updateProgress) { p.remaining = len(undesiredInstances) })
 
 		if len(undesiredInstances) == 0 {
 			break
 		}
 
-		log.Info("Found undesired instances", "count", len(undesiredInstances))
+		if batches == 1 && canaries > 0 {
+			if err := r.soakCanaries(pollInterval); err != nil {
+				return err
+			}
+			r.track(func(p *updateProgress) { p.phase = group.UpdateRolling })
+
+			instances, err = labelAndList(r.scaled)
+			if err != nil {
+				return err
+			}
+			_, undesiredInstances = desiredAndUndesiredInstances(instances, r.updatingTo)
+		}
+
+		// The batch of canaries is followed by the pause too, once soaked.
+		if batches > 0 && policy.PauseBetweenBatches > 0 {
+			if err := r.pause(policy.PauseBetweenBatches.Duration()); err != nil {
+				return err
+			}
+
+			instances, err = labelAndList(r.scaled)
+			if err != nil {
+				return err
+			}
+			_, undesiredInstances = desiredAndUndesiredInstances(instances, r.updatingTo)
+		}
+
+		if r.isPaused() {
+			if err := r.waitWhilePaused(); err != nil {
+				return err
+			}
+
+			instances, err = labelAndList(r.scaled)
+			if err != nil {
+				return err
+			}
+			_, undesiredInstances = desiredAndUndesiredInstances(instances, r.updatingTo)
+		}
+
+		size := batch
+		if batches == 0 && canaries > 0 {
+			log.Info("Replacing canary instances", "count", canaries)
+			size = canaries
+		}
+
+		log.Info("Found undesired instances", "count", len(undesiredInstances), "batch", size)
 
 		// Sort instances first to ensure predictable destroy order.
 		sort.Sort(sortByID(undesiredInstances))
 
-		// TODO(wfarner): Make the 'batch size' configurable.
-		r.scaled.Destroy(undesiredInstances[0], instance.RollingUpdate)
-
-		expectedNewInstances++
+		for _, inst := range undesiredInstances[:minInt(size, len(undesiredInstances))] {
+			r.scaled.Destroy(inst, instance.RollingUpdate)
+			expectedNewInstances++
+			r.track(func(p *updateProgress) {
+				p.replaced++
+				p.remaining--
+			})
+		}
 	}
 
 	return nil
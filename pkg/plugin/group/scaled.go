@@ -48,6 +48,20 @@ func (s *scaledGroup) changeSettings(settings groupSettings) {
 	s.settings = settings
 }
 
+func (s *scaledGroup) setSupervisor(supervisor Supervisor) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.supervisor = supervisor
+}
+
+func (s *scaledGroup) latestSupervisor() Supervisor {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.supervisor
+}
+
 // latestSettings gives a point-in-time view of the settings for this group.  This allows other functions to
 // safely use settings and make calls to plugins without holding the lock.
 func (s *scaledGroup) latestSettings() groupSettings {
@@ -74,9 +88,10 @@ func (s *scaledGroup) CreateOne(logicalID *instance.LogicalID) {
 		Properties: types.AnyCopy(settings.config.Instance.Properties),
 	}
 
+	supervisor := s.latestSupervisor()
 	index := group_types.Index{
-		Group:    s.supervisor.ID(),
-		Sequence: s.supervisor.Size(),
+		Group:    supervisor.ID(),
+		Sequence: supervisor.Size(),
 	}
 	spec, err := settings.flavorPlugin.Prepare(types.AnyCopy(settings.config.Flavor.Properties),
 		spec,
@@ -2,10 +2,12 @@ package group
 
 import (
 	"fmt"
+	"reflect"
 	"sort"
 	"sync"
 	"time"
 
+	group_types "github.com/docker/infrakit/pkg/plugin/group/types"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 )
@@ -18,6 +20,47 @@ type scaler struct {
 	maxParallelNum uint
 	lock           sync.Mutex
 	stop           chan bool
+
+	// sizeVersion counts the changes of the size, so that a surge is restored only if the size was not set since
+	sizeVersion uint64
+
+	// pool is the logical IDs given to the instances, or nil if the instances are given none
+	pool []instance.LogicalID
+}
+
+func init() {
+	RegisterAllocationStrategy(sizeAllocation{})
+}
+
+// sizeAllocation maintains a number of interchangeable instances.
+type sizeAllocation struct{}
+
+func (sizeAllocation) Name() string {
+	return "size"
+}
+
+func (sizeAllocation) Handles(allocation group_types.AllocationMethod) bool {
+	return allocation.Size > 0 && len(allocation.LogicalIDs) == 0 && len(allocation.Pool) == 0
+}
+
+func (sizeAllocation) Validate(config group_types.Spec) error {
+	return nil
+}
+
+func (sizeAllocation) NewSupervisor(id group.ID, scaled Scaled, config group_types.Spec,
+	pollInterval time.Duration, maxParallelNum uint) Supervisor {
+
+	return NewScalingGroup(id, scaled, config.Allocation.Size, pollInterval, maxParallelNum)
+}
+
+func (sizeAllocation) Allocated(allocation group_types.AllocationMethod, instances []instance.Description) bool {
+	return len(instances) == int(allocation.Size)
+}
+
+func (sizeAllocation) Unmaintained(allocation group_types.AllocationMethod,
+	instances []instance.Description) []instance.Description {
+
+	return nil
 }
 
 // NewScalingGroup creates a supervisor that monitors a group of instances on a provisioner, attempting to maintain a
@@ -43,19 +86,27 @@ func (s *scaler) PlanUpdate(scaled Scaled, settings groupSettings, newSettings g
 	}
 
 	desired, undesired := desiredAndUndesiredInstances(instances, newSettings)
+	policy := newSettings.config.Update
 
 	plan := scalerUpdatePlan{
 		originalSize: settings.config.Allocation.Size,
 		newSize:      newSettings.config.Allocation.Size,
+		pool:         newSettings.config.Allocation.Pool,
 		scaler:       s,
 		rollingPlan:  noopUpdate{},
 	}
+	poolChange := !reflect.DeepEqual(settings.config.Allocation.Pool, newSettings.config.Allocation.Pool)
 
 	switch {
 	case sizeChange == 0:
 		rollCount := len(undesired)
 
 		if rollCount == 0 {
+			if poolChange {
+				plan.desc = fmt.Sprintf("Changing the pool of logical IDs to %v", newSettings.config.Allocation.Pool)
+				return &plan, nil
+			}
+
 			if settings.config.InstanceHash() == newSettings.config.InstanceHash() {
 
 				// This is a no-op update because:
@@ -73,7 +124,10 @@ func (s *scaler) PlanUpdate(scaled Scaled, settings groupSettings, newSettings g
 			return &plan, nil
 		}
 
-		plan.desc = fmt.Sprintf("Performing a rolling update on %d instances", rollCount)
+		plan.desc = fmt.Sprintf("Performing a rolling update on %d instances%s",
+			rollCount,
+			explainBatches(rollCount, policy))
+		plan.surge = policy.MaxSurge
 
 	case sizeChange < 0:
 		rollCount := int(newSettings.config.Allocation.Size) - len(desired)
@@ -89,10 +143,12 @@ func (s *scaler) PlanUpdate(scaled Scaled, settings groupSettings, newSettings g
 		} else {
 			plan.desc = fmt.Sprintf(
 				"Terminating %d instances to reduce the group size to %d,"+
-					" then performing a rolling update on %d instances",
+					" then performing a rolling update on %d instances%s",
 				int(sizeChange)*-1,
 				newSettings.config.Allocation.Size,
-				rollCount)
+				rollCount,
+				explainBatches(rollCount, policy))
+			plan.surge = policy.MaxSurge
 		}
 
 	case sizeChange > 0:
@@ -105,27 +161,36 @@ func (s *scaler) PlanUpdate(scaled Scaled, settings groupSettings, newSettings g
 				newSettings.config.Allocation.Size)
 		} else {
 			plan.desc = fmt.Sprintf(
-				"Performing a rolling update on %d instances,"+
+				"Performing a rolling update on %d instances%s,"+
 					" then adding %d instances to increase the group size to %d",
 				rollCount,
+				explainBatches(rollCount, policy),
 				sizeChange,
 				newSettings.config.Allocation.Size)
+			plan.surge = policy.MaxSurge
 		}
 	}
 
 	plan.rollingPlan = &rollingupdate{
 		scaled:     scaled,
 		updatingTo: newSettings,
+		surge:      plan.surge,
+		promote:    make(chan bool, 1),
+		abort:      make(chan bool, 1),
+		paused:     newSettings.config.Update.Paused,
+		resume:     make(chan bool, 1),
 		stop:       make(chan bool),
 	}
 
-	return plan, nil
+	return &plan, nil
 }
 
 type scalerUpdatePlan struct {
 	desc         string
 	originalSize uint
 	newSize      uint
+	pool         []instance.LogicalID
+	surge        uint
 	rollingPlan  updatePlan
 	scaler       *scaler
 }
@@ -136,6 +201,10 @@ func (s scalerUpdatePlan) Explain() string {
 
 func (s scalerUpdatePlan) Run(pollInterval time.Duration) error {
 
+	// The instances are given the logical IDs of the new pool from now on.  The instances given IDs not in the pool
+	// are removed once replaced.
+	s.scaler.setPool(s.pool)
+
 	// If the number of instances is being decreased, first lower the group size.  This eliminates
 	// instances that would otherwise be rolled first, avoiding unnecessary work.
 	// We could further optimize by selecting undesired instances to destroy, for example if the
@@ -144,7 +213,22 @@ func (s scalerUpdatePlan) Run(pollInterval time.Duration) error {
 		s.scaler.SetSize(s.newSize)
 	}
 
-	if err := s.rollingPlan.Run(pollInterval); err != nil {
+	// Raise the target size while rolling so that replacements are created before undesired instances are
+	// destroyed.  The size is restored whether or not the rolling update succeeds.  The base size is the size of
+	// the plan rather than the size of the scaler, which may still be raised by a plan being stopped; and it is
+	// restored only if not set since, so a plan stopped does not undo the plan replacing it.
+	base := s.originalSize
+	if s.newSize < base {
+		base = s.newSize
+	}
+	if s.surge > 0 {
+		restore := s.scaler.raiseSize(base, s.surge)
+		defer restore()
+	}
+
+	err := s.rollingPlan.Run(pollInterval)
+
+	if err != nil {
 		return err
 	}
 
@@ -160,12 +244,85 @@ func (s scalerUpdatePlan) Stop() {
 	s.rollingPlan.Stop()
 }
 
+func (s scalerUpdatePlan) Progress() updateProgress {
+	return s.rollingPlan.Progress()
+}
+
+func (s scalerUpdatePlan) Promote() error {
+	if c, is := s.rollingPlan.(canaryUpdate); is {
+		return c.Promote()
+	}
+	return errNoCanary
+}
+
+func (s scalerUpdatePlan) Abort() error {
+	if c, is := s.rollingPlan.(canaryUpdate); is {
+		return c.Abort()
+	}
+	return errNoCanary
+}
+
+func (s scalerUpdatePlan) Pause() error {
+	if p, is := s.rollingPlan.(pausableUpdate); is {
+		return p.Pause()
+	}
+	return errNoUpdate
+}
+
+func (s scalerUpdatePlan) Resume() error {
+	if p, is := s.rollingPlan.(pausableUpdate); is {
+		return p.Resume()
+	}
+	return errNoUpdate
+}
+
 func (s *scaler) SetSize(size uint) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	log.Info("Set target size", "size", size)
 	s.size = size
+	s.sizeVersion++
+}
+
+// raiseSize raises the target size by the surge over the base size, and returns the function restoring the base
+// size.  The size is restored only if not set since, so that a plan stopped does not undo the size set by the plan
+// replacing it.
+func (s *scaler) raiseSize(base, surge uint) (restore func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	log.Info("Set target size", "size", base+surge, "surge", surge)
+	s.size = base + surge
+	s.sizeVersion++
+	raised := s.sizeVersion
+
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if s.sizeVersion != raised {
+			log.Info("Target size set since the surge, not restoring", "size", s.size, "base", base)
+			return
+		}
+		log.Info("Set target size", "size", base)
+		s.size = base
+		s.sizeVersion++
+	}
+}
+
+func (s *scaler) setPool(pool []instance.LogicalID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pool = pool
+}
+
+func (s *scaler) getPool() []instance.LogicalID {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.pool
 }
 
 func (s *scaler) getSize() uint {
@@ -236,21 +393,45 @@ func (s *scaler) converge() {
 
 	grp := sync.WaitGroup{}
 
+	// The instances not given a logical ID of the pool, if any, are not counted, so they are replaced before being
+	// removed.
+	pool := s.getPool()
+	maintained, others, free := partitionByPool(pool, descriptions)
+
 	actualSize := uint(len(descriptions))
 	desiredSize := s.getSize()
 	switch {
-	case actualSize == desiredSize:
-		log.Debug("No action - Group has enough instances", "desired", desiredSize)
+	case uint(len(maintained)) < desiredSize:
+		add := desiredSize - uint(len(maintained))
+		if pool != nil && uint(len(free)) < add {
+			log.Warn("Not enough logical IDs left in the pool", "add", add, "free", len(free))
+			add = uint(len(free))
+		}
+		log.Info("Adding instances to group", "add", add, "desired", desiredSize)
+
+		for i := 0; i < int(add); i++ {
+			var logicalID *instance.LogicalID
+			if pool != nil {
+				logicalID = &free[i]
+			}
+			grp.Add(1)
+			go func() {
+				defer grp.Done()
+
+				s.scaled.CreateOne(logicalID)
+			}()
+			s.waitIfReachParallelLimit(i, &grp)
+		}
 
 	case actualSize > desiredSize:
 		remove := actualSize - desiredSize
 		log.Info("Removing instances", "remove", remove, "desired", desiredSize)
 
-		sorted := make([]instance.Description, len(descriptions))
-		copy(sorted, descriptions)
-
-		// Sorting first ensures that redundant operations are non-destructive.
-		sort.Sort(sortByID(sorted))
+		// Sorting first ensures that redundant operations are non-destructive.  The instances not maintained are
+		// removed first.
+		sort.Sort(sortByID(others))
+		sort.Sort(sortByID(maintained))
+		sorted := append(others, maintained...)
 
 		// TODO(wfarner): Consider favoring removal of instances that do not match the desired configuration by
 		// injecting a sorter.
@@ -264,19 +445,8 @@ func (s *scaler) converge() {
 			s.waitIfReachParallelLimit(i, &grp)
 		}
 
-	case actualSize < desiredSize:
-		add := desiredSize - actualSize
-		log.Info("Adding instances to group", "add", add, "desired", desiredSize)
-
-		for i := 0; i < int(add); i++ {
-			grp.Add(1)
-			go func() {
-				defer grp.Done()
-
-				s.scaled.CreateOne(nil)
-			}()
-			s.waitIfReachParallelLimit(i, &grp)
-		}
+	default:
+		log.Debug("No action - Group has enough instances", "desired", desiredSize)
 	}
 
 	// Wait for outstanding actions to finish.
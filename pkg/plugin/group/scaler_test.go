@@ -11,6 +11,7 @@ import (
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	testutil "github.com/docker/infrakit/pkg/testing"
+	types_base "github.com/docker/infrakit/pkg/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
@@ -256,10 +257,10 @@ func TestScalerPlanUpdateRollingUpdate(t *testing.T) {
 	)
 	plan, err := scaler.PlanUpdate(scaled, settings, settings)
 	require.NoError(t, err)
-	require.IsType(t, scalerUpdatePlan{}, plan)
+	require.IsType(t, &scalerUpdatePlan{}, plan)
 	require.Equal(t,
 		"Performing a rolling update on 1 instances",
-		plan.(scalerUpdatePlan).desc,
+		plan.(*scalerUpdatePlan).desc,
 	)
 }
 
@@ -298,10 +299,10 @@ func TestScalerPlanUpdateScaleDown(t *testing.T) {
 	)
 	plan, err := scaler.PlanUpdate(scaled, settingsOld, settingsNew)
 	require.NoError(t, err)
-	require.IsType(t, scalerUpdatePlan{}, plan)
+	require.IsType(t, &scalerUpdatePlan{}, plan)
 	require.Equal(t,
 		"Terminating 1 instances to reduce the group size to 1",
-		plan.(scalerUpdatePlan).desc,
+		plan.(*scalerUpdatePlan).desc,
 	)
 }
 
@@ -340,10 +341,10 @@ func TestScalerPlanUpdateScaleDownRollingUpdate(t *testing.T) {
 	)
 	plan, err := scaler.PlanUpdate(scaled, settingsOld, settingsNew)
 	require.NoError(t, err)
-	require.IsType(t, scalerUpdatePlan{}, plan)
+	require.IsType(t, &scalerUpdatePlan{}, plan)
 	require.Equal(t,
 		"Terminating 1 instances to reduce the group size to 1, then performing a rolling update on 1 instances",
-		plan.(scalerUpdatePlan).desc,
+		plan.(*scalerUpdatePlan).desc,
 	)
 }
 
@@ -382,10 +383,10 @@ func TestScalerPlanUpdateScaleUp(t *testing.T) {
 	)
 	plan, err := scaler.PlanUpdate(scaled, settingsOld, settingsNew)
 	require.NoError(t, err)
-	require.IsType(t, scalerUpdatePlan{}, plan)
+	require.IsType(t, &scalerUpdatePlan{}, plan)
 	require.Equal(t,
 		"Adding 1 instances to increase the group size to 2",
-		plan.(scalerUpdatePlan).desc,
+		plan.(*scalerUpdatePlan).desc,
 	)
 }
 
@@ -424,9 +425,111 @@ func TestScalerPlanUpdateScaleUpRollingUpdate(t *testing.T) {
 	)
 	plan, err := scaler.PlanUpdate(scaled, settingsOld, settingsNew)
 	require.NoError(t, err)
-	require.IsType(t, scalerUpdatePlan{}, plan)
+	require.IsType(t, &scalerUpdatePlan{}, plan)
 	require.Equal(t,
 		"Performing a rolling update on 1 instances, then adding 1 instances to increase the group size to 2",
-		plan.(scalerUpdatePlan).desc,
+		plan.(*scalerUpdatePlan).desc,
 	)
 }
+
+func TestScalerPlanUpdateRollingUpdateInBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	groupID := group.ID("scaler")
+	scaled := mock_group.NewMockScaled(ctrl)
+	instancePlugin := mock_instance.NewMockPlugin(ctrl)
+	settings := groupSettings{
+		instancePlugin: instancePlugin,
+		config: types.Spec{
+			Allocation: types.AllocationMethod{
+				Size: 5,
+			},
+			Update: types.UpdatePolicy{
+				MaxSurge:            1,
+				MaxUnavailable:      1,
+				PauseBetweenBatches: types_base.FromDuration(30 * time.Second),
+			},
+		},
+	}
+	scaler := NewScalingGroup(groupID, scaled, 5, 1*time.Millisecond, 1)
+	existingInst := instance.Description{
+		ID: instance.ID("id1"),
+		Tags: map[string]string{
+			"infrakit.config_sha": "different-hash",
+		},
+	}
+	gomock.InOrder(
+		scaled.EXPECT().List().Return([]instance.Description{
+			existingInst, existingInst, existingInst, existingInst, existingInst,
+		}, nil),
+	)
+	plan, err := scaler.PlanUpdate(scaled, settings, settings)
+	require.NoError(t, err)
+	require.IsType(t, &scalerUpdatePlan{}, plan)
+	require.Equal(t,
+		"Performing a rolling update on 5 instances in 3 batches (2, 2, 1), "+
+			"surge of 1, at most 1 unavailable, pausing 30s between batches",
+		plan.(*scalerUpdatePlan).desc,
+	)
+	require.Equal(t, uint(1), plan.(*scalerUpdatePlan).surge)
+}
+
+// stoppableUpdate runs until stopped
+type stoppableUpdate struct {
+	noopUpdate
+	running chan struct{}
+	stop    chan struct{}
+}
+
+func (u stoppableUpdate) Run(_ time.Duration) error {
+	close(u.running)
+	<-u.stop
+	return nil
+}
+
+func (u stoppableUpdate) Stop() {
+	close(u.stop)
+}
+
+func TestScalerUpdatePlanSurgeReplaced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewScalingGroup(group.ID("scaler"), mock_group.NewMockScaled(ctrl), 3, 1*time.Millisecond, 1).(*scaler)
+
+	run := func(plan scalerUpdatePlan) chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- plan.Run(time.Millisecond)
+		}()
+		<-plan.rollingPlan.(stoppableUpdate).running
+		return done
+	}
+	newPlan := func() scalerUpdatePlan {
+		return scalerUpdatePlan{
+			originalSize: 3,
+			newSize:      3,
+			surge:        1,
+			scaler:       s,
+			rollingPlan:  stoppableUpdate{running: make(chan struct{}), stop: make(chan struct{})},
+		}
+	}
+
+	first := newPlan()
+	firstDone := run(first)
+	require.Equal(t, uint(4), s.Size())
+
+	// A new plan starts while the size is raised by the first, which is stopped after
+	second := newPlan()
+	secondDone := run(second)
+	require.Equal(t, uint(4), s.Size())
+
+	first.Stop()
+	require.NoError(t, <-firstDone)
+	require.Equal(t, uint(4), s.Size())
+
+	second.Stop()
+	require.NoError(t, <-secondDone)
+	require.Equal(t, uint(3), s.Size())
+}
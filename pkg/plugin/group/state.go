@@ -8,6 +8,7 @@ import (
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"sync"
+	"time"
 )
 
 // Supervisor watches over a group of instances.
@@ -24,22 +25,171 @@ type Supervisor interface {
 type groupSettings struct {
 	instancePlugin instance.Plugin
 	flavorPlugin   flavor.Plugin
+	strategy       AllocationStrategy
 	config         types.Spec
 }
 
+// historyLimit is the number of commits retained in the history of a group.
+const historyLimit = 10
+
 type groupContext struct {
 	settings   groupSettings
 	supervisor Supervisor
-	scaled     *scaledGroup
-	update     updatePlan
-	lock       sync.Mutex
+	// strategy is the allocation strategy of the supervisor.
+	strategy AllocationStrategy
+	scaled   *scaledGroup
+	// health replaces the unhealthy instances of the group.
+	health *healthMonitor
+	update updatePlan
+	// commit records the commit that started the update in progress.
+	commit   group.Commit
+	rollback *group.Rollback
+	history  []group.Commit
+	lock     sync.Mutex
 }
 
-func (c *groupContext) setUpdate(plan updatePlan) {
+func (c *groupContext) currentSupervisor() Supervisor {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.supervisor
+}
+
+func (c *groupContext) currentStrategy() AllocationStrategy {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.strategy
+}
+
+// replaceSupervisor stops the supervisor of the group and starts another, for a different allocation strategy.
+func (c *groupContext) replaceSupervisor(strategy AllocationStrategy, supervisor Supervisor) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.supervisor.Stop()
+	c.strategy = strategy
+	c.supervisor = supervisor
+	c.scaled.setSupervisor(supervisor)
+	go supervisor.Run()
+}
+
+func (c *groupContext) setUpdate(plan updatePlan, hash string) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	c.update = plan
+	c.commit = group.Commit{Hash: hash, Plan: plan.Explain(), Started: time.Now()}
+}
+
+// record appends a commit to the history, discarding the oldest commits beyond the history limit.  The caller must
+// hold the lock.
+func (c *groupContext) record(commit group.Commit) {
+	c.history = append(c.history, commit)
+	if len(c.history) > historyLimit {
+		c.history = c.history[len(c.history)-historyLimit:]
+	}
+}
+
+// finishCommit records the outcome of the update in progress.  The caller must hold the lock.
+func (c *groupContext) finishCommit(err error) {
+	commit := c.commit
+	commit.Finished = time.Now()
+	switch err {
+	case nil:
+		commit.Phase = group.UpdateCompleted
+	case errUpdateHalted:
+		commit.Phase = group.UpdateHalted
+	default:
+		commit.Phase = group.UpdateFailed
+		commit.Error = err.Error()
+	}
+	c.record(commit)
+}
+
+// finishUpdate clears the update in progress, provided it has not since been replaced by another update.
+func (c *groupContext) finishUpdate(plan updatePlan, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.update == plan {
+		c.finishCommit(err)
+		c.update = nil
+	}
+}
+
+// rollbackUpdate swaps a failed update for one restoring the configuration with the given hash, provided the failed
+// update is still the one in progress.
+func (c *groupContext) rollbackUpdate(failed updatePlan, cause error, plan updatePlan, hash string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.update != failed {
+		return false
+	}
+	c.finishCommit(cause)
+	c.update = plan
+	c.commit = group.Commit{Hash: hash, Plan: plan.Explain(), Started: time.Now(), Rollback: true}
+	return true
+}
+
+func (c *groupContext) setRollback(rollback *group.Rollback) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.rollback = rollback
+}
+
+func (c *groupContext) lastRollback() *group.Rollback {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.rollback
+}
+
+func (c *groupContext) currentUpdate() updatePlan {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.update
+}
+
+// describeUpdate reports the progress of the update in progress, or nil if the group is not updating.
+func (c *groupContext) describeUpdate() *group.Update {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.update == nil {
+		return nil
+	}
+
+	progress := c.update.Progress()
+	update := group.Update{
+		Plan:      c.commit.Plan,
+		Phase:     progress.phase,
+		Replaced:  progress.replaced,
+		Remaining: progress.remaining,
+		Started:   c.commit.Started,
+		LastError: progress.lastError,
+	}
+	if c.commit.Rollback {
+		update.Phase = group.UpdateRollingBack
+		if update.LastError == "" && c.rollback != nil {
+			update.LastError = c.rollback.Reason
+		}
+	}
+	if update.Phase == "" {
+		update.Phase = group.UpdateRolling
+	}
+	return &update
+}
+
+// commitHistory returns the most recent commits to the group, oldest first.
+func (c *groupContext) commitHistory() []group.Commit {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]group.Commit{}, c.history...)
 }
 
 func (c *groupContext) updating() bool {
@@ -55,16 +205,20 @@ func (c *groupContext) stopUpdating() {
 
 	if c.update != nil {
 		c.update.Stop()
+		c.finishCommit(errUpdateHalted)
 		c.update = nil
 	}
 }
 
-func (c *groupContext) changeSettings(settings groupSettings) {
+// changeSettings changes the settings of the group and returns the settings replaced.
+func (c *groupContext) changeSettings(settings groupSettings) groupSettings {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	previous := c.settings
 	c.settings = settings
 	c.scaled.changeSettings(settings)
+	return previous
 }
 
 type groups struct {
@@ -38,12 +38,119 @@ type Spec struct {
 	Instance   InstancePlugin
 	Flavor     FlavorPlugin
 	Allocation AllocationMethod
+	Update     UpdatePolicy
+	Health     *HealthPolicy `json:",omitempty"`
 }
 
 // AllocationMethod defines the type of allocation and supervision needed by a flavor's Group.
 type AllocationMethod struct {
 	Size       uint
 	LogicalIDs []instance.LogicalID
+
+	// Pool is the logical IDs given to the instances of a group allocated by Size, each to one instance at most, for
+	// example a range of addresses larger than the group.  It must hold at least Size plus MaxSurge IDs.
+	Pool []instance.LogicalID `json:",omitempty"`
+}
+
+// UpdatePolicy controls the pace of a rolling update.  The zero value replaces one instance at a time, waiting
+// for each replacement to become healthy before moving on.
+type UpdatePolicy struct {
+
+	// BatchSize is the maximum number of undesired instances destroyed in each step of the update.  Defaults to 1,
+	// or to MaxSurge + MaxUnavailable when either is set.
+	BatchSize uint `json:",omitempty"`
+
+	// MaxUnavailable is the maximum number of instances that may be unavailable during the update, beyond those
+	// covered by MaxSurge.  It caps the batch size.
+	MaxUnavailable uint `json:",omitempty"`
+
+	// MaxSurge is the number of instances that may be created above the target size while updating.  Only
+	// applies to groups allocated by Size.
+	MaxSurge uint `json:",omitempty"`
+
+	// PauseBetweenBatches is the time to wait after a batch is healthy before starting the next one, including
+	// after the batch of canaries once soaked or promoted.
+	PauseBetweenBatches types.Duration `json:",omitempty"`
+
+	// MinHealthyDuration is how long the instances of a batch must be continuously healthy before the batch
+	// is considered complete.
+	MinHealthyDuration types.Duration `json:",omitempty"`
+
+	// UnhealthyThreshold is the number of updated instances that may be reported unhealthy before the update is
+	// considered failed.
+	UnhealthyThreshold uint `json:",omitempty"`
+
+	// AutoRollback reverts the group to its previous configuration when an update fails.
+	AutoRollback bool `json:",omitempty"`
+
+	// Canary, if set, replaces a few instances first and holds the update until they prove healthy.
+	Canary *CanaryPolicy `json:",omitempty"`
+
+	// Paused holds updates before they replace any more instances, until they are resumed.
+	Paused bool `json:",omitempty"`
+}
+
+// CanaryPolicy describes the canary phase of a rolling update.  The canary instances are replaced with the new
+// configuration first, and the rest of the group is only updated once the canaries have stayed healthy for the
+// soak period, or the canary is promoted explicitly.
+type CanaryPolicy struct {
+
+	// Count is the number of instances replaced in the canary phase.
+	Count uint
+
+	// Soak is how long the canary instances must remain healthy before the update proceeds.
+	Soak types.Duration `json:",omitempty"`
+
+	// Manual holds the update after the soak period until the canary is explicitly promoted or aborted.
+	Manual bool `json:",omitempty"`
+}
+
+// HealthPolicy controls the automatic replacement of instances that the flavor reports as unhealthy, whatever the
+// allocation of the group.  In a group allocated by LogicalIDs, an instance replaced is given the same logical ID,
+// and the members missing count against MaxConcurrentReplacements, so that by default no member is replaced unless
+// all the others are present.  Instances are not checked while an update is in progress.
+type HealthPolicy struct {
+
+	// GracePeriod is how long an instance is left alone after it is first seen by the group, giving it time to
+	// become healthy.
+	GracePeriod types.Duration `json:",omitempty"`
+
+	// UnhealthyThreshold is the number of consecutive health checks that an instance must fail before it is
+	// replaced.  Defaults to 1.
+	UnhealthyThreshold uint `json:",omitempty"`
+
+	// MaxConcurrentReplacements is the maximum number of instances replaced at once.  Defaults to 1.
+	MaxConcurrentReplacements uint `json:",omitempty"`
+}
+
+// Threshold returns the number of consecutive failed health checks after which an instance is replaced.
+func (p HealthPolicy) Threshold() uint {
+	if p.UnhealthyThreshold == 0 {
+		return 1
+	}
+	return p.UnhealthyThreshold
+}
+
+// MaxReplacements returns the maximum number of instances replaced at once.
+func (p HealthPolicy) MaxReplacements() uint {
+	if p.MaxConcurrentReplacements == 0 {
+		return 1
+	}
+	return p.MaxConcurrentReplacements
+}
+
+// Batch returns the maximum number of instances replaced in each step of a rolling update.
+func (p UpdatePolicy) Batch() uint {
+	batch := p.BatchSize
+	if limit := p.MaxSurge + p.MaxUnavailable; limit > 0 {
+		if batch == 0 || batch > limit {
+			batch = limit
+		}
+	}
+	if batch == 0 {
+		batch = 1
+	}
+	return batch
 }
 
 // Index is the index of the instance's creation.  It provides a context for knowing
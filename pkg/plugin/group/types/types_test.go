@@ -90,3 +90,18 @@ func VerifyValidCharsInHash(t *testing.T, hash string) {
 	validString := regexp.MustCompile(regex)
 	require.True(t, validString.MatchString(hash), fmt.Sprintf("Invalid characters found in string: %v. Valid characters are %v", hash, regex))
 }
+
+func TestUpdatePolicyBatch(t *testing.T) {
+	require.Equal(t, uint(1), UpdatePolicy{}.Batch())
+	require.Equal(t, uint(4), UpdatePolicy{BatchSize: 4}.Batch())
+	require.Equal(t, uint(3), UpdatePolicy{MaxSurge: 1, MaxUnavailable: 2}.Batch())
+	require.Equal(t, uint(2), UpdatePolicy{BatchSize: 5, MaxUnavailable: 2}.Batch())
+	require.Equal(t, uint(1), UpdatePolicy{BatchSize: 1, MaxSurge: 2}.Batch())
+
+	// The update policy is not part of the instance configuration.
+	spec := Spec{}
+	require.NoError(t, json.Unmarshal([]byte(specA), &spec))
+	updated := spec
+	updated.Update = UpdatePolicy{BatchSize: 10}
+	require.Equal(t, spec.InstanceHash(), updated.InstanceHash())
+}
@@ -37,6 +37,9 @@ type Info struct {
 
 	// Interfaces (optional) is a slice of interface descriptions by the type and version
 	Interfaces []InterfaceDescription `json:",omitempty"`
+
+	// Circuits (optional) are the states of the circuit breakers of the plugins called by this plugin, by address
+	Circuits map[string]string `json:",omitempty"`
 }
 
 // InterfaceDescription is a holder for RPC interface version and method descriptions
@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -44,6 +45,7 @@ func NewInstancePlugin(client ec2iface.EC2API, namespaceTags map[string]string)
 }
 
 func (p awsInstancePlugin) tagInstance(
+	ctx context.Context,
 	instance *ec2.Instance,
 	systemTags map[string]string,
 	userTags map[string]string) error {
@@ -57,7 +59,8 @@ func (p awsInstancePlugin) tagInstance(
 		ec2Tags = append(ec2Tags, &ec2.Tag{Key: aws.String(key), Value: aws.String(allTags[key])})
 	}
 
-	_, err := p.client.CreateTags(&ec2.CreateTagsInput{Resources: []*string{instance.InstanceId}, Tags: ec2Tags})
+	_, err := p.client.CreateTagsWithContext(ctx,
+		&ec2.CreateTagsInput{Resources: []*string{instance.InstanceId}, Tags: ec2Tags})
 	return err
 }
 
@@ -113,8 +116,13 @@ func (p awsInstancePlugin) Validate(req *types.Any) error {
 
 // Label implements labeling the instances.
 func (p awsInstancePlugin) Label(id instance.ID, labels map[string]string) error {
+	return p.LabelContext(context.Background(), id, labels)
+}
+
+// LabelContext implements instance.ContextPlugin.LabelContext.
+func (p awsInstancePlugin) LabelContext(ctx context.Context, id instance.ID, labels map[string]string) error {
 
-	output, err := p.client.DescribeTags(&ec2.DescribeTagsInput{
+	output, err := p.client.DescribeTagsWithContext(ctx, &ec2.DescribeTagsInput{
 		Filters: []*ec2.Filter{
 			{
 				Name:   aws.String("resource-id"),
@@ -140,7 +148,7 @@ func (p awsInstancePlugin) Label(id instance.ID, labels map[string]string) error
 		}
 	}
 
-	return ec2CreateTags(p.client, id, merged)
+	return ec2CreateTagsWithContext(ctx, p.client, id, merged)
 }
 
 // mergeTags merges multiple maps of tags, implementing 'last write wins' for colliding keys.
@@ -168,7 +176,7 @@ func mergeTags(tagMaps ...map[string]string) ([]string, map[string]string) {
 	return keys, tags
 }
 
-func (p awsInstancePlugin) findEBSVolumeAttachments(spec instance.Spec) ([]*string, error) {
+func (p awsInstancePlugin) findEBSVolumeAttachments(ctx context.Context, spec instance.Spec) ([]*string, error) {
 	found := []*string{}
 
 	// for querying volumes
@@ -185,7 +193,7 @@ func (p awsInstancePlugin) findEBSVolumeAttachments(spec instance.Spec) ([]*stri
 		return found, nil // nothing
 	}
 
-	volumes, err := p.client.DescribeVolumes(&ec2.DescribeVolumesInput{
+	volumes, err := p.client.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{
 		Filters: []*ec2.Filter{
 			{
 				Name:   aws.String(fmt.Sprintf("tag:%s", VolumeTag)),
@@ -234,6 +242,12 @@ func (p awsInstancePlugin) hasNamespaceTags(tags []*ec2.Tag) bool {
 
 // Provision creates a new instance.
 func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
+	return p.ProvisionContext(context.Background(), spec)
+}
+
+// ProvisionContext implements instance.ContextPlugin.ProvisionContext.  Once the context is done, the instance
+// launched is no longer waited on nor attached the volumes.
+func (p awsInstancePlugin) ProvisionContext(ctx context.Context, spec instance.Spec) (*instance.ID, error) {
 
 	if spec.Properties == nil {
 		return nil, errors.New("Properties must be set")
@@ -265,7 +279,7 @@ func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
 			base64.StdEncoding.EncodeToString([]byte(*request.RunInstancesInput.UserData)))
 	}
 
-	reservation, err := p.client.RunInstances(&request.RunInstancesInput)
+	reservation, err := p.client.RunInstancesWithContext(ctx, &request.RunInstancesInput)
 	if err != nil {
 		return nil, err
 	}
@@ -277,13 +291,13 @@ func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
 
 	id := (*instance.ID)(ec2Instance.InstanceId)
 
-	err = p.tagInstance(ec2Instance, spec.Tags, request.Tags)
+	err = p.tagInstance(ctx, ec2Instance, spec.Tags, request.Tags)
 	if err != nil {
 		return id, err
 	}
 
 	// work with attachments
-	awsVolumeIDs, err := p.findEBSVolumeAttachments(spec)
+	awsVolumeIDs, err := p.findEBSVolumeAttachments(ctx, spec)
 	if err != nil {
 		return id, err
 	}
@@ -291,9 +305,11 @@ func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
 	if len(awsVolumeIDs) > 0 {
 		log.Infof("Waiting for instance %s to enter running state before attaching volume", *id)
 		for {
-			time.Sleep(10 * time.Second)
+			if err := sleepWithContext(ctx, 10*time.Second); err != nil {
+				return id, err
+			}
 
-			inst, err := p.client.DescribeInstances(&ec2.DescribeInstancesInput{
+			inst, err := p.client.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
 				InstanceIds: []*string{ec2Instance.InstanceId},
 			})
 			if err == nil {
@@ -309,7 +325,7 @@ func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
 		}
 
 		for _, awsVolumeID := range awsVolumeIDs {
-			_, err := p.client.AttachVolume(&ec2.AttachVolumeInput{
+			_, err := p.client.AttachVolumeWithContext(ctx, &ec2.AttachVolumeInput{
 				InstanceId: ec2Instance.InstanceId,
 				VolumeId:   awsVolumeID,
 				Device:     aws.String("/dev/sdf"),
@@ -322,8 +338,8 @@ func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
 
 	for _, attachVolumeInput := range request.AttachVolumeInputs {
 		attachVolumeInput.InstanceId = ec2Instance.InstanceId
-		err := retry(30*time.Second, 500*time.Millisecond, func() error {
-			_, err := p.client.AttachVolume(&attachVolumeInput)
+		err := retryWithContext(ctx, 30*time.Second, 500*time.Millisecond, func() error {
+			_, err := p.client.AttachVolumeWithContext(ctx, &attachVolumeInput)
 			return err
 		})
 		if err != nil {
@@ -335,8 +351,13 @@ func (p awsInstancePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
 }
 
 // Destroy terminates an existing instance.
-func (p awsInstancePlugin) Destroy(id instance.ID, ctx instance.Context) error {
-	result, err := p.client.TerminateInstances(&ec2.TerminateInstancesInput{
+func (p awsInstancePlugin) Destroy(id instance.ID, reason instance.Context) error {
+	return p.DestroyContext(context.Background(), id, reason)
+}
+
+// DestroyContext implements instance.ContextPlugin.DestroyContext.
+func (p awsInstancePlugin) DestroyContext(ctx context.Context, id instance.ID, reason instance.Context) error {
+	result, err := p.client.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{
 		InstanceIds: []*string{aws.String(string(id))}})
 
 	if err != nil {
@@ -374,9 +395,10 @@ func describeGroupRequest(namespaceTags, tags map[string]string, nextToken *stri
 	return &ec2.DescribeInstancesInput{NextToken: nextToken, Filters: filters}
 }
 
-func (p awsInstancePlugin) describeInstances(tags map[string]string, properties bool, nextToken *string) ([]instance.Description, error) {
+func (p awsInstancePlugin) describeInstances(ctx context.Context, tags map[string]string, properties bool,
+	nextToken *string) ([]instance.Description, error) {
 
-	result, err := p.client.DescribeInstances(describeGroupRequest(p.namespaceTags, tags, nextToken))
+	result, err := p.client.DescribeInstancesWithContext(ctx, describeGroupRequest(p.namespaceTags, tags, nextToken))
 	if err != nil {
 		return nil, err
 	}
@@ -412,7 +434,7 @@ func (p awsInstancePlugin) describeInstances(tags map[string]string, properties
 
 	if result.NextToken != nil {
 		// There are more pages of results.
-		remainingPages, err := p.describeInstances(tags, properties, result.NextToken)
+		remainingPages, err := p.describeInstances(ctx, tags, properties, result.NextToken)
 		if err != nil {
 			return nil, err
 		}
@@ -425,7 +447,13 @@ func (p awsInstancePlugin) describeInstances(tags map[string]string, properties
 
 // DescribeInstances implements instance.Provisioner.DescribeInstances.
 func (p awsInstancePlugin) DescribeInstances(tags map[string]string, properties bool) ([]instance.Description, error) {
-	return p.describeInstances(tags, properties, nil)
+	return p.DescribeInstancesContext(context.Background(), tags, properties)
+}
+
+// DescribeInstancesContext implements instance.ContextPlugin.DescribeInstancesContext.
+func (p awsInstancePlugin) DescribeInstancesContext(ctx context.Context, tags map[string]string,
+	properties bool) ([]instance.Description, error) {
+	return p.describeInstances(ctx, tags, properties, nil)
 }
 
 func (p awsInstancePlugin) describeInstance(id instance.ID) (*ec2.Instance, error) {
@@ -30,7 +30,7 @@ func TestInstanceLifecycle(t *testing.T) {
 
 	instanceID := "test-id"
 
-	clientMock.EXPECT().RunInstances(gomock.Any()).
+	clientMock.EXPECT().RunInstancesWithContext(gomock.Any(), gomock.Any()).
 		Return(&ec2.Reservation{Instances: []*ec2.Instance{{InstanceId: &instanceID}}}, nil)
 
 	tagRequest := ec2.CreateTagsInput{
@@ -42,7 +42,7 @@ func TestInstanceLifecycle(t *testing.T) {
 			{Key: aws.String("type"), Value: aws.String("testing")},
 		},
 	}
-	clientMock.EXPECT().CreateTags(&tagRequest).Return(&ec2.CreateTagsOutput{}, nil)
+	clientMock.EXPECT().CreateTagsWithContext(gomock.Any(), &tagRequest).Return(&ec2.CreateTagsOutput{}, nil)
 
 	// TODO(wfarner): Test user-data and private IP plumbing.
 	id, err := pluginImpl.Provision(instance.Spec{Properties: inputJSON, Tags: tags})
@@ -52,7 +52,7 @@ func TestInstanceLifecycle(t *testing.T) {
 
 	// Destroy the instance.
 
-	clientMock.EXPECT().TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: []*string{&instanceID}}).
+	clientMock.EXPECT().TerminateInstancesWithContext(gomock.Any(), &ec2.TerminateInstancesInput{InstanceIds: []*string{&instanceID}}).
 		Return(&ec2.TerminateInstancesOutput{
 			TerminatingInstances: []*ec2.InstanceStateChange{{InstanceId: &instanceID}}},
 			nil)
@@ -66,7 +66,7 @@ func TestCreateInstanceError(t *testing.T) {
 	clientMock := mock_ec2.NewMockEC2API(ctrl)
 
 	runError := errors.New("request failed")
-	clientMock.EXPECT().RunInstances(gomock.Any()).Return(&ec2.Reservation{}, runError)
+	clientMock.EXPECT().RunInstancesWithContext(gomock.Any(), gomock.Any()).Return(&ec2.Reservation{}, runError)
 
 	pluginImpl := NewInstancePlugin(clientMock, map[string]string{"cluster": "test"})
 	properties := types.AnyString("{}")
@@ -84,7 +84,7 @@ func TestDestroyInstanceError(t *testing.T) {
 	instanceID := "test-id"
 
 	runError := errors.New("request failed")
-	clientMock.EXPECT().TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: []*string{&instanceID}}).
+	clientMock.EXPECT().TerminateInstancesWithContext(gomock.Any(), &ec2.TerminateInstancesInput{InstanceIds: []*string{&instanceID}}).
 		Return(nil, runError)
 
 	pluginImpl := NewInstancePlugin(clientMock, testNamespace)
@@ -160,12 +160,12 @@ func TestListGroup(t *testing.T) {
 
 	// Split instance IDs across multiple reservations and request pages.
 	gomock.InOrder(
-		clientMock.EXPECT().DescribeInstances(describeGroupRequest(testNamespace, tags, nil)).
+		clientMock.EXPECT().DescribeInstancesWithContext(gomock.Any(), describeGroupRequest(testNamespace, tags, nil)).
 			Return(describeInstancesResponse([][]string{
 				{"a", "b", "c"},
 				{"d", "e"},
 			}, tags, &page2Token), nil),
-		clientMock.EXPECT().DescribeInstances(describeGroupRequest(testNamespace, tags, &page2Token)).
+		clientMock.EXPECT().DescribeInstancesWithContext(gomock.Any(), describeGroupRequest(testNamespace, tags, &page2Token)).
 			Return(describeInstancesResponse([][]string{{"f", "g"}}, tags, nil), nil),
 	)
 
@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"context"
 	"math/rand"
 	"regexp"
 	"sort"
@@ -38,13 +39,19 @@ func arnOrNameToName(arnOrName string) string {
 }
 
 func ec2CreateTags(client ec2iface.EC2API, id instance.ID, tags ...map[string]string) error {
+	return ec2CreateTagsWithContext(context.Background(), client, id, tags...)
+}
+
+func ec2CreateTagsWithContext(ctx context.Context, client ec2iface.EC2API, id instance.ID,
+	tags ...map[string]string) error {
 	ec2Tags := []*ec2.Tag{}
 	for _, t := range tags {
 		for k, v := range t {
 			ec2Tags = append(ec2Tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(v)})
 		}
 	}
-	_, err := client.CreateTags(&ec2.CreateTagsInput{Resources: []*string{aws.String(string(id))}, Tags: ec2Tags})
+	_, err := client.CreateTagsWithContext(ctx,
+		&ec2.CreateTagsInput{Resources: []*string{aws.String(string(id))}, Tags: ec2Tags})
 	return err
 }
 
@@ -110,11 +117,28 @@ func newUnrestrictedName(tags ...map[string]string) string {
 }
 
 func retry(duration time.Duration, sleep time.Duration, f func() error) error {
+	return retryWithContext(context.Background(), duration, sleep, f)
+}
+
+// retryWithContext retries until f succeeds or the duration passes, or the context is done.
+func retryWithContext(ctx context.Context, duration time.Duration, sleep time.Duration, f func() error) error {
 	stop := time.Now().Add(duration)
 	for {
 		if err := f(); err == nil || time.Now().After(stop) {
 			return err
 		}
-		time.Sleep(sleep)
+		if err := sleepWithContext(ctx, sleep); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepWithContext sleeps for the duration, or returns the error of the context once it is done.
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(duration):
+		return nil
 	}
 }
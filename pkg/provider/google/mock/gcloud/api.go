@@ -4,6 +4,8 @@
 package gcloud
 
 import (
+	context "context"
+
 	gcloud "github.com/docker/infrakit/pkg/provider/google/plugin/gcloud"
 	gomock "github.com/golang/mock/gomock"
 	v1 "google.golang.org/api/compute/v1"
@@ -187,3 +189,13 @@ func (_m *MockAPI) SetInstanceTemplate(_param0 string, _param1 string) error {
 func (_mr *_MockAPIRecorder) SetInstanceTemplate(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetInstanceTemplate", arg0, arg1)
 }
+
+func (_m *MockAPI) WithContext(_param0 context.Context) gcloud.API {
+	ret := _m.ctrl.Call(_m, "WithContext", _param0)
+	ret0, _ := ret[0].(gcloud.API)
+	return ret0
+}
+
+func (_mr *_MockAPIRecorder) WithContext(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "WithContext", arg0)
+}
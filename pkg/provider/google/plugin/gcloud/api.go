@@ -31,6 +31,9 @@ type API interface {
 	// GetZone returns the zone short name.
 	GetZone() string
 
+	// WithContext returns the API making the calls, and waiting on the operations, within the context.
+	WithContext(ctx context.Context) API
+
 	// ListInstances lists the instances.
 	ListInstances() ([]*compute.Instance, error)
 
@@ -110,6 +113,7 @@ type computeServiceWrapper struct {
 	project string
 	zone    string
 	service *compute.Service
+	ctx     context.Context
 }
 
 // NewAPI creates a new API instance.
@@ -154,6 +158,7 @@ func NewAPI(project, zone string) (API, error) {
 		project: project,
 		zone:    zone,
 		service: service,
+		ctx:     context.Background(),
 	}, nil
 }
 
@@ -197,6 +202,12 @@ func findZone() string {
 	return ""
 }
 
+func (g *computeServiceWrapper) WithContext(ctx context.Context) API {
+	wrapper := *g
+	wrapper.ctx = ctx
+	return &wrapper
+}
+
 func (g *computeServiceWrapper) GetProject() string {
 	return g.project
 }
@@ -210,7 +221,7 @@ func (g *computeServiceWrapper) ListInstances() ([]*compute.Instance, error) {
 
 	pageToken := ""
 	for {
-		list, err := g.service.Instances.List(g.project, g.zone).PageToken(pageToken).Do()
+		list, err := g.service.Instances.List(g.project, g.zone).PageToken(pageToken).Context(g.ctx).Do()
 		if err != nil {
 			return nil, err
 		}
@@ -229,7 +240,7 @@ func (g *computeServiceWrapper) ListInstances() ([]*compute.Instance, error) {
 }
 
 func (g *computeServiceWrapper) GetInstance(name string) (*compute.Instance, error) {
-	return g.service.Instances.Get(g.project, g.zone, name).Do()
+	return g.service.Instances.Get(g.project, g.zone, name).Context(g.ctx).Do()
 }
 
 func (g *computeServiceWrapper) addAPIUrlPrefix(value string, prefix string) string {
@@ -291,7 +302,7 @@ func (g *computeServiceWrapper) CreateInstance(name string, settings *InstanceSe
 		},
 	}
 
-	return g.doCall(g.service.Instances.Insert(g.project, g.zone, instance))
+	return g.doCall(g.service.Instances.Insert(g.project, g.zone, instance).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) attachedDisks(instanceName string, disksSettings []DiskSettings) ([]*compute.AttachedDisk, error) {
@@ -327,12 +338,12 @@ func (g *computeServiceWrapper) attachedDisk(instanceName string, settings DiskS
 	if settings.ReuseExisting {
 		log.Debugln("Trying to reuse disk", diskName)
 
-		disk, err := g.service.Disks.Get(g.project, g.zone, diskName).Do()
+		disk, err := g.service.Disks.Get(g.project, g.zone, diskName).Context(g.ctx).Do()
 		if err != nil || disk == nil {
 			log.Debugln("Couldn't find existing disk", diskName)
 		} else if disk.SourceImage != sourceImage {
 			log.Debugln("Found existing disk that uses a wrong image. Let's delete", diskName)
-			if err := g.doCall(g.service.Disks.Delete(g.project, g.zone, disk.Name)); err != nil {
+			if err := g.doCall(g.service.Disks.Delete(g.project, g.zone, disk.Name).Context(g.ctx)); err != nil {
 				return nil, err
 			}
 		} else {
@@ -350,7 +361,7 @@ func (g *computeServiceWrapper) attachedDisk(instanceName string, settings DiskS
 			Name:   diskName,
 			SizeGb: settings.SizeGb,
 			Type:   diskType,
-		})); err != nil {
+		}).Context(g.ctx)); err != nil {
 			return nil, err
 		}
 
@@ -380,7 +391,7 @@ func (g *computeServiceWrapper) AddInstanceToTargetPool(targetPool string, insta
 		Instances: references,
 	}
 
-	return g.doCall(g.service.TargetPools.AddInstance(g.project, g.region(), targetPool, request))
+	return g.doCall(g.service.TargetPools.AddInstance(g.project, g.region(), targetPool, request).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) AddInstanceMetadata(instanceName string, items []*compute.MetadataItems) error {
@@ -405,19 +416,19 @@ func (g *computeServiceWrapper) AddInstanceMetadata(instanceName string, items [
 
 	}
 
-	return g.doCall(g.service.Instances.SetMetadata(g.project, g.zone, instanceName, instance.Metadata))
+	return g.doCall(g.service.Instances.SetMetadata(g.project, g.zone, instanceName, instance.Metadata).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) DeleteInstance(name string) error {
-	return g.doCall(g.service.Instances.Delete(g.project, g.zone, name))
+	return g.doCall(g.service.Instances.Delete(g.project, g.zone, name).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) DeleteInstanceGroupManager(name string) error {
-	return g.doCall(g.service.InstanceGroupManagers.Delete(g.project, g.zone, name))
+	return g.doCall(g.service.InstanceGroupManagers.Delete(g.project, g.zone, name).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) DeleteInstanceTemplate(name string) error {
-	return g.doCall(g.service.InstanceTemplates.Delete(g.project, name))
+	return g.doCall(g.service.InstanceTemplates.Delete(g.project, name).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) ListInstanceGroupInstances(name string) ([]*compute.InstanceWithNamedPorts, error) {
@@ -427,7 +438,7 @@ func (g *computeServiceWrapper) ListInstanceGroupInstances(name string) ([]*comp
 	for {
 		instances, err := g.service.InstanceGroups.ListInstances(g.project, g.zone, name, &compute.InstanceGroupsListInstancesRequest{
 			InstanceState: "ALL",
-		}).PageToken(pageToken).Do()
+		}).PageToken(pageToken).Context(g.ctx).Do()
 		if err != nil {
 			return nil, err
 		}
@@ -492,7 +503,7 @@ func (g *computeServiceWrapper) CreateInstanceTemplate(name string, settings *In
 		},
 	}
 
-	return g.doCall(g.service.InstanceTemplates.Insert(g.project, template))
+	return g.doCall(g.service.InstanceTemplates.Insert(g.project, template).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) CreateInstanceGroupManager(name string, settings *InstanceManagerSettings) error {
@@ -506,7 +517,7 @@ func (g *computeServiceWrapper) CreateInstanceGroupManager(name string, settings
 		TargetSize:       settings.TargetSize,
 	}
 
-	return g.doCall(g.service.InstanceGroupManagers.Insert(g.project, g.zone, groupManager))
+	return g.doCall(g.service.InstanceGroupManagers.Insert(g.project, g.zone, groupManager).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) SetInstanceTemplate(name string, templateName string) error {
@@ -514,11 +525,11 @@ func (g *computeServiceWrapper) SetInstanceTemplate(name string, templateName st
 		InstanceTemplate: templateName,
 	}
 
-	return g.doCall(g.service.InstanceGroupManagers.SetInstanceTemplate(g.project, g.zone, name, request))
+	return g.doCall(g.service.InstanceGroupManagers.SetInstanceTemplate(g.project, g.zone, name, request).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) ResizeInstanceGroupManager(name string, targetSize int64) error {
-	return g.doCall(g.service.InstanceGroupManagers.Resize(g.project, g.zone, name, targetSize))
+	return g.doCall(g.service.InstanceGroupManagers.Resize(g.project, g.zone, name, targetSize).Context(g.ctx))
 }
 
 func (g *computeServiceWrapper) region() string {
@@ -545,7 +556,11 @@ func (g *computeServiceWrapper) doCall(call Call) error {
 			return nil
 		}
 
-		time.Sleep(1 * time.Second)
+		select {
+		case <-g.ctx.Done():
+			return g.ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
 
 		op, err = g.getOperationCall(op).Do()
 		if err != nil {
@@ -557,11 +572,11 @@ func (g *computeServiceWrapper) doCall(call Call) error {
 func (g *computeServiceWrapper) getOperationCall(op *compute.Operation) Call {
 	switch {
 	case op.Zone != "":
-		return g.service.ZoneOperations.Get(g.project, last(op.Zone), op.Name)
+		return g.service.ZoneOperations.Get(g.project, last(op.Zone), op.Name).Context(g.ctx)
 	case op.Region != "":
-		return g.service.RegionOperations.Get(g.project, last(op.Region), op.Name)
+		return g.service.RegionOperations.Get(g.project, last(op.Region), op.Name).Context(g.ctx)
 	default:
-		return g.service.GlobalOperations.Get(g.project, op.Name)
+		return g.service.GlobalOperations.Get(g.project, op.Name).Context(g.ctx)
 	}
 }
 
@@ -324,6 +324,32 @@ func (p *plugin) SetSize(id group.ID, size int) error {
 	return p.API.ResizeInstanceGroupManager(string(id), int64(size))
 }
 
+// PromoteCanary is not supported, updates are performed by the instance group manager.
+func (p *plugin) PromoteCanary(id group.ID) error {
+	return fmt.Errorf("not implemented")
+}
+
+// AbortCanary is not supported, updates are performed by the instance group manager.
+func (p *plugin) AbortCanary(id group.ID) error {
+	return fmt.Errorf("not implemented")
+}
+
+// PlanCommit only explains the plan, since the instances are managed by the instance group manager.
+func (p *plugin) PlanCommit(config group.Spec) (group.Plan, error) {
+	explain, err := p.CommitGroup(config, true)
+	return group.Plan{Explain: explain}, err
+}
+
+// PauseUpdate is not supported, updates are performed by the instance group manager.
+func (p *plugin) PauseUpdate(id group.ID) error {
+	return fmt.Errorf("not implemented")
+}
+
+// ResumeUpdate is not supported, updates are performed by the instance group manager.
+func (p *plugin) ResumeUpdate(id group.ID) error {
+	return fmt.Errorf("not implemented")
+}
+
 func last(url string) string {
 	parts := strings.Split(url, "/")
 	return parts[len(parts)-1]
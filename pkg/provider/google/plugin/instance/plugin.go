@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"sort"
@@ -53,12 +54,28 @@ func (p *plugin) Validate(req *types.Any) error {
 }
 
 func (p *plugin) Label(instance instance.ID, labels map[string]string) error {
+	return p.label(p.API, instance, labels)
+}
+
+func (p *plugin) LabelContext(ctx context.Context, instance instance.ID, labels map[string]string) error {
+	return p.label(p.API.WithContext(ctx), instance, labels)
+}
+
+func (p *plugin) label(api gcloud.API, instance instance.ID, labels map[string]string) error {
 	metadata := gcloud.TagsToMetaData(labels)
 
-	return p.API.AddInstanceMetadata(string(instance), metadata)
+	return api.AddInstanceMetadata(string(instance), metadata)
 }
 
 func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
+	return p.provision(p.API, spec)
+}
+
+func (p *plugin) ProvisionContext(ctx context.Context, spec instance.Spec) (*instance.ID, error) {
+	return p.provision(p.API.WithContext(ctx), spec)
+}
+
+func (p *plugin) provision(api gcloud.API, spec instance.Spec) (*instance.ID, error) {
 	properties, err := instance_types.ParseProperties(spec.Properties)
 	if err != nil {
 		return nil, err
@@ -95,12 +112,12 @@ func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
 	// user provided some.
 	settings.MetaData = gcloud.TagsToMetaData(tags)
 
-	if err = p.API.CreateInstance(name, settings); err != nil {
+	if err = api.CreateInstance(name, settings); err != nil {
 		return nil, err
 	}
 
 	for _, targetPool := range properties.TargetPools {
-		if err = p.API.AddInstanceToTargetPool(targetPool, name); err != nil {
+		if err = api.AddInstanceToTargetPool(targetPool, name); err != nil {
 			return nil, err
 		}
 	}
@@ -108,8 +125,16 @@ func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
 	return &id, nil
 }
 
-func (p *plugin) Destroy(id instance.ID, ctx instance.Context) error {
-	err := p.API.DeleteInstance(string(id))
+func (p *plugin) Destroy(id instance.ID, reason instance.Context) error {
+	return p.destroy(p.API, id)
+}
+
+func (p *plugin) DestroyContext(ctx context.Context, id instance.ID, reason instance.Context) error {
+	return p.destroy(p.API.WithContext(ctx), id)
+}
+
+func (p *plugin) destroy(api gcloud.API, id instance.ID) error {
+	err := api.DeleteInstance(string(id))
 
 	log.Debugln("destroy", id, "err=", err)
 
@@ -117,12 +142,22 @@ func (p *plugin) Destroy(id instance.ID, ctx instance.Context) error {
 }
 
 func (p *plugin) DescribeInstances(tags map[string]string, properties bool) ([]instance.Description, error) {
+	return p.describeInstances(p.API, tags, properties)
+}
+
+func (p *plugin) DescribeInstancesContext(ctx context.Context, tags map[string]string,
+	properties bool) ([]instance.Description, error) {
+	return p.describeInstances(p.API.WithContext(ctx), tags, properties)
+}
+
+func (p *plugin) describeInstances(api gcloud.API, tags map[string]string,
+	properties bool) ([]instance.Description, error) {
 	log.Debugln("describe-instances", tags)
 
 	// apply the scoping namespace to restrict what we search for
 	_, tags = mergeTags(tags, p.namespace)
 
-	instances, err := p.API.ListInstances()
+	instances, err := api.ListInstances()
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"testing"
@@ -272,6 +273,22 @@ func TestDestroy(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDestroyContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	api, ctrl := NewMockGCloud(t)
+	defer ctrl.Finish()
+	withContext, _ := NewMockGCloud(t)
+	api.EXPECT().WithContext(ctx).Return(withContext)
+	withContext.EXPECT().DeleteInstance("instance-id").Return(nil)
+
+	plugin := NewPlugin(api, nil)
+	err := plugin.(instance.ContextPlugin).DestroyContext(ctx, "instance-id", instance.Termination)
+
+	require.NoError(t, err)
+}
+
 func TestDestroyFails(t *testing.T) {
 	api, _ := NewMockGCloud(t)
 	api.EXPECT().DeleteInstance("instance-wrong-id").Return(errors.New("BUG"))
@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -902,23 +903,39 @@ func ensureUniqueFile(dir string) string {
 	return n
 }
 
+// lockFiles acquires the fs lock, waiting until the context is done
+func (p *plugin) lockFiles(ctx context.Context, op string) error {
+	for {
+		if err := p.fsLock.TryLock(); err == nil {
+			return nil
+		}
+		log.Infof("Can't acquire fsLock on %v, waiting", op)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
 // Provision creates a new instance based on the spec.
 func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
+	return p.ProvisionContext(context.Background(), spec)
+}
+
+// ProvisionContext creates a new instance based on the spec, unless the context is done before the files of the
+// instance are written.
+func (p *plugin) ProvisionContext(ctx context.Context, spec instance.Spec) (*instance.ID, error) {
 
 	// Because the format of the spec.Properties is simply the same tf.json
 	// we simply look for vm instance and merge in the tags, and user init, etc.
 
 	// Hold the fs lock for the duration since the file is written at the end
-	var name string
-	for {
-		if err := p.fsLock.TryLock(); err == nil {
-			defer p.fsLock.Unlock()
-			name = ensureUniqueFile(p.Dir)
-			break
-		}
-		log.Infoln("Can't acquire fsLock on Provision, waiting")
-		time.Sleep(time.Second)
+	if err := p.lockFiles(ctx, "Provision"); err != nil {
+		return nil, err
 	}
+	defer p.fsLock.Unlock()
+	name := ensureUniqueFile(p.Dir)
 	id := instance.ID(name)
 
 	// Decode the given spec and find the VM resource
@@ -950,7 +967,10 @@ func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
 	}
 	// Handle any platform specific updates to the VM properties prior to writing out
 	platformSpecificUpdates(vmType, TResourceName(name), spec.LogicalID, vmProps)
-	// Write out the tf.json file
+	// Write out the tf.json file, unless the caller has given up
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
 	if err = p.writeTerraformFiles(decomposedFiles.FileMap, decomposedFiles.CurrentFiles); err != nil {
 		return nil, err
 	}
@@ -960,14 +980,16 @@ func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
 
 // Label labels the instance
 func (p *plugin) Label(instance instance.ID, labels map[string]string) error {
+	return p.LabelContext(context.Background(), instance, labels)
+}
+
+// LabelContext labels the instance, unless the context is done before the fs lock is acquired.
+func (p *plugin) LabelContext(ctx context.Context, instance instance.ID, labels map[string]string) error {
 	// Acquire lock
-	for {
-		if err := p.fsLock.TryLock(); err == nil {
-			defer p.fsLock.Unlock()
-			break
-		}
-		time.Sleep(time.Second)
+	if err := p.lockFiles(ctx, "Label"); err != nil {
+		return err
 	}
+	defer p.fsLock.Unlock()
 
 	tf, filename, err := p.parseFileForInstanceID(instance)
 	if err != nil {
@@ -997,27 +1019,29 @@ func (p *plugin) Label(instance instance.ID, labels map[string]string) error {
 }
 
 // Destroy terminates an existing instance.
-func (p *plugin) Destroy(instID instance.ID, context instance.Context) error {
+func (p *plugin) Destroy(instID instance.ID, reason instance.Context) error {
+	return p.DestroyContext(context.Background(), instID, reason)
+}
+
+// DestroyContext terminates an existing instance, unless the context is done before the fs lock is acquired.
+func (p *plugin) DestroyContext(ctx context.Context, instID instance.ID, reason instance.Context) error {
 	processAttach := true
-	if context == instance.RollingUpdate {
+	if reason == instance.RollingUpdate {
 		// Do not destroy related resources since this instance will be re-provisioned
 		processAttach = false
 	}
-	err := p.doDestroy(instID, processAttach, true)
+	err := p.doDestroy(ctx, instID, processAttach, true)
 	return err
 }
 
 // doDestroy terminates an existing instance and optionally terminates any related
 // resources
-func (p *plugin) doDestroy(inst instance.ID, processAttach, executeTfApply bool) error {
+func (p *plugin) doDestroy(ctx context.Context, inst instance.ID, processAttach, executeTfApply bool) error {
 	// Acquire lock
-	for {
-		if err := p.fsLock.TryLock(); err == nil {
-			defer p.fsLock.Unlock()
-			break
-		}
-		time.Sleep(time.Second)
+	if err := p.lockFiles(ctx, "Destroy"); err != nil {
+		return err
 	}
+	defer p.fsLock.Unlock()
 
 	tf, filename, err := p.parseFileForInstanceID(inst)
 	if err != nil {
@@ -1077,7 +1101,7 @@ func (p *plugin) doDestroy(inst instance.ID, processAttach, executeTfApply bool)
 			}
 			// Delete any resources that are no longer referenced
 			for id := range idsToDestroy {
-				err = p.doDestroy(instance.ID(id), false, false)
+				err = p.doDestroy(ctx, instance.ID(id), false, false)
 				if err != nil {
 					return err
 				}
@@ -1110,15 +1134,19 @@ func parseAttachTag(tf *TFormat) ([]string, error) {
 
 // DescribeInstances returns descriptions of all instances matching all of the provided tags.
 func (p *plugin) DescribeInstances(tags map[string]string, properties bool) ([]instance.Description, error) {
+	return p.DescribeInstancesContext(context.Background(), tags, properties)
+}
+
+// DescribeInstancesContext returns descriptions of the instances matching all the tags, unless the context is
+// done before the fs lock is acquired or while the properties are shown.
+func (p *plugin) DescribeInstancesContext(ctx context.Context, tags map[string]string,
+	properties bool) ([]instance.Description, error) {
 	log.Debugln("describe-instances", tags)
 	// Acquire lock since we are reading all files and potentially running "terraform show"
-	for {
-		if err := p.fsLock.TryLock(); err == nil {
-			defer p.fsLock.Unlock()
-			break
-		}
-		time.Sleep(time.Second)
+	if err := p.lockFiles(ctx, "DescribeInstances"); err != nil {
+		return nil, err
 	}
+	defer p.fsLock.Unlock()
 
 	// localSpecs are what we told terraform to create - these are the generated files.
 	localSpecs, err := p.scanLocalFiles()
@@ -1130,6 +1158,9 @@ func (p *plugin) DescribeInstances(tags map[string]string, properties bool) ([]i
 	if properties {
 		// TODO - not the most efficient, but here we assume we're usually just one vm type
 		for vmResourceType := range localSpecs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
 
 			if instances, err := doTerraformShow(p.Dir, vmResourceType); err == nil {
 
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+)
+
+var log = logutil.New("module", "rpc/audit")
+
+const (
+	// EnvFile is the environment variable of the file of the audit log of the plugins
+	EnvFile = "INFRAKIT_AUDIT_FILE"
+
+	// EnvEvents is the environment variable to publish the entries of the audit log as events, if true
+	EnvEvents = "INFRAKIT_AUDIT_EVENTS"
+
+	// Topic is the topic of the events of the audit log
+	Topic = "audit"
+)
+
+// MutatingMethods are the patterns of the rpc methods that change the state, and so are audited
+var MutatingMethods = []string{
+	"*.Commit*",
+	"*.Destroy*",
+	"*.Free*",
+	"*.Provision",
+	"*.Label",
+	"*.SetSize",
+	"Manager.Rollback",
+	"Manager.Terminate",
+	"Group.PromoteCanary",
+	"Group.AbortCanary",
+	"Group.PauseUpdate",
+	"Group.ResumeUpdate",
+	"L4.Publish",
+	"L4.Unpublish",
+	"L4.RegisterBackends",
+	"L4.DeregisterBackends",
+	"L4.ConfigureHealthCheck",
+}
+
+// Mutating returns true if the rpc method changes the state
+func Mutating(method string) bool {
+	for _, pattern := range MutatingMethods {
+		if matched, _ := path.Match(pattern, method); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Outcome is the outcome of a call
+type Outcome string
+
+const (
+	// OutcomeOK is the outcome of the calls that succeeded
+	OutcomeOK Outcome = "ok"
+
+	// OutcomeError is the outcome of the calls that failed
+	OutcomeError Outcome = "error"
+)
+
+// Entry is a call recorded in the audit log
+type Entry struct {
+	// Time is when the call returned
+	Time time.Time
+
+	// Identity is the identity of the caller, if known
+	Identity string `json:",omitempty"`
+
+	// Plugin is the name of the plugin called
+	Plugin string
+
+	// Method is the rpc method called, such as Instance.Destroy
+	Method string
+
+	// Digest is the SHA-256 digest of the arguments of the call
+	Digest string
+
+	// Outcome is the outcome of the call
+	Outcome Outcome
+
+	// Error is the error of the call, if failed
+	Error string `json:",omitempty"`
+}
+
+// Digest returns the digest of the arguments of a call
+func Digest(args []byte) string {
+	sum := sha256.Sum256(args)
+	return hex.EncodeToString(sum[:])
+}
+
+// Filter selects the entries of the log.  The zero value selects all the entries.
+type Filter struct {
+	// Identity is the identity of the caller
+	Identity string
+
+	// Plugin is the name of the plugin
+	Plugin string
+
+	// Method is a pattern of the methods, such as *.Destroy*
+	Method string
+
+	// Since is the earliest time of the entries
+	Since time.Time
+}
+
+// Matches returns true if the entry is selected by the filter
+func (f Filter) Matches(entry Entry) bool {
+	if f.Identity != "" && f.Identity != entry.Identity {
+		return false
+	}
+	if f.Plugin != "" && f.Plugin != entry.Plugin {
+		return false
+	}
+	if f.Method != "" {
+		if matched, _ := path.Match(f.Method, entry.Method); !matched {
+			return false
+		}
+	}
+	return f.Since.IsZero() || !entry.Time.Before(f.Since)
+}
+
+// Log is an append-only log of the calls
+type Log interface {
+	// Append appends the entry to the log
+	Append(entry Entry) error
+
+	// Entries returns the entries selected by the filter, oldest first
+	Entries(filter Filter) ([]Entry, error)
+}
+
+// Options configure the audit log of the plugins
+type Options struct {
+	// File is the file of the log.  The file is rotated once it reaches the max size.
+	File string `json:",omitempty"`
+
+	// MaxSize is the size in bytes of the file before it is rotated.  Zero for the default.
+	MaxSize int64 `json:",omitempty"`
+
+	// MaxFiles is the number of files kept, including the current one.  Zero for the default.
+	MaxFiles int `json:",omitempty"`
+
+	// Events publishes the entries as events of the plugins, on the audit topic
+	Events bool `json:",omitempty"`
+
+	// KV is the kv store of the log, instead of the file
+	KV store.KV `json:"-"`
+}
+
+// DefaultOptions returns the options set by the environment, or nil if none are set.
+func DefaultOptions() *Options {
+	options := Options{
+		File:   os.Getenv(EnvFile),
+		Events: strings.ToLower(os.Getenv(EnvEvents)) == "true",
+	}
+	if options == (Options{}) {
+		return nil
+	}
+	return &options
+}
+
+// Log returns the log of the options, or nil if neither a kv store nor a file is set.
+func (o *Options) Log() Log {
+	switch {
+	case o == nil:
+		return nil
+	case o.KV != nil:
+		return NewKVLog(o.KV)
+	case o.File != "":
+		return NewFileLog(o.File, o.MaxSize, o.MaxFiles)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/store/mem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutating(t *testing.T) {
+	require.True(t, Mutating("Instance.Destroy"))
+	require.True(t, Mutating("Group.CommitGroup"))
+	require.True(t, Mutating("Group.DestroyGroup"))
+	require.True(t, Mutating("Instance.Provision"))
+	require.True(t, Mutating("Instance.Label"))
+	require.True(t, Mutating("Group.SetSize"))
+	require.True(t, Mutating("Metadata.Commit"))
+	require.False(t, Mutating("Instance.DescribeInstances"))
+	require.False(t, Mutating("Metadata.Get"))
+	require.False(t, Mutating("Handshake.Implements"))
+}
+
+func testLog(t *testing.T, log Log) {
+	now := time.Now()
+	require.NoError(t, log.Append(Entry{Time: now, Identity: "alice", Plugin: "group", Method: "Group.CommitGroup",
+		Digest: Digest([]byte("a")), Outcome: OutcomeOK}))
+	require.NoError(t, log.Append(Entry{Time: now, Identity: "bob", Plugin: "simulator", Method: "Instance.Destroy",
+		Digest: Digest([]byte("b")), Outcome: OutcomeError, Error: "not found"}))
+	require.NoError(t, log.Append(Entry{Time: now.Add(time.Minute), Identity: "alice", Plugin: "simulator",
+		Method: "Instance.Provision", Digest: Digest([]byte("c")), Outcome: OutcomeOK}))
+
+	entries, err := log.Entries(Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	require.Equal(t, "Group.CommitGroup", entries[0].Method)
+	require.Equal(t, "Instance.Destroy", entries[1].Method)
+	require.Equal(t, "not found", entries[1].Error)
+	require.Equal(t, "Instance.Provision", entries[2].Method)
+
+	entries, err = log.Entries(Filter{Identity: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+
+	entries, err = log.Entries(Filter{Plugin: "simulator", Method: "Instance.*"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+
+	entries, err = log.Entries(Filter{Since: now.Add(time.Second)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	require.Equal(t, "Instance.Provision", entries[0].Method)
+}
+
+func TestFileLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testLog(t, NewFileLog(filepath.Join(dir, "audit.log"), 0, 0))
+}
+
+func TestFileLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "audit.log")
+	log := NewFileLog(file, 200, 3)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, log.Append(Entry{Time: time.Unix(int64(i), 0), Plugin: "simulator",
+			Method: "Instance.Destroy", Outcome: OutcomeOK}))
+	}
+
+	for _, rotated := range []string{file, file + ".1", file + ".2"} {
+		info, err := os.Stat(rotated)
+		require.NoError(t, err)
+		require.True(t, info.Size() <= 200)
+	}
+	_, err = os.Stat(file + ".3")
+	require.True(t, os.IsNotExist(err))
+
+	entries, err := log.Entries(Filter{})
+	require.NoError(t, err)
+	require.True(t, len(entries) > 0 && len(entries) < 10)
+	for i := 1; i < len(entries); i++ {
+		require.True(t, entries[i-1].Time.Before(entries[i].Time))
+	}
+	require.Equal(t, int64(9), entries[len(entries)-1].Time.Unix())
+}
+
+func TestKVLog(t *testing.T) {
+	testLog(t, NewKVLog(mem.NewStore("audit")))
+}
+
+func TestOptions(t *testing.T) {
+	var options *Options
+	require.Nil(t, options.Log())
+	require.Nil(t, (&Options{Events: true}).Log())
+	require.NotNil(t, (&Options{File: "audit.log"}).Log())
+	require.NotNil(t, (&Options{KV: mem.NewStore("audit")}).Log())
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/infrakit/pkg/util/flock"
+)
+
+const (
+	// DefaultMaxSize is the default size of the file of the log before it is rotated
+	DefaultMaxSize = 10 * 1024 * 1024
+
+	// DefaultMaxFiles is the default number of files of the log kept
+	DefaultMaxFiles = 5
+)
+
+// fileLog appends the entries to a file, one JSON object per line.  The file is rotated into numbered files
+// (path.1 the most recent) once it reaches the max size.  The file is locked while written, so the plugins of a
+// host can share the log.
+type fileLog struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+}
+
+// NewFileLog returns a log appended to the file, rotated at the max size keeping the max number of files.  Zero
+// values are the defaults.
+func NewFileLog(path string, maxSize int64, maxFiles int) Log {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxFiles
+	}
+	return &fileLog{path: path, maxSize: maxSize, maxFiles: maxFiles}
+}
+
+func (l *fileLog) rotated(i int) string {
+	if i == 0 {
+		return l.path
+	}
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+func (l *fileLog) locked(f func() error) error {
+	lock, err := os.OpenFile(l.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := flock.Lock(lock); err != nil {
+		return err
+	}
+	defer flock.Unlock(lock)
+	return f()
+}
+
+// Append implements Log
+func (l *fileLog) Append(entry Entry) error {
+	buff, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	buff = append(buff, '\n')
+
+	return l.locked(func() error {
+		if info, err := os.Stat(l.path); err == nil && info.Size() > 0 && info.Size()+int64(len(buff)) > l.maxSize {
+			if err := l.rotate(); err != nil {
+				return err
+			}
+		}
+
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(buff)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+func (l *fileLog) rotate() error {
+	if err := os.Remove(l.rotated(l.maxFiles - 1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := l.maxFiles - 2; i >= 0; i-- {
+		if err := os.Rename(l.rotated(i), l.rotated(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries implements Log
+func (l *fileLog) Entries(filter Filter) ([]Entry, error) {
+	entries := []Entry{}
+	err := l.locked(func() error {
+		for i := l.maxFiles - 1; i >= 0; i-- {
+			f, err := os.Open(l.rotated(i))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				entry := Entry{}
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					log.Warn("Bad entry", "file", f.Name(), "err", err)
+					continue
+				}
+				if filter.Matches(entry) {
+					entries = append(entries, entry)
+				}
+			}
+			err = scanner.Err()
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
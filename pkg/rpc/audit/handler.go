@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
+)
+
+// Handler records the mutating rpc calls served by the next handler
+type Handler struct {
+	// Next serves the calls
+	Next http.Handler
+
+	// Plugin is the name of the plugin serving the calls
+	Plugin string
+
+	// Log is the log of the calls.  Nil to not log the calls.
+	Log Log
+
+	// Publish publishes the entries of the calls.  Nil to not publish.
+	Publish func(Entry)
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		h.Next.ServeHTTP(resp, req)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	call := struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}{}
+	if json.Unmarshal(body, &call) != nil || !Mutating(call.Method) {
+		h.Next.ServeHTTP(resp, req)
+		return
+	}
+
+	recorder := rpc.NewRecorder()
+	h.Next.ServeHTTP(recorder, req)
+
+	entry := Entry{
+		Time:     time.Now(),
+		Identity: auth.IdentityOf(req),
+		Plugin:   h.Plugin,
+		Method:   call.Method,
+		Digest:   Digest(call.Params),
+		Outcome:  OutcomeOK,
+	}
+	if callErr := callError(recorder); callErr != "" {
+		entry.Outcome = OutcomeError
+		entry.Error = callErr
+	}
+
+	if h.Log != nil {
+		if err := h.Log.Append(entry); err != nil {
+			log.Error("Cannot append to the audit log", "entry", entry, "err", err)
+		}
+	}
+	if h.Publish != nil {
+		h.Publish(entry)
+	}
+
+	for k, v := range recorder.HeaderMap {
+		resp.Header()[k] = v
+	}
+	resp.WriteHeader(recorder.Code)
+	recorder.Body.WriteTo(resp)
+}
+
+// callError returns the error of the rpc call replied, or "" if the call succeeded
+func callError(recorder *rpc.ResponseRecorder) string {
+	reply := struct {
+		Error *json.RawMessage `json:"error"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &reply); err != nil {
+		return http.StatusText(recorder.Code)
+	}
+	if reply.Error == nil || string(*reply.Error) == "null" {
+		return ""
+	}
+	message := struct {
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(*reply.Error, &message) == nil && message.Message != "" {
+		return message.Message
+	}
+	return string(*reply.Error)
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/rpc/auth"
+	"github.com/docker/infrakit/pkg/store/mem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	log := NewKVLog(mem.NewStore("audit"))
+	published := []Entry{}
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, "identity.key")
+	key, err := auth.LoadIdentityKey(keyFile)
+	require.NoError(t, err)
+
+	handler := &Handler{
+		Next: http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			if strings.Contains(req.URL.Path, "fail") {
+				resp.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32000,"message":"no such instance"},"id":1}`))
+				return
+			}
+			resp.Write([]byte(`{"jsonrpc":"2.0","result":{},"id":1}`))
+		}),
+		Plugin:  "simulator",
+		Log:     log,
+		Publish: func(entry Entry) { published = append(published, entry) },
+	}
+	server := httptest.NewServer(auth.VerifyIdentity(keyFile, handler))
+	defer server.Close()
+
+	signer := key
+	call := func(path, method, params string) string {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path,
+			strings.NewReader(`{"jsonrpc":"2.0","method":"`+method+`","params":`+params+`,"id":1}`))
+		require.NoError(t, err)
+		signer.Sign(req.Header, "alice")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		buff, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(buff)
+	}
+
+	require.Contains(t, call("/", "Instance.DescribeInstances", `[{}]`), "result")
+	require.Contains(t, call("/", "Instance.Destroy", `[{"Instance":"a"}]`), "result")
+	require.Contains(t, call("/fail", "Instance.Destroy", `[{"Instance":"b"}]`), "no such instance")
+
+	entries, err := log.Entries(Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	require.Equal(t, 2, len(published))
+	require.Equal(t, entries[1].Digest, published[1].Digest)
+
+	require.Equal(t, "alice", entries[0].Identity)
+	require.Equal(t, "simulator", entries[0].Plugin)
+	require.Equal(t, "Instance.Destroy", entries[0].Method)
+	require.Equal(t, Digest([]byte(`[{"Instance":"a"}]`)), entries[0].Digest)
+	require.Equal(t, OutcomeOK, entries[0].Outcome)
+
+	require.Equal(t, OutcomeError, entries[1].Outcome)
+	require.Equal(t, "no such instance", entries[1].Error)
+
+	// The identities not signed by the key of the host are not trusted
+	signer = auth.IdentityKey("forged")
+	call("/", "Instance.Destroy", `[{"Instance":"c"}]`)
+	entries, err = log.Entries(Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	require.Equal(t, "", entries[2].Identity)
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/docker/infrakit/pkg/store"
+)
+
+// kvLog appends the entries to a kv store, keyed by time so the keys sort in the order of the entries
+type kvLog struct {
+	kv store.KV
+}
+
+// NewKVLog returns a log appended to the kv store
+func NewKVLog(kv store.KV) Log {
+	return &kvLog{kv: kv}
+}
+
+// Append implements Log
+func (l *kvLog) Append(entry Entry) error {
+	buff, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// The key is the time of the entry, moved on if taken by another entry of the same time
+	for t := entry.Time.UnixNano(); ; t++ {
+		err := store.CompareAndSwap(l.kv, fmt.Sprintf("%020d", t), 0, buff)
+		if _, conflict := err.(store.ErrConflict); !conflict {
+			return err
+		}
+	}
+}
+
+// Entries implements Log
+func (l *kvLog) Entries(filter Filter) ([]Entry, error) {
+	pairs, err := l.kv.Entries()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	values := map[string][]byte{}
+	for pair := range pairs {
+		key := fmt.Sprintf("%v", pair.Key)
+		keys = append(keys, key)
+		values[key] = pair.Value
+	}
+	sort.Strings(keys)
+
+	entries := []Entry{}
+	for _, key := range keys {
+		entry := Entry{}
+		if err := json.Unmarshal(values[key], &entry); err != nil {
+			log.Warn("Bad entry", "key", key, "err", err)
+			continue
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
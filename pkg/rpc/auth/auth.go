@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	broker "github.com/docker/infrakit/pkg/broker/server"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+var log = logutil.New("module", "rpc/auth")
+
+const (
+	// EnvTokensFile is the environment variable of the file of the tokens of the identities
+	EnvTokensFile = "INFRAKIT_AUTH_TOKENS_FILE"
+
+	// EnvPolicyFile is the environment variable of the file of the policy
+	EnvPolicyFile = "INFRAKIT_AUTH_POLICY_FILE"
+
+	// EnvProxies is the environment variable of the identities of the proxies trusted to pass the identity of
+	// their callers, separated by commas
+	EnvProxies = "INFRAKIT_AUTH_PROXIES"
+
+	// HeaderIdentity is the header of the identity of the caller, set by the mux when proxying to the leader or to
+	// the plugins on this host.  It is trusted only from the proxies of the guard, or if signed by the identity key
+	// of the host (see IdentityKey).
+	HeaderIdentity = "X-Infrakit-Identity"
+)
+
+// Options are the files configuring the authentication and the authorization of the callers of the servers.
+type Options struct {
+	// TokensFile is the file of the bearer tokens, keyed by identity.  Without tokens, only the callers with a
+	// client certificate verified by the server are authenticated.
+	TokensFile string `json:",omitempty"`
+
+	// PolicyFile is the file of the policy.  Without policy, the callers authenticated can call all the methods.
+	PolicyFile string `json:",omitempty"`
+
+	// Proxies are the identities of the proxies, like the muxes of the other nodes forwarding to the leader, trusted
+	// to pass the identity of their callers.  The calls they forward are authorized for the identity passed.
+	Proxies []string `json:",omitempty"`
+}
+
+// DefaultOptions returns the options set by the environment, or nil if none are set.
+func DefaultOptions() *Options {
+	options := Options{
+		TokensFile: os.Getenv(EnvTokensFile),
+		PolicyFile: os.Getenv(EnvPolicyFile),
+	}
+	for _, proxy := range strings.Split(os.Getenv(EnvProxies), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			options.Proxies = append(options.Proxies, proxy)
+		}
+	}
+	if options.TokensFile == "" && options.PolicyFile == "" && len(options.Proxies) == 0 {
+		return nil
+	}
+	return &options
+}
+
+// Guard returns the guard of the options, or nil without options.
+func (o *Options) Guard() (*Guard, error) {
+	if o == nil {
+		return nil, nil
+	}
+	guard := &Guard{Authenticators: []Authenticator{Certificates{}}, Proxies: o.Proxies}
+	if o.TokensFile != "" {
+		tokens, err := LoadTokens(o.TokensFile)
+		if err != nil {
+			return nil, err
+		}
+		guard.Authenticators = append(guard.Authenticators, tokens)
+	}
+	if o.PolicyFile != "" {
+		policy, err := LoadPolicy(o.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		guard.Policy = policy
+	}
+	return guard, nil
+}
+
+// Guard authenticates the callers and authorizes their calls
+type Guard struct {
+	// Authenticators identify the callers, in order
+	Authenticators []Authenticator
+
+	// Policy authorizes the calls.  Nil to allow all the calls of the callers authenticated.
+	Policy *Policy
+
+	// Proxies are the identities trusted to pass the identity of their callers in HeaderIdentity
+	Proxies []string
+}
+
+// Authenticate returns the identity of the caller of the request.  An ErrNotAuthorized is returned if the
+// request has no valid credentials.
+func (g *Guard) Authenticate(req *http.Request) (string, error) {
+	for _, authenticator := range g.Authenticators {
+		identity, err := authenticator.Authenticate(req)
+		if err != nil {
+			return "", err
+		}
+		if identity != "" {
+			return identity, nil
+		}
+	}
+	return "", broker.ErrNotAuthorized("no credentials")
+}
+
+// caller returns the identity of the caller on whose behalf the identity authenticated calls.  That is the identity
+// passed by a trusted proxy, or the identity authenticated itself.
+func (g *Guard) caller(identity string, req *http.Request) (string, error) {
+	for _, proxy := range g.Proxies {
+		if proxy != identity {
+			continue
+		}
+		caller := req.Header.Get(HeaderIdentity)
+		if caller == "" {
+			return "", broker.ErrNotAuthorized(fmt.Sprintf("proxy %v passed no identity", identity))
+		}
+		return caller, nil
+	}
+	return identity, nil
+}
+
+// Authorize returns an ErrNotAuthorized if the identity is not allowed to call the method
+func (g *Guard) Authorize(identity, method string) error {
+	if g.Policy == nil || g.Policy.Allows(identity, method) {
+		return nil
+	}
+	return broker.ErrNotAuthorized(fmt.Sprintf("%v cannot call %v", identity, method))
+}
+
+type identityKey struct{}
+
+// IdentityOf returns the identity of the caller of a request let through by a guard, or "" if not known.
+func IdentityOf(req *http.Request) string {
+	identity, _ := req.Context().Value(identityKey{}).(string)
+	return identity
+}
+
+// Handler returns the handler that lets through to the next only the requests of the callers authenticated, and
+// only the rpc calls authorized.  The requests other than rpc calls, like the info and the events, need only be
+// authenticated.  The calls forwarded by the proxies trusted are authorized for the callers of the proxies.
+func (g *Guard) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		identity, err := g.Authenticate(req)
+		if err == nil {
+			identity, err = g.caller(identity, req)
+		}
+		if err != nil {
+			log.Warn("Not authenticated", "remote", req.RemoteAddr, "url", req.URL, "err", err)
+			http.Error(resp, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if req.Method == http.MethodPost {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				http.Error(resp, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			call := struct {
+				Method string           `json:"method"`
+				ID     *json.RawMessage `json:"id"`
+			}{}
+			if err := json.Unmarshal(body, &call); err != nil {
+				log.Warn("Not an rpc call", "identity", identity, "remote", req.RemoteAddr, "err", err)
+				replyError(resp, http.StatusBadRequest, nil, &json2.Error{Code: json2.E_PARSE, Message: err.Error()})
+				return
+			}
+
+			if err := g.Authorize(identity, call.Method); err != nil {
+				log.Warn("Not authorized", "identity", identity, "method", call.Method, "remote", req.RemoteAddr)
+				replyError(resp, http.StatusForbidden, call.ID,
+					&json2.Error{Code: json2.E_SERVER, Message: err.Error()})
+				return
+			}
+		}
+
+		next.ServeHTTP(resp, req.WithContext(context.WithValue(req.Context(), identityKey{}, identity)))
+	})
+}
+
+// replyError replies to the rpc call with the error, so the clients return the error of the call
+func replyError(resp http.ResponseWriter, status int, id *json.RawMessage, err *json2.Error) {
+	buff, _ := json.Marshal(struct {
+		Version string           `json:"jsonrpc"`
+		Error   *json2.Error     `json:"error"`
+		ID      *json.RawMessage `json:"id"`
+	}{
+		Version: "2.0",
+		Error:   err,
+		ID:      id,
+	})
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	resp.Write(buff)
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/rpc/v2/json2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard(t *testing.T) {
+	guard := &Guard{
+		Authenticators: []Authenticator{Certificates{}, Tokens{"alice": "a-token", "bob": "b-token"}},
+		Policy: &Policy{
+			Roles:      map[string][]string{"admin": {"*"}, "operator": {"*.Describe*"}},
+			Identities: map[string][]string{"alice": {"admin"}, "bob": {"operator"}},
+		},
+	}
+
+	called := []string{}
+	server := httptest.NewServer(guard.Handler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		called = append(called, IdentityOf(req))
+		resp.Write([]byte(`{"jsonrpc":"2.0","result":{},"id":1}`))
+	})))
+	defer server.Close()
+
+	call := func(token, method string) (int, error) {
+		req, err := http.NewRequest(http.MethodPost, server.URL,
+			strings.NewReader(`{"jsonrpc":"2.0","method":"`+method+`","params":[{}],"id":1}`))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return resp.StatusCode, nil
+		}
+		result := map[string]interface{}{}
+		return resp.StatusCode, json2.DecodeClientResponse(resp.Body, &result)
+	}
+
+	status, _ := call("", "Instance.DescribeInstances")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = call("bad-token", "Instance.DescribeInstances")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, err := call("b-token", "Instance.DescribeInstances")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	status, err = call("b-token", "Instance.Destroy")
+	require.Equal(t, http.StatusForbidden, status)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not authorized: bob cannot call Instance.Destroy")
+
+	status, err = call("a-token", "Instance.Destroy")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, []string{"bob", "alice"}, called)
+
+	// The bodies that are not rpc calls are refused before being authorized
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"method":`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer a-token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Error(t, json2.DecodeClientResponse(resp.Body, &map[string]interface{}{}))
+	require.Equal(t, []string{"bob", "alice"}, called)
+}
+
+func TestGuardProxies(t *testing.T) {
+	guard := &Guard{
+		Authenticators: []Authenticator{Tokens{"mux-2": "mux-token", "bob": "b-token"}},
+		Policy: &Policy{
+			Roles:      map[string][]string{"admin": {"*"}, "operator": {"*.Describe*"}},
+			Identities: map[string][]string{"mux-2": {"admin"}, "alice": {"admin"}, "bob": {"operator"}},
+		},
+		Proxies: []string{"mux-2"},
+	}
+
+	called := []string{}
+	server := httptest.NewServer(guard.Handler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		called = append(called, IdentityOf(req))
+		resp.Write([]byte(`{"jsonrpc":"2.0","result":{},"id":1}`))
+	})))
+	defer server.Close()
+
+	call := func(token, identity, method string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL,
+			strings.NewReader(`{"jsonrpc":"2.0","method":"`+method+`","params":[{}],"id":1}`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if identity != "" {
+			req.Header.Set(HeaderIdentity, identity)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The calls forwarded by the proxy are authorized for the caller passed, not for the proxy
+	require.Equal(t, http.StatusForbidden, call("mux-token", "bob", "Instance.Destroy"))
+	require.Equal(t, http.StatusOK, call("mux-token", "bob", "Instance.DescribeInstances"))
+	require.Equal(t, http.StatusOK, call("mux-token", "alice", "Instance.Destroy"))
+
+	// The proxy must pass the identity of the caller
+	require.Equal(t, http.StatusUnauthorized, call("mux-token", "", "Instance.DescribeInstances"))
+
+	// The identity passed by the callers other than the proxies is ignored
+	require.Equal(t, http.StatusForbidden, call("b-token", "alice", "Instance.Destroy"))
+
+	require.Equal(t, []string{"bob", "alice"}, called)
+}
+
+func TestClientTransport(t *testing.T) {
+	headers := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		headers = append(headers, req.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	_, err := (&http.Client{Transport: ClientTransport(nil)}).Get(server.URL)
+	require.NoError(t, err)
+
+	os.Setenv(EnvToken, "a-token")
+	defer os.Unsetenv(EnvToken)
+	_, err = (&http.Client{Transport: ClientTransport(nil)}).Get(server.URL)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"", "Bearer a-token"}, headers)
+}
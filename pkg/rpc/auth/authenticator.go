@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	broker "github.com/docker/infrakit/pkg/broker/server"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// EnvToken is the environment variable of the bearer token presented by the clients
+const EnvToken = "INFRAKIT_AUTH_TOKEN"
+
+// Authenticator identifies the caller of a request
+type Authenticator interface {
+	// Authenticate returns the identity of the caller, or "" if the request has no credentials of this kind.
+	// An ErrNotAuthorized is returned if the credentials are not valid.
+	Authenticate(req *http.Request) (string, error)
+}
+
+// Tokens authenticates the callers by the bearer tokens of the requests.  The tokens are keyed by identity.
+type Tokens map[string]string
+
+// LoadTokens loads the tokens of the identities from the file, in JSON or YAML
+func LoadTokens(file string) (Tokens, error) {
+	buff, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	any, err := types.AnyYAML(buff)
+	if err != nil {
+		return nil, err
+	}
+	tokens := Tokens{}
+	return tokens, any.Decode(&tokens)
+}
+
+// Authenticate implements Authenticator
+func (t Tokens) Authenticate(req *http.Request) (string, error) {
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", nil
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	for identity, known := range t {
+		if known != "" && subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			return identity, nil
+		}
+	}
+	return "", broker.ErrNotAuthorized("invalid token")
+}
+
+// Certificates authenticates the callers by the client certificates verified by the TLS server.  The identity is
+// the common name of the certificate.
+type Certificates struct{}
+
+// Authenticate implements Authenticator
+func (c Certificates) Authenticate(req *http.Request) (string, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return "", nil
+	}
+	return req.TLS.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+// bearer presents the token with the requests
+type bearer struct {
+	token     string
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (b *bearer) RoundTrip(req *http.Request) (*http.Response, error) {
+	copy := *req
+	copy.Header = http.Header{}
+	for k, v := range req.Header {
+		copy.Header[k] = v
+	}
+	copy.Header.Set("Authorization", "Bearer "+b.token)
+	return b.transport.RoundTrip(&copy)
+}
+
+// ClientTransport returns the transport that presents the bearer token set by the environment with the requests,
+// or the transport as is if no token is set.
+func ClientTransport(transport http.RoundTripper) http.RoundTripper {
+	token := os.Getenv(EnvToken)
+	if token == "" {
+		return transport
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &bearer{token: token, transport: transport}
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/run/local"
+)
+
+const (
+	// EnvIdentityKeyFile is the environment variable of the file of the key signing the identities passed by the
+	// mux to the plugins on this host
+	EnvIdentityKeyFile = "INFRAKIT_AUTH_IDENTITY_KEY_FILE"
+
+	// HeaderIdentitySignature is the header of the signature of the identity in HeaderIdentity
+	HeaderIdentitySignature = "X-Infrakit-Identity-Signature"
+)
+
+// IdentityKeyFile returns the file of the identity key set by the environment
+func IdentityKeyFile() string {
+	return local.Getenv(EnvIdentityKeyFile, filepath.Join(local.InfrakitHome(), "identity.key"))
+}
+
+// IdentityKey signs the identities of the callers passed by the mux to the plugins on the same host.  The plugins
+// trust only the identities signed, and not those set by any other process able to reach their sockets.
+type IdentityKey []byte
+
+// DefaultIdentityKey loads the identity key of the file set by the environment (see IdentityKeyFile)
+func DefaultIdentityKey() (IdentityKey, error) {
+	return LoadIdentityKey(IdentityKeyFile())
+}
+
+// LoadIdentityKey loads the identity key of the file.  The file is created with a random key, readable only by
+// the owner, if it does not exist yet.
+func LoadIdentityKey(file string) (IdentityKey, error) {
+	key, err := ReadIdentityKey(file)
+	if os.IsNotExist(err) {
+		if err := createIdentityKey(file); err != nil {
+			return nil, err
+		}
+		key, err = ReadIdentityKey(file)
+	}
+	return key, err
+}
+
+// ReadIdentityKey reads the identity key of the file
+func ReadIdentityKey(file string) (IdentityKey, error) {
+	buff, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(buff)))
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid identity key in %v", file)
+	}
+	return IdentityKey(key), nil
+}
+
+// createIdentityKey writes a random key to the file, unless another process has created it first.  The key is
+// written aside then linked, so the file is never seen partially written.
+func createIdentityKey(file string) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.WriteString(hex.EncodeToString(key))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0600); err != nil {
+		return err
+	}
+	if err := os.Link(temp.Name(), file); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (k IdentityKey) sign(identity string) string {
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte(identity))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign sets the identity, and its signature, in the headers.  The headers are removed if the identity is "".
+func (k IdentityKey) Sign(header http.Header, identity string) {
+	header.Del(HeaderIdentity)
+	header.Del(HeaderIdentitySignature)
+	if identity == "" {
+		return
+	}
+	header.Set(HeaderIdentity, identity)
+	header.Set(HeaderIdentitySignature, k.sign(identity))
+}
+
+// Verify returns the identity in the headers if signed by the key, or "" if not.
+func (k IdentityKey) Verify(header http.Header) string {
+	identity := header.Get(HeaderIdentity)
+	if identity == "" || len(k) == 0 {
+		return ""
+	}
+	signature, err := hex.DecodeString(header.Get(HeaderIdentitySignature))
+	if err != nil {
+		return ""
+	}
+	expected, _ := hex.DecodeString(k.sign(identity))
+	if !hmac.Equal(signature, expected) {
+		return ""
+	}
+	return identity
+}
+
+// VerifyIdentity returns the handler that sets the identity of the requests not authenticated by a guard to the
+// identity passed by the mux of this host, if signed by the identity key of the file.  The key is read at the first
+// request passing an identity, since it is created by the mux.
+func VerifyIdentity(file string, next http.Handler) http.Handler {
+	var key IdentityKey
+	var lock sync.Mutex
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if IdentityOf(req) != "" || req.Header.Get(HeaderIdentity) == "" {
+			next.ServeHTTP(resp, req)
+			return
+		}
+		lock.Lock()
+		if key == nil {
+			k, err := ReadIdentityKey(file)
+			if err != nil {
+				log.Warn("Cannot read the identity key", "file", file, "err", err)
+			}
+			key = k
+		}
+		verified := key.Verify(req.Header)
+		lock.Unlock()
+
+		if verified == "" {
+			log.Warn("Identity not verified", "identity", req.Header.Get(HeaderIdentity), "remote", req.RemoteAddr)
+			next.ServeHTTP(resp, req)
+			return
+		}
+		next.ServeHTTP(resp, req.WithContext(context.WithValue(req.Context(), identityKey{}, verified)))
+	})
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identity")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "identity.key")
+	key, err := LoadIdentityKey(file)
+	require.NoError(t, err)
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The key created is loaded by the others
+	loaded, err := LoadIdentityKey(file)
+	require.NoError(t, err)
+	require.Equal(t, key, loaded)
+
+	header := http.Header{}
+	key.Sign(header, "alice")
+	require.Equal(t, "alice", loaded.Verify(header))
+	require.Equal(t, "", IdentityKey("other").Verify(header))
+	require.Equal(t, "", IdentityKey(nil).Verify(header))
+
+	header.Set(HeaderIdentity, "bob")
+	require.Equal(t, "", key.Verify(header))
+
+	header.Del(HeaderIdentitySignature)
+	require.Equal(t, "", key.Verify(header))
+
+	key.Sign(header, "")
+	require.Equal(t, "", header.Get(HeaderIdentity))
+	require.Equal(t, "", header.Get(HeaderIdentitySignature))
+}
+
+func TestVerifyIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identity")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "identity.key")
+	identities := []string{}
+	handler := VerifyIdentity(file, http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		identities = append(identities, IdentityOf(req))
+	}))
+	call := func(key IdentityKey, identity string) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		key.Sign(req.Header, identity)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The key is not created until the mux starts
+	call(IdentityKey("forged"), "alice")
+
+	key, err := LoadIdentityKey(file)
+	require.NoError(t, err)
+	call(key, "alice")
+	call(IdentityKey("forged"), "bob")
+	call(key, "")
+
+	require.Equal(t, []string{"", "alice", "", ""}, identities)
+}
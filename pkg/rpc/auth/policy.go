@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// AnyIdentity is the identity of the policy that matches all the authenticated identities
+const AnyIdentity = "*"
+
+// Policy maps the identities to the methods they are allowed to call.  The methods are the rpc methods of the
+// interfaces, such as Group.DescribeGroup or Instance.Destroy, and are matched against the patterns of the roles,
+// such as Group.Describe* or *.Describe*.  For example,
+//
+//	Roles:
+//	  admin: [ "*" ]
+//	  operator: [ "*.Describe*", "*.Inspect*", "*.List", "*.Get", "Manager.IsLeader" ]
+//	Identities:
+//	  alice: [ admin ]
+//	  "*": [ operator ]
+//
+// The handshake methods are allowed to all the authenticated identities.
+type Policy struct {
+	// Roles are the patterns of the methods allowed, by role
+	Roles map[string][]string
+
+	// Identities are the roles of the identities.  The roles of AnyIdentity apply to all the identities.
+	Identities map[string][]string
+}
+
+// LoadPolicy loads the policy from the file, in JSON or YAML
+func LoadPolicy(file string) (*Policy, error) {
+	buff, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	any, err := types.AnyYAML(buff)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := any.Decode(policy); err != nil {
+		return nil, err
+	}
+	return policy, policy.Validate()
+}
+
+// Validate checks the roles of the identities exist and the patterns are well formed
+func (p *Policy) Validate() error {
+	for role, patterns := range p.Roles {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("bad pattern %v of role %v: %v", pattern, role, err)
+			}
+		}
+	}
+	for identity, roles := range p.Identities {
+		for _, role := range roles {
+			if _, has := p.Roles[role]; !has {
+				return fmt.Errorf("unknown role %v of %v", role, identity)
+			}
+		}
+	}
+	return nil
+}
+
+// Allows returns true if the identity is allowed to call the method
+func (p *Policy) Allows(identity, method string) bool {
+	if matched, _ := path.Match("Handshake.*", method); matched {
+		return true
+	}
+	roles := append(append([]string{}, p.Identities[identity]...), p.Identities[AnyIdentity]...)
+	for _, role := range roles {
+		for _, pattern := range p.Roles[role] {
+			if matched, _ := path.Match(pattern, method); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
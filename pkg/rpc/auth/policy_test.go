@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "policy.yml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`
+Roles:
+  admin: [ "*" ]
+  operator: [ "*.Describe*", "Group.Inspect*" ]
+  prober: [ "Manager.IsLeader" ]
+Identities:
+  alice: [ admin ]
+  bob: [ operator ]
+  "*": [ prober ]
+`), 0600))
+
+	policy, err := LoadPolicy(file)
+	require.NoError(t, err)
+
+	require.True(t, policy.Allows("alice", "Instance.Destroy"))
+	require.True(t, policy.Allows("bob", "Instance.DescribeInstances"))
+	require.True(t, policy.Allows("bob", "Group.InspectGroups"))
+	require.False(t, policy.Allows("bob", "Instance.Destroy"))
+	require.False(t, policy.Allows("bob", "Group.CommitGroup"))
+
+	// The roles of any identity, and the handshake, are allowed to all
+	require.True(t, policy.Allows("bob", "Manager.IsLeader"))
+	require.True(t, policy.Allows("carol", "Manager.IsLeader"))
+	require.True(t, policy.Allows("carol", "Handshake.Implements"))
+	require.False(t, policy.Allows("carol", "Instance.DescribeInstances"))
+
+	require.Error(t, (&Policy{Identities: map[string][]string{"bob": {"missing"}}}).Validate())
+	require.Error(t, (&Policy{Roles: map[string][]string{"bad": {"[a-"}}}).Validate())
+}
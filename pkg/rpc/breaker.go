@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+)
+
+var log = logutil.New("module", "rpc")
+
+const (
+	// ClientBreakerFailuresEnv is the environment variable of the number of calls failed in a row that opens the
+	// circuit of an endpoint (see BreakerPolicy)
+	ClientBreakerFailuresEnv = "INFRAKIT_CLIENT_BREAKER_FAILURES"
+
+	// ClientBreakerCooldownEnv is the environment variable of the time a circuit stays open (in time.Duration)
+	ClientBreakerCooldownEnv = "INFRAKIT_CLIENT_BREAKER_COOLDOWN"
+)
+
+// CircuitState is the state of the circuit breaker of an endpoint
+type CircuitState string
+
+const (
+	// CircuitClosed is the state of the circuits letting the calls through
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen is the state of the circuits failing the calls fast, the endpoint being unavailable
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen is the state of the circuits letting a trial call through, once open for the cooldown
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// ErrCircuitOpen is the error of the calls failed fast, the circuit of the endpoint being open
+type ErrCircuitOpen string
+
+// Error implements error
+func (e ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit open: %v is unavailable", string(e))
+}
+
+// BreakerPolicy is when the circuit of an endpoint opens, failing the calls fast without calling the endpoint.
+// The circuit opens once the calls failed in a row, even after their retries, because the endpoint is unavailable.
+// Once open for the cooldown, it lets a trial call through, and closes again if the call succeeds.
+type BreakerPolicy struct {
+	// Failures is the number of calls failed in a row that opens the circuit.  Zero to never open the circuit.
+	Failures int
+
+	// Cooldown is the time the circuit stays open before a trial call
+	Cooldown time.Duration
+}
+
+// DefaultBreakerPolicy returns the breaker policy set by the environment, or opening the circuit for 5s after 5
+// calls failed.
+func DefaultBreakerPolicy() BreakerPolicy {
+	policy := BreakerPolicy{
+		Failures: 5,
+		Cooldown: 5 * time.Second,
+	}
+	if failures, err := strconv.Atoi(os.Getenv(ClientBreakerFailuresEnv)); err == nil {
+		policy.Failures = failures
+	}
+	if cooldown, err := time.ParseDuration(os.Getenv(ClientBreakerCooldownEnv)); err == nil {
+		policy.Cooldown = cooldown
+	}
+	return policy
+}
+
+// Breaker is the circuit breaker of an endpoint, shared by the clients of the endpoint in the process
+type Breaker struct {
+	address  string
+	policy   BreakerPolicy
+	state    CircuitState
+	failures int
+	opened   time.Time
+	lock     sync.Mutex
+}
+
+var (
+	breakers     = map[string]*Breaker{}
+	breakersLock sync.Mutex
+)
+
+// BreakerOf returns the breaker of the endpoint, created with the policy if new
+func BreakerOf(address string, policy BreakerPolicy) *Breaker {
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+
+	b, has := breakers[address]
+	if !has {
+		b = &Breaker{address: address, policy: policy, state: CircuitClosed}
+		breakers[address] = b
+	}
+	return b
+}
+
+// Circuits returns the states of the circuits of the endpoints called by this process, by address
+func Circuits() map[string]CircuitState {
+	breakersLock.Lock()
+	defer breakersLock.Unlock()
+
+	circuits := map[string]CircuitState{}
+	for address, b := range breakers {
+		circuits[address] = b.State()
+	}
+	return circuits
+}
+
+// State returns the state of the circuit
+func (b *Breaker) State() CircuitState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// Allow returns an ErrCircuitOpen if the call must fail fast.  Otherwise, the outcome of the call must be recorded
+// with Done or Abandon.
+func (b *Breaker) Allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.opened) < b.policy.Cooldown {
+			return ErrCircuitOpen(b.address)
+		}
+		// Let this call through as the trial, failing the others until it returns
+		b.state = CircuitHalfOpen
+		return nil
+	case CircuitHalfOpen:
+		return ErrCircuitOpen(b.address)
+	}
+	return nil
+}
+
+// Done records the outcome of a call let through, failed or not because the endpoint is unavailable
+func (b *Breaker) Done(unavailable bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !unavailable {
+		if b.state != CircuitClosed {
+			log.Info("Circuit closed", "address", b.address)
+		}
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.policy.Failures > 0 && (b.state == CircuitHalfOpen || b.failures >= b.policy.Failures) {
+		if b.state == CircuitClosed {
+			log.Warn("Circuit open", "address", b.address, "failures", b.failures)
+		}
+		b.state = CircuitOpen
+		b.opened = time.Now()
+	}
+}
+
+// Abandon records a call let through but abandoned by its caller, leaving a trial to the next call
+func (b *Breaker) Abandon() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.state = CircuitOpen
+	}
+}
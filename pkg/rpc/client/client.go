@@ -2,19 +2,20 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"os"
 	"path"
 	"sync"
 	"time"
 
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/gorilla/rpc/v2/json2"
 )
@@ -25,27 +26,44 @@ var (
 )
 
 type client struct {
-	http *http.Client
-	addr string
-	url  *url.URL
+	http    *http.Client
+	addr    string
+	url     *url.URL
+	retry   RetryPolicy
+	breaker *rpc.Breaker
 }
 
-// NewHandshaker returns a handshaker object, or a generic, untyped rpc object
-func NewHandshaker(address string) (rpc.Handshaker, error) {
+func newClient(address string, retry RetryPolicy) (*client, error) {
 	u, httpC, err := parseAddress(address)
 	if err != nil {
 		return nil, err
 	}
-	return &client{addr: address, http: httpC, url: u}, nil
+	return &client{
+		addr:    address,
+		http:    httpC,
+		url:     u,
+		retry:   retry,
+		breaker: rpc.BreakerOf(address, rpc.DefaultBreakerPolicy()),
+	}, nil
+}
+
+// NewHandshaker returns a handshaker object, or a generic, untyped rpc object
+func NewHandshaker(address string) (rpc.Handshaker, error) {
+	return newClient(address, DefaultRetryPolicy())
 }
 
 // New creates a new Client that communicates with a unix socket and validates the remote API.
+// The calls are retried with the retry policy set by the environment (see DefaultRetryPolicy).
 func New(address string, api spi.InterfaceSpec) (Client, error) {
-	u, httpC, err := parseAddress(address)
+	return NewWithRetryPolicy(address, api, DefaultRetryPolicy())
+}
+
+// NewWithRetryPolicy creates a new Client that validates the remote API, and retries the calls with the policy.
+func NewWithRetryPolicy(address string, api spi.InterfaceSpec, retry RetryPolicy) (Client, error) {
+	unvalidatedClient, err := newClient(address, retry)
 	if err != nil {
 		return nil, err
 	}
-	unvalidatedClient := &client{addr: address, http: httpC, url: u}
 	cl := &handshakingClient{client: unvalidatedClient, iface: api, lock: &sync.Mutex{}}
 	// check handshake
 	if err := cl.handshake(); err != nil {
@@ -57,17 +75,6 @@ func New(address string, api spi.InterfaceSpec) (Client, error) {
 	return cl, nil
 }
 
-// ClientTimeoutEnv environment variable for the client timeout (in time.Duration)
-const ClientTimeoutEnv = "INFRAKIT_CLIENT_TIMEOUT"
-
-// timeout returns the client rpc http timeout
-func timeout() time.Duration {
-	if parsed, err := time.ParseDuration(os.Getenv(ClientTimeoutEnv)); err == nil {
-		return parsed
-	}
-	return time.Duration(1 * time.Second)
-}
-
 func parseAddress(address string) (*url.URL, *http.Client, error) {
 	if path.Ext(address) == ".listen" {
 		buff, err := ioutil.ReadFile(address)
@@ -88,7 +95,6 @@ func parseAddress(address string) (*url.URL, *http.Client, error) {
 		u.Host = "h"
 		u.Path = "" // clear it since it's a file path and we are using it to connect.
 		return u, &http.Client{
-			Timeout: timeout(),
 			Transport: &http.Transport{
 				// TODO(chungers) - fix this deprecation
 				Dial: func(proto, addr string) (conn net.Conn, err error) {
@@ -109,7 +115,15 @@ func parseAddress(address string) (*url.URL, *http.Client, error) {
 			}).Dial,
 			TLSHandshakeTimeout: timeout(),
 		}
-		return u, &http.Client{Transport: transport}, nil
+		if u.Scheme == "https" {
+			// Verify the server and present the certificate set by the environment, if any
+			tlsConfig, err := rpc.DefaultTLSOptions().ClientConfig()
+			if err != nil {
+				return nil, nil, err
+			}
+			transport.TLSClientConfig = tlsConfig
+		}
+		return u, &http.Client{Transport: auth.ClientTransport(transport)}, nil
 
 	default:
 	}
@@ -141,16 +155,66 @@ func (c client) Addr() string {
 }
 
 func (c client) Call(method string, arg interface{}, result interface{}) error {
+	return c.CallContext(context.Background(), method, arg, result)
+}
+
+// CallContext retries the calls of the idempotent methods, and those with an idempotency key, failed because the
+// endpoint is unavailable.  The calls fail fast while the circuit of the endpoint is open.
+func (c client) CallContext(ctx context.Context, method string, arg interface{}, result interface{}) error {
 	message, err := json2.EncodeClientRequest(method, arg)
 	if err != nil {
 		return err
 	}
 
+	key := IdempotencyKey(ctx)
+	attempts := 1
+	if key != "" || Idempotent(method) {
+		attempts = c.retry.Attempts
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		unavailable, err := c.send(ctx, method, message, key, result)
+		switch {
+		case ctx.Err() != nil:
+			c.breaker.Abandon()
+			return err
+		case !unavailable || attempt >= attempts:
+			c.breaker.Done(unavailable)
+			return err
+		}
+
+		backoff := c.retry.backoff(attempt)
+		log.Warn("Retrying", "addr", c.addr, "method", method, "attempt", attempt, "backoff", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			c.breaker.Abandon()
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// send sends the call once, returning true if the call failed because the endpoint is unavailable
+func (c client) send(ctx context.Context, method string, message []byte, key string,
+	result interface{}) (bool, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, methodTimeout(method))
+	defer cancel()
+
 	req, err := http.NewRequest(http.MethodPost, c.url.String(), bytes.NewReader(message))
 	if err != nil {
-		return err
+		return false, err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
+	rpc.SetDeadline(ctx, req.Header)
+	if key != "" {
+		req.Header.Set(rpc.HeaderIdempotencyKey, key)
+	}
 
 	requestData, err := httputil.DumpRequest(req, true)
 	if err == nil {
@@ -161,7 +225,7 @@ func (c client) Call(method string, arg interface{}, result interface{}) error {
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return err
+		return true, err
 	}
 
 	defer resp.Body.Close()
@@ -173,5 +237,10 @@ func (c client) Call(method string, arg interface{}, result interface{}) error {
 		log.Warn("Client RECEIVE", "err", err)
 	}
 
-	return json2.DecodeClientResponse(resp.Body, result)
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		// Replied by the mux, for the plugin behind it
+		return true, fmt.Errorf("%v: %v", c.addr, resp.Status)
+	}
+	return false, json2.DecodeClientResponse(resp.Body, result)
 }
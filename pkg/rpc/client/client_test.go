@@ -1,8 +1,14 @@
 package client
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/docker/infrakit/pkg/rpc"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,3 +35,130 @@ func TestParseAddress(t *testing.T) {
 	require.Equal(t, "https://host:9090", u.String())
 
 }
+
+func TestMethodTimeout(t *testing.T) {
+	defer os.Unsetenv(ClientTimeoutEnv)
+	defer os.Unsetenv(ClientTimeoutsEnv)
+
+	require.Equal(t, 1*time.Second, methodTimeout("Instance.DescribeInstances"))
+	require.Equal(t, MethodTimeouts["Instance.Provision"], methodTimeout("Instance.Provision"))
+
+	os.Setenv(ClientTimeoutEnv, "3s")
+	os.Setenv(ClientTimeoutsEnv, "Instance.Provision=10m, *.Describe*=2s,bad,Group.*=bad")
+	require.Equal(t, 10*time.Minute, methodTimeout("Instance.Provision"))
+	require.Equal(t, 2*time.Second, methodTimeout("Instance.DescribeInstances"))
+	require.Equal(t, 3*time.Second, methodTimeout("Group.CommitGroup"))
+	require.Equal(t, MethodTimeouts["Instance.Destroy"], methodTimeout("Instance.Destroy"))
+}
+
+func TestRetries(t *testing.T) {
+	unavailable := 0
+	requests := []*http.Request{}
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req)
+		if unavailable > 0 {
+			unavailable--
+			resp.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		resp.Write([]byte(`{"jsonrpc":"2.0","result":{},"id":1}`))
+	}))
+	defer server.Close()
+
+	c, err := newClient(server.URL, RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+	require.NoError(t, err)
+	result := map[string]interface{}{}
+
+	// Idempotent calls are retried
+	unavailable = 2
+	require.NoError(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	require.Equal(t, 3, len(requests))
+
+	// Until the attempts run out
+	requests, unavailable = nil, 3
+	require.Error(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	require.Equal(t, 3, len(requests))
+
+	// Other calls are not retried
+	requests, unavailable = nil, 1
+	require.Error(t, c.Call("Instance.Provision", struct{}{}, &result))
+	require.Equal(t, 1, len(requests))
+	require.Equal(t, "", requests[0].Header.Get(rpc.HeaderIdempotencyKey))
+
+	// Unless they have an idempotency key
+	requests, unavailable = nil, 1
+	require.NoError(t, c.CallContext(WithIdempotencyKey(context.Background(), "key"), "Instance.Provision",
+		struct{}{}, &result))
+	require.Equal(t, 2, len(requests))
+	for _, req := range requests {
+		require.Equal(t, "key", req.Header.Get(rpc.HeaderIdempotencyKey))
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	unavailable, failed := true, false
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		requests++
+		switch {
+		case unavailable:
+			resp.WriteHeader(http.StatusBadGateway)
+			return
+		case failed:
+			resp.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":1}`))
+			return
+		}
+		resp.Write([]byte(`{"jsonrpc":"2.0","result":{},"id":1}`))
+	}))
+	defer server.Close()
+
+	breaker := rpc.BreakerOf(server.URL, rpc.BreakerPolicy{Failures: 2, Cooldown: 100 * time.Millisecond})
+	c, err := newClient(server.URL, RetryPolicy{Attempts: 2, Backoff: time.Millisecond})
+	require.NoError(t, err)
+	result := map[string]interface{}{}
+
+	// The calls failed after their retries open the circuit
+	require.Error(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	require.Equal(t, rpc.CircuitClosed, breaker.State())
+	require.Error(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	require.Equal(t, rpc.CircuitOpen, breaker.State())
+	require.Equal(t, rpc.CircuitOpen, rpc.Circuits()[server.URL])
+	require.Equal(t, 4, requests)
+
+	// The calls fail fast while open
+	err = c.Call("Instance.DescribeInstances", struct{}{}, &result)
+	require.Equal(t, rpc.ErrCircuitOpen(server.URL), err)
+	require.Equal(t, 4, requests)
+
+	// A trial call failed opens the circuit again
+	time.Sleep(100 * time.Millisecond)
+	require.Error(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	require.Equal(t, rpc.CircuitOpen, breaker.State())
+
+	// A trial call succeeded closes the circuit
+	unavailable = false
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	require.Equal(t, rpc.CircuitClosed, breaker.State())
+
+	// The errors replied by the plugin do not open the circuit
+	failed = true
+	for i := 0; i < 3; i++ {
+		require.Error(t, c.Call("Instance.DescribeInstances", struct{}{}, &result))
+	}
+	require.Equal(t, rpc.CircuitClosed, breaker.State())
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	require.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	require.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	require.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	require.Equal(t, time.Second, policy.backoff(5))
+
+	require.True(t, Idempotent("Instance.DescribeInstances"))
+	require.True(t, Idempotent("Group.Size"))
+	require.True(t, Idempotent("Metadata.Get"))
+	require.False(t, Idempotent("Instance.Provision"))
+	require.False(t, Idempotent("Group.SetSize"))
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/docker/infrakit/pkg/spi"
@@ -82,3 +83,11 @@ func (c *handshakingClient) Call(method string, arg interface{}, result interfac
 
 	return c.client.Call(method, arg, result)
 }
+
+func (c *handshakingClient) CallContext(ctx context.Context, method string, arg interface{}, result interface{}) error {
+	if err := c.handshake(); err != nil {
+		return err
+	}
+
+	return c.client.CallContext(ctx, method, arg, result)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	// ClientRetriesEnv is the environment variable of the number of attempts of the calls retried (see RetryPolicy)
+	ClientRetriesEnv = "INFRAKIT_CLIENT_RETRIES"
+
+	// ClientBackoffEnv is the environment variable of the backoff before the first retry (in time.Duration)
+	ClientBackoffEnv = "INFRAKIT_CLIENT_BACKOFF"
+)
+
+// IdempotentMethods are the patterns of the methods retried, since calling them again changes nothing.  The other
+// methods are retried only if the call has an idempotency key (see WithIdempotencyKey).
+var IdempotentMethods = []string{
+	"Handshake.*",
+	"*.Describe*",
+	"*.Inspect*",
+	"*.Size",
+	"*.List",
+	"*.Get",
+}
+
+// Idempotent returns true if the method can be called again without effect
+func Idempotent(method string) bool {
+	for _, pattern := range IdempotentMethods {
+		if matched, _ := path.Match(pattern, method); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy is how the calls failed because the plugin is unavailable, like when restarting, are retried.  The
+// calls are not retried once the plugin replies, even with an error.
+type RetryPolicy struct {
+	// Attempts is the number of attempts of a call, including the first.  One or less to not retry.
+	Attempts int
+
+	// Backoff is the time before the first retry, doubled at each retry
+	Backoff time.Duration
+
+	// MaxBackoff is the longest time between the retries
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy set by the environment, or of 3 attempts backing off from 100ms.
+func DefaultRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		Attempts:   3,
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+	if attempts, err := strconv.Atoi(os.Getenv(ClientRetriesEnv)); err == nil {
+		policy.Attempts = attempts
+	}
+	if backoff, err := time.ParseDuration(os.Getenv(ClientBackoffEnv)); err == nil {
+		policy.Backoff = backoff
+	}
+	return policy
+}
+
+// backoff returns the time to wait after the attempt, counted from 1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns the context of the calls of the key, to be retried even if their methods are not
+// idempotent.  The server replies to the calls of the same key once, and replays its reply to the retries.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key of the context, or "" if none.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
@@ -1,5 +1,9 @@
 package client
 
+import (
+	"context"
+)
+
 // Client allows execution of RPCs.
 type Client interface {
 
@@ -8,4 +12,8 @@ type Client interface {
 
 	// Call invokes an RPC method with an argument and a pointer to a result that will hold the return value.
 	Call(method string, arg interface{}, result interface{}) error
+
+	// CallContext invokes an RPC method within the context.  The call is abandoned, and the server told to stop
+	// the work, once the context is canceled or its deadline passes.
+	CallContext(ctx context.Context, method string, arg interface{}, result interface{}) error
 }
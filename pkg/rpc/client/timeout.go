@@ -0,0 +1,57 @@
+package client
+
+import (
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// ClientTimeoutEnv environment variable for the client timeout (in time.Duration)
+	ClientTimeoutEnv = "INFRAKIT_CLIENT_TIMEOUT"
+
+	// ClientTimeoutsEnv is the environment variable of the timeouts of the methods, as a comma separated list of
+	// method pattern and time.Duration pairs, such as Instance.Provision=10m,*.Describe*=2s.  The first pattern
+	// matching the method applies.
+	ClientTimeoutsEnv = "INFRAKIT_CLIENT_TIMEOUTS"
+)
+
+// MethodTimeouts are the default timeouts of the methods slower than the others, like those calling the cloud
+// providers.  The other methods time out after the client timeout.
+var MethodTimeouts = map[string]time.Duration{
+	"Instance.Provision": 5 * time.Minute,
+	"Instance.Destroy":   5 * time.Minute,
+	"Instance.Label":     1 * time.Minute,
+}
+
+// timeout returns the client rpc http timeout
+func timeout() time.Duration {
+	if parsed, err := time.ParseDuration(os.Getenv(ClientTimeoutEnv)); err == nil {
+		return parsed
+	}
+	return time.Duration(1 * time.Second)
+}
+
+// methodTimeout returns the timeout of the calls of the method
+func methodTimeout(method string) time.Duration {
+	for _, pair := range strings.Split(os.Getenv(ClientTimeoutsEnv), ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if matched, _ := path.Match(strings.TrimSpace(kv[0]), method); !matched {
+			continue
+		}
+		parsed, err := time.ParseDuration(strings.TrimSpace(kv[1]))
+		if err != nil {
+			log.Warn("Bad timeout", "env", ClientTimeoutsEnv, "method", kv[0], "err", err)
+			continue
+		}
+		return parsed
+	}
+	if t, has := MethodTimeouts[method]; has {
+		return t
+	}
+	return timeout()
+}
@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// HeaderDeadline is the header of the deadline of a call, in RFC3339 with nanoseconds.  The clients set it from
+// the context of the call so the servers, and the plugins behind them, stop the work once the caller gives up.
+const HeaderDeadline = "X-Infrakit-Deadline"
+
+// SetDeadline sets the header of the deadline of the context, if the context has a deadline.
+func SetDeadline(ctx context.Context, header http.Header) {
+	if deadline, has := ctx.Deadline(); has {
+		header.Set(HeaderDeadline, deadline.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+// DeadlineHandler returns the handler that serves the requests with the deadline of their header set on their
+// context.  The context of a request is also canceled once its caller hangs up.
+func DeadlineHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		value := req.Header.Get(HeaderDeadline)
+		if value == "" {
+			next.ServeHTTP(resp, req)
+			return
+		}
+		deadline, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			http.Error(resp, "bad deadline: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithDeadline(req.Context(), deadline)
+		defer cancel()
+		next.ServeHTTP(resp, req.WithContext(ctx))
+	})
+}
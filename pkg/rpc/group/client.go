@@ -36,6 +36,13 @@ func (c client) CommitGroup(grp group.Spec, pretend bool) (string, error) {
 	return resp.Details, nil
 }
 
+func (c client) PlanCommit(grp group.Spec) (group.Plan, error) {
+	req := PlanCommitRequest{Spec: grp}
+	resp := PlanCommitResponse{}
+	err := c.client.Call("Group.PlanCommit", req, &resp)
+	return resp.Plan, err
+}
+
 func (c client) FreeGroup(id group.ID) error {
 	req := FreeGroupRequest{ID: id}
 	resp := FreeGroupResponse{}
@@ -100,3 +107,27 @@ func (c client) SetSize(id group.ID, size int) error {
 	}
 	return c.client.Call("Group.SetSize", req, &resp)
 }
+
+func (c client) PromoteCanary(id group.ID) error {
+	req := PromoteCanaryRequest{ID: id}
+	resp := PromoteCanaryResponse{}
+	return c.client.Call("Group.PromoteCanary", req, &resp)
+}
+
+func (c client) AbortCanary(id group.ID) error {
+	req := AbortCanaryRequest{ID: id}
+	resp := AbortCanaryResponse{}
+	return c.client.Call("Group.AbortCanary", req, &resp)
+}
+
+func (c client) PauseUpdate(id group.ID) error {
+	req := PauseUpdateRequest{ID: id}
+	resp := PauseUpdateResponse{}
+	return c.client.Call("Group.PauseUpdate", req, &resp)
+}
+
+func (c client) ResumeUpdate(id group.ID) error {
+	req := ResumeUpdateRequest{ID: id}
+	resp := ResumeUpdateResponse{}
+	return c.client.Call("Group.ResumeUpdate", req, &resp)
+}
@@ -371,3 +371,112 @@ func TestGroupNamedPluginSizeSetSize(t *testing.T) {
 	require.Equal(t, 1001, <-sizeActual)
 	require.Equal(t, gid, <-gidActual)
 }
+
+func TestGroupPluginPromoteAbortCanary(t *testing.T) {
+	socketPath := tempSocket()
+
+	id := group.ID("group")
+	promoted := make(chan group.ID, 1)
+	aborted := make(chan group.ID, 1)
+	server, err := rpc_server.StartPluginAtPath(socketPath, PluginServer(&testing_group.Plugin{
+		DoPromoteCanary: func(req group.ID) error {
+			promoted <- req
+			return nil
+		},
+		DoAbortCanary: func(req group.ID) error {
+			aborted <- req
+			return errors.New("no")
+		},
+	}))
+	require.NoError(t, err)
+
+	client := must(NewClient(socketPath))
+
+	require.NoError(t, client.PromoteCanary(id))
+
+	err = client.AbortCanary(id)
+	require.Error(t, err)
+	require.Equal(t, "no", err.Error())
+
+	server.Stop()
+	require.Equal(t, id, <-promoted)
+	require.Equal(t, id, <-aborted)
+}
+
+func TestGroupPluginPauseResumeUpdate(t *testing.T) {
+	socketPath := tempSocket()
+
+	id := group.ID("group")
+	paused := make(chan group.ID, 1)
+	resumed := make(chan group.ID, 1)
+	server, err := rpc_server.StartPluginAtPath(socketPath, PluginServer(&testing_group.Plugin{
+		DoPauseUpdate: func(req group.ID) error {
+			paused <- req
+			return nil
+		},
+		DoResumeUpdate: func(req group.ID) error {
+			resumed <- req
+			return errors.New("no")
+		},
+	}))
+	require.NoError(t, err)
+
+	client := must(NewClient(socketPath))
+
+	require.NoError(t, client.PauseUpdate(id))
+
+	err = client.ResumeUpdate(id)
+	require.Error(t, err)
+	require.Equal(t, "no", err.Error())
+
+	server.Stop()
+	require.Equal(t, id, <-paused)
+	require.Equal(t, id, <-resumed)
+}
+
+func TestGroupPluginPlanCommit(t *testing.T) {
+	socketPath := tempSocket()
+
+	id := group.ID("group")
+	plan := group.Plan{
+		Explain: "Performing a rolling update on 2 instances",
+		Changes: []types.Change{
+			{
+				Path: "Instance/Properties/Image",
+				Old:  types.AnyValueMust("ubuntu:16.04"),
+				New:  types.AnyValueMust("ubuntu:18.04"),
+			},
+		},
+		Instances: instance.Plan{
+			Destroy: []instance.ID{"a", "b"},
+			Create:  2,
+		},
+	}
+	planned := make(chan group.Spec, 1)
+	server, err := rpc_server.StartPluginAtPath(socketPath, PluginServer(&testing_group.Plugin{
+		DoPlanCommit: func(req group.Spec) (group.Plan, error) {
+			planned <- req
+			if req.ID != id {
+				return group.Plan{}, errors.New("no")
+			}
+			return plan, nil
+		},
+	}))
+	require.NoError(t, err)
+
+	client := must(NewClient(socketPath))
+
+	actual, err := client.PlanCommit(group.Spec{ID: id})
+	require.NoError(t, err)
+	require.Equal(t, plan.Explain, actual.Explain)
+	require.Equal(t, plan.Instances, actual.Instances)
+	require.Equal(t, 1, len(actual.Changes))
+	require.Equal(t, plan.Changes[0].Path, actual.Changes[0].Path)
+	require.Equal(t, `"ubuntu:18.04"`, actual.Changes[0].New.String())
+
+	_, err = client.PlanCommit(group.Spec{ID: "other"})
+	require.Error(t, err)
+
+	server.Stop()
+	require.Equal(t, id, (<-planned).ID)
+}
@@ -85,6 +85,19 @@ func (p *Group) CommitGroup(_ *http.Request, req *CommitGroupRequest, resp *Comm
 	})
 }
 
+// PlanCommit is the rpc method to plan the commit of a group
+func (p *Group) PlanCommit(_ *http.Request, req *PlanCommitRequest, resp *PlanCommitResponse) error {
+	return p.keyed.Do(req, func(v interface{}) error {
+		plan, err := v.(group.Plugin).PlanCommit(req.Spec)
+		if err != nil {
+			return err
+		}
+		resp.Plan = plan
+		resp.ID = req.Spec.ID
+		return nil
+	})
+}
+
 // FreeGroup is the rpc method to free a group
 func (p *Group) FreeGroup(_ *http.Request, req *FreeGroupRequest, resp *FreeGroupResponse) error {
 	return p.keyed.Do(req, func(v interface{}) error {
@@ -176,3 +189,55 @@ func (p *Group) SetSize(_ *http.Request, req *SetSizeRequest, resp *SetSizeRespo
 		return nil
 	})
 }
+
+// PromoteCanary is the rpc method to promote the canary of an update in progress
+func (p *Group) PromoteCanary(_ *http.Request, req *PromoteCanaryRequest, resp *PromoteCanaryResponse) error {
+	return p.keyed.Do(req, func(v interface{}) error {
+
+		err := v.(group.Plugin).PromoteCanary(req.ID)
+		if err != nil {
+			return err
+		}
+		resp.ID = req.ID
+		return nil
+	})
+}
+
+// AbortCanary is the rpc method to abort the canary of an update in progress
+func (p *Group) AbortCanary(_ *http.Request, req *AbortCanaryRequest, resp *AbortCanaryResponse) error {
+	return p.keyed.Do(req, func(v interface{}) error {
+
+		err := v.(group.Plugin).AbortCanary(req.ID)
+		if err != nil {
+			return err
+		}
+		resp.ID = req.ID
+		return nil
+	})
+}
+
+// PauseUpdate is the rpc method to pause an update in progress
+func (p *Group) PauseUpdate(_ *http.Request, req *PauseUpdateRequest, resp *PauseUpdateResponse) error {
+	return p.keyed.Do(req, func(v interface{}) error {
+
+		err := v.(group.Plugin).PauseUpdate(req.ID)
+		if err != nil {
+			return err
+		}
+		resp.ID = req.ID
+		return nil
+	})
+}
+
+// ResumeUpdate is the rpc method to resume a paused update
+func (p *Group) ResumeUpdate(_ *http.Request, req *ResumeUpdateRequest, resp *ResumeUpdateResponse) error {
+	return p.keyed.Do(req, func(v interface{}) error {
+
+		err := v.(group.Plugin).ResumeUpdate(req.ID)
+		if err != nil {
+			return err
+		}
+		resp.ID = req.ID
+		return nil
+	})
+}
@@ -26,6 +26,22 @@ type CommitGroupResponse struct {
 	Details string
 }
 
+// PlanCommitRequest is the rpc wrapper for input to plan the commit of a group
+type PlanCommitRequest struct {
+	Spec group.Spec
+}
+
+// Plugin implements pkg/rpc/internal/Addressable
+func (r PlanCommitRequest) Plugin() (plugin.Name, error) {
+	return plugin.Name(fmt.Sprintf("./%v", r.Spec.ID)), nil
+}
+
+// PlanCommitResponse is the rpc wrapper for the plan of committing a group
+type PlanCommitResponse struct {
+	ID   group.ID
+	Plan group.Plan
+}
+
 // FreeGroupRequest is the rpc wrapper for input to free a group
 type FreeGroupRequest struct {
 	ID group.ID
@@ -138,3 +154,63 @@ func (r SetSizeRequest) Plugin() (plugin.Name, error) {
 type SetSizeResponse struct {
 	ID group.ID
 }
+
+// PromoteCanaryRequest is the rpc wrapper for the input to promote a canary
+type PromoteCanaryRequest struct {
+	ID group.ID
+}
+
+// Plugin implements pkg/rpc/internal/Addressable
+func (r PromoteCanaryRequest) Plugin() (plugin.Name, error) {
+	return plugin.Name(fmt.Sprintf("./%v", r.ID)), nil
+}
+
+// PromoteCanaryResponse is the rpc wrapper for the output of promoting a canary
+type PromoteCanaryResponse struct {
+	ID group.ID
+}
+
+// AbortCanaryRequest is the rpc wrapper for the input to abort a canary
+type AbortCanaryRequest struct {
+	ID group.ID
+}
+
+// Plugin implements pkg/rpc/internal/Addressable
+func (r AbortCanaryRequest) Plugin() (plugin.Name, error) {
+	return plugin.Name(fmt.Sprintf("./%v", r.ID)), nil
+}
+
+// AbortCanaryResponse is the rpc wrapper for the output of aborting a canary
+type AbortCanaryResponse struct {
+	ID group.ID
+}
+
+// PauseUpdateRequest is the rpc wrapper for the input to pause an update
+type PauseUpdateRequest struct {
+	ID group.ID
+}
+
+// Plugin implements pkg/rpc/internal/Addressable
+func (r PauseUpdateRequest) Plugin() (plugin.Name, error) {
+	return plugin.Name(fmt.Sprintf("./%v", r.ID)), nil
+}
+
+// PauseUpdateResponse is the rpc wrapper for the output of pausing an update
+type PauseUpdateResponse struct {
+	ID group.ID
+}
+
+// ResumeUpdateRequest is the rpc wrapper for the input to resume an update
+type ResumeUpdateRequest struct {
+	ID group.ID
+}
+
+// Plugin implements pkg/rpc/internal/Addressable
+func (r ResumeUpdateRequest) Plugin() (plugin.Name, error) {
+	return plugin.Name(fmt.Sprintf("./%v", r.ID)), nil
+}
+
+// ResumeUpdateResponse is the rpc wrapper for the output of resuming an update
+type ResumeUpdateResponse struct {
+	ID group.ID
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/rpc/auth"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// HeaderIdempotencyKey is the header of the key of a call the client may retry.  The servers serve the calls of
+// the same key once, and replay the reply to the others.
+const HeaderIdempotencyKey = "X-Infrakit-Idempotency-Key"
+
+// reply is the reply to the calls of a key, available once done is closed
+type reply struct {
+	digest  [sha256.Size]byte
+	done    chan struct{}
+	code    int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+type idempotentHandler struct {
+	next    http.Handler
+	ttl     time.Duration
+	replies map[string]*reply
+	lock    sync.Mutex
+}
+
+// IdempotentHandler returns the handler that serves the calls of the same idempotency key once, and replays the
+// reply to the calls of the key received within the ttl.  A call received while the first call of its key is
+// served waits for the reply.  The keys are scoped by the identity of the caller and the method called, and a key
+// reused with other params is refused.
+func IdempotentHandler(next http.Handler, ttl time.Duration) http.Handler {
+	return &idempotentHandler{next: next, ttl: ttl, replies: map[string]*reply{}}
+}
+
+// ServeHTTP implements http.Handler
+func (h *idempotentHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if req.Header.Get(HeaderIdempotencyKey) == "" {
+		h.next.ServeHTTP(resp, req)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	call := struct {
+		Method string           `json:"method"`
+		Params json.RawMessage  `json:"params"`
+		ID     *json.RawMessage `json:"id"`
+	}{}
+	if err := json.Unmarshal(body, &call); err != nil {
+		replyError(resp, http.StatusBadRequest, nil, &json2.Error{Code: json2.E_PARSE, Message: err.Error()})
+		return
+	}
+
+	key := fmt.Sprintf("%s\x00%s\x00%s", auth.IdentityOf(req), call.Method, req.Header.Get(HeaderIdempotencyKey))
+	digest := sha256.Sum256(call.Params)
+
+	h.lock.Lock()
+	now := time.Now()
+	for k, r := range h.replies {
+		if !r.expires.IsZero() && now.After(r.expires) {
+			delete(h.replies, k)
+		}
+	}
+	r, replay := h.replies[key]
+	if !replay {
+		r = &reply{digest: digest, done: make(chan struct{})}
+		h.replies[key] = r
+	}
+	h.lock.Unlock()
+
+	if replay && r.digest != digest {
+		replyError(resp, http.StatusConflict, call.ID, &json2.Error{
+			Code:    json2.E_BAD_PARAMS,
+			Message: fmt.Sprintf("idempotency key %v reused with other params", req.Header.Get(HeaderIdempotencyKey)),
+		})
+		return
+	}
+
+	if !replay {
+		h.serve(key, r, req)
+	} else {
+		select {
+		case <-r.done:
+		case <-req.Context().Done():
+			return
+		}
+	}
+
+	for k, v := range r.header {
+		resp.Header()[k] = v
+	}
+	resp.WriteHeader(r.code)
+	resp.Write(r.body)
+}
+
+// serve serves the first call of the key and records the reply.  The reply is done even if the handler panics: the
+// calls waiting then fail, and the key is dropped so that the call can be retried.
+func (h *idempotentHandler) serve(key string, r *reply, req *http.Request) {
+	recorder := NewRecorder()
+	served := false
+	defer func() {
+		h.lock.Lock()
+		r.code, r.header, r.body = recorder.Code, recorder.HeaderMap, recorder.Body.Bytes()
+		if !served {
+			r.code, r.header, r.body = http.StatusInternalServerError, http.Header{}, nil
+			if h.replies[key] == r {
+				delete(h.replies, key)
+			}
+		}
+		r.expires = time.Now().Add(h.ttl)
+		h.lock.Unlock()
+		close(r.done)
+	}()
+
+	h.next.ServeHTTP(recorder, req)
+	served = true
+}
+
+// replyError replies to the rpc call with the error, so the clients return the error of the call
+func replyError(resp http.ResponseWriter, status int, id *json.RawMessage, err *json2.Error) {
+	buff, _ := json.Marshal(struct {
+		Version string           `json:"jsonrpc"`
+		Error   *json2.Error     `json:"error"`
+		ID      *json.RawMessage `json:"id"`
+	}{
+		Version: "2.0",
+		Error:   err,
+		ID:      id,
+	})
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	resp.Write(buff)
+}
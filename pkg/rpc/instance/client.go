@@ -1,6 +1,8 @@
 package instance
 
 import (
+	"context"
+
 	"github.com/docker/infrakit/pkg/plugin"
 	rpc_client "github.com/docker/infrakit/pkg/rpc/client"
 	"github.com/docker/infrakit/pkg/spi/instance"
@@ -37,11 +39,16 @@ func (c client) Validate(properties *types.Any) error {
 
 // Provision creates a new instance based on the spec.
 func (c client) Provision(spec instance.Spec) (*instance.ID, error) {
+	return c.ProvisionContext(context.Background(), spec)
+}
+
+// ProvisionContext creates a new instance based on the spec, within the context.
+func (c client) ProvisionContext(ctx context.Context, spec instance.Spec) (*instance.ID, error) {
 	_, instanceType := c.name.GetLookupAndType()
 	req := ProvisionRequest{Spec: spec, Type: instanceType}
 	resp := ProvisionResponse{}
 
-	if err := c.client.Call("Instance.Provision", req, &resp); err != nil {
+	if err := c.client.CallContext(ctx, "Instance.Provision", req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -50,29 +57,45 @@ func (c client) Provision(spec instance.Spec) (*instance.ID, error) {
 
 // Label labels the instance
 func (c client) Label(instance instance.ID, labels map[string]string) error {
+	return c.LabelContext(context.Background(), instance, labels)
+}
+
+// LabelContext labels the instance, within the context.
+func (c client) LabelContext(ctx context.Context, instance instance.ID, labels map[string]string) error {
 	_, instanceType := c.name.GetLookupAndType()
 	req := LabelRequest{Type: instanceType, Instance: instance, Labels: labels}
 	resp := LabelResponse{}
 
-	return c.client.Call("Instance.Label", req, &resp)
+	return c.client.CallContext(ctx, "Instance.Label", req, &resp)
 }
 
 // Destroy terminates an existing instance.
-func (c client) Destroy(instance instance.ID, context instance.Context) error {
+func (c client) Destroy(instance instance.ID, reason instance.Context) error {
+	return c.DestroyContext(context.Background(), instance, reason)
+}
+
+// DestroyContext terminates an existing instance, within the context.
+func (c client) DestroyContext(ctx context.Context, instance instance.ID, reason instance.Context) error {
 	_, instanceType := c.name.GetLookupAndType()
-	req := DestroyRequest{Instance: instance, Type: instanceType, Context: context}
+	req := DestroyRequest{Instance: instance, Type: instanceType, Context: reason}
 	resp := DestroyResponse{}
 
-	return c.client.Call("Instance.Destroy", req, &resp)
+	return c.client.CallContext(ctx, "Instance.Destroy", req, &resp)
 }
 
 // DescribeInstances returns descriptions of all instances matching all of the provided tags.
 func (c client) DescribeInstances(tags map[string]string, properties bool) ([]instance.Description, error) {
+	return c.DescribeInstancesContext(context.Background(), tags, properties)
+}
+
+// DescribeInstancesContext returns descriptions of the instances matching all the tags, within the context.
+func (c client) DescribeInstancesContext(ctx context.Context, tags map[string]string,
+	properties bool) ([]instance.Description, error) {
 	_, instanceType := c.name.GetLookupAndType()
 	req := DescribeInstancesRequest{Tags: tags, Type: instanceType, Properties: properties}
 	resp := DescribeInstancesResponse{}
 
-	err := c.client.Call("Instance.DescribeInstances", req, &resp)
+	err := c.client.CallContext(ctx, "Instance.DescribeInstances", req, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package instance
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -82,6 +83,36 @@ func (p *Instance) Types() []string {
 	return types
 }
 
+// withContext returns the plugin serving the calls within their contexts, ignoring the contexts if the plugin
+// cannot stop its work.
+func withContext(p instance.Plugin) instance.ContextPlugin {
+	if c, is := p.(instance.ContextPlugin); is {
+		return c
+	}
+	return contextIgnored{Plugin: p}
+}
+
+type contextIgnored struct {
+	instance.Plugin
+}
+
+func (c contextIgnored) ProvisionContext(_ context.Context, spec instance.Spec) (*instance.ID, error) {
+	return c.Provision(spec)
+}
+
+func (c contextIgnored) LabelContext(_ context.Context, id instance.ID, labels map[string]string) error {
+	return c.Label(id, labels)
+}
+
+func (c contextIgnored) DestroyContext(_ context.Context, id instance.ID, reason instance.Context) error {
+	return c.Destroy(id, reason)
+}
+
+func (c contextIgnored) DescribeInstancesContext(_ context.Context, labels map[string]string,
+	properties bool) ([]instance.Description, error) {
+	return c.DescribeInstances(labels, properties)
+}
+
 func (p *Instance) getPlugin(instanceType string) instance.Plugin {
 	if instanceType == "" {
 		return p.plugin
@@ -108,13 +139,13 @@ func (p *Instance) Validate(_ *http.Request, req *ValidateRequest, resp *Validat
 }
 
 // Provision creates a new instance based on the spec.
-func (p *Instance) Provision(_ *http.Request, req *ProvisionRequest, resp *ProvisionResponse) error {
+func (p *Instance) Provision(r *http.Request, req *ProvisionRequest, resp *ProvisionResponse) error {
 	resp.Type = req.Type
 	c := p.getPlugin(req.Type)
 	if c == nil {
 		return fmt.Errorf("no-plugin:%s", req.Type)
 	}
-	id, err := c.Provision(req.Spec)
+	id, err := withContext(c).ProvisionContext(r.Context(), req.Spec)
 	if err != nil {
 		return err
 	}
@@ -123,13 +154,13 @@ func (p *Instance) Provision(_ *http.Request, req *ProvisionRequest, resp *Provi
 }
 
 // Label labels the instance
-func (p *Instance) Label(_ *http.Request, req *LabelRequest, resp *LabelResponse) error {
+func (p *Instance) Label(r *http.Request, req *LabelRequest, resp *LabelResponse) error {
 	resp.Type = req.Type
 	c := p.getPlugin(req.Type)
 	if c == nil {
 		return fmt.Errorf("no-plugin:%s", req.Type)
 	}
-	err := c.Label(req.Instance, req.Labels)
+	err := withContext(c).LabelContext(r.Context(), req.Instance, req.Labels)
 	if err != nil {
 		return err
 	}
@@ -138,13 +169,13 @@ func (p *Instance) Label(_ *http.Request, req *LabelRequest, resp *LabelResponse
 }
 
 // Destroy terminates an existing instance.
-func (p *Instance) Destroy(_ *http.Request, req *DestroyRequest, resp *DestroyResponse) error {
+func (p *Instance) Destroy(r *http.Request, req *DestroyRequest, resp *DestroyResponse) error {
 	resp.Type = req.Type
 	c := p.getPlugin(req.Type)
 	if c == nil {
 		return fmt.Errorf("no-plugin:%s", req.Type)
 	}
-	err := c.Destroy(req.Instance, req.Context)
+	err := withContext(c).DestroyContext(r.Context(), req.Instance, req.Context)
 	if err != nil {
 		return err
 	}
@@ -153,13 +184,13 @@ func (p *Instance) Destroy(_ *http.Request, req *DestroyRequest, resp *DestroyRe
 }
 
 // DescribeInstances returns descriptions of all instances matching all of the provided tags.
-func (p *Instance) DescribeInstances(_ *http.Request, req *DescribeInstancesRequest, resp *DescribeInstancesResponse) error {
+func (p *Instance) DescribeInstances(r *http.Request, req *DescribeInstancesRequest, resp *DescribeInstancesResponse) error {
 	resp.Type = req.Type
 	c := p.getPlugin(req.Type)
 	if c == nil {
 		return fmt.Errorf("no-plugin:%s", req.Type)
 	}
-	desc, err := c.DescribeInstances(req.Tags, req.Properties)
+	desc, err := withContext(c).DescribeInstancesContext(r.Context(), req.Tags, req.Properties)
 	if err != nil {
 		return err
 	}
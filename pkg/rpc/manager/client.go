@@ -5,6 +5,8 @@ import (
 
 	"github.com/docker/infrakit/pkg/manager"
 	rpc_client "github.com/docker/infrakit/pkg/rpc/client"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -69,3 +71,43 @@ func (c client) Terminate(specs []types.Spec) error {
 	err := c.client.Call("Manager.Terminate", req, &resp)
 	return err
 }
+
+// CommitGroups commits the group specs as the desired state of all groups
+func (c client) CommitGroups(specs []group.Spec, options manager.CommitOptions) (manager.GroupChanges, error) {
+	req := CommitGroupsRequest{
+		Specs:   specs,
+		Options: options,
+	}
+	resp := CommitGroupsResponse{}
+	err := c.client.Call("Manager.CommitGroups", req, &resp)
+	return resp.Changes, err
+}
+
+// Revisions returns the revisions of the specs committed
+func (c client) Revisions() ([]store.Revision, error) {
+	req := RevisionsRequest{}
+	resp := RevisionsResponse{}
+	err := c.client.Call("Manager.Revisions", req, &resp)
+	return resp.Revisions, err
+}
+
+// InspectRevision returns the specs committed in the given revision
+func (c client) InspectRevision(number int) ([]types.Spec, error) {
+	req := InspectRevisionRequest{
+		Number: number,
+	}
+	resp := InspectRevisionResponse{}
+	err := c.client.Call("Manager.InspectRevision", req, &resp)
+	return resp.Specs, err
+}
+
+// Rollback commits the specs of a previous revision as a new revision
+func (c client) Rollback(number int, options manager.CommitOptions) (manager.GroupChanges, error) {
+	req := RollbackRequest{
+		Number:  number,
+		Options: options,
+	}
+	resp := RollbackResponse{}
+	err := c.client.Call("Manager.Rollback", req, &resp)
+	return resp.Changes, err
+}
@@ -5,9 +5,12 @@ import (
 	"io/ioutil"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/docker/infrakit/pkg/manager"
 	"github.com/docker/infrakit/pkg/rpc/server"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
 	testing_manager "github.com/docker/infrakit/pkg/testing/manager"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/stretchr/testify/require"
@@ -227,3 +230,106 @@ func TestManagerInspect(t *testing.T) {
 	server.Stop()
 
 }
+
+func TestManagerCommitGroups(t *testing.T) {
+	socketPath := tempSocket()
+
+	expect := []group.Spec{
+		{
+			ID:         "workers",
+			Properties: types.AnyValueMust(map[string]interface{}{"a": 1, "b": 2}),
+		},
+	}
+	changes := manager.GroupChanges{
+		Change: []group.ID{"workers"},
+		Remove: []group.ID{"managers"},
+		Prune:  manager.PruneDestroy,
+	}
+
+	m := &testing_manager.Plugin{
+		DoCommitGroups: func(specs []group.Spec, options manager.CommitOptions) (manager.GroupChanges, error) {
+			require.EqualValues(t, types.AnyValueMust(expect), types.AnyValueMust(specs))
+			require.Equal(t, manager.CommitOptions{
+				Prune:   manager.PruneDestroy,
+				Pretend: true,
+				Author:  "alice",
+			}, options)
+			return changes, nil
+		},
+	}
+	server, err := server.StartPluginAtPath(socketPath, PluginServer(m))
+	require.NoError(t, err)
+
+	actual, err := must(NewClient(socketPath)).CommitGroups(expect,
+		manager.CommitOptions{Prune: manager.PruneDestroy, Pretend: true, Author: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, changes, actual)
+
+	expectErr := errors.New("boom")
+
+	// test for error
+	m.DoCommitGroups = func(specs []group.Spec, options manager.CommitOptions) (manager.GroupChanges, error) {
+		return manager.GroupChanges{}, expectErr
+	}
+	_, err = must(NewClient(socketPath)).CommitGroups(expect, manager.CommitOptions{Prune: manager.PruneFree})
+	require.Error(t, err)
+	require.Equal(t, expectErr.Error(), err.Error())
+
+	server.Stop()
+}
+
+func TestManagerRevisions(t *testing.T) {
+	socketPath := tempSocket()
+
+	now := time.Unix(1500000000, 0).UTC()
+	revisions := []store.Revision{
+		{Number: 1, Time: now, Message: "Commit groups workers"},
+		{Number: 2, Time: now, Author: "alice", Message: "Add managers"},
+	}
+	specs := []types.Spec{
+		{
+			Kind:       "group",
+			Metadata:   types.Metadata{Name: "workers"},
+			Properties: types.AnyValueMust(map[string]interface{}{"a": 1}),
+		},
+	}
+	changes := manager.GroupChanges{Remove: []group.ID{"managers"}, Prune: manager.PruneFree}
+
+	m := &testing_manager.Plugin{
+		DoRevisions: func() ([]store.Revision, error) {
+			return revisions, nil
+		},
+		DoInspectRevision: func(number int) ([]types.Spec, error) {
+			if number != 1 {
+				return nil, errors.New("no revision")
+			}
+			return specs, nil
+		},
+		DoRollback: func(number int, options manager.CommitOptions) (manager.GroupChanges, error) {
+			require.Equal(t, 1, number)
+			require.Equal(t, manager.CommitOptions{Prune: manager.PruneFree, Message: "undo"}, options)
+			return changes, nil
+		},
+	}
+	server, err := server.StartPluginAtPath(socketPath, PluginServer(m))
+	require.NoError(t, err)
+
+	client := must(NewClient(socketPath))
+
+	actualRevisions, err := client.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, revisions, actualRevisions)
+
+	actualSpecs, err := client.InspectRevision(1)
+	require.NoError(t, err)
+	require.EqualValues(t, types.AnyValueMust(specs), types.AnyValueMust(actualSpecs))
+
+	_, err = client.InspectRevision(3)
+	require.Error(t, err)
+
+	actualChanges, err := client.Rollback(1, manager.CommitOptions{Prune: manager.PruneFree, Message: "undo"})
+	require.NoError(t, err)
+	require.Equal(t, changes, actualChanges)
+
+	server.Stop()
+}
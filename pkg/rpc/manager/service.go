@@ -6,6 +6,8 @@ import (
 
 	"github.com/docker/infrakit/pkg/manager"
 	"github.com/docker/infrakit/pkg/spi"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -111,3 +113,78 @@ type TerminateResponse struct {
 func (p *Manager) Terminate(_ *http.Request, req *TerminateRequest, resp *TerminateResponse) error {
 	return p.manager.Terminate(req.Specs)
 }
+
+// CommitGroupsRequest is the rpc request
+type CommitGroupsRequest struct {
+	Specs   []group.Spec
+	Options manager.CommitOptions
+}
+
+// CommitGroupsResponse is the rpc response
+type CommitGroupsResponse struct {
+	Changes manager.GroupChanges
+}
+
+// CommitGroups is the rpc method for Manager.CommitGroups
+func (p *Manager) CommitGroups(_ *http.Request, req *CommitGroupsRequest, resp *CommitGroupsResponse) error {
+	changes, err := p.manager.CommitGroups(req.Specs, req.Options)
+	resp.Changes = changes
+	return err
+}
+
+// RevisionsRequest is the rpc request
+type RevisionsRequest struct {
+}
+
+// RevisionsResponse is the rpc response
+type RevisionsResponse struct {
+	Revisions []store.Revision
+}
+
+// Revisions is the rpc method for Manager.Revisions
+func (p *Manager) Revisions(_ *http.Request, req *RevisionsRequest, resp *RevisionsResponse) error {
+	revisions, err := p.manager.Revisions()
+	if err != nil {
+		return err
+	}
+	resp.Revisions = revisions
+	return nil
+}
+
+// InspectRevisionRequest is the rpc request
+type InspectRevisionRequest struct {
+	Number int
+}
+
+// InspectRevisionResponse is the rpc response
+type InspectRevisionResponse struct {
+	Specs []types.Spec
+}
+
+// InspectRevision is the rpc method for Manager.InspectRevision
+func (p *Manager) InspectRevision(_ *http.Request, req *InspectRevisionRequest, resp *InspectRevisionResponse) error {
+	specs, err := p.manager.InspectRevision(req.Number)
+	if err != nil {
+		return err
+	}
+	resp.Specs = specs
+	return nil
+}
+
+// RollbackRequest is the rpc request
+type RollbackRequest struct {
+	Number  int
+	Options manager.CommitOptions
+}
+
+// RollbackResponse is the rpc response
+type RollbackResponse struct {
+	Changes manager.GroupChanges
+}
+
+// Rollback is the rpc method for Manager.Rollback
+func (p *Manager) Rollback(_ *http.Request, req *RollbackRequest, resp *RollbackResponse) error {
+	changes, err := p.manager.Rollback(req.Number, req.Options)
+	resp.Changes = changes
+	return err
+}
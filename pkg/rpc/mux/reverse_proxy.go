@@ -2,6 +2,7 @@ package mux
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	logutil "github.com/docker/infrakit/pkg/log"
 	manager_discovery "github.com/docker/infrakit/pkg/manager/discovery"
 	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/rpc/event"
 	event_spi "github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/types"
@@ -29,6 +31,13 @@ type ReverseProxy struct {
 	// if this is nil, consider this is local.  If it's not nil, then redirect to this url instead
 	forward     *url.URL
 	forwardLock sync.Mutex
+
+	// tlsConfig is the TLS config of the connections to the leader and to the plugins over https
+	tlsConfig *tls.Config
+
+	// identityKey signs the identities of the callers passed to the plugins on this host.  Nil if the callers are
+	// not authenticated.
+	identityKey auth.IdentityKey
 }
 
 // NewReverseProxy creates a mux reverse proxy
@@ -83,7 +92,8 @@ func (rp *ReverseProxy) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 // We need to rewrite the request to change the host. This is so that
 // some CDNs that checks for the Host header won't barf.
 // We modify this only after the default Director has done its thing.
-func defaultDirector(u *url.URL) func(*http.Request) {
+// The identity authenticated by the guard is passed on, signed by the key if not nil.
+func defaultDirector(u *url.URL, key auth.IdentityKey) func(*http.Request) {
 	targetQuery := u.RawQuery
 	return func(req *http.Request) {
 		req.URL.Scheme = u.Scheme
@@ -99,13 +109,29 @@ func defaultDirector(u *url.URL) func(*http.Request) {
 		}
 		req.Header.Set("Host", u.Host)
 		req.Host = u.Host
+
+		// Pass on the identity authenticated by the guard, never the one of the caller.  The plugins on this host
+		// trust it signed by the identity key, and the leader only from the muxes it trusts as proxies.
+		identity := auth.IdentityOf(req)
+		if key != nil {
+			key.Sign(req.Header, identity)
+			return
+		}
+		req.Header.Del(auth.HeaderIdentity)
+		req.Header.Del(auth.HeaderIdentitySignature)
+		if identity != "" {
+			req.Header.Set(auth.HeaderIdentity, identity)
+		}
 	}
 }
 
 func (rp *ReverseProxy) forwardHTTP(resp http.ResponseWriter, req *http.Request) {
 	log.Debug("forwarding traffic", "url", rp.forward, "V", logutil.V(100), "req", req)
 	reversep := httputil.NewSingleHostReverseProxy(rp.forward)
-	reversep.Director = defaultDirector(rp.forward)
+	reversep.Director = defaultDirector(rp.forward, nil)
+	if rp.tlsConfig != nil {
+		reversep.Transport = &http.Transport{TLSClientConfig: rp.tlsConfig}
+	}
 	handler := &loggingHandler{handler: reversep}
 	handler.ServeHTTP(resp, req)
 	return
@@ -271,7 +297,7 @@ func (rp *ReverseProxy) reverseProxyHandler(u *url.URL) (proxy http.Handler, pre
 			u.Host = uu.Host
 
 		case "http", "https":
-			reversep.Transport = &http.Transport{}
+			reversep.Transport = &http.Transport{TLSClientConfig: rp.tlsConfig}
 			u.Scheme = uu.Scheme
 			u.Host = uu.Host
 
@@ -279,7 +305,7 @@ func (rp *ReverseProxy) reverseProxyHandler(u *url.URL) (proxy http.Handler, pre
 		}
 
 	}
-	reversep.Director = defaultDirector(u)
+	reversep.Director = defaultDirector(u, rp.identityKey)
 	proxy = reversep
 	return
 }
@@ -1,6 +1,7 @@
 package mux
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -17,6 +18,7 @@ import (
 	"github.com/docker/infrakit/pkg/leader"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	rpc_server "github.com/docker/infrakit/pkg/rpc/server"
 	"gopkg.in/tylerb/graceful.v1"
 )
@@ -25,6 +27,13 @@ import (
 type Options struct {
 	Leadership <-chan leader.Leadership
 	Registry   leader.Store
+
+	// TLS are the certificates of the mux.  The mux serves TLS, and verifies the clients if a CA is set, and
+	// presents the certificate when forwarding to the leader.  Nil to serve plain HTTP.
+	TLS *rpc.TLSOptions
+
+	// Auth configures the authentication and the authorization of the callers.  Nil to accept all the callers.
+	Auth *auth.Options
 }
 
 // SavePID makes sure the directory exists and writes the pid to a file
@@ -54,12 +63,37 @@ func SavePID(listen string) (string, error) {
 func NewServer(listen string, advertiseHostPort string,
 	plugins func() discovery.Plugins, options Options) (rpc_server.Stoppable, error) {
 
-	advertise := &url.URL{Host: advertiseHostPort, Scheme: "http"}
+	serverTLS, err := options.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientTLS, err := options.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	advertise := &url.URL{Host: advertiseHostPort, Scheme: options.TLS.Scheme()}
+
+	guard, err := options.Auth.Guard()
+	if err != nil {
+		return nil, err
+	}
 
 	proxy := NewReverseProxy(plugins)
+	proxy.tlsConfig = clientTLS
+	var handler http.Handler = proxy
+	if guard != nil {
+		// Sign the identities passed to the plugins on this host, so they trust none set by other processes
+		identityKey, err := auth.DefaultIdentityKey()
+		if err != nil {
+			return nil, err
+		}
+		proxy.identityKey = identityKey
+		handler = guard.Handler(proxy)
+	}
 	server := &graceful.Server{
 		Timeout: 10 * time.Second,
-		Server:  &http.Server{Addr: listen, Handler: proxy},
+		Server:  &http.Server{Addr: listen, Handler: handler, TLSConfig: serverTLS},
 	}
 
 	var advertiseURL *url.URL
@@ -118,8 +152,11 @@ func NewServer(listen string, advertiseHostPort string,
 	if err != nil {
 		return nil, err
 	}
+	if serverTLS != nil {
+		listener = tls.NewListener(listener, serverTLS)
+	}
 
-	log.Info("Listening", "listen", listen)
+	log.Info("Listening", "listen", listen, "tls", serverTLS != nil, "guard", guard != nil)
 
 	go func() {
 		defer func() {
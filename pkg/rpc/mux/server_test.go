@@ -3,14 +3,18 @@ package mux
 import (
 	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/discovery/local"
+	"github.com/docker/infrakit/pkg/discovery/remote"
+	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/rpc"
 	"github.com/docker/infrakit/pkg/rpc/client"
 	rpc_metadata "github.com/docker/infrakit/pkg/rpc/metadata"
+	testing_tls "github.com/docker/infrakit/pkg/testing/tls"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/stretchr/testify/require"
 
@@ -68,3 +72,52 @@ func TestMuxServer(t *testing.T) {
 	require.Equal(t, "Metadata", m["Implements"].([]interface{})[0].(map[string]interface{})["Name"])
 	T(100).Infoln("body=", string(body))
 }
+
+func TestMuxServerMutualTLS(t *testing.T) {
+
+	pluginName := "metadata"
+	socketPath, server := startPlugin(t, pluginName)
+	defer server.Stop()
+
+	lookup, err := local.NewPluginDiscoveryWithDir(filepath.Dir(socketPath))
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certs := testing_tls.Certs(t, dir, "mux")
+
+	// The pid file may be left if an earlier run exited before the server removed it
+	os.Remove(filepath.Join(local.Dir(), "9092.pid"))
+
+	server, err = NewServer(":9092", "localhost:9092", func() discovery.Plugins {
+		return lookup
+	}, Options{
+		TLS: &rpc.TLSOptions{CAFile: certs.CAFile, CertFile: certs.CertFile, KeyFile: certs.KeyFile},
+	})
+	require.NoError(t, err)
+	defer server.Stop()
+
+	defer func() {
+		os.Unsetenv(rpc.EnvTLSCAFile)
+		os.Unsetenv(rpc.EnvTLSCertFile)
+		os.Unsetenv(rpc.EnvTLSKeyFile)
+	}()
+	os.Setenv(rpc.EnvTLSCAFile, certs.CAFile)
+
+	// Refused without the certificate of the client
+	_, err = rpc_metadata.NewClient("https://localhost:9092/" + pluginName + "/")
+	require.Error(t, err)
+
+	os.Setenv(rpc.EnvTLSCertFile, certs.CertFile)
+	os.Setenv(rpc.EnvTLSKeyFile, certs.KeyFile)
+
+	plugins, err := remote.NewPluginDiscovery(remote.ParseURLMust("https://localhost:9092"))
+	require.NoError(t, err)
+	endpoint, err := plugins.Find(plugin.Name(pluginName))
+	require.NoError(t, err)
+	require.Equal(t, "https://localhost:9092/"+pluginName+"/", endpoint.Address)
+
+	require.Equal(t, []string{"region"},
+		first(must(rpc_metadata.NewClient(endpoint.Address)).List(types.PathFromString("aws"))))
+}
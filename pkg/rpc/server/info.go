@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/docker/infrakit/pkg/plugin"
+	rpc_server "github.com/docker/infrakit/pkg/rpc"
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/template"
 )
@@ -130,5 +131,12 @@ func (m *PluginInfo) getInfo() *plugin.Info {
 
 	meta.Implements = myImplements
 	meta.Interfaces = myInterfaces
+
+	if circuits := rpc_server.Circuits(); len(circuits) > 0 {
+		meta.Circuits = map[string]string{}
+		for address, state := range circuits {
+			meta.Circuits[address] = string(state)
+		}
+	}
 	return meta
 }
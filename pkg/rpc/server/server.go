@@ -1,17 +1,22 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	broker "github.com/docker/infrakit/pkg/broker/server"
 	logutil "github.com/docker/infrakit/pkg/log"
 	rpc_server "github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/audit"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/types"
@@ -26,6 +31,9 @@ var (
 	debugV = logutil.V(1000)
 )
 
+// IdempotencyTTL is how long the reply to a call with an idempotency key is replayed to the retries of the call
+var IdempotencyTTL = 10 * time.Minute
+
 // Stoppable support proactive stopping, and blocking until stopped.
 type Stoppable interface {
 	Stop()
@@ -84,20 +92,52 @@ type VersionedInterface interface {
 	Types() []string
 }
 
+// ListenerOptions are the options of the servers listening on tcp
+type ListenerOptions struct {
+	// TLS are the certificates of the server.  Nil to serve plain HTTP.
+	TLS *rpc_server.TLSOptions
+
+	// Auth configures the authentication and the authorization of the callers.  Nil to accept all the callers.
+	Auth *auth.Options
+
+	// Audit configures the audit log of the mutating calls.  Nil to not audit the calls.
+	Audit *audit.Options
+}
+
+// DefaultListenerOptions returns the options set by the environment (see rpc.DefaultTLSOptions,
+// auth.DefaultOptions and audit.DefaultOptions).
+func DefaultListenerOptions() ListenerOptions {
+	return ListenerOptions{
+		TLS:   rpc_server.DefaultTLSOptions(),
+		Auth:  auth.DefaultOptions(),
+		Audit: audit.DefaultOptions(),
+	}
+}
+
 // StartListenerAtPath starts an HTTP server listening on tcp port with discovery entry at specified path.
+// The server serves TLS and guards the calls if set by the environment (see DefaultListenerOptions).
 // Returns a Stoppable that can be used to stop or block on the server.
 func StartListenerAtPath(listen []string, discoverPath string,
 	receiver VersionedInterface, more ...VersionedInterface) (Stoppable, error) {
-	return startAtPath(listen, discoverPath, receiver, more...)
+	return StartListenerAtPathWithOptions(listen, discoverPath, DefaultListenerOptions(), receiver, more...)
+}
+
+// StartListenerAtPathWithOptions starts an HTTP server listening on tcp port with discovery entry at specified
+// path, with the given options.
+// Returns a Stoppable that can be used to stop or block on the server.
+func StartListenerAtPathWithOptions(listen []string, discoverPath string, options ListenerOptions,
+	receiver VersionedInterface, more ...VersionedInterface) (Stoppable, error) {
+	return startAtPath(listen, discoverPath, options, receiver, more...)
 }
 
 // StartPluginAtPath starts an HTTP server listening on a unix socket at the specified path.
+// The server audits the calls if set by the environment (see audit.DefaultOptions).
 // Returns a Stoppable that can be used to stop or block on the server.
 func StartPluginAtPath(socketPath string, receiver VersionedInterface, more ...VersionedInterface) (Stoppable, error) {
-	return startAtPath(nil, socketPath, receiver, more...)
+	return startAtPath(nil, socketPath, ListenerOptions{Audit: audit.DefaultOptions()}, receiver, more...)
 }
 
-func startAtPath(listen []string, discoverPath string,
+func startAtPath(listen []string, discoverPath string, options ListenerOptions,
 	receiver VersionedInterface, more ...VersionedInterface) (Stoppable, error) {
 
 	server := rpc.NewServer()
@@ -159,6 +199,8 @@ func startAtPath(listen []string, discoverPath string,
 	router.HandleFunc(rpc_server.URLAPI, info.ShowAPI)
 	router.HandleFunc(rpc_server.URLFunctions, info.ShowTemplateFunctions)
 
+	auditEvents := options.Audit != nil && options.Audit.Events
+
 	intercept := broker.Interceptor{
 		Pre: func(topic string, headers map[string][]string) error {
 			for _, target := range targets {
@@ -168,6 +210,9 @@ func startAtPath(listen []string, discoverPath string,
 					}
 				}
 			}
+			if auditEvents && (topic == audit.Topic || strings.HasPrefix(topic, audit.Topic+"/")) {
+				return nil
+			}
 			return broker.ErrInvalidTopic(topic)
 		},
 		Do: events.ServeHTTP,
@@ -177,9 +222,44 @@ func startAtPath(listen []string, discoverPath string,
 	}
 	router.HandleFunc(rpc_server.URLEventsPrefix, intercept.ServeHTTP)
 
-	logger := loggingHandler{handler: server}
+	var rpcHandler http.Handler = server
+	auditLog := options.Audit.Log()
+	if auditLog != nil || auditEvents {
+		audited := &audit.Handler{
+			Next:   server,
+			Plugin: strings.TrimSuffix(filepath.Base(discoverPath), ".listen"),
+			Log:    auditLog,
+		}
+		if auditEvents {
+			audited.Publish = func(entry audit.Entry) {
+				events.Publish(audit.Topic, event.Event{
+					Topic:     types.PathFromString(audit.Topic),
+					Type:      event.Type("Audit"),
+					ID:        fmt.Sprintf("%s-%d", entry.Plugin, entry.Time.UnixNano()),
+					Timestamp: entry.Time,
+					Data:      types.AnyValueMust(entry),
+				}.Init(), 1*time.Second)
+			}
+		}
+		rpcHandler = audited
+	}
+
+	logger := loggingHandler{
+		handler: rpc_server.DeadlineHandler(rpc_server.IdempotentHandler(rpcHandler, IdempotencyTTL)),
+	}
 	router.Handle("/", logger)
 
+	guard, err := options.Auth.Guard()
+	if err != nil {
+		return nil, err
+	}
+	// The callers not authenticated by the guard are identified by the identity passed by the mux of this host, if
+	// signed by the identity key they share
+	var handler http.Handler = auth.VerifyIdentity(auth.IdentityKeyFile(), router)
+	if guard != nil {
+		handler = guard.Handler(router)
+	}
+
 	gracefulServer := graceful.Server{
 		Timeout: 10 * time.Second,
 	}
@@ -189,7 +269,11 @@ func startAtPath(listen []string, discoverPath string,
 	if len(listen) > 0 {
 		gracefulServer.Server = &http.Server{
 			Addr:    listen[0],
-			Handler: router,
+			Handler: handler,
+		}
+		tlsConfig, err := options.TLS.ServerConfig()
+		if err != nil {
+			return nil, err
 		}
 		l, err := net.Listen("tcp", listen[0])
 		if err != nil {
@@ -197,20 +281,28 @@ func startAtPath(listen []string, discoverPath string,
 		}
 		listener = l
 
+		// Clients discover the scheme from the discovery entry: tcp for plain HTTP, https for TLS
+		scheme := "tcp"
+		if tlsConfig != nil {
+			gracefulServer.Server.TLSConfig = tlsConfig
+			listener = tls.NewListener(l, tlsConfig)
+			scheme = "https"
+		}
+
 		advertise := listen[0]
 		if len(listen) > 1 {
 			advertise = listen[1]
 		}
-		if err := ioutil.WriteFile(discoverPath, []byte(fmt.Sprintf("tcp://%s", advertise)), 0644); err != nil {
+		if err := ioutil.WriteFile(discoverPath, []byte(fmt.Sprintf("%s://%s", scheme, advertise)), 0644); err != nil {
 			return nil, err
 		}
 
-		log.Info("Listening", "listen", listen, "discover", discoverPath)
+		log.Info("Listening", "listen", listen, "discover", discoverPath, "tls", tlsConfig != nil, "guard", guard != nil)
 
 	} else {
 		gracefulServer.Server = &http.Server{
 			Addr:    fmt.Sprintf("unix://%s", discoverPath),
-			Handler: router,
+			Handler: handler,
 		}
 		l, err := net.Listen("unix", discoverPath)
 		if err != nil {
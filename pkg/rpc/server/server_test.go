@@ -1,17 +1,27 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	plugin_mock "github.com/docker/infrakit/pkg/mock/spi/instance"
 	"github.com/docker/infrakit/pkg/plugin"
+	rpc_server "github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/audit"
+	"github.com/docker/infrakit/pkg/rpc/auth"
+	rpc_client "github.com/docker/infrakit/pkg/rpc/client"
 	plugin_rpc "github.com/docker/infrakit/pkg/rpc/instance"
 	"github.com/docker/infrakit/pkg/spi/instance"
+	testing_tls "github.com/docker/infrakit/pkg/testing/tls"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -102,3 +112,334 @@ func TestTCPServer(t *testing.T) {
 
 	server.Stop()
 }
+
+func setTLSEnv(files testing_tls.Files) {
+	os.Setenv(rpc_server.EnvTLSCAFile, files.CAFile)
+	os.Setenv(rpc_server.EnvTLSCertFile, files.CertFile)
+	os.Setenv(rpc_server.EnvTLSKeyFile, files.KeyFile)
+}
+
+func TestMutualTLSServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+
+	properties := types.AnyString(`{"foo":"bar"}`)
+	mock.EXPECT().Validate(properties).Return(nil)
+
+	service := plugin_rpc.PluginServer(mock)
+
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	plugins := testing_tls.Certs(t, dir, "plugins")
+	other := testing_tls.Certs(t, dir, "other")
+	defer setTLSEnv(testing_tls.Files{})
+
+	discover := filepath.Join(dir, "tls.listen")
+	name := plugin.Name(filepath.Base(discover))
+	server, err := StartListenerAtPathWithOptions([]string{"localhost:7778"}, discover, ListenerOptions{
+		TLS: &rpc_server.TLSOptions{CAFile: plugins.CAFile, CertFile: plugins.CertFile, KeyFile: plugins.KeyFile},
+	}, service)
+	require.NoError(t, err)
+	defer server.Stop()
+
+	buff, err := ioutil.ReadFile(discover)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(buff), "https://"))
+
+	// The clients verify the server and present their certificate, as set by the environment
+	setTLSEnv(plugins)
+	c, err := plugin_rpc.NewClient(name, discover)
+	require.NoError(t, err)
+	require.NoError(t, c.Validate(properties))
+
+	// Clients without a certificate are refused
+	setTLSEnv(testing_tls.Files{CAFile: plugins.CAFile})
+	_, err = plugin_rpc.NewClient(name, discover)
+	require.Error(t, err)
+
+	// Clients of another CA neither verify the server nor are verified
+	setTLSEnv(other)
+	_, err = plugin_rpc.NewClient(name, discover)
+	require.Error(t, err)
+}
+
+func TestGuardedServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+	mock.EXPECT().DescribeInstances(gomock.Any(), false).Return([]instance.Description{}, nil)
+	mock.EXPECT().Destroy(instance.ID("id"), instance.Termination).Return(nil)
+
+	service := plugin_rpc.PluginServer(mock)
+
+	dir, err := ioutil.TempDir("", "auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	options := &auth.Options{
+		TokensFile: filepath.Join(dir, "tokens.yml"),
+		PolicyFile: filepath.Join(dir, "policy.yml"),
+	}
+	require.NoError(t, ioutil.WriteFile(options.TokensFile, []byte("admin: admin-token\noperator: operator-token\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(options.PolicyFile, []byte(`
+Roles:
+  admin: [ "*" ]
+  read-only: [ "*.Describe*" ]
+Identities:
+  admin: [ admin ]
+  operator: [ read-only ]
+`), 0600))
+
+	discover := filepath.Join(dir, "guarded.listen")
+	name := plugin.Name(filepath.Base(discover))
+	server, err := StartListenerAtPathWithOptions([]string{"localhost:7779"}, discover,
+		ListenerOptions{Auth: options}, service)
+	require.NoError(t, err)
+	defer server.Stop()
+	defer os.Unsetenv(auth.EnvToken)
+
+	// Callers without a token are not authenticated
+	_, err = plugin_rpc.NewClient(name, discover)
+	require.Error(t, err)
+
+	// Read-only operators describe but cannot destroy
+	os.Setenv(auth.EnvToken, "operator-token")
+	c, err := plugin_rpc.NewClient(name, discover)
+	require.NoError(t, err)
+	_, err = c.DescribeInstances(map[string]string{}, false)
+	require.NoError(t, err)
+	err = c.Destroy(instance.ID("id"), instance.Termination)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not authorized")
+
+	os.Setenv(auth.EnvToken, "admin-token")
+	c, err = plugin_rpc.NewClient(name, discover)
+	require.NoError(t, err)
+	require.NoError(t, c.Destroy(instance.ID("id"), instance.Termination))
+}
+
+func TestAuditedServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+	mock.EXPECT().DescribeInstances(gomock.Any(), false).Return([]instance.Description{}, nil)
+	mock.EXPECT().Destroy(instance.ID("id"), instance.Termination).Return(nil)
+	mock.EXPECT().Destroy(instance.ID("gone"), instance.Termination).Return(errors.New("no such instance"))
+
+	service := plugin_rpc.PluginServer(mock)
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	options := &auth.Options{TokensFile: filepath.Join(dir, "tokens.yml")}
+	require.NoError(t, ioutil.WriteFile(options.TokensFile, []byte("admin: admin-token\n"), 0600))
+	auditOptions := &audit.Options{File: filepath.Join(dir, "audit.log")}
+
+	discover := filepath.Join(dir, "audited.listen")
+	name := plugin.Name(filepath.Base(discover))
+	server, err := StartListenerAtPathWithOptions([]string{"localhost:7780"}, discover,
+		ListenerOptions{Auth: options, Audit: auditOptions}, service)
+	require.NoError(t, err)
+	defer server.Stop()
+	defer os.Unsetenv(auth.EnvToken)
+
+	os.Setenv(auth.EnvToken, "admin-token")
+	c, err := plugin_rpc.NewClient(name, discover)
+	require.NoError(t, err)
+	_, err = c.DescribeInstances(map[string]string{}, false)
+	require.NoError(t, err)
+	require.NoError(t, c.Destroy(instance.ID("id"), instance.Termination))
+	require.Error(t, c.Destroy(instance.ID("gone"), instance.Termination))
+
+	// Only the mutating calls are recorded
+	entries, err := auditOptions.Log().Entries(audit.Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	for _, entry := range entries {
+		require.Equal(t, "admin", entry.Identity)
+		require.Equal(t, "audited", entry.Plugin)
+		require.Equal(t, "Instance.Destroy", entry.Method)
+	}
+	require.Equal(t, audit.OutcomeOK, entries[0].Outcome)
+	require.Equal(t, audit.OutcomeError, entries[1].Outcome)
+	require.Contains(t, entries[1].Error, "no such instance")
+	require.NotEqual(t, entries[0].Digest, entries[1].Digest)
+}
+
+// contextPlugin provisions until the context of the call is done
+type contextPlugin struct {
+	instance.Plugin
+	deadline chan time.Time
+	done     chan error
+}
+
+func (p *contextPlugin) ProvisionContext(ctx context.Context, spec instance.Spec) (*instance.ID, error) {
+	deadline, _ := ctx.Deadline()
+	p.deadline <- deadline
+	<-ctx.Done()
+	p.done <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func (p *contextPlugin) LabelContext(ctx context.Context, id instance.ID, labels map[string]string) error {
+	return p.Label(id, labels)
+}
+
+func (p *contextPlugin) DestroyContext(ctx context.Context, id instance.ID, reason instance.Context) error {
+	return p.Destroy(id, reason)
+}
+
+func (p *contextPlugin) DescribeInstancesContext(ctx context.Context, labels map[string]string,
+	properties bool) ([]instance.Description, error) {
+	return p.DescribeInstances(labels, properties)
+}
+
+func TestCallContext(t *testing.T) {
+	p := &contextPlugin{deadline: make(chan time.Time, 1), done: make(chan error, 1)}
+
+	socket := filepath.Join(os.TempDir(), fmt.Sprintf("%d-context.sock", time.Now().UnixNano()))
+	name := plugin.Name(filepath.Base(socket))
+	server, err := StartPluginAtPath(socket, plugin_rpc.PluginServer(p))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	c, err := plugin_rpc.NewClient(name, socket)
+	require.NoError(t, err)
+
+	// The deadline of the caller is that of the plugin
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	expected, _ := ctx.Deadline()
+	_, err = c.(instance.ContextPlugin).ProvisionContext(ctx, instance.Spec{})
+	require.Error(t, err)
+	require.True(t, expected.Equal(<-p.deadline))
+	require.Equal(t, context.DeadlineExceeded, <-p.done)
+
+	// The plugin stops once the caller cancels
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-p.deadline
+		cancel()
+	}()
+	_, err = c.(instance.ContextPlugin).ProvisionContext(ctx, instance.Spec{})
+	require.Error(t, err)
+	select {
+	case err := <-p.done:
+		require.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "plugin not canceled")
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+	instanceID := instance.ID("id")
+	mock.EXPECT().Provision(instance.Spec{}).Return(&instanceID, nil)
+	mock.EXPECT().Provision(instance.Spec{}).Return(&instanceID, nil)
+
+	socket := filepath.Join(os.TempDir(), fmt.Sprintf("%d-idempotency.sock", time.Now().UnixNano()))
+	name := plugin.Name(filepath.Base(socket))
+	server, err := StartPluginAtPath(socket, plugin_rpc.PluginServer(mock))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	c, err := plugin_rpc.NewClient(name, socket)
+	require.NoError(t, err)
+
+	// The calls of the same key are served once
+	ctx := rpc_client.WithIdempotencyKey(context.Background(), "provision-1")
+	for i := 0; i < 3; i++ {
+		id, err := c.(instance.ContextPlugin).ProvisionContext(ctx, instance.Spec{})
+		require.NoError(t, err)
+		require.Equal(t, instanceID, *id)
+	}
+
+	ctx = rpc_client.WithIdempotencyKey(context.Background(), "provision-2")
+	_, err = c.(instance.ContextPlugin).ProvisionContext(ctx, instance.Spec{})
+	require.NoError(t, err)
+
+	// A key reused with other params is refused, instead of replaying the reply of another call
+	ctx = rpc_client.WithIdempotencyKey(context.Background(), "provision-1")
+	_, err = c.(instance.ContextPlugin).ProvisionContext(ctx, instance.Spec{Tags: map[string]string{"a": "b"}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "idempotency key provision-1 reused with other params")
+}
+
+func TestIdempotencyKeyOfIdentities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+	instanceID := instance.ID("id")
+	mock.EXPECT().Provision(instance.Spec{}).Return(&instanceID, nil).Times(2)
+
+	dir, err := ioutil.TempDir("", "idempotency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	options := &auth.Options{TokensFile: filepath.Join(dir, "tokens.yml")}
+	require.NoError(t, ioutil.WriteFile(options.TokensFile, []byte("alice: a-token\nbob: b-token\n"), 0600))
+
+	discover := filepath.Join(dir, "idempotency.listen")
+	name := plugin.Name(filepath.Base(discover))
+	server, err := StartListenerAtPathWithOptions([]string{"localhost:7781"}, discover,
+		ListenerOptions{Auth: options}, plugin_rpc.PluginServer(mock))
+	require.NoError(t, err)
+	defer server.Stop()
+	defer os.Unsetenv(auth.EnvToken)
+
+	// The same key used by other callers is served for each, not replayed across callers
+	ctx := rpc_client.WithIdempotencyKey(context.Background(), "provision-1")
+	for _, token := range []string{"a-token", "b-token", "a-token"} {
+		os.Setenv(auth.EnvToken, token)
+		c, err := plugin_rpc.NewClient(name, discover)
+		require.NoError(t, err)
+		_, err = c.(instance.ContextPlugin).ProvisionContext(ctx, instance.Spec{})
+		require.NoError(t, err)
+	}
+}
+
+// idempotentCall serves the call with the idempotency key by the handler
+func idempotentCall(handler http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set(rpc_server.HeaderIdempotencyKey, "provision-1")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestIdempotencyKeyPanic(t *testing.T) {
+	calls := 0
+	handler := rpc_server.IdempotentHandler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("provision failed")
+		}
+		resp.Write([]byte("provisioned"))
+	}), time.Minute)
+
+	body := `{"jsonrpc":"2.0","method":"Instance.Provision","params":{},"id":1}`
+	require.Panics(t, func() { idempotentCall(handler, body) })
+
+	// The key of the call that panicked is dropped, so the call is served again when retried
+	resp := idempotentCall(handler, body)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "provisioned", resp.Body.String())
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyKeyParseError(t *testing.T) {
+	handler := rpc_server.IdempotentHandler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		require.Fail(t, "call served")
+	}), time.Minute)
+
+	resp := idempotentCall(handler, `{"method":`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Contains(t, resp.Body.String(), "-32700")
+}
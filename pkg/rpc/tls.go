@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+const (
+	// EnvTLSCAFile is the environment variable of the CA certificate that verifies the peers
+	EnvTLSCAFile = "INFRAKIT_TLS_CA_FILE"
+
+	// EnvTLSCertFile is the environment variable of the certificate presented to the peers
+	EnvTLSCertFile = "INFRAKIT_TLS_CERT_FILE"
+
+	// EnvTLSKeyFile is the environment variable of the private key of the certificate
+	EnvTLSKeyFile = "INFRAKIT_TLS_KEY_FILE"
+)
+
+// TLSOptions are the certificates securing the rpc of the plugins over tcp.  The servers present the certificate,
+// and require the clients to present a certificate signed by the CA if a CA is set (mutual TLS).  The clients
+// verify the servers with the CA, and present the certificate if set.
+type TLSOptions struct {
+	// CAFile is the PEM file of the CA certificate that verifies the peers
+	CAFile string `json:",omitempty"`
+
+	// CertFile is the PEM file of the certificate presented to the peers
+	CertFile string `json:",omitempty"`
+
+	// KeyFile is the PEM file of the private key of the certificate
+	KeyFile string `json:",omitempty"`
+}
+
+// DefaultTLSOptions returns the TLS options set by the environment, or nil if none are set.
+func DefaultTLSOptions() *TLSOptions {
+	options := TLSOptions{
+		CAFile:   os.Getenv(EnvTLSCAFile),
+		CertFile: os.Getenv(EnvTLSCertFile),
+		KeyFile:  os.Getenv(EnvTLSKeyFile),
+	}
+	if options == (TLSOptions{}) {
+		return nil
+	}
+	return &options
+}
+
+func certPool(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %v", caFile)
+	}
+	return pool, nil
+}
+
+// ServerConfig returns the TLS config of the servers, or nil without options.  The certificate and its key are
+// required.  Only the clients with a certificate signed by the CA are accepted if the CA is set.
+func (o *TLSOptions) ServerConfig() (*tls.Config, error) {
+	if o == nil {
+		return nil, nil
+	}
+	if o.CertFile == "" || o.KeyFile == "" {
+		return nil, fmt.Errorf("certificate and key are required to serve TLS")
+	}
+	config := tlsconfig.ServerDefault()
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config.Certificates = []tls.Certificate{cert}
+	if o.CAFile != "" {
+		// Only the CA given, not the system's, so that only the certificates issued for the plugins are accepted
+		pool, err := certPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientConfig returns the TLS config of the clients, or nil without options.  The servers are verified with the
+// CA if set, or else with the CAs of the system.
+func (o *TLSOptions) ClientConfig() (*tls.Config, error) {
+	if o == nil {
+		return nil, nil
+	}
+	config := tlsconfig.ClientDefault()
+	if o.CAFile != "" {
+		pool, err := certPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// Scheme returns the scheme of the urls of the servers with the options: https with TLS, or else http.
+func (o *TLSOptions) Scheme() string {
+	if o == nil {
+		return "http"
+	}
+	return "https"
+}
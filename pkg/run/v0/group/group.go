@@ -12,6 +12,7 @@ import (
 	flavor_client "github.com/docker/infrakit/pkg/rpc/flavor"
 	instance_client "github.com/docker/infrakit/pkg/rpc/instance"
 	"github.com/docker/infrakit/pkg/run"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/flavor"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
@@ -143,6 +144,12 @@ func Run(plugins func() discovery.Plugins, name plugin.Name,
 		run.Metadata: metadata_plugin.NewPluginFromChannel(updateSnapshot),
 		run.Group:    groupPlugin,
 	}
+	if events, is := groupPlugin.(event.Plugin); is {
+		// Replacements of unhealthy instances are published under the health topic.
+		impls[run.Event] = map[string]event.Plugin{
+			"health": events,
+		}
+	}
 	onStop = func() {
 		close(stopSnapshot)
 	}
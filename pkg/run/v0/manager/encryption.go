@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/infrakit/pkg/store/encrypted"
+)
+
+const (
+	// EnvEncryptionKeyFile is the file of the keys that encrypt the specs stored in the backend
+	EnvEncryptionKeyFile = "INFRAKIT_MANAGER_ENCRYPTION_KEY_FILE"
+
+	// EnvEncryptionKeys are the keys that encrypt the specs stored in the backend, base64 encoded and comma
+	// separated.  They are used if there is no key file.
+	EnvEncryptionKeys = "INFRAKIT_MANAGER_ENCRYPTION_KEYS"
+
+	// EnvEncryptionMigrate loads the specs not encrypted if true (see EncryptionConfig.Migrate)
+	EnvEncryptionMigrate = "INFRAKIT_MANAGER_ENCRYPTION_MIGRATE"
+)
+
+// EncryptionConfig is the configuration of the encryption of the specs stored in the backend, whatever the backend.
+// The first key encrypts and all the keys decrypt, so a key is rotated by adding the new key first, and removing
+// the old key once the specs have been encrypted again with the new key, which the leader does as it assumes the
+// leadership.  The specs not encrypted are refused once keys are configured, unless migrating.
+type EncryptionConfig struct {
+	// KeyFile is the file of the keys, base64 encoded, one per line
+	KeyFile string `json:",omitempty"`
+
+	// KeyEnv is the environment variable of the keys, base64 encoded and comma separated.  The keys are read from
+	// the environment rather than set in the options, so that they are not logged.
+	KeyEnv string `json:",omitempty"`
+
+	// Migrate loads the specs stored before the encryption was turned on, which are then encrypted as the leader
+	// assumes the leadership.  Unset once the specs have been encrypted, since the specs not encrypted could
+	// otherwise be loaded in place of the specs encrypted.
+	Migrate bool `json:",omitempty"`
+}
+
+// defaultEncryption returns the configuration of the encryption given by the environment, or nil if the specs are
+// not encrypted.
+func defaultEncryption() *EncryptionConfig {
+	migrate := strings.ToLower(os.Getenv(EnvEncryptionMigrate)) == "true"
+	if file := os.Getenv(EnvEncryptionKeyFile); file != "" {
+		return &EncryptionConfig{KeyFile: file, Migrate: migrate}
+	}
+	if _, has := os.LookupEnv(EnvEncryptionKeys); has {
+		return &EncryptionConfig{KeyEnv: EnvEncryptionKeys, Migrate: migrate}
+	}
+	return nil
+}
+
+func (c EncryptionConfig) keys() (encrypted.Keys, error) {
+	switch {
+	case c.KeyFile != "":
+		return encrypted.KeysFromFile(c.KeyFile)
+	case c.KeyEnv != "":
+		return encrypted.KeysFromEnv(c.KeyEnv)
+	}
+	return nil, fmt.Errorf("no encryption keys configured")
+}
@@ -59,6 +59,7 @@ func configEtcdBackends(options BackendEtcdOptions, managerConfig *Options) erro
 		managerConfig.leader = leader
 		managerConfig.leaderStore = leaderStore
 		managerConfig.store = snapshot
+		managerConfig.revisions = etcd_store.NewKV(etcdClient, etcd_store.RevisionsPrefix)
 		managerConfig.cleanUpFunc = func() { etcdClient.Close() }
 	}
 
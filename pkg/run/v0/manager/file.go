@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/docker/infrakit/pkg/leader"
 	file_leader "github.com/docker/infrakit/pkg/leader/file"
 	"github.com/docker/infrakit/pkg/run/local"
 	file_store "github.com/docker/infrakit/pkg/store/file"
@@ -34,6 +35,10 @@ type BackendFileOptions struct {
 
 	// ID is the id of the node
 	ID string
+
+	// LeaseTTL is how long the leadership lasts unless renewed.  The lease is kept next to the leader file.
+	// Zero to lead without a lease, in which case the writes of the leader are not fenced.
+	LeaseTTL types.Duration
 }
 
 // DefaultBackendFileOptions is the default for the file backend
@@ -42,11 +47,19 @@ var DefaultBackendFileOptions = BackendFileOptions{
 	PollInterval: types.FromDuration(5 * time.Second),
 	LeaderFile:   local.Getenv(EnvLeaderFile, filepath.Join(local.InfrakitHome(), "leader")),
 	StoreDir:     local.Getenv(EnvStoreDir, filepath.Join(local.InfrakitHome(), "configs")),
+	LeaseTTL:     types.FromDuration(15 * time.Second),
 }
 
 func configFileBackends(options BackendFileOptions, managerConfig *Options) error {
 
-	leader, err := file_leader.NewDetector(options.PollInterval.Duration(), options.LeaderFile, options.ID)
+	var detector *leader.Poller
+	var err error
+	if ttl := options.LeaseTTL.Duration(); ttl > 0 {
+		detector, err = file_leader.NewLeaseDetector(options.PollInterval.Duration(), ttl,
+			options.LeaderFile, options.LeaderFile+".lease", options.ID)
+	} else {
+		detector, err = file_leader.NewDetector(options.PollInterval.Duration(), options.LeaderFile, options.ID)
+	}
 	if err != nil {
 		return err
 	}
@@ -58,9 +71,11 @@ func configFileBackends(options BackendFileOptions, managerConfig *Options) erro
 	}
 
 	if managerConfig != nil {
-		managerConfig.leader = leader
+		managerConfig.leader = detector
 		managerConfig.leaderStore = leaderStore
 		managerConfig.store = snapshot
+		managerConfig.revisions = file_store.NewStore("revision", options.StoreDir)
+		managerConfig.fenced = options.LeaseTTL.Duration() > 0
 	}
 	return nil
 }
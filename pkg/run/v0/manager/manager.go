@@ -12,11 +12,16 @@ import (
 	"github.com/docker/infrakit/pkg/manager"
 	"github.com/docker/infrakit/pkg/plugin"
 	metadata_plugin "github.com/docker/infrakit/pkg/plugin/metadata"
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/rpc/mux"
-	rpc "github.com/docker/infrakit/pkg/rpc/server"
+	rpc_server "github.com/docker/infrakit/pkg/rpc/server"
 	"github.com/docker/infrakit/pkg/run"
 	"github.com/docker/infrakit/pkg/run/local"
 	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/encrypted"
+	"github.com/docker/infrakit/pkg/store/fenced"
+	"github.com/docker/infrakit/pkg/store/versioned"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -49,7 +54,7 @@ func init() {
 // Options capture the options for starting up the plugin.
 type Options struct {
 	// Backend is the backend used for leadership, persistence, etc.
-	// Possible values are file, etcd, and swarm
+	// Possible values are file, etcd, swarm, and raft
 	Backend string
 
 	// Name of the backend
@@ -61,10 +66,20 @@ type Options struct {
 	// Mux is the tcp frontend for remote connectivity
 	Mux *MuxConfig
 
+	// Revisions is the number of revisions of the committed specs kept in the history.  Each revision is stored
+	// under its own key.  The swarm backend keeps no revisions.
+	Revisions int
+
+	// Encryption is the configuration of the encryption of the specs stored in the backend.  Nil to store the
+	// specs in plain text.
+	Encryption *EncryptionConfig
+
 	plugins     func() discovery.Plugins
 	leader      leader.Detector
 	leaderStore leader.Store
 	store       store.Snapshot
+	revisions   store.KV
+	fenced      bool
 	cleanUpFunc func()
 }
 
@@ -75,6 +90,14 @@ type MuxConfig struct {
 
 	// Advertise is the public listen string e.g. public_ip:24864
 	Advertise string
+
+	// TLS are the certificates of the mux.  Nil to serve plain HTTP.  The default is set by the environment
+	// (see rpc.DefaultTLSOptions).
+	TLS *rpc.TLSOptions `json:",omitempty"`
+
+	// Auth configures the authentication and the authorization of the callers of the mux.  Nil to accept all the
+	// callers.  The default is set by the environment (see auth.DefaultOptions).
+	Auth *auth.Options `json:",omitempty"`
 }
 
 // DefaultOptions return an Options with default values filled in.
@@ -87,7 +110,11 @@ func defaultOptions() (options Options) {
 		Mux: &MuxConfig{
 			Listen:    local.Getenv(EnvMuxListen, ":24864"),
 			Advertise: local.Getenv(EnvAdvertise, "localhost:24864"),
+			TLS:       rpc.DefaultTLSOptions(),
+			Auth:      auth.DefaultOptions(),
 		},
+		Revisions:  versioned.DefaultMaxRevisions,
+		Encryption: defaultEncryption(),
 	}
 
 	options.Backend = os.Getenv(EnvOptionsBackend)
@@ -101,6 +128,9 @@ func defaultOptions() (options Options) {
 	case "file":
 		options.Backend = "file"
 		options.Settings = types.AnyValueMust(DefaultBackendFileOptions)
+	case "raft":
+		options.Backend = "raft"
+		options.Settings = types.AnyValueMust(DefaultBackendRaftOptions)
 	default:
 		options.Backend = "file"
 		options.Settings = types.AnyValueMust(DefaultBackendFileOptions)
@@ -129,50 +159,25 @@ func Run(plugins func() discovery.Plugins, name plugin.Name,
 
 	options.plugins = plugins
 
-	switch strings.ToLower(options.Backend) {
-	case "etcd":
-		backendOptions := DefaultBackendEtcdOptions
-		err = options.Settings.Decode(&backendOptions)
-		if err != nil {
-			return
-		}
-		log.Info("starting up etcd backend", "options", backendOptions)
-		err = configEtcdBackends(backendOptions, &options)
-		if err != nil {
-			return
-		}
-		log.Info("etcd backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
-	case "file":
-		backendOptions := DefaultBackendFileOptions
-		err = options.Settings.Decode(&backendOptions)
-		if err != nil {
-			return
-		}
-		log.Info("starting up file backend", "options", backendOptions)
-		err = configFileBackends(backendOptions, &options)
-		if err != nil {
-			return
-		}
-		log.Info("file backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
-	case "swarm":
-		backendOptions := DefaultBackendSwarmOptions
-		err = options.Settings.Decode(&backendOptions)
-		if err != nil {
-			return
-		}
-		log.Info("starting up swarm backend", "options", backendOptions)
-		err = configSwarmBackends(backendOptions, &options)
-		if err != nil {
-			return
-		}
-		log.Info("swarm backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
-	default:
-		err = fmt.Errorf("unknown backend:%v", options.Backend)
+	err = configBackends(&options)
+	if err != nil {
+		return
+	}
+
+	// Fence the writes of the leader by the epoch of its lease, so a replaced leader cannot overwrite the specs
+	// committed by its successor.
+	var mgr manager.Backend
+	var epoch func() uint64
+	if options.fenced {
+		epoch = func() uint64 { return mgr.Epoch() }
+	}
+	options.store, err = specsSnapshot(options, epoch)
+	if err != nil {
 		return
 	}
 
 	lookup, _ := options.BackendName.GetLookupAndType()
-	mgr := manager.NewManager(plugins(), options.leader, options.leaderStore, options.store, lookup)
+	mgr = manager.NewManager(plugins(), options.leader, options.leaderStore, options.store, lookup)
 	log.Info("Start manager", "m", mgr)
 
 	_, err = mgr.Start()
@@ -202,7 +207,7 @@ func Run(plugins func() discovery.Plugins, name plugin.Name,
 		run.Metadata:          metadataUpdatable,
 	}
 
-	var muxServer rpc.Stoppable
+	var muxServer rpc_server.Stoppable
 
 	if options.Mux != nil {
 
@@ -211,6 +216,8 @@ func Run(plugins func() discovery.Plugins, name plugin.Name,
 			mux.Options{
 				Leadership: options.leader.Receive(),
 				Registry:   options.leaderStore,
+				TLS:        options.Mux.TLS,
+				Auth:       options.Mux.Auth,
 			})
 		if err != nil {
 			panic(err)
@@ -231,3 +238,108 @@ func Run(plugins func() discovery.Plugins, name plugin.Name,
 }
 
 type cleanup func()
+
+// configBackends configures the leadership and the store of the backend of the options.
+func configBackends(options *Options) error {
+	switch strings.ToLower(options.Backend) {
+	case "etcd":
+		backendOptions := DefaultBackendEtcdOptions
+		if err := options.Settings.Decode(&backendOptions); err != nil {
+			return err
+		}
+		log.Info("starting up etcd backend", "options", backendOptions)
+		if err := configEtcdBackends(backendOptions, options); err != nil {
+			return err
+		}
+		log.Info("etcd backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
+	case "file":
+		backendOptions := DefaultBackendFileOptions
+		if err := options.Settings.Decode(&backendOptions); err != nil {
+			return err
+		}
+		log.Info("starting up file backend", "options", backendOptions)
+		if err := configFileBackends(backendOptions, options); err != nil {
+			return err
+		}
+		log.Info("file backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
+	case "swarm":
+		backendOptions := DefaultBackendSwarmOptions
+		if err := options.Settings.Decode(&backendOptions); err != nil {
+			return err
+		}
+		log.Info("starting up swarm backend", "options", backendOptions)
+		if err := configSwarmBackends(backendOptions, options); err != nil {
+			return err
+		}
+		log.Info("swarm backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
+	case "raft":
+		backendOptions := DefaultBackendRaftOptions
+		// The peers configured replace the default peers instead of being merged with them
+		backendOptions.Peers = nil
+		if err := options.Settings.Decode(&backendOptions); err != nil {
+			return err
+		}
+		if len(backendOptions.Peers) == 0 {
+			backendOptions.Peers = DefaultBackendRaftOptions.Peers
+		}
+		log.Info("starting up raft backend", "options", backendOptions)
+		if err := configRaftBackends(backendOptions, options); err != nil {
+			return err
+		}
+		log.Info("raft backend", "leader", options.leader, "store", options.store, "cleanup", options.cleanUpFunc)
+	default:
+		return fmt.Errorf("unknown backend:%v", options.Backend)
+	}
+	return nil
+}
+
+// specsSnapshot returns the snapshot of the specs over the store of the backend: encrypted at rest if configured,
+// fenced by the epoch, and keeping the history of the revisions if the backend has a store for them.
+func specsSnapshot(options Options, epoch func() uint64) (store.Snapshot, error) {
+	snapshot, revisions := options.store, options.revisions
+
+	// Encrypt the specs at rest, including their revisions.  If migrating, the specs saved before are still
+	// loaded, and are encrypted with the current key as the leader assumes the leadership.
+	if options.Encryption != nil {
+		keys, err := options.Encryption.keys()
+		if err != nil {
+			return nil, err
+		}
+		snapshot = encrypted.NewSnapshot(snapshot, keys, options.Encryption.Migrate)
+		if revisions != nil {
+			revisions = encrypted.NewKV(revisions, keys, options.Encryption.Migrate)
+		}
+	}
+
+	snapshot = fenced.NewSnapshot(snapshot, epoch)
+	if revisions == nil {
+		return snapshot, nil
+	}
+
+	// Keep the latest specs where they have always been stored, and their revisions each under its own key
+	return versioned.NewSnapshot(snapshot, revisions, options.Revisions), nil
+}
+
+// OpenSnapshot opens the snapshot of the specs in the backend of the options, as the manager does but without
+// starting the manager, so that the specs can be exported and imported offline.  The writes are not fenced.  The
+// raft backend cannot be opened this way, since its store is only reachable through the nodes of the cluster.
+// Call the returned func to release the resources once done.
+func OpenSnapshot(options Options) (store.Snapshot, func(), error) {
+	if strings.ToLower(options.Backend) == "raft" {
+		return nil, nil, fmt.Errorf("cannot open the raft backend without running the manager")
+	}
+	if err := configBackends(&options); err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		if options.cleanUpFunc != nil {
+			options.cleanUpFunc()
+		}
+	}
+	snapshot, err := specsSnapshot(options, nil)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return snapshot, cleanup, nil
+}
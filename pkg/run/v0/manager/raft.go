@@ -0,0 +1,198 @@
+package manager
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	raft_leader "github.com/docker/infrakit/pkg/leader/raft"
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/run/local"
+	raft_store "github.com/docker/infrakit/pkg/store/raft"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/raft"
+)
+
+const (
+	// EnvRaftListen is the listen string of the raft node (localhost:24865)
+	EnvRaftListen = "INFRAKIT_RAFT_LISTEN"
+
+	// EnvRaftPeers are the urls of all the members of the raft cluster, as id=url pairs separated by commas
+	// (manager1=https://10.0.0.1:24865,manager2=https://10.0.0.2:24865)
+	EnvRaftPeers = "INFRAKIT_RAFT_PEERS"
+
+	// EnvRaftPeerIdentities are the common names of the certificates of the peers accepted, separated by commas.
+	// The ids of the peers by default.
+	EnvRaftPeerIdentities = "INFRAKIT_RAFT_PEER_IDENTITIES"
+
+	// EnvRaftDir is the directory where the raft state is stored
+	EnvRaftDir = "INFRAKIT_RAFT_DIR"
+)
+
+// BackendRaftOptions contain the options for the raft backend, where the managers form their own raft cluster
+// for leader election and storage.
+type BackendRaftOptions struct {
+	// PollInterval is how often to check
+	PollInterval types.Duration
+
+	// ID is the id of the node.  It must be one of the peers.
+	ID string
+
+	// Listen is the listen string of the raft node, e.g. 10.0.0.1:24865
+	Listen string
+
+	// Peers are the urls of all the members of the cluster, by id, including this node.  Usually 3 or 5.
+	Peers map[string]string
+
+	// TLS are the certificates of the raft node.  The node serves TLS and requires the peers to present a
+	// certificate signed by the CA.  Required if there are other peers.  The default is set by the environment
+	// (see rpc.DefaultTLSOptions).
+	TLS *rpc.TLSOptions `json:",omitempty"`
+
+	// PeerIdentities are the common names of the certificates of the peers accepted.  The ids of the peers if
+	// not set.
+	PeerIdentities []string `json:",omitempty"`
+
+	// Dir is the path to the directory where the raft state is stored
+	Dir string
+
+	// HeartbeatInterval is how often the leader contacts the followers
+	HeartbeatInterval types.Duration
+
+	// ElectionTimeout is how long before an election when the leader is not heard from
+	ElectionTimeout types.Duration
+}
+
+// DefaultBackendRaftOptions is the default for the raft backend: a cluster of one
+var DefaultBackendRaftOptions = defaultBackendRaftOptions()
+
+func defaultBackendRaftOptions() BackendRaftOptions {
+	id := local.Getenv(EnvID, "manager1")
+	listen := local.Getenv(EnvRaftListen, "localhost:24865")
+	tlsOptions := rpc.DefaultTLSOptions()
+	peers := parsePeers(local.Getenv(EnvRaftPeers, ""))
+	if len(peers) == 0 {
+		_, port, _ := net.SplitHostPort(listen)
+		peers = map[string]string{id: tlsOptions.Scheme() + "://localhost:" + port}
+	}
+	var identities []string
+	for _, identity := range strings.Split(local.Getenv(EnvRaftPeerIdentities, ""), ",") {
+		if identity = strings.TrimSpace(identity); identity != "" {
+			identities = append(identities, identity)
+		}
+	}
+	return BackendRaftOptions{
+		PollInterval:      types.FromDuration(1 * time.Second),
+		ID:                id,
+		Listen:            listen,
+		Peers:             peers,
+		TLS:               tlsOptions,
+		PeerIdentities:    identities,
+		Dir:               local.Getenv(EnvRaftDir, filepath.Join(local.InfrakitHome(), "raft")),
+		HeartbeatInterval: types.FromDuration(raft.DefaultHeartbeatInterval),
+		ElectionTimeout:   types.FromDuration(raft.DefaultElectionTimeout),
+	}
+}
+
+// parsePeers parses id=url pairs separated by commas
+func parsePeers(s string) map[string]string {
+	peers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 {
+			peers[kv[0]] = kv[1]
+		}
+	}
+	return peers
+}
+
+func configRaftBackends(options BackendRaftOptions, managerConfig *Options) error {
+	if _, has := options.Peers[options.ID]; !has {
+		return fmt.Errorf("node %v is not one of the peers %v", options.ID, options.Peers)
+	}
+	members := []string{}
+	for id := range options.Peers {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	// The peers replicate the specs and elect the leader, so they must be authenticated
+	if len(members) > 1 && (options.TLS == nil || options.TLS.CAFile == "") {
+		return fmt.Errorf("the raft peers %v require TLS with a CA verifying the peers", members)
+	}
+	serverTLS, err := options.TLS.ServerConfig()
+	if err != nil {
+		return err
+	}
+	clientTLS, err := options.TLS.ClientConfig()
+	if err != nil {
+		return err
+	}
+	identities := options.PeerIdentities
+	if len(identities) == 0 {
+		identities = members
+	}
+
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return err
+	}
+
+	node, err := raft.NewStore(raft.Config{
+		ID:                options.ID,
+		Members:           members,
+		HeartbeatInterval: options.HeartbeatInterval.Duration(),
+		ElectionTimeout:   options.ElectionTimeout.Duration(),
+		Transport:         raft.NewHTTPTransport(options.Peers, options.ElectionTimeout.Duration(), clientTLS),
+		Storage:           raft.NewFileStorage(filepath.Join(options.Dir, options.ID+".json")),
+	})
+	if err != nil {
+		return err
+	}
+
+	handler := raft.NewHTTPHandler(node)
+	if serverTLS != nil && serverTLS.ClientCAs != nil {
+		handler = raft.AllowPeers(identities, handler)
+	}
+	listener, err := net.Listen("tcp", options.Listen)
+	if err != nil {
+		return err
+	}
+	if serverTLS != nil {
+		listener = tls.NewListener(listener, serverTLS)
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		log.Info("Starting raft node", "id", options.ID, "listen", options.Listen, "tls", serverTLS != nil,
+			"members", members, "peers", identities)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error("Raft server stopped", "err", err)
+		}
+	}()
+	node.Start()
+
+	snapshot, err := raft_store.NewSnapshot(node, raft_store.DefaultKey)
+	if err != nil {
+		node.Stop()
+		server.Close()
+		return err
+	}
+
+	if managerConfig != nil {
+		managerConfig.leader = raft_leader.NewDetector(options.PollInterval.Duration(), node)
+		managerConfig.leaderStore = raft_leader.NewStore(node)
+		managerConfig.store = snapshot
+		managerConfig.revisions = raft_store.NewKV(node, raft_store.RevisionsPrefix)
+		managerConfig.fenced = true
+		managerConfig.cleanUpFunc = func() {
+			node.Stop()
+			server.Close()
+		}
+	}
+	return nil
+}
@@ -4,11 +4,13 @@ import (
 	"time"
 
 	"github.com/docker/go-connections/tlsconfig"
+	"github.com/docker/infrakit/pkg/leader"
 	swarm_leader "github.com/docker/infrakit/pkg/leader/swarm"
 	logutil "github.com/docker/infrakit/pkg/log"
 	swarm_store "github.com/docker/infrakit/pkg/store/swarm"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/docker/infrakit/pkg/util/docker"
+	"golang.org/x/net/context"
 )
 
 // BackendSwarmOptions contain the options for the swarm backend
@@ -17,6 +19,10 @@ type BackendSwarmOptions struct {
 	PollInterval types.Duration
 	// Docker holds the connection params to the Docker engine for join tokens, etc.
 	Docker docker.ConnectInfo `json:",inline" yaml:",inline"`
+
+	// LeaseTTL is how long the leadership lasts unless renewed.  The lease is kept in the swarm annotations.
+	// Zero to lead without a lease, in which case the writes of the leader are not fenced.
+	LeaseTTL types.Duration
 }
 
 // DefaultBackendSwarmOptions is the Options for using the swarm backend.
@@ -26,6 +32,7 @@ var DefaultBackendSwarmOptions = BackendSwarmOptions{
 		Host: "unix:///var/run/docker.sock",
 		TLS:  &tlsconfig.Options{},
 	},
+	LeaseTTL: types.FromDuration(15 * time.Second),
 }
 
 func configSwarmBackends(options BackendSwarmOptions, managerConfig *Options) error {
@@ -41,13 +48,24 @@ func configSwarmBackends(options BackendSwarmOptions, managerConfig *Options) er
 		return err
 	}
 
-	leader := swarm_leader.NewDetector(options.PollInterval.Duration(), dockerClient)
+	var detector *leader.Poller
+	if ttl := options.LeaseTTL.Duration(); ttl > 0 {
+		info, err := dockerClient.Info(context.Background())
+		if err != nil {
+			dockerClient.Close()
+			return err
+		}
+		detector = swarm_leader.NewLeaseDetector(options.PollInterval.Duration(), ttl, info.Swarm.NodeID, dockerClient)
+	} else {
+		detector = swarm_leader.NewDetector(options.PollInterval.Duration(), dockerClient)
+	}
 	leaderStore := swarm_leader.NewStore(dockerClient)
 
 	if managerConfig != nil {
-		managerConfig.leader = leader
+		managerConfig.leader = detector
 		managerConfig.leaderStore = leaderStore
 		managerConfig.store = snapshot
+		managerConfig.fenced = options.LeaseTTL.Duration() > 0
 		managerConfig.cleanUpFunc = func() {
 			dockerClient.Close()
 			log.Debug("closed docker connection", "client", dockerClient, "V", logutil.V(100))
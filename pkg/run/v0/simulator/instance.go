@@ -88,19 +88,16 @@ func (s *instanceSimulator) Label(key instance.ID, labels map[string]string) err
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	exists, err := s.instances.Exists(key)
+	// The store may be shared with other processes, so the labels are only written if the instance has not changed
+	// since it was read.
+	buff, version, err := s.instances.ReadVersion(key)
 	if err != nil {
 		return err
 	}
-	if !exists {
+	if version == 0 {
 		return fmt.Errorf("not found %v", key)
 	}
 
-	buff, err := s.instances.Read(key)
-	if err != nil {
-		return err
-	}
-
 	n := instance.Description{}
 	if err := types.AnyYAMLMust(buff).Decode(&n); err != nil {
 		return err
@@ -118,7 +115,7 @@ func (s *instanceSimulator) Label(key instance.ID, labels map[string]string) err
 		return err
 	}
 
-	return s.instances.Write(key, buff)
+	return store.CompareAndSwap(s.instances, key, version, buff)
 }
 
 // Destroy terminates an existing instance.
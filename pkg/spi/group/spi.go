@@ -1,6 +1,8 @@
 package group
 
 import (
+	"time"
+
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
@@ -9,13 +11,17 @@ import (
 // InterfaceSpec is the current name and version of the Group API.
 var InterfaceSpec = spi.InterfaceSpec{
 	Name:    "Group",
-	Version: "0.1.0",
+	Version: "0.2.0",
 }
 
 // Plugin defines the functions for a Group plugin.
 type Plugin interface {
 	CommitGroup(grp Spec, pretend bool) (string, error)
 
+	// PlanCommit returns the plan of committing the spec, without making any changes.  The plan details the
+	// changes of the instance and flavor configurations, and the instances destroyed, created or left alone.
+	PlanCommit(grp Spec) (Plan, error)
+
 	FreeGroup(ID) error
 
 	DescribeGroup(ID) (Description, error)
@@ -35,6 +41,22 @@ type Plugin interface {
 	// SetSize sets the size.
 	// This function should block until completion.
 	SetSize(ID, int) error
+
+	// PromoteCanary ends the canary phase of an update in progress, continuing the update with the rest of
+	// the group.  Error is returned if the group has no canary in progress.
+	PromoteCanary(ID) error
+
+	// AbortCanary ends the canary phase of an update in progress, rolling the group back to its previous
+	// configuration.  Error is returned if the group has no canary in progress.
+	AbortCanary(ID) error
+
+	// PauseUpdate holds the update in progress before it replaces any more instances.  Error is returned if
+	// the group has no update in progress.
+	PauseUpdate(ID) error
+
+	// ResumeUpdate continues a paused update from where it stopped.  Error is returned if the group has no
+	// paused update.
+	ResumeUpdate(ID) error
 }
 
 // ID is the unique identifier for a Group.
@@ -53,8 +75,119 @@ type Spec struct {
 	Properties *types.Any
 }
 
+// Plan is the detailed plan of a commit of a group.
+type Plan struct {
+	// Explain is the explanation of the update plan, as returned by a commit when pretending.
+	Explain string
+
+	// Changes are the field-level differences of the instance and flavor configurations.  The paths are
+	// relative to the group properties, as in Instance/Properties/Tags.
+	Changes []types.Change `json:",omitempty"`
+
+	// Instances are the instances destroyed, created or left alone by the commit.
+	Instances instance.Plan
+}
+
 // Description is a placeholder for the reported state of a Group.
 type Description struct {
 	Instances []instance.Description
 	Converged bool
+
+	// Update is the update in progress, if any.
+	Update *Update `json:",omitempty"`
+
+	// Rollback is set when the most recent update failed and the group was reverted to its previous configuration.
+	Rollback *Rollback `json:",omitempty"`
+
+	// History is the most recent commits to the group, oldest first.
+	History []Commit `json:",omitempty"`
+}
+
+// UpdatePhase is the stage of an update.
+type UpdatePhase string
+
+const (
+	// UpdateRolling is the phase of an update replacing instances.
+	UpdateRolling UpdatePhase = "Rolling"
+
+	// UpdateCanary is the phase of an update replacing and checking canary instances.
+	UpdateCanary UpdatePhase = "Canary"
+
+	// UpdatePausing is the phase of an update waiting between batches.
+	UpdatePausing UpdatePhase = "Pausing"
+
+	// UpdatePaused is the phase of an update held until it is resumed.
+	UpdatePaused UpdatePhase = "Paused"
+
+	// UpdateRollingBack is the phase of an update reverting a failed update.
+	UpdateRollingBack UpdatePhase = "RollingBack"
+
+	// UpdateCompleted is the outcome of an update that finished successfully.
+	UpdateCompleted UpdatePhase = "Completed"
+
+	// UpdateFailed is the outcome of an update that finished with an error.
+	UpdateFailed UpdatePhase = "Failed"
+
+	// UpdateHalted is the outcome of an update that was stopped before finishing, for example by another commit.
+	UpdateHalted UpdatePhase = "Halted"
+)
+
+// Update describes the progress of an update in progress.
+type Update struct {
+	// Plan is the explanation of the update plan.
+	Plan string
+
+	// Phase is the current stage of the update.
+	Phase UpdatePhase
+
+	// Replaced is the number of instances replaced so far.
+	Replaced int
+
+	// Remaining is the number of instances yet to be replaced.
+	Remaining int
+
+	// Started is when the update started.
+	Started time.Time
+
+	// LastError is the most recent error encountered by the update.
+	LastError string `json:",omitempty"`
+}
+
+// Commit records a change to the configuration of a group.
+type Commit struct {
+	// Hash is the configuration hash committed.
+	Hash string
+
+	// Plan is the explanation of the plan executed for the commit.
+	Plan string
+
+	// Phase is the outcome of the commit; one of UpdateCompleted, UpdateFailed or UpdateHalted.
+	Phase UpdatePhase
+
+	// Started is when the commit was made.
+	Started time.Time
+
+	// Finished is when the resulting update finished.
+	Finished time.Time
+
+	// Error is the error that caused the update to fail.
+	Error string `json:",omitempty"`
+
+	// Rollback is true if the commit reverted a failed update.
+	Rollback bool `json:",omitempty"`
+}
+
+// Rollback describes an automatic revert of a failed update.
+type Rollback struct {
+	// From is the configuration hash of the update that failed.
+	From string
+
+	// To is the configuration hash being restored.
+	To string
+
+	// Reason is the error that caused the update to fail.
+	Reason string
+
+	// Time is when the rollback started.
+	Time time.Time
 }
@@ -1,6 +1,8 @@
 package instance
 
 import (
+	"context"
+
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -37,3 +39,21 @@ type Plugin interface {
 	// The properties flag indicates the client is interested in receiving details about each instance.
 	DescribeInstances(labels map[string]string, properties bool) ([]Description, error)
 }
+
+// ContextPlugin is implemented by the plugins that stop the work of a call once its caller cancels it, or once its
+// deadline passes.  The rpc server calls these methods, with the context of the call, instead of those of Plugin.
+type ContextPlugin interface {
+	Plugin
+
+	// ProvisionContext creates a new instance based on the spec, within the context.
+	ProvisionContext(ctx context.Context, spec Spec) (*ID, error)
+
+	// LabelContext labels the instance, within the context.
+	LabelContext(ctx context.Context, instance ID, labels map[string]string) error
+
+	// DestroyContext terminates an existing instance, within the context.
+	DestroyContext(ctx context.Context, instance ID, context Context) error
+
+	// DescribeInstancesContext returns descriptions of the instances matching all the tags, within the context.
+	DescribeInstancesContext(ctx context.Context, labels map[string]string, properties bool) ([]Description, error)
+}
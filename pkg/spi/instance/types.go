@@ -57,3 +57,18 @@ type Context struct {
 	// The string pointer denotes the field is optional
 	Reason string
 }
+
+// Plan lists the instances that a change destroys, creates or leaves alone.
+type Plan struct {
+	// Destroy are the instances destroyed, including the instances replaced.
+	Destroy []ID `json:",omitempty"`
+
+	// Create is the number of instances created, including the replacements.
+	Create int
+
+	// LogicalIDs are the logical IDs of the instances created, when known.
+	LogicalIDs []LogicalID `json:",omitempty"`
+
+	// Keep are the instances left alone.
+	Keep []ID `json:",omitempty"`
+}
@@ -0,0 +1,152 @@
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+)
+
+var log = logutil.New("module", "store/encrypted")
+
+// ErrNotEncrypted is returned when an object not encrypted is loaded where only encrypted objects are accepted
+var ErrNotEncrypted = fmt.Errorf("not encrypted")
+
+// Keys are the AES keys of the encryption, of 16, 24 or 32 bytes.  The first key encrypts, and all the keys
+// decrypt.  A key is rotated by adding the new key first, encrypting the data again with Rotate, and then
+// removing the old key.
+type Keys [][]byte
+
+// ParseKeys parses the keys, base64 encoded and separated by new lines or commas.  Blank lines and lines
+// starting with # are ignored.
+func ParseKeys(text string) (Keys, error) {
+	keys := Keys{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.Split(line, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			key, err := base64.StdEncoding.DecodeString(field)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode key %d: %v", len(keys)+1, err)
+			}
+			switch len(key) {
+			case 16, 24, 32:
+			default:
+				return nil, fmt.Errorf("key %d is %d bytes; must be 16, 24 or 32", len(keys)+1, len(key))
+			}
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys")
+	}
+	return keys, nil
+}
+
+// KeysFromFile reads the keys from the file, as parsed by ParseKeys
+func KeysFromFile(path string) (Keys, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(string(buff))
+}
+
+// KeysFromEnv reads the keys from the environment variable, as parsed by ParseKeys
+func KeysFromEnv(name string) (Keys, error) {
+	text, has := os.LookupEnv(name)
+	if !has {
+		return nil, fmt.Errorf("no keys in %v", name)
+	}
+	return ParseKeys(text)
+}
+
+// envelope is what is saved in the backend.  The key is identified so that the data encrypted with any of the keys
+// can be decrypted.
+type envelope struct {
+	// Encrypted tells the envelope apart from data saved in the backend before it was encrypted
+	Encrypted bool
+
+	// Key is the id of the key
+	Key string
+
+	Nonce []byte
+	Data  []byte
+}
+
+// id returns the id of the key, which does not reveal the key
+func id(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// aad is the additional data authenticated with the data: the id of the key, and the name the data is saved under,
+// so that the data cannot be moved under another name
+func aad(key, name string) []byte {
+	return []byte(key + "\x00" + name)
+}
+
+func aead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the data saved under the name with the first key
+func (k Keys) seal(data []byte, name string) (envelope, error) {
+	if len(k) == 0 {
+		return envelope{}, fmt.Errorf("no keys")
+	}
+	gcm, err := aead(k[0])
+	if err != nil {
+		return envelope{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return envelope{}, err
+	}
+	key := id(k[0])
+	return envelope{
+		Encrypted: true,
+		Key:       key,
+		Nonce:     nonce,
+		Data:      gcm.Seal(nil, nonce, data, aad(key, name)),
+	}, nil
+}
+
+// open decrypts the data saved under the name with the key it was encrypted with
+func (k Keys) open(e envelope, name string) ([]byte, error) {
+	for _, key := range k {
+		if id(key) != e.Key {
+			continue
+		}
+		gcm, err := aead(key)
+		if err != nil {
+			return nil, err
+		}
+		return gcm.Open(nil, e.Nonce, e.Data, aad(e.Key, name))
+	}
+	return nil, fmt.Errorf("no key %v to decrypt", e.Key)
+}
+
+// current returns true if the envelope is encrypted with the first key
+func (k Keys) current(e envelope) bool {
+	return e.Encrypted && len(k) > 0 && e.Key == id(k[0])
+}
@@ -0,0 +1,245 @@
+package encrypted
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/store"
+)
+
+// KV encrypts the values written in the backend kv store
+type KV struct {
+	backend store.KV
+	keys    Keys
+	migrate bool
+}
+
+// NewKV returns a kv store that encrypts the values written in the backend with AES-GCM.  The keys are not
+// encrypted, but each value is bound to its key.  The values not encrypted are refused with ErrNotEncrypted,
+// unless migrate is set: the values written before they were encrypted are then still read, and are encrypted at
+// the next write or by Rotate.
+func NewKV(backend store.KV, keys Keys, migrate bool) store.KV {
+	return &KV{
+		backend: backend,
+		keys:    keys,
+		migrate: migrate,
+	}
+}
+
+// name is the name a value is encrypted under
+func name(key interface{}) string {
+	return fmt.Sprintf("%v", key)
+}
+
+func (s *KV) seal(key interface{}, value []byte) ([]byte, error) {
+	e, err := s.keys.seal(value, name(key))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(e)
+}
+
+// unseal returns the value decrypted, and its envelope if it is encrypted
+func (s *KV) unseal(key interface{}, value []byte) ([]byte, envelope, error) {
+	if value == nil {
+		return nil, envelope{}, nil
+	}
+	e := envelope{}
+	if err := json.Unmarshal(value, &e); err != nil || !e.Encrypted {
+		// The value was written before it was encrypted
+		if !s.migrate {
+			return nil, envelope{}, ErrNotEncrypted
+		}
+		return value, envelope{}, nil
+	}
+	buff, err := s.keys.open(e, name(key))
+	return buff, e, err
+}
+
+func (s *KV) open(key interface{}, value []byte) ([]byte, error) {
+	buff, _, err := s.unseal(key, value)
+	return buff, err
+}
+
+// Close implements io.Closer
+func (s *KV) Close() error {
+	return s.backend.Close()
+}
+
+// Key returns an id given the key
+func (s *KV) Key(key interface{}) string {
+	return s.backend.Key(key)
+}
+
+// Write encrypts and writes the value
+func (s *KV) Write(key interface{}, value []byte) error {
+	buff, err := s.seal(key, value)
+	if err != nil {
+		return err
+	}
+	return s.backend.Write(key, buff)
+}
+
+// Read reads and decrypts the value
+func (s *KV) Read(key interface{}) ([]byte, error) {
+	buff, err := s.backend.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(key, buff)
+}
+
+// Exists checks for existence
+func (s *KV) Exists(key interface{}) (bool, error) {
+	return s.backend.Exists(key)
+}
+
+// Delete deletes the object by id
+func (s *KV) Delete(key interface{}) error {
+	return s.backend.Delete(key)
+}
+
+// Entries returns the entries decrypted.  The entries that cannot be decrypted are skipped.
+func (s *KV) Entries() (<-chan store.Pair, error) {
+	entries, err := s.backend.Entries()
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan store.Pair)
+	go func() {
+		defer close(out)
+		for entry := range entries {
+			buff, err := s.open(entry.Key, entry.Value)
+			if err != nil {
+				log.Warn("Cannot decrypt", "key", entry.Key, "err", err)
+				continue
+			}
+			out <- store.Pair{Key: entry.Key, Value: buff}
+		}
+	}()
+	return out, nil
+}
+
+// ReadVersion reads and decrypts the value, with its version
+func (s *KV) ReadVersion(key interface{}) ([]byte, uint64, error) {
+	buff, version, err := s.backend.ReadVersion(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	buff, err = s.open(key, buff)
+	return buff, version, err
+}
+
+// Txn encrypts the values of the operations and applies them in a transaction of the backend
+func (s *KV) Txn(cmps []store.Cmp, ops []store.Op) error {
+	sealed := []store.Op{}
+	for _, op := range ops {
+		if !op.Delete {
+			buff, err := s.seal(op.Key, op.Value)
+			if err != nil {
+				return err
+			}
+			op.Value = buff
+		}
+		sealed = append(sealed, op)
+	}
+	return s.backend.Txn(cmps, sealed)
+}
+
+// Watch streams the changes of the backend, with the values decrypted.  The values that cannot be decrypted are
+// streamed as nil.
+func (s *KV) Watch(prefix string) (<-chan store.Event, chan<- struct{}, error) {
+	changes, stop, err := s.backend.Watch(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan store.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		defer close(stop)
+
+		for {
+			select {
+			case <-done:
+				return
+			case event, open := <-changes:
+				if !open {
+					return
+				}
+				buff, err := s.open(event.Key, event.Value)
+				if err != nil {
+					log.Warn("Cannot decrypt", "key", event.Key, "err", err)
+				}
+				event.Value = buff
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return events, done, nil
+}
+
+// Rotated implements store.Rotator.  It returns true if all the values are encrypted with the first key.
+func (s *KV) Rotated() (bool, error) {
+	entries, err := s.backend.Entries()
+	if err != nil {
+		return false, err
+	}
+	rotated := true
+	for entry := range entries {
+		// The entries are drained, so the backend is not blocked
+		e := envelope{}
+		if err := json.Unmarshal(entry.Value, &e); err != nil || !e.Encrypted || !s.keys.current(e) {
+			rotated = false
+		}
+	}
+	return rotated, nil
+}
+
+// Rotate encrypts again with the first key the values encrypted with another key or not encrypted.  The values
+// are swapped at the version read, so the values written meanwhile are left as written.
+func (s *KV) Rotate() error {
+	entries, err := s.backend.Entries()
+	if err != nil {
+		return err
+	}
+	keys := []interface{}{}
+	for entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+
+	for _, key := range keys {
+		raw, version, err := s.backend.ReadVersion(key)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			continue
+		}
+		value, e, err := s.unseal(key, raw)
+		if err != nil {
+			return err
+		}
+		if s.keys.current(e) {
+			continue
+		}
+		buff, err := s.seal(key, value)
+		if err != nil {
+			return err
+		}
+		err = store.CompareAndSwap(s.backend, key, version, buff)
+		if _, is := err.(store.ErrConflict); is {
+			// Written meanwhile, with the current key
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package encrypted
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/store/mem"
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKV(t *testing.T) {
+	testing_store.KV(t, NewKV(mem.NewStore("test"), Keys{testKey(1)}, false))
+}
+
+func TestWatch(t *testing.T) {
+	testing_store.Watch(t, NewKV(mem.NewStore("test"), Keys{testKey(1)}, false))
+}
+
+func TestKVRotate(t *testing.T) {
+	backend := mem.NewStore("test")
+
+	// A value written before it was encrypted
+	require.NoError(t, backend.Write("plain", []byte("secret-1")))
+
+	kv := NewKV(backend, Keys{testKey(1)}, true)
+	require.NoError(t, kv.Write("old", []byte("secret-2")))
+
+	v, err := kv.Read("plain")
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret-1"), v)
+
+	raw, err := backend.Read("old")
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(raw), "secret"))
+
+	rotating := NewKV(backend, Keys{testKey(2), testKey(1)}, true).(*KV)
+	done, err := rotating.Rotated()
+	require.NoError(t, err)
+	require.False(t, done)
+	require.NoError(t, rotating.Rotate())
+	done, err = rotating.Rotated()
+	require.NoError(t, err)
+	require.True(t, done)
+
+	rotated := NewKV(backend, Keys{testKey(2)}, false)
+	for key, value := range map[string]string{"plain": "secret-1", "old": "secret-2"} {
+		v, err := rotated.Read(key)
+		require.NoError(t, err)
+		require.Equal(t, []byte(value), v)
+
+		raw, err := backend.Read(key)
+		require.NoError(t, err)
+		require.False(t, strings.Contains(string(raw), "secret"))
+	}
+
+	_, err = NewKV(backend, Keys{testKey(1)}, false).Read("old")
+	require.Error(t, err)
+}
+
+func TestKVMigrate(t *testing.T) {
+	backend := mem.NewStore("test")
+	require.NoError(t, backend.Write("plain", []byte("secret-1")))
+
+	kv := NewKV(backend, Keys{testKey(1)}, false)
+	_, err := kv.Read("plain")
+	require.Equal(t, ErrNotEncrypted, err)
+
+	// The values are bound to their keys, so they cannot be moved under another key
+	require.NoError(t, kv.Write("a", []byte("secret-2")))
+	raw, err := backend.Read("a")
+	require.NoError(t, err)
+	require.NoError(t, backend.Write("b", raw))
+	_, err = kv.Read("b")
+	require.Error(t, err)
+
+	v, err := kv.Read("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret-2"), v)
+}
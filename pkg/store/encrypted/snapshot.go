@@ -0,0 +1,216 @@
+package encrypted
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// snapshotName is the name the object of a snapshot is encrypted under
+const snapshotName = "snapshot"
+
+// ErrNotConditional is returned when the keys are rotated over a backend that does not implement store.Conditional,
+// since the object encrypted again could overwrite an object saved meanwhile
+var ErrNotConditional = fmt.Errorf("cannot rotate the keys over a backend that is not conditional")
+
+// Snapshot encrypts the objects saved in the backend snapshot
+type Snapshot struct {
+	backend store.Snapshot
+	keys    Keys
+	migrate bool
+
+	// encrypted is true once an encrypted object has been loaded or saved, after which the objects not encrypted
+	// are refused even if migrating
+	encrypted bool
+	lock      sync.Mutex
+}
+
+// NewSnapshot returns a snapshot that encrypts the objects saved in the backend snapshot with AES-GCM.  The objects
+// not encrypted are refused with ErrNotEncrypted, unless migrate is set: an object saved in the backend before it
+// was encrypted is then still loaded, and is encrypted at the next save or by Rotate, until an encrypted object has
+// been seen.  The snapshot implements store.Conditional if the backend does.
+func NewSnapshot(backend store.Snapshot, keys Keys, migrate bool) store.Snapshot {
+	s := &Snapshot{
+		backend: backend,
+		keys:    keys,
+		migrate: migrate,
+	}
+	if c, is := backend.(store.Conditional); is {
+		return &conditional{Snapshot: s, backend: c}
+	}
+	return s
+}
+
+// load returns the object as saved in the backend, and its envelope if it is encrypted
+func (s *Snapshot) load() (*types.Any, envelope, error) {
+	var raw *types.Any
+	if err := s.backend.Load(&raw); err != nil || raw == nil {
+		return nil, envelope{}, err
+	}
+	return raw, envelopeOf(raw), nil
+}
+
+// envelopeOf returns the envelope of the object saved in the backend if it is encrypted
+func envelopeOf(raw *types.Any) envelope {
+	e := envelope{}
+	if err := raw.Decode(&e); err == nil && e.Encrypted {
+		return e
+	}
+	return envelope{}
+}
+
+// seen records that an encrypted object has been seen
+func (s *Snapshot) seen() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.encrypted = true
+}
+
+// plaintext returns the object as saved in the backend if it is not encrypted, or ErrNotEncrypted if the objects
+// not encrypted are refused
+func (s *Snapshot) plaintext(raw *types.Any) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.migrate || s.encrypted {
+		log.Warn("Refusing an object not encrypted", "migrate", s.migrate)
+		return nil, ErrNotEncrypted
+	}
+	return raw.Bytes(), nil
+}
+
+// open returns the object saved in the backend, decrypted if it is encrypted
+func (s *Snapshot) open(raw *types.Any, e envelope) ([]byte, error) {
+	if !e.Encrypted {
+		// The object was saved before it was encrypted
+		return s.plaintext(raw)
+	}
+	buff, err := s.keys.open(e, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	s.seen()
+	return buff, nil
+}
+
+func (s *Snapshot) seal(obj interface{}) (envelope, error) {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return envelope{}, err
+	}
+	return s.keys.seal(any.Bytes(), snapshotName)
+}
+
+// Save implements store.Snapshot.Save
+func (s *Snapshot) Save(obj interface{}) error {
+	e, err := s.seal(obj)
+	if err != nil {
+		return err
+	}
+	if err := s.backend.Save(e); err != nil {
+		return err
+	}
+	s.seen()
+	return nil
+}
+
+// Load implements store.Snapshot.Load
+func (s *Snapshot) Load(output interface{}) error {
+	raw, e, err := s.load()
+	if err != nil || raw == nil {
+		return err
+	}
+	buff, err := s.open(raw, e)
+	if err != nil {
+		return err
+	}
+	return types.AnyBytes(buff).Decode(output)
+}
+
+// Rotated implements store.Rotator.  It returns true if the object is encrypted with the first key.
+func (s *Snapshot) Rotated() (bool, error) {
+	raw, e, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	return raw == nil || s.keys.current(e), nil
+}
+
+// Rotate implements store.Rotator.  The object is encrypted again only over a backend that implements
+// store.Conditional, so ErrNotConditional is returned if the object is encrypted with another key or not encrypted.
+func (s *Snapshot) Rotate() error {
+	rotated, err := s.Rotated()
+	if err != nil || rotated {
+		return err
+	}
+	return ErrNotConditional
+}
+
+// Watch implements store.Watchable if the backend does
+func (s *Snapshot) Watch() (<-chan store.Event, chan<- struct{}, error) {
+	return store.WatchSnapshot(s.backend)
+}
+
+// Close implements io.Closer
+func (s *Snapshot) Close() error {
+	return s.backend.Close()
+}
+
+// conditional is the snapshot over a backend that implements store.Conditional
+type conditional struct {
+	*Snapshot
+	backend store.Conditional
+}
+
+// LoadVersion implements store.Conditional
+func (c *conditional) LoadVersion(output interface{}) (uint64, error) {
+	var raw *types.Any
+	version, err := c.backend.LoadVersion(&raw)
+	if err != nil || raw == nil {
+		return version, err
+	}
+	buff, err := c.open(raw, envelopeOf(raw))
+	if err != nil {
+		return version, err
+	}
+	return version, types.AnyBytes(buff).Decode(output)
+}
+
+// SaveVersion implements store.Conditional
+func (c *conditional) SaveVersion(obj interface{}, version uint64) error {
+	e, err := c.seal(obj)
+	if err != nil {
+		return err
+	}
+	if err := c.backend.SaveVersion(e, version); err != nil {
+		return err
+	}
+	c.seen()
+	return nil
+}
+
+// Rotate encrypts the object again with the first key, if it is encrypted with another key or not encrypted.  The
+// object is saved again only if it has not been saved meanwhile.
+func (c *conditional) Rotate() error {
+	var raw *types.Any
+	version, err := c.backend.LoadVersion(&raw)
+	if err != nil || raw == nil {
+		return err
+	}
+	e := envelopeOf(raw)
+	if c.keys.current(e) {
+		return nil
+	}
+	buff, err := c.open(raw, e)
+	if err != nil {
+		return err
+	}
+	log.Info("Encrypting snapshot with the current key")
+	err = c.SaveVersion(types.AnyBytes(buff), version)
+	if _, is := err.(store.ErrConflict); is {
+		// Saved meanwhile, with the current key
+		return nil
+	}
+	return err
+}
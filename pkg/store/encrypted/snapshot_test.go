@@ -0,0 +1,158 @@
+package encrypted
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/file"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Groups []string
+}
+
+func testKey(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	keys, err := ParseKeys("# current key first\n" + k2 + "\n\n" + k1 + "\n")
+	require.NoError(t, err)
+	require.Equal(t, Keys{testKey(2), testKey(1)}, keys)
+
+	keys, err = ParseKeys(k2 + "," + k1)
+	require.NoError(t, err)
+	require.Equal(t, Keys{testKey(2), testKey(1)}, keys)
+
+	_, err = ParseKeys("")
+	require.Error(t, err)
+
+	_, err = ParseKeys(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+
+	_, err = ParseKeys("not base64!")
+	require.Error(t, err)
+}
+
+func TestEncryptedSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-encrypted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	// An object saved before it was encrypted
+	require.NoError(t, backend.Save(config{Groups: []string{"workers"}}))
+
+	s := NewSnapshot(backend, Keys{testKey(1)}, true)
+
+	loaded := config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+
+	require.NoError(t, s.Save(config{Groups: []string{"managers"}}))
+
+	buff, err := ioutil.ReadFile(filepath.Join(dir, "global.config"))
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(buff), "managers"))
+
+	loaded = config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+
+	// Cannot be loaded without the key
+	require.Error(t, NewSnapshot(backend, Keys{testKey(2)}, false).Load(&loaded))
+
+	// Empty
+	empty, err := file.NewSnapshot(dir, "empty.config")
+	require.NoError(t, err)
+	var nothing *config
+	require.NoError(t, NewSnapshot(empty, Keys{testKey(1)}, false).Load(&nothing))
+	require.Nil(t, nothing)
+}
+
+func TestEncryptedSnapshotRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-encrypted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	require.NoError(t, NewSnapshot(backend, Keys{testKey(1)}, false).Save(config{Groups: []string{"workers"}}))
+
+	// The new key is added first; the old key still decrypts
+	rotating := NewSnapshot(backend, Keys{testKey(2), testKey(1)}, false).(*conditional)
+	loaded := config{}
+	require.NoError(t, rotating.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+
+	require.NoError(t, rotating.Rotate())
+
+	// The old key can be removed
+	loaded = config{}
+	require.NoError(t, NewSnapshot(backend, Keys{testKey(2)}, false).Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+	require.Error(t, NewSnapshot(backend, Keys{testKey(1)}, false).Load(&loaded))
+}
+
+func TestEncryptedSnapshotMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-encrypted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	require.NoError(t, backend.Save(config{Groups: []string{"workers"}}))
+
+	// The objects not encrypted are refused unless migrating
+	loaded := config{}
+	require.Equal(t, ErrNotEncrypted, NewSnapshot(backend, Keys{testKey(1)}, false).Load(&loaded))
+
+	// Once an encrypted object has been seen, the objects not encrypted are refused even if migrating
+	s := NewSnapshot(backend, Keys{testKey(1)}, true)
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+	require.NoError(t, s.Save(config{Groups: []string{"managers"}}))
+	require.NoError(t, backend.Save(config{Groups: []string{"workers"}}))
+	require.Equal(t, ErrNotEncrypted, s.Load(&loaded))
+}
+
+// unconditional hides the conditional methods of the backend
+type unconditional struct {
+	store.Snapshot
+}
+
+func TestEncryptedSnapshotRotateUnconditional(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-encrypted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	require.NoError(t, NewSnapshot(backend, Keys{testKey(1)}, false).Save(config{Groups: []string{"workers"}}))
+
+	// Nothing to rotate
+	require.NoError(t, NewSnapshot(unconditional{backend}, Keys{testKey(1)}, false).(store.Rotator).Rotate())
+
+	// The object is not saved again, since it could overwrite an object saved meanwhile
+	rotating := NewSnapshot(unconditional{backend}, Keys{testKey(2), testKey(1)}, false).(store.Rotator)
+	require.Equal(t, ErrNotConditional, rotating.Rotate())
+	rotated, err := rotating.Rotated()
+	require.NoError(t, err)
+	require.False(t, rotated)
+}
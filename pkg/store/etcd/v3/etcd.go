@@ -1,6 +1,7 @@
 package etcd
 
 import (
+	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/store"
@@ -13,6 +14,9 @@ const (
 
 	// DefaultKey is the key used to persist the config.
 	DefaultKey = "infrakit/configs/groups.json"
+
+	// RevisionsPrefix is the prefix of the keys of the revisions of the config.
+	RevisionsPrefix = "infrakit/configs/revisions"
 )
 
 var log = logutil.New("module", "etcd/store")
@@ -96,6 +100,57 @@ func (s *snapshot) Load(output interface{}) error {
 	return any.Decode(&output)
 }
 
+// LoadVersion implements store.Conditional.  The version is the revision of the last change of the key.
+func (s *snapshot) LoadVersion(output interface{}) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	resp, err := s.client.Client.Get(ctx, s.key)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return 0, nil
+	}
+	return uint64(resp.Kvs[0].ModRevision), types.AnyBytes(resp.Kvs[0].Value).Decode(output)
+}
+
+// SaveVersion implements store.Conditional, in an etcd transaction
+func (s *snapshot) SaveVersion(obj interface{}, version uint64) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	defer cancel()
+	resp, err := s.client.Client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(s.key), "=", int64(version))).
+		Then(clientv3.OpPut(s.key, any.String())).
+		Else(clientv3.OpGet(s.key)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if resp.Succeeded {
+		return nil
+	}
+	actual := uint64(0)
+	if kvs := resp.Responses[0].GetResponseRange().Kvs; len(kvs) > 0 {
+		actual = uint64(kvs[0].ModRevision)
+	}
+	return store.ErrConflict{Key: s.key, Expected: version, Actual: actual}
+}
+
+// Watch implements store.Watchable
+func (s *snapshot) Watch() (<-chan store.Event, chan<- struct{}, error) {
+	return watch(s.client, s.key, func(change *clientv3.Event) store.Event {
+		if change.Type == clientv3.EventTypeDelete {
+			return store.Event{Type: store.EventDelete, Key: s.key}
+		}
+		return store.Event{Type: store.EventPut, Key: s.key, Version: uint64(change.Kv.ModRevision)}
+	})
+}
+
 // Close releases the resources and closes the connection to etcd
 func (s *snapshot) Close() error {
 	if s.client == nil {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/coreos/etcd/clientv3"
 	testutil "github.com/docker/infrakit/pkg/testing"
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/docker/infrakit/pkg/util/etcd/v3"
 	"github.com/stretchr/testify/require"
@@ -37,6 +38,26 @@ func TestWithRealEtcd(t *testing.T) {
 	defer etcd.StopContainer.Start(containerName)
 
 	t.Run("SaveLoad", testSaveLoad)
+	t.Run("KV", testKV)
+}
+
+func testKV(t *testing.T) {
+
+	if testutil.SkipTests("etcd") {
+		t.SkipNow()
+	}
+
+	etcdClient, err := etcd.NewClient(etcd.Options{
+		Config: clientv3.Config{
+			Endpoints: []string{etcd.LocalIP() + ":2379"},
+		},
+		RequestTimeout: 1 * time.Second,
+	})
+	require.NoError(t, err)
+	defer etcdClient.Close()
+
+	testing_store.KV(t, NewKV(etcdClient, "test/kv"))
+	testing_store.Watch(t, NewKV(etcdClient, "test/watch"))
 }
 
 func testSaveLoad(t *testing.T) {
@@ -0,0 +1,165 @@
+package etcd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/util/etcd/v3"
+	"golang.org/x/net/context"
+)
+
+// KV stores the kv pairs in etcd, with the keys under a prefix.  The versions are the etcd mod revisions of the
+// keys.
+type KV struct {
+	client *etcd.Client
+	prefix string
+}
+
+// NewKV returns a kv store given the client and the prefix of the keys
+func NewKV(client *etcd.Client, prefix string) store.KV {
+	return &KV{client: client, prefix: prefix}
+}
+
+// Close implements io.Closer.  The client is shared, so it is not closed.
+func (s *KV) Close() error {
+	return nil
+}
+
+// Key returns an id given the key.
+func (s *KV) Key(key interface{}) string {
+	return fmt.Sprintf("%v/%v", s.prefix, key)
+}
+
+func (s *KV) get(key interface{}) (*clientv3.GetResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	defer cancel()
+	return s.client.Client.Get(ctx, s.Key(key))
+}
+
+// Write writes the object
+func (s *KV) Write(key interface{}, value []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	defer cancel()
+	_, err := s.client.Client.Put(ctx, s.Key(key), string(value))
+	return err
+}
+
+// Read loads the object
+func (s *KV) Read(key interface{}) ([]byte, error) {
+	value, version, err := s.ReadVersion(key)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("not found %v", key)
+	}
+	return value, nil
+}
+
+// Exists checks for existence
+func (s *KV) Exists(key interface{}) (bool, error) {
+	_, version, err := s.ReadVersion(key)
+	return version > 0, err
+}
+
+// Delete deletes the object by id
+func (s *KV) Delete(key interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	defer cancel()
+	resp, err := s.client.Client.Delete(ctx, s.Key(key))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return fmt.Errorf("not found %v", key)
+	}
+	return nil
+}
+
+// Entries returns the entries
+func (s *KV) Entries() (<-chan store.Pair, error) {
+	prefix := s.Key("")
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	defer cancel()
+	resp, err := s.client.Client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan store.Pair)
+	go func() {
+		defer close(out)
+		for _, kv := range resp.Kvs {
+			out <- store.Pair{Key: strings.TrimPrefix(string(kv.Key), prefix), Value: kv.Value}
+		}
+	}()
+	return out, nil
+}
+
+// ReadVersion loads the object and its version
+func (s *KV) ReadVersion(key interface{}) ([]byte, uint64, error) {
+	resp, err := s.get(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+	return resp.Kvs[0].Value, uint64(resp.Kvs[0].ModRevision), nil
+}
+
+// Txn applies the operations in an etcd transaction if the keys compared are at their versions
+func (s *KV) Txn(cmps []store.Cmp, ops []store.Op) error {
+	conditions := []clientv3.Cmp{}
+	gets := []clientv3.Op{}
+	for _, cmp := range cmps {
+		k := s.Key(cmp.Key)
+		conditions = append(conditions, clientv3.Compare(clientv3.ModRevision(k), "=", int64(cmp.Version)))
+		gets = append(gets, clientv3.OpGet(k))
+	}
+
+	then := []clientv3.Op{}
+	for _, op := range ops {
+		if op.Delete {
+			then = append(then, clientv3.OpDelete(s.Key(op.Key)))
+			continue
+		}
+		then = append(then, clientv3.OpPut(s.Key(op.Key), string(op.Value)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	defer cancel()
+	resp, err := s.client.Client.Txn(ctx).If(conditions...).Then(then...).Else(gets...).Commit()
+	if err != nil {
+		return err
+	}
+	if resp.Succeeded {
+		return nil
+	}
+
+	// Report the first key that has changed
+	for i, cmp := range cmps {
+		actual := uint64(0)
+		if kvs := resp.Responses[i].GetResponseRange().Kvs; len(kvs) > 0 {
+			actual = uint64(kvs[0].ModRevision)
+		}
+		if actual != cmp.Version {
+			return store.ErrConflict{Key: cmp.Key, Expected: cmp.Version, Actual: actual}
+		}
+	}
+	return store.ErrConflict{}
+}
+
+// Watch streams the changes of the keys that start with the prefix, as watched by etcd
+func (s *KV) Watch(prefix string) (<-chan store.Event, chan<- struct{}, error) {
+	root := s.Key("")
+	return watch(s.client, s.Key(prefix), func(change *clientv3.Event) store.Event {
+		key := strings.TrimPrefix(string(change.Kv.Key), root)
+		if change.Type == clientv3.EventTypeDelete {
+			return store.Event{Type: store.EventDelete, Key: key}
+		}
+		return store.Event{Type: store.EventPut, Key: key, Value: change.Kv.Value, Version: uint64(change.Kv.ModRevision)}
+	}, clientv3.WithPrefix())
+}
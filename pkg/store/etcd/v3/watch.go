@@ -0,0 +1,49 @@
+package etcd
+
+import (
+	"github.com/coreos/etcd/clientv3"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/util/etcd/v3"
+	"golang.org/x/net/context"
+)
+
+// watch streams the events of the changes of the key, until done is closed.  The convert function returns the
+// event of a change of etcd.
+func watch(client *etcd.Client, key string, convert func(*clientv3.Event) store.Event,
+	options ...clientv3.OpOption) (<-chan store.Event, chan<- struct{}, error) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := client.Client.Watch(ctx, key, options...)
+
+	events := make(chan store.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		defer cancel()
+
+		for {
+			select {
+
+			case <-done:
+				return
+
+			case resp, open := <-changes:
+				if !open {
+					return
+				}
+				if err := resp.Err(); err != nil {
+					log.Warn("Error watching", "key", key, "err", err)
+					continue
+				}
+				for _, change := range resp.Events {
+					select {
+					case events <- convert(change):
+					case <-done:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return events, done, nil
+}
@@ -0,0 +1,195 @@
+package fenced
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/leader"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var log = logutil.New("module", "store/fenced")
+
+// envelope is what is saved in the backend snapshot
+type envelope struct {
+	// Fenced tells the envelope apart from objects saved in the backend before any epoch was kept.
+	Fenced bool
+
+	// Epoch is the epoch of the leader that saved the object
+	Epoch uint64
+
+	Object *types.Any
+}
+
+type snapshot struct {
+	backend store.Snapshot
+	epoch   func() uint64
+	lock    sync.Mutex
+}
+
+// NewSnapshot returns a snapshot that fences the writes to the backend snapshot by the epoch of the leadership.
+// The epoch func returns the epoch of the writer at the time of the write.  Save fails with leader.ErrStaleEpoch if
+// an object has been saved with a higher epoch, so a leader that has been replaced cannot overwrite the objects
+// saved by its successor.  If the backend implements store.Conditional, the epoch is checked and saved atomically
+// in the backend; otherwise the check is only atomic within a process.  If epoch is nil the writes are not fenced,
+// and are passed to the backend as they are.  The objects saved with or without an epoch are loaded alike.
+func NewSnapshot(backend store.Snapshot, epoch func() uint64) store.Snapshot {
+	return &snapshot{
+		backend: backend,
+		epoch:   epoch,
+	}
+}
+
+func (s *snapshot) load() (envelope, error) {
+	var raw *types.Any
+	if err := s.backend.Load(&raw); err != nil {
+		return envelope{}, err
+	}
+	return envelopeOf(raw), nil
+}
+
+// envelopeOf returns the envelope of the object saved in the backend
+func envelopeOf(raw *types.Any) envelope {
+	if raw == nil {
+		return envelope{}
+	}
+
+	e := envelope{}
+	if err := raw.Decode(&e); err == nil && e.Fenced {
+		return e
+	}
+
+	// The object was saved without an epoch
+	return envelope{Object: raw}
+}
+
+// check returns an ErrStaleEpoch if the epoch is lower than the epoch of the object saved
+func check(epoch uint64, current envelope) error {
+	if epoch < current.Epoch {
+		log.Warn("Rejecting save with stale epoch", "epoch", epoch, "current", current.Epoch)
+		return leader.ErrStaleEpoch{Epoch: epoch, Current: current.Epoch}
+	}
+	return nil
+}
+
+// Save implements store.Snapshot.Save
+func (s *snapshot) Save(obj interface{}) error {
+	if s.epoch == nil {
+		return s.backend.Save(obj)
+	}
+
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if conditional, is := s.backend.(store.Conditional); is {
+		return s.saveVersion(conditional, any)
+	}
+
+	current, err := s.load()
+	if err != nil {
+		return err
+	}
+	epoch := s.epoch()
+	if err := check(epoch, current); err != nil {
+		return err
+	}
+	return s.backend.Save(envelope{Fenced: true, Epoch: epoch, Object: any})
+}
+
+// saveVersion saves the object only if the object checked has not been replaced in the meantime.  The check is
+// done again if another writer has saved first.
+func (s *snapshot) saveVersion(backend store.Conditional, any *types.Any) error {
+	for {
+		var raw *types.Any
+		version, err := backend.LoadVersion(&raw)
+		if err != nil {
+			return err
+		}
+		epoch := s.epoch()
+		if err := check(epoch, envelopeOf(raw)); err != nil {
+			return err
+		}
+		err = backend.SaveVersion(envelope{Fenced: true, Epoch: epoch, Object: any}, version)
+		if _, is := err.(store.ErrConflict); is {
+			log.Warn("Saved by another writer, checking the epoch again", "version", version, "err", err)
+			continue
+		}
+		return err
+	}
+}
+
+// Load implements store.Snapshot.Load
+func (s *snapshot) Load(output interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, err := s.load()
+	if err != nil {
+		return err
+	}
+	if e.Object == nil {
+		return nil
+	}
+	return e.Object.Decode(output)
+}
+
+// Watch implements store.Watchable if the backend does
+func (s *snapshot) Watch() (<-chan store.Event, chan<- struct{}, error) {
+	return store.WatchSnapshot(s.backend)
+}
+
+// Rotated implements store.Rotator if the backend does
+func (s *snapshot) Rotated() (bool, error) {
+	return store.Rotated(s.backend)
+}
+
+// Rotate implements store.Rotator if the backend does.  The object is encrypted again by saving it with the epoch
+// of the writer, and only if it has not been saved meanwhile, so a leader that has been replaced cannot rotate the
+// keys.  The backend must implement store.Conditional for the rotation to be fenced.
+func (s *snapshot) Rotate() error {
+	if s.epoch == nil {
+		return store.Rotate(s.backend)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rotated, err := store.Rotated(s.backend)
+	if err != nil || rotated {
+		return err
+	}
+	conditional, is := s.backend.(store.Conditional)
+	if !is {
+		return fmt.Errorf("cannot rotate the keys over a backend that is not conditional")
+	}
+
+	var raw *types.Any
+	version, err := conditional.LoadVersion(&raw)
+	if err != nil || raw == nil {
+		return err
+	}
+	current := envelopeOf(raw)
+	epoch := s.epoch()
+	if err := check(epoch, current); err != nil {
+		return err
+	}
+	log.Info("Saving the object again to rotate the keys", "epoch", epoch)
+	err = conditional.SaveVersion(envelope{Fenced: true, Epoch: epoch, Object: current.Object}, version)
+	if _, is := err.(store.ErrConflict); is {
+		// Saved meanwhile, with the current key
+		return nil
+	}
+	return err
+}
+
+// Close implements io.Closer
+func (s *snapshot) Close() error {
+	return s.backend.Close()
+}
@@ -0,0 +1,198 @@
+package fenced
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/encrypted"
+	"github.com/docker/infrakit/pkg/store/file"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Groups []string
+}
+
+func TestFencedSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-fenced")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	// An object saved before keeping epochs
+	require.NoError(t, backend.Save(config{Groups: []string{"workers"}}))
+
+	epochA, epochB := uint64(1), uint64(0)
+	a := NewSnapshot(backend, func() uint64 { return epochA })
+	b := NewSnapshot(backend, func() uint64 { return epochB })
+
+	loaded := config{}
+	require.NoError(t, a.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+
+	require.NoError(t, a.Save(config{Groups: []string{"workers", "managers"}}))
+
+	// b takes over with a new epoch
+	epochB = 2
+	require.NoError(t, b.Save(config{Groups: []string{"managers"}}))
+
+	// a has been replaced but still acts as the leader
+	err = a.Save(config{Groups: []string{"workers"}})
+	require.Equal(t, leader.ErrStaleEpoch{Epoch: 1, Current: 2}, err)
+
+	loaded = config{}
+	require.NoError(t, a.Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+}
+
+func TestFencedSnapshotEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-fenced")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	s := NewSnapshot(backend, func() uint64 { return 0 })
+
+	var loaded *config
+	require.NoError(t, s.Load(&loaded))
+	require.Nil(t, loaded)
+
+	require.NoError(t, s.Save(config{Groups: []string{"workers"}}))
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+}
+
+func TestUnfencedSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-fenced")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	require.NoError(t, NewSnapshot(backend, func() uint64 { return 5 }).Save(config{Groups: []string{"workers"}}))
+
+	// The fenced objects are loaded without fencing
+	s := NewSnapshot(backend, nil)
+	loaded := config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+
+	// Writes are not fenced, and are saved in the backend as they are
+	require.NoError(t, s.Save(config{Groups: []string{"managers"}}))
+
+	loaded = config{}
+	require.NoError(t, backend.Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+
+	loaded = config{}
+	require.NoError(t, NewSnapshot(backend, func() uint64 { return 4 }).Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+}
+
+// racing saves an object with another epoch right after the object is loaded for the next save
+type racing struct {
+	store.Snapshot
+	conditional store.Conditional
+	race        func()
+}
+
+func (r *racing) LoadVersion(output interface{}) (uint64, error) {
+	version, err := r.conditional.LoadVersion(output)
+	if r.race != nil {
+		r.race()
+		r.race = nil
+	}
+	return version, err
+}
+
+func (r *racing) SaveVersion(obj interface{}, version uint64) error {
+	return r.conditional.SaveVersion(obj, version)
+}
+
+func TestFencedSnapshotConditional(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-fenced")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	a := NewSnapshot(&racing{
+		Snapshot:    backend,
+		conditional: backend.(store.Conditional),
+		race: func() {
+			// The successor saves in another process, between the check and the save of a
+			require.NoError(t, NewSnapshot(backend, func() uint64 { return 2 }).Save(config{Groups: []string{"managers"}}))
+		},
+	}, func() uint64 { return 1 })
+
+	err = a.Save(config{Groups: []string{"workers"}})
+	require.Equal(t, leader.ErrStaleEpoch{Epoch: 1, Current: 2}, err)
+
+	loaded := config{}
+	require.NoError(t, NewSnapshot(backend, nil).Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+}
+
+func testKey(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+// unconditional hides the conditional methods of the backend
+type unconditional struct {
+	store.Snapshot
+}
+
+func TestFencedSnapshotRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-fenced")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	epochA, epochB := uint64(1), uint64(2)
+	require.NoError(t, NewSnapshot(encrypted.NewSnapshot(backend, encrypted.Keys{testKey(1)}, false),
+		func() uint64 { return epochA }).Save(config{Groups: []string{"workers"}}))
+
+	rotating := encrypted.Keys{testKey(2), testKey(1)}
+	a := NewSnapshot(encrypted.NewSnapshot(backend, rotating, false), func() uint64 { return epochA })
+	b := NewSnapshot(encrypted.NewSnapshot(backend, rotating, false), func() uint64 { return epochB })
+
+	// Not rotated over a backend that is not conditional
+	require.Error(t, NewSnapshot(encrypted.NewSnapshot(unconditional{backend}, rotating, false),
+		func() uint64 { return epochB }).(store.Rotator).Rotate())
+
+	// Another leader takes over with a new epoch, and saves with the old key
+	require.NoError(t, NewSnapshot(encrypted.NewSnapshot(backend, encrypted.Keys{testKey(1)}, false),
+		func() uint64 { return epochB }).Save(config{Groups: []string{"managers"}}))
+
+	// a has been replaced, so it cannot rotate the keys
+	require.Equal(t, leader.ErrStaleEpoch{Epoch: 1, Current: 2}, a.(store.Rotator).Rotate())
+	require.Error(t, NewSnapshot(encrypted.NewSnapshot(backend, encrypted.Keys{testKey(2)}, false), nil).
+		Load(&config{}))
+
+	require.NoError(t, b.(store.Rotator).Rotate())
+	rotated, err := store.Rotated(b)
+	require.NoError(t, err)
+	require.True(t, rotated)
+
+	// The old key can be removed, and the epoch is kept
+	loaded := config{}
+	require.NoError(t, NewSnapshot(encrypted.NewSnapshot(backend, encrypted.Keys{testKey(2)}, false), nil).
+		Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+	require.Equal(t, leader.ErrStaleEpoch{Epoch: 1, Current: 2}, a.Save(config{Groups: []string{"workers"}}))
+}
@@ -1,6 +1,7 @@
 package file
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,6 +11,8 @@ import (
 
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/util/flock"
+	"gopkg.in/fsnotify.v1"
 	"math/rand"
 )
 
@@ -48,6 +51,114 @@ func (s *Store) Close() error {
 	return nil
 }
 
+// versions is the index of the versions of the entries.  It is kept in a hidden file next to the entries.
+type versions struct {
+	// Revision is the last version given to an entry
+	Revision uint64
+
+	// Versions are the versions of the entries, by id
+	Versions map[string]uint64
+
+	changed bool
+}
+
+func (v *versions) remove(id string) {
+	delete(v.Versions, id)
+	v.changed = true
+}
+
+// legacyVersion is the version of the entries written before the versions were kept
+const legacyVersion = 1
+
+// locked runs the function holding the lock of the store, with the index of the versions.  The index is saved
+// after the function if changed, even if the function fails part way.  The lock is a file lock, so the processes sharing the directory
+// are serialized as well.
+func (s *Store) locked(f func(*versions) error) error {
+	lock, err := os.OpenFile(filepath.Join(s.Dir, "."+s.t+".lock"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := flock.Lock(lock); err != nil {
+		return err
+	}
+	defer flock.Unlock(lock)
+
+	index := &versions{Versions: map[string]uint64{}}
+	fp := filepath.Join(s.Dir, "."+s.t+".versions")
+	buff, err := ioutil.ReadFile(fp)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(buff, index); err != nil {
+			return err
+		}
+		if index.Versions == nil {
+			index.Versions = map[string]uint64{}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	if index.Revision < legacyVersion {
+		index.Revision = legacyVersion
+	}
+
+	ferr := f(index)
+	if index.changed {
+		buff, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(fp, buff); err != nil {
+			return err
+		}
+	}
+	return ferr
+}
+
+// writeFile writes to a hidden temporary file then renames it, so readers never see a partial file.
+func writeFile(fp string, buff []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(fp), ".tmp-"+filepath.Base(fp))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(buff)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fp)
+}
+
+// version returns the version of the entry, or 0 if it does not exist
+func (s *Store) version(index *versions, id string) (uint64, error) {
+	if _, err := os.Stat(filepath.Join(s.Dir, id)); os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if v, has := index.Versions[id]; has {
+		return v, nil
+	}
+	return legacyVersion, nil
+}
+
+func (s *Store) put(index *versions, id string, value []byte) error {
+	if err := writeFile(filepath.Join(s.Dir, id), value); err != nil {
+		return err
+	}
+	index.Revision++
+	index.Versions[id] = index.Revision
+	index.changed = true
+	return nil
+}
+
 // Write writes the object and returns an id or error.
 func (s *Store) Write(key interface{}, value []byte) error {
 	if key == nil {
@@ -55,7 +166,9 @@ func (s *Store) Write(key interface{}, value []byte) error {
 	}
 	id := s.IDFunc(key)
 	log.Debug("Write", "id", id, "value", string(value), "V", logV)
-	return ioutil.WriteFile(filepath.Join(s.Dir, string(id)), value, 0644)
+	return s.locked(func(index *versions) error {
+		return s.put(index, id, value)
+	})
 }
 
 // Key returns an id given the key. The id contains type, etc.
@@ -89,7 +202,55 @@ func (s *Store) Delete(key interface{}) error {
 	id := s.Key(key)
 	fp := filepath.Join(s.Dir, id)
 	log.Debug("Delete", "key", key, "file", fp, "V", logV)
-	return os.Remove(fp)
+	return s.locked(func(index *versions) error {
+		index.remove(id)
+		return os.Remove(fp)
+	})
+}
+
+// ReadVersion loads the object and its version
+func (s *Store) ReadVersion(key interface{}) (value []byte, version uint64, err error) {
+	id := s.Key(key)
+	err = s.locked(func(index *versions) error {
+		version, err = s.version(index, id)
+		if err != nil || version == 0 {
+			return err
+		}
+		value, err = ioutil.ReadFile(filepath.Join(s.Dir, id))
+		return err
+	})
+	return
+}
+
+// Txn applies the operations if the keys compared are at their versions.  The transaction is atomic with respect
+// to the other users of the store, which are serialized by a file lock, but the entries are separate files, so a
+// crash in the middle of a transaction may leave only some of the operations applied.
+func (s *Store) Txn(cmps []store.Cmp, ops []store.Op) error {
+	return s.locked(func(index *versions) error {
+		for _, cmp := range cmps {
+			actual, err := s.version(index, s.Key(cmp.Key))
+			if err != nil {
+				return err
+			}
+			if actual != cmp.Version {
+				return store.ErrConflict{Key: cmp.Key, Expected: cmp.Version, Actual: actual}
+			}
+		}
+		for _, op := range ops {
+			id := s.Key(op.Key)
+			if op.Delete {
+				index.remove(id)
+				if err := os.Remove(filepath.Join(s.Dir, id)); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := s.put(index, id, op.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Entries returns the entries
@@ -128,3 +289,35 @@ func (s *Store) Entries() (<-chan store.Pair, error) {
 	}()
 	return out, nil
 }
+
+// Watch streams the changes of the keys that start with the prefix, as notified by the file system
+func (s *Store) Watch(prefix string) (<-chan store.Event, chan<- struct{}, error) {
+	return watch(s.Dir, func(change fsnotify.Event) (event store.Event, ok bool) {
+		name := filepath.Base(change.Name)
+		if !strings.HasPrefix(name, s.t+"-") {
+			return
+		}
+		key := strings.TrimPrefix(name, s.t+"-")
+		if !strings.HasPrefix(key, prefix) {
+			return
+		}
+
+		switch {
+		case change.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			return store.Event{Type: store.EventDelete, Key: key}, true
+
+		case change.Op&(fsnotify.Create|fsnotify.Write) != 0:
+			value, version, err := s.ReadVersion(key)
+			if err != nil {
+				log.Warn("Cannot read change", "key", key, "err", err)
+				return
+			}
+			if version == 0 {
+				// Removed since
+				return
+			}
+			return store.Event{Type: store.EventPut, Key: key, Value: value, Version: version}, true
+		}
+		return
+	})
+}
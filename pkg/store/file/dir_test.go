@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/docker/infrakit/pkg/store"
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/stretchr/testify/require"
 )
@@ -97,3 +98,11 @@ func TestFiles(t *testing.T) {
 	}
 
 }
+
+func TestKV(t *testing.T) {
+	testing_store.KV(t, NewStore(fmt.Sprintf("dirtest-%v", rand.Int63()), os.TempDir()))
+}
+
+func TestWatch(t *testing.T) {
+	testing_store.Watch(t, NewStore(fmt.Sprintf("dirtest-%v", rand.Int63()), os.TempDir()))
+}
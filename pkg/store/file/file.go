@@ -2,12 +2,15 @@ package file
 
 import (
 	"encoding/json"
+	"hash/fnv"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/docker/infrakit/pkg/run/local"
 	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/util/flock"
+	"gopkg.in/fsnotify.v1"
 )
 
 type snapshot struct {
@@ -31,7 +34,7 @@ func (s *snapshot) Save(obj interface{}) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filepath.Join(s.dir, s.name), buff, 0644)
+	return writeFile(filepath.Join(s.dir, s.name), buff)
 }
 
 // Load loads a snapshot and marshals into the given reference
@@ -47,7 +50,89 @@ func (s *snapshot) Load(output interface{}) error {
 	return nil
 }
 
+// locked runs the function holding the lock of the snapshot.  The lock is a file lock, so the processes sharing the
+// directory are serialized as well.
+func (s *snapshot) locked(f func() error) error {
+	lock, err := os.OpenFile(filepath.Join(s.dir, "."+s.name+".lock"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := flock.Lock(lock); err != nil {
+		return err
+	}
+	defer flock.Unlock(lock)
+	return f()
+}
+
+// read returns the content of the file and its version, a hash of the content.  The version is 0 if the file does
+// not exist.
+func (s *snapshot) read() ([]byte, uint64, error) {
+	buff, err := ioutil.ReadFile(filepath.Join(s.dir, s.name))
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	hash := fnv.New64a()
+	hash.Write(buff)
+	version := hash.Sum64()
+	if version == 0 {
+		version = 1
+	}
+	return buff, version, nil
+}
+
+// LoadVersion implements store.Conditional.  The version is a hash of the content of the file.
+func (s *snapshot) LoadVersion(output interface{}) (version uint64, err error) {
+	var buff []byte
+	err = s.locked(func() error {
+		buff, version, err = s.read()
+		return err
+	})
+	if err != nil || version == 0 {
+		return version, err
+	}
+	return version, json.Unmarshal(buff, output)
+}
+
+// SaveVersion implements store.Conditional
+func (s *snapshot) SaveVersion(obj interface{}, version uint64) error {
+	buff, err := json.MarshalIndent(obj, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	return s.locked(func() error {
+		_, actual, err := s.read()
+		if err != nil {
+			return err
+		}
+		if actual != version {
+			return store.ErrConflict{Key: s.name, Expected: version, Actual: actual}
+		}
+		return writeFile(filepath.Join(s.dir, s.name), buff)
+	})
+}
+
 // Close implements Closer
 func (s *snapshot) Close() error {
 	return nil
 }
+
+// Watch implements store.Watchable.  The changes are notified by the file system, so edits of the file by
+// other processes are seen.
+func (s *snapshot) Watch() (<-chan store.Event, chan<- struct{}, error) {
+	return watch(s.dir, func(change fsnotify.Event) (event store.Event, ok bool) {
+		if filepath.Base(change.Name) != s.name {
+			return
+		}
+		switch {
+		case change.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			return store.Event{Type: store.EventDelete, Key: s.name}, true
+		case change.Op&(fsnotify.Create|fsnotify.Write) != 0:
+			return store.Event{Type: store.EventPut, Key: s.name}, true
+		}
+		return
+	})
+}
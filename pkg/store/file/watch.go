@@ -0,0 +1,57 @@
+package file
+
+import (
+	"github.com/docker/infrakit/pkg/store"
+	"gopkg.in/fsnotify.v1"
+)
+
+// watch streams the events of the changes of the files in the directory, until done is closed.  The filter
+// returns the event of a change, or false to ignore the change.
+func watch(dir string, filter func(fsnotify.Event) (store.Event, bool)) (<-chan store.Event, chan<- struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, nil, err
+	}
+
+	events := make(chan store.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+
+		for {
+			select {
+
+			case <-done:
+				return
+
+			case err, open := <-watcher.Errors:
+				if !open {
+					return
+				}
+				log.Warn("Error watching", "dir", dir, "err", err)
+
+			case change, open := <-watcher.Events:
+				if !open {
+					return
+				}
+				log.Debug("Change", "dir", dir, "change", change, "V", logV)
+
+				event, ok := filter(change)
+				if !ok {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return events, done, nil
+}
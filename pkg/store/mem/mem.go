@@ -10,16 +10,20 @@ import (
 
 // Mem stores the kv pairs with key by a prefix set at construct time.  This is used for dev, learning mostly
 type Mem struct {
-	prefix string
-	store  map[string][]byte
-	lock   sync.RWMutex
+	prefix   string
+	store    map[string][]byte
+	versions map[string]uint64
+	revision uint64
+	watchers store.Watchers
+	lock     sync.RWMutex
 }
 
 // NewStore returns a store
 func NewStore(prefix string) store.KV {
 	return &Mem{
-		prefix: prefix,
-		store:  map[string][]byte{},
+		prefix:   prefix,
+		store:    map[string][]byte{},
+		versions: map[string]uint64{},
 	}
 }
 
@@ -32,10 +36,30 @@ func (s *Mem) Close() error {
 func (s *Mem) Write(key interface{}, object []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.store[s.Key(key)] = object
+	s.put(key, object)
 	return nil
 }
 
+// put writes the object with a new version.  The caller must hold the lock.
+func (s *Mem) put(key interface{}, object []byte) {
+	id := s.Key(key)
+	s.revision++
+	s.store[id] = object
+	s.versions[id] = s.revision
+	s.watchers.Notify(store.Event{Type: store.EventPut, Key: key, Value: object, Version: s.revision})
+}
+
+// remove deletes the object.  The caller must hold the lock.
+func (s *Mem) remove(key interface{}) {
+	id := s.Key(key)
+	if _, has := s.store[id]; !has {
+		return
+	}
+	delete(s.store, id)
+	delete(s.versions, id)
+	s.watchers.Notify(store.Event{Type: store.EventDelete, Key: key})
+}
+
 // Key returns an id given the key. The id contains type, etc.
 func (s *Mem) Key(key interface{}) string {
 	return fmt.Sprintf("%v-%v", s.prefix, key)
@@ -72,7 +96,35 @@ func (s *Mem) Delete(key interface{}) error {
 	if !exists {
 		return fmt.Errorf("not found %v", key)
 	}
-	delete(s.store, s.Key(key))
+	s.remove(key)
+	return nil
+}
+
+// ReadVersion loads the object and its version
+func (s *Mem) ReadVersion(key interface{}) ([]byte, uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	id := s.Key(key)
+	return s.store[id], s.versions[id], nil
+}
+
+// Txn applies the operations atomically if the keys compared are at their versions
+func (s *Mem) Txn(cmps []store.Cmp, ops []store.Op) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, cmp := range cmps {
+		if actual := s.versions[s.Key(cmp.Key)]; actual != cmp.Version {
+			return store.ErrConflict{Key: cmp.Key, Expected: cmp.Version, Actual: actual}
+		}
+	}
+	for _, op := range ops {
+		if op.Delete {
+			s.remove(op.Key)
+			continue
+		}
+		s.put(op.Key, op.Value)
+	}
 	return nil
 }
 
@@ -92,3 +144,9 @@ func (s *Mem) Entries() (<-chan store.Pair, error) {
 	}()
 	return out, nil
 }
+
+// Watch streams the changes of the keys that start with the prefix
+func (s *Mem) Watch(prefix string) (<-chan store.Event, chan<- struct{}, error) {
+	events, done := s.watchers.Add(prefix)
+	return events, done, nil
+}
@@ -0,0 +1,15 @@
+package mem
+
+import (
+	"testing"
+
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
+)
+
+func TestKV(t *testing.T) {
+	testing_store.KV(t, NewStore("test"))
+}
+
+func TestWatch(t *testing.T) {
+	testing_store.Watch(t, NewStore("test"))
+}
@@ -0,0 +1,203 @@
+package raft
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/raft"
+)
+
+const (
+	// DefaultKey is the key used to persist the config.
+	DefaultKey = "infrakit/configs/groups.json"
+
+	// RevisionsPrefix is the prefix of the keys of the revisions of the config.
+	RevisionsPrefix = "infrakit/configs/revisions"
+)
+
+// NewSnapshot returns a snapshot stored in the raft cluster under the key.  Only the leader can save; loads
+// return the object as last applied on this node.
+func NewSnapshot(s *raft.Store, key string) (store.Snapshot, error) {
+	snapshot := &snapshot{store: s, key: key}
+	s.Observe(func(c raft.Change) {
+		if c.Key != key {
+			return
+		}
+		event := store.Event{Type: store.EventPut, Key: key, Version: c.Version}
+		if c.Version == 0 {
+			event.Type = store.EventDelete
+		}
+		snapshot.watchers.Notify(event)
+	})
+	return snapshot, nil
+}
+
+type snapshot struct {
+	store    *raft.Store
+	key      string
+	watchers store.Watchers
+}
+
+// Save marshals (encodes) and saves a snapshot of the given object.
+func (s *snapshot) Save(obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.store.Put(s.key, any.Bytes())
+}
+
+// Load loads a snapshot and marshals (decodes) into the given reference.
+// If no data is available to unmarshal into the given struct, the fuction returns nil.
+func (s *snapshot) Load(output interface{}) error {
+	v, has := s.store.Get(s.key)
+	if !has {
+		return nil
+	}
+	return types.AnyBytes(v).Decode(output)
+}
+
+// LoadVersion implements store.Conditional
+func (s *snapshot) LoadVersion(output interface{}) (uint64, error) {
+	v, version, has := s.store.GetVersion(s.key)
+	if !has {
+		return 0, nil
+	}
+	return version, types.AnyBytes(v).Decode(output)
+}
+
+// SaveVersion implements store.Conditional.  Only the leader can save.
+func (s *snapshot) SaveVersion(obj interface{}, version uint64) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	err = s.store.Txn([]raft.Cmp{{Key: s.key, Version: version}}, []raft.Op{{Key: s.key, Value: any.Bytes()}})
+	if conflict, is := err.(raft.ErrConflict); is {
+		return store.ErrConflict{Key: s.key, Expected: conflict.Expected, Actual: conflict.Actual}
+	}
+	return err
+}
+
+// Watch implements store.Watchable.  The changes are notified as they are applied on this node.
+func (s *snapshot) Watch() (<-chan store.Event, chan<- struct{}, error) {
+	events, done := s.watchers.Add("")
+	return events, done, nil
+}
+
+// Close implements io.Closer.  The raft node is shared, so it is not stopped.
+func (s *snapshot) Close() error {
+	return nil
+}
+
+// KV stores the kv pairs in the raft cluster, with the keys under a prefix
+type KV struct {
+	store    *raft.Store
+	prefix   string
+	watchers store.Watchers
+}
+
+// NewKV returns a kv store given the prefix of the keys
+func NewKV(s *raft.Store, prefix string) store.KV {
+	kv := &KV{store: s, prefix: prefix}
+	root := kv.Key("")
+	s.Observe(func(c raft.Change) {
+		if !strings.HasPrefix(c.Key, root) {
+			return
+		}
+		event := store.Event{Type: store.EventPut, Key: strings.TrimPrefix(c.Key, root), Value: c.Value,
+			Version: c.Version}
+		if c.Version == 0 {
+			event.Type = store.EventDelete
+		}
+		kv.watchers.Notify(event)
+	})
+	return kv
+}
+
+// Close implements io.Closer.  The raft node is shared, so it is not stopped.
+func (s *KV) Close() error {
+	return nil
+}
+
+// Key returns an id given the key.
+func (s *KV) Key(key interface{}) string {
+	return fmt.Sprintf("%v/%v", s.prefix, key)
+}
+
+// Write writes the object.  Only the leader can write.
+func (s *KV) Write(key interface{}, value []byte) error {
+	return s.store.Put(s.Key(key), value)
+}
+
+// Read loads the object
+func (s *KV) Read(key interface{}) ([]byte, error) {
+	v, has := s.store.Get(s.Key(key))
+	if !has {
+		return nil, fmt.Errorf("not found %v", key)
+	}
+	return v, nil
+}
+
+// Exists checks for existence
+func (s *KV) Exists(key interface{}) (bool, error) {
+	_, has := s.store.Get(s.Key(key))
+	return has, nil
+}
+
+// Delete deletes the object by id.  Only the leader can delete.
+func (s *KV) Delete(key interface{}) error {
+	return s.store.Delete(s.Key(key))
+}
+
+// Entries returns the entries
+func (s *KV) Entries() (<-chan store.Pair, error) {
+	prefix := s.Key("")
+	keys := s.store.Keys(prefix)
+	out := make(chan store.Pair)
+	go func() {
+		defer close(out)
+		for _, k := range keys {
+			if v, has := s.store.Get(k); has {
+				out <- store.Pair{Key: strings.TrimPrefix(k, prefix), Value: v}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// ReadVersion loads the object and its version
+func (s *KV) ReadVersion(key interface{}) ([]byte, uint64, error) {
+	v, version, _ := s.store.GetVersion(s.Key(key))
+	return v, version, nil
+}
+
+// Txn applies the operations atomically if the keys compared are at their versions.  Only the leader can apply
+// transactions.
+func (s *KV) Txn(cmps []store.Cmp, ops []store.Op) error {
+	keys := map[string]interface{}{}
+	rcmps := []raft.Cmp{}
+	for _, cmp := range cmps {
+		k := s.Key(cmp.Key)
+		keys[k] = cmp.Key
+		rcmps = append(rcmps, raft.Cmp{Key: k, Version: cmp.Version})
+	}
+	rops := []raft.Op{}
+	for _, op := range ops {
+		rops = append(rops, raft.Op{Key: s.Key(op.Key), Value: op.Value, Delete: op.Delete})
+	}
+
+	err := s.store.Txn(rcmps, rops)
+	if conflict, is := err.(raft.ErrConflict); is {
+		return store.ErrConflict{Key: keys[conflict.Key], Expected: conflict.Expected, Actual: conflict.Actual}
+	}
+	return err
+}
+
+// Watch streams the changes of the keys that start with the prefix, as they are applied on this node
+func (s *KV) Watch(prefix string) (<-chan store.Event, chan<- struct{}, error) {
+	events, done := s.watchers.Add(prefix)
+	return events, done, nil
+}
@@ -0,0 +1,104 @@
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/store"
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
+	"github.com/docker/infrakit/pkg/util/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T) *raft.Store {
+	s, err := raft.NewStore(raft.Config{
+		ID:                "m1",
+		Members:           []string{"m1"},
+		HeartbeatInterval: 5 * time.Millisecond,
+		ElectionTimeout:   20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	s.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.True(t, s.IsLeader())
+	return s
+}
+
+type config struct {
+	Groups []string
+}
+
+func TestSnapshot(t *testing.T) {
+	s := testStore(t)
+	defer s.Stop()
+
+	snapshot, err := NewSnapshot(s, DefaultKey)
+	require.NoError(t, err)
+
+	var loaded *config
+	require.NoError(t, snapshot.Load(&loaded))
+	require.Nil(t, loaded)
+
+	require.NoError(t, snapshot.Save(config{Groups: []string{"workers"}}))
+	require.NoError(t, snapshot.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+}
+
+func TestKV(t *testing.T) {
+	s := testStore(t)
+	defer s.Stop()
+
+	kv := NewKV(s, "instances")
+
+	exists, err := kv.Exists("i-1")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = kv.Read("i-1")
+	require.Error(t, err)
+
+	require.NoError(t, kv.Write("i-1", []byte("one")))
+	require.NoError(t, kv.Write("i-2", []byte("two")))
+
+	v, err := kv.Read("i-1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("one"), v)
+
+	// Keys outside the prefix are not listed
+	require.NoError(t, NewKV(s, "other").Write("i-3", []byte("three")))
+
+	entries, err := kv.Entries()
+	require.NoError(t, err)
+	found := []store.Pair{}
+	for entry := range entries {
+		found = append(found, entry)
+	}
+	require.Equal(t, []store.Pair{
+		{Key: "i-1", Value: []byte("one")},
+		{Key: "i-2", Value: []byte("two")},
+	}, found)
+
+	require.NoError(t, kv.Delete("i-1"))
+	require.Error(t, kv.Delete("i-1"))
+	exists, err = kv.Exists("i-1")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestKVTxn(t *testing.T) {
+	s := testStore(t)
+	defer s.Stop()
+
+	testing_store.KV(t, NewKV(s, "txn"))
+}
+
+func TestKVWatch(t *testing.T) {
+	s := testStore(t)
+	defer s.Stop()
+
+	testing_store.Watch(t, NewKV(s, "watch"))
+}
@@ -1,7 +1,9 @@
 package store
 
 import (
+	"fmt"
 	"io"
+	"time"
 )
 
 // Snapshot provides means to save and load an object.  This is not meant to be
@@ -17,6 +19,37 @@ type Snapshot interface {
 	Load(output interface{}) error
 }
 
+// Revision describes a numbered version of the object saved in a snapshot.
+type Revision struct {
+	// Number is the number of the revision, starting from 1.
+	Number int
+
+	// Time is when the revision was saved.
+	Time time.Time
+
+	// Author is who made the change, if known.
+	Author string `json:",omitempty"`
+
+	// Message describes the change.
+	Message string `json:",omitempty"`
+}
+
+// Versioned is a snapshot that keeps a history of the objects saved.  Save records a new revision only if the
+// object is different from the latest revision, and Load loads the latest revision.
+type Versioned interface {
+	Snapshot
+
+	// Commit saves the object as a new revision with the author and message.  The latest revision is returned
+	// if the object is the same as the one in the latest revision.
+	Commit(obj interface{}, author, message string) (Revision, error)
+
+	// Revisions returns the revisions in the history, oldest first.
+	Revisions() ([]Revision, error)
+
+	// LoadRevision loads the object of the given revision into the output.
+	LoadRevision(number int, output interface{}) error
+}
+
 // Pair is the kv pair
 type Pair struct {
 	Key   interface{}
@@ -39,6 +72,141 @@ type KV interface {
 	Delete(key interface{}) error
 	// Entries returns the entries, unsorted
 	Entries() (<-chan Pair, error)
+
+	// ReadVersion loads the object and its version.  The version changes each time the object is written, and
+	// is never reused for the same key.  The version is 0 and the object nil if the key does not exist.
+	ReadVersion(key interface{}) ([]byte, uint64, error)
+
+	// Txn applies the operations atomically, only if the keys compared are still at their versions.  An
+	// ErrConflict is returned, and no operation applied, if any key has changed.
+	Txn(cmps []Cmp, ops []Op) error
+
+	// Watch streams the changes of the keys that start with the prefix, made by any writer from the time of the
+	// call.  Stores that watch the file system may skip a change that is overwritten before it is seen, but the
+	// last change of a key is always streamed.  Close done to stop watching; the events channel is then closed.
+	Watch(prefix string) (events <-chan Event, done chan<- struct{}, err error)
+}
+
+// Watchable is implemented by the snapshots that can notify of changes, including those made by other processes.
+type Watchable interface {
+
+	// Watch streams an event each time the snapshot is saved.  The events do not carry the object, which is
+	// read by Load.  Close done to stop watching; the events channel is then closed.
+	Watch() (events <-chan Event, done chan<- struct{}, err error)
+}
+
+// Conditional is implemented by the snapshots that can save an object only if no other writer has saved since it
+// was loaded, atomically in the backend.
+type Conditional interface {
+
+	// LoadVersion loads the object like Load, and returns the version of the snapshot to save at.
+	LoadVersion(output interface{}) (uint64, error)
+
+	// SaveVersion saves the object only if the snapshot is still at the version loaded.  An ErrConflict is
+	// returned, and the object not saved, otherwise.
+	SaveVersion(obj interface{}, version uint64) error
+}
+
+// Rotator is implemented by the stores that encrypt the objects saved, to encrypt them again with the current key
+// once the keys have changed.
+type Rotator interface {
+
+	// Rotated returns true if the objects are all encrypted with the current key, so there is nothing to rotate.
+	Rotated() (bool, error)
+
+	// Rotate encrypts again with the current key the objects encrypted with another key, or not encrypted.
+	Rotate() error
+}
+
+// Rotated returns true if the objects of the store are all encrypted with the current key, or if the store is not
+// a Rotator.
+func Rotated(s interface{}) (bool, error) {
+	rotator, is := s.(Rotator)
+	if !is {
+		return true, nil
+	}
+	return rotator.Rotated()
+}
+
+// Rotate encrypts the objects of the store again with the current key if the store is a Rotator.  Nothing is done
+// otherwise.
+func Rotate(s interface{}) error {
+	rotator, is := s.(Rotator)
+	if !is {
+		return nil
+	}
+	return rotator.Rotate()
+}
+
+// WatchSnapshot watches the snapshot if it is Watchable.  An error is returned otherwise.
+func WatchSnapshot(snapshot Snapshot) (<-chan Event, chan<- struct{}, error) {
+	watchable, is := snapshot.(Watchable)
+	if !is {
+		return nil, nil, fmt.Errorf("snapshot cannot be watched")
+	}
+	return watchable.Watch()
+}
+
+// EventType is the type of a change
+type EventType string
+
+const (
+	// EventPut is the type of the change when a key is written
+	EventPut EventType = "put"
+
+	// EventDelete is the type of the change when a key is deleted
+	EventDelete EventType = "delete"
+)
+
+// Event is a change of a key
+type Event struct {
+	// Type is the type of the change
+	Type EventType
+
+	// Key is the key changed
+	Key interface{}
+
+	// Value is the value written.  It is nil if the key is deleted.
+	Value []byte `json:",omitempty"`
+
+	// Version is the version written.  It is 0 if the key is deleted, or if the store cannot tell.
+	Version uint64 `json:",omitempty"`
+}
+
+// Cmp is a condition of a transaction: the key must be at the version.  Version 0 means the key must not exist.
+type Cmp struct {
+	Key     interface{}
+	Version uint64
+}
+
+// Op is an operation of a transaction: the value is written to the key, or the key is deleted.  Deleting a key
+// that does not exist is not an error.
+type Op struct {
+	Key    interface{}
+	Value  []byte
+	Delete bool
+}
+
+// ErrConflict is returned when a key compared in a transaction is not at the version expected
+type ErrConflict struct {
+	// Key is the key compared
+	Key interface{}
+
+	// Expected is the version expected
+	Expected uint64
+
+	// Actual is the current version of the key
+	Actual uint64
+}
+
+func (e ErrConflict) Error() string {
+	return fmt.Sprintf("conflict on %v: expected version %d, actual %d", e.Key, e.Expected, e.Actual)
+}
+
+// CompareAndSwap writes the value only if the key is still at the version read.  Version 0 means the key must
+// not exist.
+func CompareAndSwap(kv KV, key interface{}, version uint64, value []byte) error {
+	return kv.Txn([]Cmp{{Key: key, Version: version}}, []Op{{Key: key, Value: value}})
 }
 
 // Visit visits all entries matching the tags
@@ -51,6 +51,41 @@ func (s *snapshot) Load(output interface{}) error {
 	return nil
 }
 
+// LoadVersion implements store.Conditional.  The version is the version of the swarm object, which changes at each
+// update of the swarm.
+func (s *snapshot) LoadVersion(output interface{}) (uint64, error) {
+	info, err := s.client.SwarmInspect(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	version := info.ClusterInfo.Meta.Version.Index
+	if label, has := info.ClusterInfo.Spec.Annotations.Labels[SwarmLabel]; has {
+		return version, decode(label, output)
+	}
+	return version, nil
+}
+
+// SaveVersion implements store.Conditional.  The swarm refuses the update if it has been updated since the version.
+func (s *snapshot) SaveVersion(obj interface{}, version uint64) error {
+	label, err := encode(obj)
+	if err != nil {
+		return err
+	}
+	info, err := s.client.SwarmInspect(context.Background())
+	if err != nil {
+		return err
+	}
+	if actual := info.ClusterInfo.Meta.Version.Index; actual != version {
+		return store.ErrConflict{Key: SwarmLabel, Expected: version, Actual: actual}
+	}
+	if info.ClusterInfo.Spec.Annotations.Labels == nil {
+		info.ClusterInfo.Spec.Annotations.Labels = map[string]string{}
+	}
+	info.ClusterInfo.Spec.Annotations.Labels[SwarmLabel] = label
+	return s.client.SwarmUpdate(context.Background(), swarm.Version{Index: version}, info.ClusterInfo.Spec,
+		swarm.UpdateFlags{})
+}
+
 // Close implements io.Closer
 func (s *snapshot) Close() error {
 	if s.client == nil {
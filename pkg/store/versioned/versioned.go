@@ -0,0 +1,284 @@
+package versioned
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var log = logutil.New("module", "store/versioned")
+
+// DefaultMaxRevisions is the number of revisions kept in the history by default
+const DefaultMaxRevisions = 10
+
+// entry is a revision and the object saved in it.  Each entry is written in the revisions store under its number.
+type entry struct {
+	store.Revision
+
+	Object *types.Any
+}
+
+// history is what was saved in the backend snapshot when the whole history was kept there.  It is still loaded,
+// and its revisions are written in the revisions store at the next commit.
+type history struct {
+	// Versioned tells the history apart from the objects saved in the backend.
+	Versioned bool
+
+	Entries []entry
+}
+
+type snapshot struct {
+	backend   store.Snapshot
+	revisions store.KV
+	max       int
+	lock      sync.Mutex
+	now       func() time.Time
+}
+
+// NewSnapshot returns a snapshot that keeps the history of the objects saved in the backend snapshot.  The latest
+// object is saved in the backend as it is, so that it is still loaded by the versions that keep no history, and
+// each revision is written in the revisions store under its own key.  At most max revisions are kept, the oldest
+// revisions being deleted first.  The revisions are written once the object is saved: a revision that cannot be
+// written is only logged, and the object saved is then listed as a revision without author nor message, as are
+// the objects saved in the backend by the versions that keep no history.
+func NewSnapshot(backend store.Snapshot, revisions store.KV, max int) store.Versioned {
+	if max <= 0 {
+		max = DefaultMaxRevisions
+	}
+	return &snapshot{
+		backend:   backend,
+		revisions: revisions,
+		max:       max,
+		now:       time.Now,
+	}
+}
+
+// current returns the object saved in the backend, and the revisions of the history if one was saved there
+func (s *snapshot) current() (*types.Any, []entry, error) {
+	var raw *types.Any
+	if err := s.backend.Load(&raw); err != nil || raw == nil {
+		return nil, nil, err
+	}
+	h := history{}
+	if err := raw.Decode(&h); err == nil && h.Versioned {
+		if len(h.Entries) == 0 {
+			return nil, nil, nil
+		}
+		return h.Entries[len(h.Entries)-1].Object, h.Entries, nil
+	}
+	return raw, nil, nil
+}
+
+// written returns the revisions written in the revisions store, oldest first
+func (s *snapshot) written() ([]entry, error) {
+	entries := []entry{}
+	pairs, err := s.revisions.Entries()
+	if err != nil {
+		return nil, err
+	}
+	for pair := range pairs {
+		e := entry{}
+		if err := json.Unmarshal(pair.Value, &e); err != nil {
+			log.Warn("Skipping revision that cannot be decoded", "key", pair.Key, "err", err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Number < entries[j].Number })
+	return entries, nil
+}
+
+// load returns the revisions, oldest first, and the number of the latest revisions not yet written in the
+// revisions store.  The object saved in the backend is the latest revision.
+func (s *snapshot) load() ([]entry, int, error) {
+	object, saved, err := s.current()
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := s.written()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unwritten := 0
+	for _, e := range saved {
+		if len(entries) == 0 || e.Number > entries[len(entries)-1].Number {
+			entries = append(entries, e)
+			unwritten++
+		}
+	}
+
+	if object != nil && (len(entries) == 0 || !same(entries[len(entries)-1].Object, object)) {
+		// The object was saved without writing its revision
+		e := entry{Revision: store.Revision{Number: 1, Message: "Saved before keeping revisions"}, Object: object}
+		if len(entries) > 0 {
+			e.Number = entries[len(entries)-1].Number + 1
+			e.Message = "Saved without keeping a revision"
+		}
+		entries = append(entries, e)
+		unwritten++
+	}
+	return entries, unwritten, nil
+}
+
+// kept returns the revisions kept out of the revisions loaded
+func (s *snapshot) kept(entries []entry) []entry {
+	if len(entries) > s.max {
+		return entries[len(entries)-s.max:]
+	}
+	return entries
+}
+
+// keep writes the revisions not yet written in the revisions store, and deletes the revisions beyond the max.  The
+// object is already saved in the backend, so the failures are only logged.
+func (s *snapshot) keep(entries []entry, unwritten int) {
+	first, written := len(entries)-s.max, len(entries)-unwritten
+	for i, e := range entries {
+		switch {
+		case i < first && i < written:
+			if err := s.revisions.Delete(e.Number); err != nil {
+				log.Warn("Cannot delete revision", "number", e.Number, "err", err)
+			}
+		case i >= first && i >= written:
+			buff, err := json.Marshal(e)
+			if err == nil {
+				err = s.revisions.Write(e.Number, buff)
+			}
+			if err != nil {
+				log.Warn("Cannot write revision", "number", e.Number, "err", err)
+			}
+		}
+	}
+}
+
+// Save implements store.Snapshot.Save
+func (s *snapshot) Save(obj interface{}) error {
+	_, err := s.Commit(obj, "", "")
+	return err
+}
+
+// Load implements store.Snapshot.Load.  It loads the latest revision.
+func (s *snapshot) Load(output interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	object, _, err := s.current()
+	if err != nil || object == nil {
+		return err
+	}
+	return object.Decode(output)
+}
+
+// Commit implements store.Versioned.Commit
+func (s *snapshot) Commit(obj interface{}, author, message string) (store.Revision, error) {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return store.Revision{}, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entries, unwritten, err := s.load()
+	if err != nil {
+		return store.Revision{}, err
+	}
+
+	revision := store.Revision{
+		Number:  1,
+		Time:    s.now(),
+		Author:  author,
+		Message: message,
+	}
+	if len(entries) > 0 {
+		latest := entries[len(entries)-1]
+		if same(latest.Object, any) {
+			return latest.Revision, nil
+		}
+		revision.Number = latest.Number + 1
+	}
+
+	if err := s.backend.Save(any); err != nil {
+		return store.Revision{}, err
+	}
+	s.keep(append(entries, entry{Revision: revision, Object: any}), unwritten+1)
+	return revision, nil
+}
+
+// same returns true if the objects are equal.  The objects are compared decoded, since the encoding may differ
+// in formatting once saved by the backend.
+func same(a, b *types.Any) bool {
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// Revisions implements store.Versioned.Revisions
+func (s *snapshot) Revisions() ([]store.Revision, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entries, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	revisions := []store.Revision{}
+	for _, e := range s.kept(entries) {
+		revisions = append(revisions, e.Revision)
+	}
+	return revisions, nil
+}
+
+// LoadRevision implements store.Versioned.LoadRevision
+func (s *snapshot) LoadRevision(number int, output interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entries, _, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, e := range s.kept(entries) {
+		if e.Number == number {
+			return e.Object.Decode(output)
+		}
+	}
+	return fmt.Errorf("no revision %d", number)
+}
+
+// Watch implements store.Watchable if the backend does
+func (s *snapshot) Watch() (<-chan store.Event, chan<- struct{}, error) {
+	return store.WatchSnapshot(s.backend)
+}
+
+// Rotated implements store.Rotator if the backend or the revisions store does
+func (s *snapshot) Rotated() (bool, error) {
+	rotated, err := store.Rotated(s.backend)
+	if err != nil || !rotated {
+		return rotated, err
+	}
+	return store.Rotated(s.revisions)
+}
+
+// Rotate implements store.Rotator if the backend or the revisions store does
+func (s *snapshot) Rotate() error {
+	if err := store.Rotate(s.backend); err != nil {
+		return err
+	}
+	return store.Rotate(s.revisions)
+}
+
+// Close implements io.Closer
+func (s *snapshot) Close() error {
+	s.revisions.Close()
+	return s.backend.Close()
+}
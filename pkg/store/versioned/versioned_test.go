@@ -0,0 +1,208 @@
+package versioned
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/file"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Groups []string
+}
+
+func TestVersionedSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-versioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	revisions := file.NewStore("revision", dir)
+
+	// An object saved before keeping history
+	require.NoError(t, backend.Save(config{Groups: []string{"workers"}}))
+
+	s := NewSnapshot(backend, revisions, 3).(*snapshot)
+	now := time.Unix(1500000000, 0).UTC()
+	s.now = func() time.Time { return now }
+
+	loaded := config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"workers"}, loaded.Groups)
+
+	kept, err := s.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(kept))
+	require.Equal(t, 1, kept[0].Number)
+
+	r, err := s.Commit(config{Groups: []string{"workers", "managers"}}, "alice", "Add managers")
+	require.NoError(t, err)
+	require.Equal(t, store.Revision{Number: 2, Time: now, Author: "alice", Message: "Add managers"}, r)
+
+	// No revision is recorded if nothing changed
+	r, err = s.Commit(config{Groups: []string{"workers", "managers"}}, "bob", "Again")
+	require.NoError(t, err)
+	require.Equal(t, 2, r.Number)
+	require.Equal(t, "alice", r.Author)
+
+	require.NoError(t, s.Save(config{Groups: []string{"managers"}}))
+
+	loaded = config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+
+	// The latest object is saved in the backend as it is
+	loaded = config{}
+	require.NoError(t, backend.Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+
+	loaded = config{}
+	require.NoError(t, s.LoadRevision(2, &loaded))
+	require.Equal(t, []string{"workers", "managers"}, loaded.Groups)
+
+	// The oldest revisions are dropped
+	require.NoError(t, s.Save(config{}))
+	kept, err = s.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(kept))
+	require.Equal(t, []int{2, 3, 4}, []int{kept[0].Number, kept[1].Number, kept[2].Number})
+	require.Error(t, s.LoadRevision(1, &loaded))
+
+	exists, err := revisions.Exists(1)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// The revisions are kept in the revisions store
+	s2 := NewSnapshot(backend, revisions, 0)
+	kept2, err := s2.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, kept, kept2)
+}
+
+func TestVersionedSnapshotEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-versioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	revisions := file.NewStore("revision", dir)
+
+	s := NewSnapshot(backend, revisions, 0)
+
+	loaded := config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Nil(t, loaded.Groups)
+
+	kept, err := s.Revisions()
+	require.NoError(t, err)
+	require.Empty(t, kept)
+
+	r, err := s.Commit(config{Groups: []string{"workers"}}, "", "First")
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Number)
+}
+
+func TestVersionedSnapshotDefaultMax(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-versioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	revisions := file.NewStore("revision", dir)
+
+	s := NewSnapshot(backend, revisions, 0)
+	for i := 0; i < DefaultMaxRevisions+5; i++ {
+		require.NoError(t, s.Save(config{Groups: []string{fmt.Sprintf("group-%d", i)}}))
+	}
+	kept, err := s.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, DefaultMaxRevisions, len(kept))
+	require.Equal(t, DefaultMaxRevisions+5, kept[len(kept)-1].Number)
+}
+
+func TestVersionedSnapshotSavedWithoutRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-versioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	revisions := file.NewStore("revision", dir)
+
+	s := NewSnapshot(backend, revisions, 0)
+	_, err = s.Commit(config{Groups: []string{"workers"}}, "alice", "First")
+	require.NoError(t, err)
+
+	// Saved by a version that keeps no history
+	require.NoError(t, backend.Save(config{Groups: []string{"managers"}}))
+
+	kept, err := s.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(kept))
+	require.Equal(t, store.Revision{Number: 2, Message: "Saved without keeping a revision"}, kept[1])
+
+	r, err := s.Commit(config{Groups: []string{"workers", "managers"}}, "bob", "Third")
+	require.NoError(t, err)
+	require.Equal(t, 3, r.Number)
+
+	// The revision saved without being kept is written with the next commit
+	loaded := config{}
+	require.NoError(t, NewSnapshot(backend, revisions, 0).LoadRevision(2, &loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+}
+
+func TestVersionedSnapshotHistoryInBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-versioned")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	revisions := file.NewStore("revision", dir)
+
+	// The whole history saved in the backend
+	first, err := types.AnyValue(config{Groups: []string{"workers"}})
+	require.NoError(t, err)
+	second, err := types.AnyValue(config{Groups: []string{"managers"}})
+	require.NoError(t, err)
+	require.NoError(t, backend.Save(history{
+		Versioned: true,
+		Entries: []entry{
+			{Revision: store.Revision{Number: 1, Author: "alice"}, Object: first},
+			{Revision: store.Revision{Number: 2, Author: "bob"}, Object: second},
+		},
+	}))
+
+	s := NewSnapshot(backend, revisions, 0)
+
+	loaded := config{}
+	require.NoError(t, s.Load(&loaded))
+	require.Equal(t, []string{"managers"}, loaded.Groups)
+
+	kept, err := s.Revisions()
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "bob"}, []string{kept[0].Author, kept[1].Author})
+
+	r, err := s.Commit(config{}, "carol", "Third")
+	require.NoError(t, err)
+	require.Equal(t, 3, r.Number)
+
+	// The history is moved to the revisions store
+	for _, number := range []int{1, 2, 3} {
+		exists, err := revisions.Exists(number)
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+	loaded = config{Groups: []string{"x"}}
+	require.NoError(t, backend.Load(&loaded))
+	require.Empty(t, loaded.Groups)
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Watchers streams the events of a store to its watchers.  The events are queued for each watcher, so that
+// notifying never blocks on a slow watcher.  The zero value is ready to use.
+type Watchers struct {
+	watchers map[*watcher]struct{}
+	lock     sync.Mutex
+}
+
+type watcher struct {
+	prefix string
+	queue  []Event
+	wake   chan struct{}
+}
+
+// Add adds a watcher of the keys that start with the prefix.  Close done to remove it; the events channel is
+// then closed.
+func (w *Watchers) Add(prefix string) (<-chan Event, chan<- struct{}) {
+	x := &watcher{prefix: prefix, wake: make(chan struct{}, 1)}
+
+	w.lock.Lock()
+	if w.watchers == nil {
+		w.watchers = map[*watcher]struct{}{}
+	}
+	w.watchers[x] = struct{}{}
+	w.lock.Unlock()
+
+	events := make(chan Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		defer func() {
+			w.lock.Lock()
+			delete(w.watchers, x)
+			w.lock.Unlock()
+		}()
+
+		for {
+			w.lock.Lock()
+			queue := x.queue
+			x.queue = nil
+			w.lock.Unlock()
+
+			for _, event := range queue {
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			}
+
+			select {
+			case <-x.wake:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return events, done
+}
+
+// Notify queues the event for the watchers of its key
+func (w *Watchers) Notify(event Event) {
+	key := fmt.Sprintf("%v", event.Key)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for x := range w.watchers {
+		if !strings.HasPrefix(key, x.prefix) {
+			continue
+		}
+		x.queue = append(x.queue, event)
+		select {
+		case x.wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -11,6 +11,9 @@ type Plugin struct {
 	// DoCommitGroup implements CommitGroup
 	DoCommitGroup func(grp group.Spec, pretend bool) (string, error)
 
+	// DoPlanCommit implements PlanCommit
+	DoPlanCommit func(grp group.Spec) (group.Plan, error)
+
 	// DoFreeGroup implements FreeGroup
 	DoFreeGroup func(id group.ID) error
 
@@ -31,6 +34,18 @@ type Plugin struct {
 
 	// DoSetSize implements SetSize
 	DoSetSize func(id group.ID, size int) error
+
+	// DoPromoteCanary implements PromoteCanary
+	DoPromoteCanary func(id group.ID) error
+
+	// DoAbortCanary implements AbortCanary
+	DoAbortCanary func(id group.ID) error
+
+	// DoPauseUpdate implements PauseUpdate
+	DoPauseUpdate func(id group.ID) error
+
+	// DoResumeUpdate implements ResumeUpdate
+	DoResumeUpdate func(id group.ID) error
 }
 
 // CommitGroup commits spec for a group
@@ -38,6 +53,11 @@ func (t *Plugin) CommitGroup(grp group.Spec, pretend bool) (string, error) {
 	return t.DoCommitGroup(grp, pretend)
 }
 
+// PlanCommit returns the plan of committing spec for a group
+func (t *Plugin) PlanCommit(grp group.Spec) (group.Plan, error) {
+	return t.DoPlanCommit(grp)
+}
+
 // FreeGroup releases the members of the group from management
 func (t *Plugin) FreeGroup(id group.ID) error {
 	return t.DoFreeGroup(id)
@@ -72,3 +92,23 @@ func (t *Plugin) Size(id group.ID) (int, error) {
 func (t *Plugin) SetSize(id group.ID, size int) error {
 	return t.DoSetSize(id, size)
 }
+
+// PromoteCanary promotes the canary of an update in progress
+func (t *Plugin) PromoteCanary(id group.ID) error {
+	return t.DoPromoteCanary(id)
+}
+
+// AbortCanary aborts the canary of an update in progress
+func (t *Plugin) AbortCanary(id group.ID) error {
+	return t.DoAbortCanary(id)
+}
+
+// PauseUpdate pauses an update in progress
+func (t *Plugin) PauseUpdate(id group.ID) error {
+	return t.DoPauseUpdate(id)
+}
+
+// ResumeUpdate resumes a paused update
+func (t *Plugin) ResumeUpdate(id group.ID) error {
+	return t.DoResumeUpdate(id)
+}
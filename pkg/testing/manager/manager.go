@@ -3,6 +3,9 @@ package manager
 import (
 	"net/url"
 
+	"github.com/docker/infrakit/pkg/manager"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -23,6 +26,18 @@ type Plugin struct {
 
 	// DoTerminate destroys all resources associated with the specs
 	DoTerminate func(specs []types.Spec) error
+
+	// DoCommitGroups commits the group specs as the desired state of all groups
+	DoCommitGroups func(specs []group.Spec, options manager.CommitOptions) (manager.GroupChanges, error)
+
+	// DoRevisions returns the revisions of the specs committed
+	DoRevisions func() ([]store.Revision, error)
+
+	// DoInspectRevision returns the specs committed in the given revision
+	DoInspectRevision func(number int) ([]types.Spec, error)
+
+	// DoRollback commits the specs of a previous revision as a new revision
+	DoRollback func(number int, options manager.CommitOptions) (manager.GroupChanges, error)
 }
 
 // IsLeader returns true if manager is leader
@@ -49,3 +64,23 @@ func (t *Plugin) Inspect() ([]types.Object, error) {
 func (t *Plugin) Terminate(specs []types.Spec) error {
 	return t.DoTerminate(specs)
 }
+
+// CommitGroups commits the group specs as the desired state of all groups
+func (t *Plugin) CommitGroups(specs []group.Spec, options manager.CommitOptions) (manager.GroupChanges, error) {
+	return t.DoCommitGroups(specs, options)
+}
+
+// Revisions returns the revisions of the specs committed
+func (t *Plugin) Revisions() ([]store.Revision, error) {
+	return t.DoRevisions()
+}
+
+// InspectRevision returns the specs committed in the given revision
+func (t *Plugin) InspectRevision(number int) ([]types.Spec, error) {
+	return t.DoInspectRevision(number)
+}
+
+// Rollback commits the specs of a previous revision as a new revision
+func (t *Plugin) Rollback(number int, options manager.CommitOptions) (manager.GroupChanges, error) {
+	return t.DoRollback(number, options)
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/stretchr/testify/require"
+)
+
+// KV runs the tests of the versioned reads and transactions that all the implementations of store.KV must pass.
+// The kv must be empty.
+func KV(t *testing.T, kv store.KV) {
+
+	// Absent keys are at version 0
+	v, version, err := kv.ReadVersion("a")
+	require.NoError(t, err)
+	require.Nil(t, v)
+	require.Equal(t, uint64(0), version)
+
+	// Create only if absent
+	require.NoError(t, store.CompareAndSwap(kv, "a", 0, []byte("a1")))
+	err = store.CompareAndSwap(kv, "a", 0, []byte("a2"))
+	require.Error(t, err)
+	conflict, is := err.(store.ErrConflict)
+	require.True(t, is)
+	require.Equal(t, "a", conflict.Key)
+	require.Equal(t, uint64(0), conflict.Expected)
+
+	v, a1, err := kv.ReadVersion("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a1"), v)
+	require.NotEqual(t, uint64(0), a1)
+	require.Equal(t, a1, conflict.Actual)
+
+	// Every write changes the version
+	require.NoError(t, kv.Write("a", []byte("a1")))
+	_, a2, err := kv.ReadVersion("a")
+	require.NoError(t, err)
+	require.NotEqual(t, a1, a2)
+
+	// Swap from a stale version fails and leaves the value
+	err = store.CompareAndSwap(kv, "a", a1, []byte("stale"))
+	require.Error(t, err)
+	conflict, is = err.(store.ErrConflict)
+	require.True(t, is)
+	require.Equal(t, a1, conflict.Expected)
+	require.Equal(t, a2, conflict.Actual)
+
+	v, err = kv.Read("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a1"), v)
+
+	// Swap from the current version
+	require.NoError(t, store.CompareAndSwap(kv, "a", a2, []byte("a3")))
+	v, a3, err := kv.ReadVersion("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a3"), v)
+	require.NotEqual(t, a2, a3)
+
+	// A transaction over several keys is all or nothing
+	err = kv.Txn(
+		[]store.Cmp{{Key: "a", Version: a3}, {Key: "b", Version: 1 << 40}},
+		[]store.Op{{Key: "a", Value: []byte("a4")}, {Key: "b", Value: []byte("b1")}},
+	)
+	require.Error(t, err)
+	conflict, is = err.(store.ErrConflict)
+	require.True(t, is)
+	require.Equal(t, "b", conflict.Key)
+	require.Equal(t, uint64(0), conflict.Actual)
+
+	v, version, err = kv.ReadVersion("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a3"), v)
+	require.Equal(t, a3, version)
+	exists, err := kv.Exists("b")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, kv.Txn(
+		[]store.Cmp{{Key: "a", Version: a3}, {Key: "b", Version: 0}},
+		[]store.Op{{Key: "a", Value: []byte("a4")}, {Key: "b", Value: []byte("b1")}},
+	))
+	v, err = kv.Read("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a4"), v)
+	v, b1, err := kv.ReadVersion("b")
+	require.NoError(t, err)
+	require.Equal(t, []byte("b1"), v)
+
+	// Deletes in a transaction; deleting an absent key is not an error
+	require.NoError(t, kv.Txn(
+		[]store.Cmp{{Key: "b", Version: b1}},
+		[]store.Op{{Key: "b", Delete: true}, {Key: "c", Delete: true}},
+	))
+	v, version, err = kv.ReadVersion("b")
+	require.NoError(t, err)
+	require.Nil(t, v)
+	require.Equal(t, uint64(0), version)
+
+	// A key recreated is not at its old version
+	require.NoError(t, store.CompareAndSwap(kv, "b", 0, []byte("b2")))
+	_, b2, err := kv.ReadVersion("b")
+	require.NoError(t, err)
+	require.NotEqual(t, b1, b2)
+
+	// A transaction without conditions always applies
+	require.NoError(t, kv.Txn(nil, []store.Op{{Key: "a", Delete: true}, {Key: "b", Delete: true}}))
+	exists, err = kv.Exists("a")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+// Watch runs the tests of the watch of the changes that all the implementations of store.KV must pass.  The kv
+// must be empty.  Each change is waited for before the next, since some stores may skip a change that is
+// overwritten before it is seen.
+func Watch(t *testing.T, kv store.KV) {
+
+	events, done, err := kv.Watch("w")
+	require.NoError(t, err)
+
+	next := func() store.Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "no event")
+		}
+		return store.Event{}
+	}
+
+	require.NoError(t, kv.Write("w1", []byte("one")))
+	event := next()
+	require.Equal(t, store.EventPut, event.Type)
+	require.Equal(t, "w1", event.Key)
+	require.Equal(t, []byte("one"), event.Value)
+
+	// Only the keys with the prefix are watched, including those written in transactions
+	require.NoError(t, kv.Write("x1", []byte("other")))
+	require.NoError(t, kv.Txn(nil, []store.Op{{Key: "w2", Value: []byte("two")}}))
+	event = next()
+	require.Equal(t, store.EventPut, event.Type)
+	require.Equal(t, "w2", event.Key)
+	require.Equal(t, []byte("two"), event.Value)
+
+	require.NoError(t, kv.Delete("w1"))
+	event = next()
+	require.Equal(t, store.EventDelete, event.Type)
+	require.Equal(t, "w1", event.Key)
+
+	close(done)
+	for range events {
+	}
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change is a difference between two documents at a path.  Old is nil when the value is added, and New is nil
+// when the value is removed.
+type Change struct {
+	// Path is the / separated path of the value changed.  Array elements are indexed as in Ports[0].
+	Path string
+
+	// Old is the value before the change.
+	Old *Any `json:",omitempty"`
+
+	// New is the value after the change.
+	New *Any `json:",omitempty"`
+}
+
+// Diff returns the field-level differences between two documents, sorted by path.  Objects are compared field by
+// field and arrays of the same length element by element.  Any other difference is reported for the whole value.
+// A nil document is the same as an empty object.
+func Diff(a, b *Any) ([]Change, error) {
+	va, vb := map[string]interface{}{}, map[string]interface{}{}
+	var oa, ob interface{} = va, vb
+	if err := a.Decode(&oa); err != nil {
+		return nil, err
+	}
+	if err := b.Decode(&ob); err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	diffValues("", oa, ob, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func diffValues(path string, a, b interface{}, changes *[]Change) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, is := b.(map[string]interface{}); is {
+			diffObjects(path, a, b, changes)
+			return
+		}
+	case []interface{}:
+		if b, is := b.([]interface{}); is && len(a) == len(b) {
+			for i := range a {
+				diffValues(fmt.Sprintf("%s[%d]", path, i), a[i], b[i], changes)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: pathOrRoot(path), Old: AnyValueMust(a), New: AnyValueMust(b)})
+	}
+}
+
+func diffObjects(path string, a, b map[string]interface{}, changes *[]Change) {
+	for k, va := range a {
+		vb, has := b[k]
+		if !has {
+			*changes = append(*changes, Change{Path: joinPath(path, k), Old: AnyValueMust(va)})
+			continue
+		}
+		diffValues(joinPath(path, k), va, vb, changes)
+	}
+	for k, vb := range b {
+		if _, has := a[k]; !has {
+			*changes = append(*changes, Change{Path: joinPath(path, k), New: AnyValueMust(vb)})
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	key = strings.Replace(key, "/", "~1", -1)
+	if path == "" {
+		return key
+	}
+	return path + "/" + key
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
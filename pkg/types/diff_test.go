@@ -0,0 +1,72 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	a := AnyYAMLMust([]byte(`
+Instance:
+  Plugin: simulator/compute
+  Properties:
+    Image: ubuntu:16.04
+    Ports: [ 80, 443 ]
+    Tags:
+      env: dev
+      a/b: 1
+Flavor:
+  Plugin: vanilla
+`))
+	b := AnyYAMLMust([]byte(`
+Instance:
+  Plugin: simulator/compute
+  Properties:
+    Image: ubuntu:18.04
+    Ports: [ 80, 8443 ]
+    Tags:
+      a/b: 1
+      tier: web
+    Volumes: [ data ]
+Flavor:
+  Plugin: vanilla
+`))
+
+	changes, err := Diff(a, b)
+	require.NoError(t, err)
+
+	paths := []string{}
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	require.Equal(t, []string{
+		"Instance/Properties/Image",
+		"Instance/Properties/Ports[1]",
+		"Instance/Properties/Tags/env",
+		"Instance/Properties/Tags/tier",
+		"Instance/Properties/Volumes",
+	}, paths)
+
+	require.Equal(t, `"ubuntu:16.04"`, changes[0].Old.String())
+	require.Equal(t, `"ubuntu:18.04"`, changes[0].New.String())
+	require.Equal(t, `443`, changes[1].Old.String())
+	require.Equal(t, `8443`, changes[1].New.String())
+	require.Equal(t, `"dev"`, changes[2].Old.String())
+	require.Nil(t, changes[2].New)
+	require.Nil(t, changes[3].Old)
+	require.Equal(t, `"web"`, changes[3].New.String())
+
+	changes, err = Diff(a, a)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	changes, err = Diff(nil, AnyValueMust(map[string]interface{}{"Size": 3}))
+	require.NoError(t, err)
+	require.Equal(t, []Change{{Path: "Size", New: AnyValueMust(3)}}, changes)
+
+	changes, err = Diff(AnyValueMust([]int{1}), AnyValueMust([]int{1, 2}))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(changes))
+	require.Equal(t, ".", changes[0].Path)
+}
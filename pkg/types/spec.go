@@ -59,7 +59,7 @@ type Dependency struct {
 	Name string `json:"name"`
 
 	// Bind is an associative array of pointer to the fields in the object to a variable name that will be referenced
-	// in the properties or template of the owning spec.
+	// in the properties of the owning spec, as values @bind(name), or in its template, as {{ var `name` }}.
 	Bind map[string]*Pointer `json:"bind"`
 }
 
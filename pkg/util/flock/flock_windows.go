@@ -0,0 +1,21 @@
+package flock
+
+import (
+	"os"
+	"sync"
+)
+
+// There are no advisory locks here, so the files are only locked within a single process.
+var fileLock sync.Mutex
+
+// Lock takes an exclusive lock, blocking until the lock is available.
+func Lock(f *os.File) error {
+	fileLock.Lock()
+	return nil
+}
+
+// Unlock releases the lock
+func Unlock(f *os.File) error {
+	fileLock.Unlock()
+	return nil
+}
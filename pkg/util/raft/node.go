@@ -0,0 +1,709 @@
+package raft
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+)
+
+var (
+	log    = logutil.New("module", "util/raft")
+	debugV = logutil.V(500)
+
+	// ErrNotLeader is returned when a command is proposed to a node that is not the leader, or when the leader
+	// lost the leadership before the command was committed.
+	ErrNotLeader = errors.New("not the leader")
+
+	// ErrTimeout is returned when a command is not committed in time.  The command may still be committed later.
+	ErrTimeout = errors.New("timeout waiting for commit")
+
+	// ErrStopped is returned when the node is stopped
+	ErrStopped = errors.New("raft node stopped")
+)
+
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leading
+)
+
+// maxEntries is the maximum number of entries sent in an AppendRequest
+const maxEntries = 256
+
+type waiter struct {
+	term uint64
+	done chan error
+}
+
+// Node is a member of a raft cluster.  Commands proposed to the leader are replicated to a majority of the nodes
+// before being applied, in the same order, to the state machine of each node.
+type Node struct {
+	config Config
+	sm     StateMachine
+	lock   sync.Mutex
+	rand   *rand.Rand
+
+	state State
+
+	role        role
+	leader      string
+	commitIndex uint64
+	lastApplied uint64
+
+	// readyIndex is the index of the first entry of the leader's term.  The leadership is only reported once it is
+	// applied, since the entries of the earlier terms are then all applied.
+	readyIndex uint64
+
+	nextIndex   map[string]uint64
+	matchIndex  map[string]uint64
+	lastContact map[string]time.Time
+	inflight    map[string]bool
+	votes       map[string]bool
+
+	electionDeadline time.Time
+	waiters          map[uint64]waiter
+	stop             chan struct{}
+	stopped          bool
+}
+
+// NewNode returns a node given the config and the state machine replicated.  The state saved in the storage is
+// restored.  Call Start to join the cluster.
+func NewNode(config Config, sm StateMachine) (*Node, error) {
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if config.ElectionTimeout == 0 {
+		config.ElectionTimeout = DefaultElectionTimeout
+	}
+	if config.SnapshotThreshold == 0 {
+		config.SnapshotThreshold = DefaultSnapshotThreshold
+	}
+	if config.Storage == nil {
+		config.Storage = NewMemStorage()
+	}
+
+	state, err := config.Storage.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state.Snapshot.Data != nil {
+		if err := sm.Restore(state.Snapshot.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	n := &Node{
+		config:      config,
+		sm:          sm,
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		state:       state,
+		commitIndex: state.Snapshot.Index,
+		lastApplied: state.Snapshot.Index,
+		waiters:     map[uint64]waiter{},
+		stop:        make(chan struct{}),
+	}
+	n.resetElectionDeadline()
+	return n, nil
+}
+
+// ID returns the id of the node
+func (n *Node) ID() string {
+	return n.config.ID
+}
+
+// Start starts the node.  It does not block.
+func (n *Node) Start() {
+	go func() {
+		ticker := time.NewTicker(n.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-n.stop:
+				return
+			case <-ticker.C:
+				n.tick()
+			}
+		}
+	}()
+}
+
+// Stop stops the node.  The commands waiting to be committed fail with ErrStopped.
+func (n *Node) Stop() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.stopped {
+		return
+	}
+	n.stopped = true
+	close(n.stop)
+	if c, is := n.config.Storage.(io.Closer); is {
+		c.Close()
+	}
+	for index, w := range n.waiters {
+		delete(n.waiters, index)
+		w.done <- ErrStopped
+	}
+}
+
+// Status returns true if this node is the leader, and the current term.  The term increases each time a new
+// leader is elected, so it is a fencing token of the leadership.
+func (n *Node) Status() (bool, uint64) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.isLeader(), n.state.Term
+}
+
+// IsLeader returns true if this node is the leader
+func (n *Node) IsLeader() bool {
+	isLeader, _ := n.Status()
+	return isLeader
+}
+
+// Leader returns the id of the leader known to this node, if any
+func (n *Node) Leader() string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.leader
+}
+
+func (n *Node) isLeader() bool {
+	return n.role == leading && n.lastApplied >= n.readyIndex
+}
+
+// Propose proposes a command to the leader and waits until the command is committed and applied, or until the
+// timeout.  The error returned by the state machine is returned.
+func (n *Node) Propose(command []byte, timeout time.Duration) error {
+	n.lock.Lock()
+	if n.stopped {
+		n.lock.Unlock()
+		return ErrStopped
+	}
+	if !n.isLeader() {
+		n.lock.Unlock()
+		return ErrNotLeader
+	}
+
+	entry := Entry{Term: n.state.Term, Index: n.lastIndex() + 1, Command: command}
+	n.state.Log = append(n.state.Log, entry)
+	if err := n.persistEntries(entry.Index); err != nil {
+		n.state.Log = n.state.Log[:len(n.state.Log)-1]
+		n.lock.Unlock()
+		return err
+	}
+
+	done := make(chan error, 1)
+	n.waiters[entry.Index] = waiter{term: entry.Term, done: done}
+	n.advanceCommit()
+	n.replicate()
+	n.lock.Unlock()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		n.lock.Lock()
+		delete(n.waiters, entry.Index)
+		n.lock.Unlock()
+		return ErrTimeout
+	}
+}
+
+func (n *Node) lastIndex() uint64 {
+	if len(n.state.Log) == 0 {
+		return n.state.Snapshot.Index
+	}
+	return n.state.Log[len(n.state.Log)-1].Index
+}
+
+func (n *Node) lastTerm() uint64 {
+	return n.termAt(n.lastIndex())
+}
+
+// termAt returns the term of the entry at the index, or 0 if the entry has been compacted or does not exist.
+func (n *Node) termAt(index uint64) uint64 {
+	switch {
+	case index == n.state.Snapshot.Index:
+		return n.state.Snapshot.Term
+	case index < n.state.Snapshot.Index || index > n.lastIndex():
+		return 0
+	}
+	return n.entryAt(index).Term
+}
+
+func (n *Node) entryAt(index uint64) Entry {
+	return n.state.Log[index-n.state.Snapshot.Index-1]
+}
+
+func (n *Node) others() []string {
+	peers := []string{}
+	for _, id := range n.config.Members {
+		if id != n.config.ID {
+			peers = append(peers, id)
+		}
+	}
+	return peers
+}
+
+func (n *Node) majority(count int) bool {
+	return count*2 > len(n.config.Members)
+}
+
+// persist saves all the state, replacing the state saved
+func (n *Node) persist() error {
+	return n.saved(n.config.Storage.Save(n.state))
+}
+
+// persistVote saves the term and the vote
+func (n *Node) persistVote() error {
+	return n.saved(n.config.Storage.SaveVote(n.state.Term, n.state.VotedFor))
+}
+
+// persistEntries saves the entries of the log from the index on, replacing those saved
+func (n *Node) persistEntries(index uint64) error {
+	return n.saved(n.config.Storage.Append(n.state.Log[index-n.state.Snapshot.Index-1:]))
+}
+
+func (n *Node) saved(err error) error {
+	if err != nil {
+		log.Error("Cannot save state", "id", n.config.ID, "err", err)
+	}
+	return err
+}
+
+func (n *Node) resetElectionDeadline() {
+	timeout := n.config.ElectionTimeout + time.Duration(n.rand.Int63n(int64(n.config.ElectionTimeout)))
+	n.electionDeadline = time.Now().Add(timeout)
+}
+
+func (n *Node) tick() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	now := time.Now()
+	if n.role == leading {
+		if !n.hasQuorum(now) {
+			log.Warn("Lost contact with the majority, stepping down", "id", n.config.ID, "term", n.state.Term)
+			n.becomeFollower(n.state.Term, "")
+			return
+		}
+		n.replicate()
+		return
+	}
+	if now.After(n.electionDeadline) {
+		n.startElection()
+	}
+}
+
+// hasQuorum returns true if the leader has heard from a majority within the election timeout.  A leader cut off
+// from the majority steps down, so it does not act as the leader while another is elected.
+func (n *Node) hasQuorum(now time.Time) bool {
+	count := 1
+	for _, peer := range n.others() {
+		if now.Sub(n.lastContact[peer]) < n.config.ElectionTimeout {
+			count++
+		}
+	}
+	return n.majority(count)
+}
+
+func (n *Node) becomeFollower(term uint64, leader string) {
+	if term > n.state.Term {
+		n.state.Term = term
+		n.state.VotedFor = ""
+		n.persistVote()
+	}
+	if n.role == leading {
+		log.Info("Lost leadership", "id", n.config.ID, "term", n.state.Term)
+	}
+	n.role = follower
+	n.leader = leader
+}
+
+func (n *Node) startElection() {
+	n.state.Term++
+	n.state.VotedFor = n.config.ID
+	n.role = candidate
+	n.leader = ""
+	n.votes = map[string]bool{n.config.ID: true}
+	n.resetElectionDeadline()
+	if err := n.persistVote(); err != nil {
+		return
+	}
+
+	log.Debug("Starting election", "id", n.config.ID, "term", n.state.Term, "V", debugV)
+	if n.majority(len(n.votes)) {
+		n.becomeLeader()
+		return
+	}
+
+	req := VoteRequest{
+		Term:         n.state.Term,
+		Candidate:    n.config.ID,
+		LastLogIndex: n.lastIndex(),
+		LastLogTerm:  n.lastTerm(),
+	}
+	for _, peer := range n.others() {
+		go func(peer string) {
+			resp, err := n.config.Transport.RequestVote(peer, req)
+			if err != nil {
+				log.Debug("Cannot request vote", "id", n.config.ID, "peer", peer, "err", err, "V", debugV)
+				return
+			}
+
+			n.lock.Lock()
+			defer n.lock.Unlock()
+
+			if resp.Term > n.state.Term {
+				n.becomeFollower(resp.Term, "")
+				return
+			}
+			if n.role != candidate || n.state.Term != req.Term || !resp.Granted {
+				return
+			}
+			n.votes[peer] = true
+			if n.majority(len(n.votes)) {
+				n.becomeLeader()
+			}
+		}(peer)
+	}
+}
+
+func (n *Node) becomeLeader() {
+	log.Info("Elected leader", "id", n.config.ID, "term", n.state.Term)
+
+	n.role = leading
+	n.leader = n.config.ID
+	n.nextIndex = map[string]uint64{}
+	n.matchIndex = map[string]uint64{}
+	n.lastContact = map[string]time.Time{}
+	n.inflight = map[string]bool{}
+
+	now := time.Now()
+	for _, peer := range n.others() {
+		n.nextIndex[peer] = n.lastIndex() + 1
+		n.lastContact[peer] = now
+	}
+
+	// Committing an entry of the new term commits the entries of the earlier terms.
+	entry := Entry{Term: n.state.Term, Index: n.lastIndex() + 1}
+	n.state.Log = append(n.state.Log, entry)
+	n.readyIndex = entry.Index
+	n.persistEntries(entry.Index)
+
+	n.advanceCommit()
+	n.replicate()
+}
+
+func (n *Node) replicate() {
+	for _, peer := range n.others() {
+		if !n.inflight[peer] {
+			n.inflight[peer] = true
+			go n.replicateTo(peer)
+		}
+	}
+}
+
+// replicateTo sends the entries, or the snapshot, that the peer is missing.  At most one request is in flight
+// to each peer.
+func (n *Node) replicateTo(peer string) {
+	n.lock.Lock()
+	if n.role != leading || n.stopped {
+		n.inflight[peer] = false
+		n.lock.Unlock()
+		return
+	}
+
+	term := n.state.Term
+	next := n.nextIndex[peer]
+
+	if next <= n.state.Snapshot.Index {
+		req := SnapshotRequest{Term: term, Leader: n.config.ID, Snapshot: n.state.Snapshot}
+		n.lock.Unlock()
+
+		resp, err := n.config.Transport.InstallSnapshot(peer, req)
+
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		n.inflight[peer] = false
+
+		if err != nil || !n.replied(peer, term, resp.Term) {
+			return
+		}
+		if req.Snapshot.Index > n.matchIndex[peer] {
+			n.matchIndex[peer] = req.Snapshot.Index
+		}
+		n.nextIndex[peer] = n.matchIndex[peer] + 1
+		n.advanceCommit()
+		return
+	}
+
+	req := AppendRequest{
+		Term:         term,
+		Leader:       n.config.ID,
+		PrevLogIndex: next - 1,
+		PrevLogTerm:  n.termAt(next - 1),
+		LeaderCommit: n.commitIndex,
+	}
+	for i := next; i <= n.lastIndex() && len(req.Entries) < maxEntries; i++ {
+		req.Entries = append(req.Entries, n.entryAt(i))
+	}
+	n.lock.Unlock()
+
+	resp, err := n.config.Transport.AppendEntries(peer, req)
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.inflight[peer] = false
+
+	if err != nil {
+		log.Debug("Cannot append entries", "id", n.config.ID, "peer", peer, "err", err, "V", debugV)
+		return
+	}
+	if !n.replied(peer, term, resp.Term) {
+		return
+	}
+
+	if resp.Success {
+		if match := req.PrevLogIndex + uint64(len(req.Entries)); match > n.matchIndex[peer] {
+			n.matchIndex[peer] = match
+		}
+		n.nextIndex[peer] = n.matchIndex[peer] + 1
+		n.advanceCommit()
+	} else {
+		// Back up to where the logs may match
+		next := n.nextIndex[peer] - 1
+		if resp.LastIndex+1 < next {
+			next = resp.LastIndex + 1
+		}
+		if next < 1 {
+			next = 1
+		}
+		n.nextIndex[peer] = next
+	}
+
+	if n.nextIndex[peer] <= n.lastIndex() {
+		n.inflight[peer] = true
+		go n.replicateTo(peer)
+	}
+}
+
+// replied handles the term of a response from the peer.  It returns false if the response is no longer relevant.
+func (n *Node) replied(peer string, term, respTerm uint64) bool {
+	if respTerm > n.state.Term {
+		n.becomeFollower(respTerm, "")
+		return false
+	}
+	if n.role != leading || n.state.Term != term {
+		return false
+	}
+	n.lastContact[peer] = time.Now()
+	return true
+}
+
+// advanceCommit commits the entries of the current term replicated on a majority of the nodes.
+func (n *Node) advanceCommit() {
+	for i := n.lastIndex(); i > n.commitIndex && i > n.state.Snapshot.Index; i-- {
+		if n.termAt(i) != n.state.Term {
+			break
+		}
+		count := 1
+		for _, peer := range n.others() {
+			if n.matchIndex[peer] >= i {
+				count++
+			}
+		}
+		if n.majority(count) {
+			n.commitIndex = i
+			break
+		}
+	}
+	n.applyCommitted()
+}
+
+func (n *Node) applyCommitted() {
+	for n.lastApplied < n.commitIndex {
+		n.lastApplied++
+		entry := n.entryAt(n.lastApplied)
+
+		var err error
+		if entry.Command != nil {
+			err = n.sm.Apply(entry.Command)
+		}
+
+		if w, has := n.waiters[entry.Index]; has {
+			delete(n.waiters, entry.Index)
+			if w.term != entry.Term {
+				// The entry proposed was replaced by the entry of another leader.
+				err = ErrNotLeader
+			}
+			w.done <- err
+		}
+	}
+	n.compact()
+}
+
+// compact replaces the entries applied by a snapshot of the state machine once there are enough of them.
+func (n *Node) compact() {
+	if n.lastApplied-n.state.Snapshot.Index < uint64(n.config.SnapshotThreshold) {
+		return
+	}
+	data, err := n.sm.Snapshot()
+	if err != nil {
+		log.Warn("Cannot snapshot", "id", n.config.ID, "err", err)
+		return
+	}
+	snapshot := Snapshot{Index: n.lastApplied, Term: n.termAt(n.lastApplied), Data: data}
+	n.state.Log = append([]Entry(nil), n.state.Log[n.lastApplied-n.state.Snapshot.Index:]...)
+	n.state.Snapshot = snapshot
+	n.persist()
+	log.Debug("Compacted log", "id", n.config.ID, "index", snapshot.Index, "V", debugV)
+}
+
+// RequestVote implements Handler.RequestVote
+func (n *Node) RequestVote(req VoteRequest) (VoteResponse, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.stopped {
+		return VoteResponse{}, ErrStopped
+	}
+	if req.Term > n.state.Term {
+		n.becomeFollower(req.Term, "")
+	}
+
+	resp := VoteResponse{Term: n.state.Term}
+	if req.Term < n.state.Term {
+		return resp, nil
+	}
+
+	upToDate := req.LastLogTerm > n.lastTerm() ||
+		(req.LastLogTerm == n.lastTerm() && req.LastLogIndex >= n.lastIndex())
+	if (n.state.VotedFor == "" || n.state.VotedFor == req.Candidate) && upToDate {
+		n.state.VotedFor = req.Candidate
+		if err := n.persistVote(); err != nil {
+			return resp, err
+		}
+		n.resetElectionDeadline()
+		resp.Granted = true
+	}
+	return resp, nil
+}
+
+// follow handles a request from the leader of the term.  It returns false if the leader's term is stale.
+func (n *Node) follow(term uint64, leader string) bool {
+	if term < n.state.Term {
+		return false
+	}
+	if term > n.state.Term || n.role != follower {
+		n.becomeFollower(term, leader)
+	}
+	n.leader = leader
+	n.resetElectionDeadline()
+	return true
+}
+
+// AppendEntries implements Handler.AppendEntries
+func (n *Node) AppendEntries(req AppendRequest) (AppendResponse, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.stopped {
+		return AppendResponse{}, ErrStopped
+	}
+
+	resp := AppendResponse{Term: n.state.Term}
+	if !n.follow(req.Term, req.Leader) {
+		return resp, nil
+	}
+	resp.Term = n.state.Term
+
+	if req.PrevLogIndex > n.lastIndex() {
+		resp.LastIndex = n.lastIndex()
+		return resp, nil
+	}
+	if req.PrevLogIndex >= n.state.Snapshot.Index && n.termAt(req.PrevLogIndex) != req.PrevLogTerm {
+		resp.LastIndex = req.PrevLogIndex - 1
+		return resp, nil
+	}
+
+	// from is the index of the first entry appended, if any
+	from := uint64(0)
+	for _, entry := range req.Entries {
+		if entry.Index <= n.state.Snapshot.Index {
+			// Compacted, hence committed
+			continue
+		}
+		if entry.Index <= n.lastIndex() {
+			if n.termAt(entry.Index) == entry.Term {
+				continue
+			}
+			// Conflicts with the leader's log: drop this entry and all that follow
+			n.state.Log = n.state.Log[:entry.Index-n.state.Snapshot.Index-1]
+		}
+		n.state.Log = append(n.state.Log, entry)
+		if from == 0 {
+			from = entry.Index
+		}
+	}
+	if from > 0 {
+		if err := n.persistEntries(from); err != nil {
+			return resp, err
+		}
+	}
+
+	last := req.PrevLogIndex + uint64(len(req.Entries))
+	if commit := req.LeaderCommit; commit > n.commitIndex {
+		if commit > last {
+			commit = last
+		}
+		if commit > n.commitIndex {
+			n.commitIndex = commit
+			n.applyCommitted()
+		}
+	}
+
+	resp.Success = true
+	resp.LastIndex = last
+	return resp, nil
+}
+
+// InstallSnapshot implements Handler.InstallSnapshot
+func (n *Node) InstallSnapshot(req SnapshotRequest) (SnapshotResponse, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.stopped {
+		return SnapshotResponse{}, ErrStopped
+	}
+
+	resp := SnapshotResponse{Term: n.state.Term}
+	if !n.follow(req.Term, req.Leader) {
+		return resp, nil
+	}
+	resp.Term = n.state.Term
+
+	snapshot := req.Snapshot
+	if snapshot.Index <= n.commitIndex {
+		return resp, nil
+	}
+	if err := n.sm.Restore(snapshot.Data); err != nil {
+		return resp, err
+	}
+
+	// Keep the entries following the snapshot if the logs match
+	if snapshot.Index <= n.lastIndex() && n.termAt(snapshot.Index) == snapshot.Term {
+		n.state.Log = append([]Entry(nil), n.state.Log[snapshot.Index-n.state.Snapshot.Index:]...)
+	} else {
+		n.state.Log = nil
+	}
+	n.state.Snapshot = snapshot
+	n.commitIndex = snapshot.Index
+	n.lastApplied = snapshot.Index
+	return resp, n.persist()
+}
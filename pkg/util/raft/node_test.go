@@ -0,0 +1,441 @@
+package raft
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/rpc"
+	testing_tls "github.com/docker/infrakit/pkg/testing/tls"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(id string, members []string) Config {
+	return Config{
+		ID:                id,
+		Members:           members,
+		HeartbeatInterval: 5 * time.Millisecond,
+		ElectionTimeout:   50 * time.Millisecond,
+	}
+}
+
+func testCluster(t *testing.T, network *Network, threshold int, ids ...string) map[string]*Store {
+	stores := map[string]*Store{}
+	for _, id := range ids {
+		config := testConfig(id, ids)
+		config.Transport = network.Transport(id)
+		config.SnapshotThreshold = threshold
+		s, err := NewStore(config)
+		require.NoError(t, err)
+		s.ProposeTimeout = time.Second
+		network.Register(id, s)
+		stores[id] = s
+	}
+	for _, s := range stores {
+		s.Start()
+	}
+	return stores
+}
+
+func stopAll(stores map[string]*Store) {
+	for _, s := range stores {
+		s.Stop()
+	}
+}
+
+// waitForLeader waits for one of the stores given to lead, and returns it
+func waitForLeader(t *testing.T, stores map[string]*Store, except ...string) *Store {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+	loop:
+		for id, s := range stores {
+			for _, e := range except {
+				if id == e {
+					continue loop
+				}
+			}
+			if s.IsLeader() {
+				return s
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.FailNow(t, "no leader elected")
+	return nil
+}
+
+func waitForValue(t *testing.T, s *Store, key, value string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if v, has := s.Get(key); has && string(v) == value {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.FailNow(t, fmt.Sprintf("%v does not have %v=%v", s.ID(), key, value))
+}
+
+func TestElectionAndReplication(t *testing.T) {
+	network := NewNetwork()
+	stores := testCluster(t, network, 0, "m1", "m2", "m3")
+	defer stopAll(stores)
+
+	first := waitForLeader(t, stores)
+	_, term := first.Status()
+
+	require.NoError(t, first.Put("a", []byte("1")))
+	for _, s := range stores {
+		waitForValue(t, s, "a", "1")
+	}
+
+	// Only the leader accepts writes
+	for _, s := range stores {
+		if s != first {
+			require.Equal(t, ErrNotLeader, s.Put("a", []byte("2")))
+		}
+	}
+
+	// Partition the leader: the others elect a new leader in a later term and the old leader steps down
+	network.Disconnect(first.ID())
+	second := waitForLeader(t, stores, first.ID())
+	isLeader, secondTerm := second.Status()
+	require.True(t, isLeader)
+	require.True(t, secondTerm > term)
+
+	require.NoError(t, second.Put("a", []byte("2")))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for first.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.False(t, first.IsLeader())
+	require.Error(t, first.Put("a", []byte("3")))
+
+	// The old leader catches up once reconnected
+	network.Reconnect(first.ID())
+	waitForValue(t, first, "a", "2")
+
+	require.NoError(t, waitForLeader(t, stores).Delete("a"))
+	require.Error(t, waitForLeader(t, stores).Delete("a"))
+	for _, s := range stores {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(s.Keys("")) > 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		require.Empty(t, s.Keys(""))
+	}
+}
+
+// entryTerm returns the term of the entry of the log of the store at the index
+func entryTerm(s *Store, index uint64) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.termAt(index)
+}
+
+func lastIndex(s *Store) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lastIndex()
+}
+
+func TestLeaderPartitionedWhileProposing(t *testing.T) {
+	network := NewNetwork()
+	stores := testCluster(t, network, 0, "m1", "m2", "m3")
+	defer stopAll(stores)
+
+	first := waitForLeader(t, stores)
+	_, term := first.Status()
+	require.NoError(t, first.Put("a", []byte("1")))
+	for _, s := range stores {
+		waitForValue(t, s, "a", "1")
+	}
+
+	// The leader is cut off as a client proposes to it: the entry is appended to its log only
+	index := lastIndex(first) + 1
+	network.Disconnect(first.ID())
+	lost := make(chan error, 1)
+	go func() {
+		lost <- first.Put("a", []byte("lost"))
+	}()
+
+	// The others elect a new leader, whose entries conflict with the entry of the old leader
+	second := waitForLeader(t, stores, first.ID())
+	_, secondTerm := second.Status()
+	require.True(t, secondTerm > term)
+	require.NoError(t, second.Put("a", []byte("2")))
+	require.Error(t, <-lost)
+	require.Equal(t, term, entryTerm(first, index))
+
+	// Once reconnected, the old leader drops the conflicting entry for those of the new leader
+	network.Reconnect(first.ID())
+	waitForValue(t, first, "a", "2")
+	require.Equal(t, secondTerm, entryTerm(first, index))
+	for _, s := range stores {
+		waitForValue(t, s, "a", "2")
+	}
+}
+
+func TestAppendEntriesConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "m2.json")
+	config := testConfig("m2", []string{"m1", "m2", "m3"})
+	config.Transport = NewNetwork().Transport("m2")
+	config.Storage = NewFileStorage(path)
+	s, err := NewStore(config)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	resp, err := s.AppendEntries(AppendRequest{
+		Term:    1,
+		Leader:  "m1",
+		Entries: []Entry{{Term: 1, Index: 1}, {Term: 1, Index: 2}, {Term: 1, Index: 3}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	// The logs do not match at the previous entry
+	resp, err = s.AppendEntries(AppendRequest{Term: 2, Leader: "m3", PrevLogIndex: 2, PrevLogTerm: 2})
+	require.NoError(t, err)
+	require.False(t, resp.Success)
+	require.Equal(t, uint64(1), resp.LastIndex)
+
+	// The entries from the first conflicting with the new leader's are dropped
+	resp, err = s.AppendEntries(AppendRequest{
+		Term:         2,
+		Leader:       "m3",
+		PrevLogIndex: 1,
+		PrevLogTerm:  1,
+		Entries:      []Entry{{Term: 1, Index: 2}, {Term: 2, Index: 3}, {Term: 2, Index: 4}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+	require.Equal(t, uint64(4), resp.LastIndex)
+
+	expected := []Entry{{Term: 1, Index: 1}, {Term: 1, Index: 2}, {Term: 2, Index: 3}, {Term: 2, Index: 4}}
+	s.lock.Lock()
+	require.Equal(t, expected, s.state.Log)
+	s.lock.Unlock()
+
+	// The log saved matches
+	saved, err := NewFileStorage(path).Load()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), saved.Term)
+	require.Equal(t, expected, saved.Log)
+}
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "m1.json")
+	storage := NewFileStorage(path)
+	defer storage.(*FileStorage).Close()
+
+	snapshot := Snapshot{Index: 2, Term: 1, Data: []byte("data")}
+	require.NoError(t, storage.Save(State{Term: 1, Snapshot: snapshot}))
+	require.NoError(t, storage.Append([]Entry{{Term: 1, Index: 3}, {Term: 1, Index: 4}}))
+	require.NoError(t, storage.SaveVote(2, "m2"))
+	require.NoError(t, storage.Append([]Entry{{Term: 2, Index: 4, Command: []byte("c")}}))
+
+	expected := State{
+		Term:     2,
+		VotedFor: "m2",
+		Snapshot: snapshot,
+		Log:      []Entry{{Term: 1, Index: 3}, {Term: 2, Index: 4, Command: []byte("c")}},
+	}
+	loaded, err := NewFileStorage(path).Load()
+	require.NoError(t, err)
+	require.Equal(t, expected, loaded)
+
+	// The changes are appended, not saved with all the state
+	buff, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, strings.Count(string(buff), "\n"))
+
+	// A change partially saved is dropped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"Entries":[{"Term":2,`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	restarted := NewFileStorage(path)
+	defer restarted.(*FileStorage).Close()
+	loaded, err = restarted.Load()
+	require.NoError(t, err)
+	require.Equal(t, expected, loaded)
+
+	require.NoError(t, restarted.Append([]Entry{{Term: 2, Index: 5}}))
+	loaded, err = NewFileStorage(path).Load()
+	require.NoError(t, err)
+	require.Equal(t, append(expected.Log, Entry{Term: 2, Index: 5}), loaded.Log)
+
+	// Saving all the state replaces the changes
+	require.NoError(t, restarted.Save(expected))
+	loaded, err = NewFileStorage(path).Load()
+	require.NoError(t, err)
+	require.Equal(t, expected, loaded)
+}
+
+func TestSnapshotInstall(t *testing.T) {
+	network := NewNetwork()
+	stores := testCluster(t, network, 5, "m1", "m2", "m3")
+	defer stopAll(stores)
+
+	leader := waitForLeader(t, stores)
+	var lagging *Store
+	for _, s := range stores {
+		if s != leader {
+			lagging = s
+			break
+		}
+	}
+	network.Disconnect(lagging.ID())
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, waitForLeader(t, stores, lagging.ID()).Put(fmt.Sprintf("k%02d", i), []byte(fmt.Sprintf("%d", i))))
+	}
+
+	// The log has been compacted, so the lagging node must install a snapshot
+	network.Reconnect(lagging.ID())
+	waitForValue(t, lagging, "k19", "19")
+	require.Equal(t, 20, len(lagging.Keys("k")))
+	require.Equal(t, "k00", lagging.Keys("k")[0])
+}
+
+func TestFileStorageRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := testConfig("m1", []string{"m1"})
+	config.Storage = NewFileStorage(filepath.Join(dir, "m1.json"))
+	config.SnapshotThreshold = 3
+
+	s, err := NewStore(config)
+	require.NoError(t, err)
+	s.Start()
+	waitForLeader(t, map[string]*Store{"m1": s})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Put(fmt.Sprintf("k%d", i), []byte("v")))
+	}
+	_, term := s.Status()
+	s.Stop()
+	require.Equal(t, ErrStopped, s.Put("k", []byte("v")))
+
+	// The state is restored from the snapshot and the log
+	s, err = NewStore(config)
+	require.NoError(t, err)
+	s.Start()
+	defer s.Stop()
+	waitForLeader(t, map[string]*Store{"m1": s})
+	require.Equal(t, 5, len(s.Keys("k")))
+	_, restarted := s.Status()
+	require.True(t, restarted > term)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	ids := []string{"m1", "m2", "m3"}
+	stores := map[string]*Store{}
+	handlers := map[string]*lateHandler{}
+	peers := map[string]string{}
+	for _, id := range ids {
+		h := &lateHandler{}
+		server := httptest.NewServer(NewHTTPHandler(h))
+		defer server.Close()
+		handlers[id] = h
+		peers[id] = server.URL
+	}
+	for _, id := range ids {
+		config := testConfig(id, ids)
+		config.Transport = NewHTTPTransport(peers, time.Second, nil)
+		s, err := NewStore(config)
+		require.NoError(t, err)
+		handlers[id].Handler = s
+		stores[id] = s
+	}
+	for _, s := range stores {
+		s.Start()
+	}
+	defer stopAll(stores)
+
+	require.NoError(t, waitForLeader(t, stores).Put("a", []byte("1")))
+	for _, s := range stores {
+		waitForValue(t, s, "a", "1")
+	}
+}
+
+// lateHandler lets the http servers start before the nodes, since the nodes need the urls of the servers
+type lateHandler struct {
+	Handler
+}
+
+func TestHTTPTransportTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infrakit-raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The nodes share a certificate, for m1
+	files := testing_tls.Certs(t, dir, "m1")
+	options := &rpc.TLSOptions{CAFile: files.CAFile, CertFile: files.CertFile, KeyFile: files.KeyFile}
+	serverTLS, err := options.ServerConfig()
+	require.NoError(t, err)
+	clientTLS, err := options.ClientConfig()
+	require.NoError(t, err)
+
+	ids := []string{"m1", "m2", "m3"}
+	stores := map[string]*Store{}
+	handlers := map[string]*lateHandler{}
+	peers := map[string]string{}
+	for _, id := range ids {
+		h := &lateHandler{}
+		server := httptest.NewUnstartedServer(AllowPeers([]string{"m1"}, NewHTTPHandler(h)))
+		server.TLS = serverTLS
+		server.StartTLS()
+		defer server.Close()
+		handlers[id] = h
+		peers[id] = server.URL
+	}
+	for _, id := range ids {
+		config := testConfig(id, ids)
+		config.Transport = NewHTTPTransport(peers, time.Second, clientTLS)
+		s, err := NewStore(config)
+		require.NoError(t, err)
+		handlers[id].Handler = s
+		stores[id] = s
+	}
+	for _, s := range stores {
+		s.Start()
+	}
+	defer stopAll(stores)
+
+	require.NoError(t, waitForLeader(t, stores).Put("a", []byte("1")))
+	for _, s := range stores {
+		waitForValue(t, s, "a", "1")
+	}
+
+	// Without a certificate
+	anonymous, err := (&rpc.TLSOptions{CAFile: files.CAFile}).ClientConfig()
+	require.NoError(t, err)
+	_, err = NewHTTPTransport(peers, time.Second, anonymous).RequestVote("m1", VoteRequest{})
+	require.Error(t, err)
+
+	// With the certificate of an identity not allowed
+	server := httptest.NewUnstartedServer(AllowPeers([]string{"m2"}, NewHTTPHandler(stores["m1"])))
+	server.TLS = serverTLS
+	server.StartTLS()
+	defer server.Close()
+	_, err = NewHTTPTransport(map[string]string{"m1": server.URL}, time.Second, clientTLS).RequestVote("m1", VoteRequest{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "403")
+}
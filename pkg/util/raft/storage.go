@@ -0,0 +1,234 @@
+package raft
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type memStorage struct {
+	state State
+	lock  sync.Mutex
+}
+
+// NewMemStorage returns a storage that keeps the state in memory, for testing
+func NewMemStorage() Storage {
+	return &memStorage{}
+}
+
+// Load implements Storage.Load
+func (s *memStorage) Load() (State, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	state := s.state
+	state.Log = append([]Entry(nil), s.state.Log...)
+	return state, nil
+}
+
+// SaveVote implements Storage.SaveVote
+func (s *memStorage) SaveVote(term uint64, votedFor string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	record{Vote: &vote{Term: term, VotedFor: votedFor}}.apply(&s.state)
+	return nil
+}
+
+// Append implements Storage.Append
+func (s *memStorage) Append(entries []Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	record{Entries: append([]Entry(nil), entries...)}.apply(&s.state)
+	return nil
+}
+
+// Save implements Storage.Save
+func (s *memStorage) Save(state State) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.state = state
+	s.state.Log = append([]Entry(nil), state.Log...)
+	return nil
+}
+
+// vote is the term and the vote of the term
+type vote struct {
+	Term     uint64
+	VotedFor string
+}
+
+// record is a change of the state, saved as a line of the file of a FileStorage
+type record struct {
+	// State replaces all the state
+	State *State `json:",omitempty"`
+
+	// Vote is the term and the vote changed
+	Vote *vote `json:",omitempty"`
+
+	// Entries are the entries appended to the log, replacing those from the index of the first entry on
+	Entries []Entry `json:",omitempty"`
+}
+
+func (r record) apply(state *State) {
+	if r.State != nil {
+		*state = *r.State
+	}
+	if r.Vote != nil {
+		state.Term = r.Vote.Term
+		state.VotedFor = r.Vote.VotedFor
+	}
+	if len(r.Entries) == 0 || r.Entries[0].Index <= state.Snapshot.Index {
+		return
+	}
+	if keep := r.Entries[0].Index - state.Snapshot.Index - 1; keep < uint64(len(state.Log)) {
+		state.Log = state.Log[:keep]
+	}
+	state.Log = append(state.Log, r.Entries...)
+}
+
+// FileStorage saves the state in a file, as json lines of the changes of the state.  The votes and the entries
+// are appended to the file, so that they do not rewrite the whole state; the file is rewritten only when all the
+// state is saved, as the log is compacted.
+type FileStorage struct {
+	path string
+	file *os.File
+	lock sync.Mutex
+}
+
+// NewFileStorage returns a storage that saves the state in the file.  The directory of the file must exist.
+func NewFileStorage(path string) Storage {
+	return &FileStorage{path: path}
+}
+
+// Load implements Storage.Load.  A change partially written as the node stopped was not saved, and is dropped.
+func (s *FileStorage) Load() (State, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := State{}
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	size := int64(0)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) == 0 {
+				return state, nil
+			}
+			log.Warn("Dropping a change partially saved", "file", s.path, "offset", size)
+			return state, os.Truncate(s.path, size)
+		}
+		if err != nil {
+			return state, err
+		}
+		r := record{}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return state, err
+		}
+		r.apply(&state)
+		size += int64(len(line))
+	}
+}
+
+// append appends the change to the file, and truncates what was written of it if it fails
+func (s *FileStorage) append(r record) error {
+	buff, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		s.file = f
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(append(buff, '\n')); err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		s.file.Truncate(info.Size())
+	}
+	return err
+}
+
+// SaveVote implements Storage.SaveVote
+func (s *FileStorage) SaveVote(term uint64, votedFor string) error {
+	return s.append(record{Vote: &vote{Term: term, VotedFor: votedFor}})
+}
+
+// Append implements Storage.Append
+func (s *FileStorage) Append(entries []Entry) error {
+	return s.append(record{Entries: entries})
+}
+
+// Save implements Storage.Save.  The state is written to a temporary file which then replaces the file, so a
+// crash does not leave a partial state behind.
+func (s *FileStorage) Save(state State) error {
+	buff, err := json.Marshal(record{State: &state})
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(buff, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	// The changes are appended to the file replacing the one open
+	return s.close()
+}
+
+func (s *FileStorage) close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Close implements io.Closer
+func (s *FileStorage) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.close()
+}
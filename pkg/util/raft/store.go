@@ -0,0 +1,257 @@
+package raft
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProposeTimeout is how long writes wait to be committed by default
+const DefaultProposeTimeout = 10 * time.Second
+
+// Cmp is a condition of a transaction: the key must be at the version.  Version 0 means the key must not exist.
+type Cmp struct {
+	Key     string
+	Version uint64
+}
+
+// Op is an operation of a transaction: the value is written to the key, or the key is deleted
+type Op struct {
+	Key    string
+	Value  []byte `json:",omitempty"`
+	Delete bool   `json:",omitempty"`
+}
+
+// ErrConflict is returned when a key compared in a transaction is not at the version expected
+type ErrConflict struct {
+	Key      string
+	Expected uint64
+	Actual   uint64
+}
+
+func (e ErrConflict) Error() string {
+	return fmt.Sprintf("conflict on %v: expected version %d, actual %d", e.Key, e.Expected, e.Actual)
+}
+
+// command is a change of the key-value map
+type command struct {
+	Op   string
+	Key  string `json:",omitempty"`
+	Cmps []Cmp  `json:",omitempty"`
+	Ops  []Op   `json:",omitempty"`
+
+	Value []byte `json:",omitempty"`
+}
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+	opTxn    = "txn"
+)
+
+// item is a value and its version
+type item struct {
+	Value   []byte
+	Version uint64
+}
+
+// kvState is the state of the key-value map, as saved in the snapshots
+type kvState struct {
+	// Revision is the last version given to a value.  Since the commands are applied in the same order on all
+	// the nodes, the versions are the same on all the nodes.
+	Revision uint64
+
+	Data map[string]item
+}
+
+// Change is a change of a key applied to the store.  The version is 0 if the key is deleted.
+type Change struct {
+	Key     string
+	Value   []byte
+	Version uint64
+}
+
+// kv is the key-value map replicated
+type kv struct {
+	kvState
+	observers []func(Change)
+	lock      sync.RWMutex
+}
+
+func (m *kv) notify(c Change) {
+	for _, observer := range m.observers {
+		observer(c)
+	}
+}
+
+func (m *kv) put(key string, value []byte) {
+	m.Revision++
+	m.Data[key] = item{Value: value, Version: m.Revision}
+	m.notify(Change{Key: key, Value: value, Version: m.Revision})
+}
+
+func (m *kv) remove(key string) {
+	if _, has := m.Data[key]; !has {
+		return
+	}
+	delete(m.Data, key)
+	m.notify(Change{Key: key})
+}
+
+func (m *kv) Apply(buff []byte) error {
+	c := command{}
+	if err := json.Unmarshal(buff, &c); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	switch c.Op {
+	case opPut:
+		m.put(c.Key, c.Value)
+	case opDelete:
+		if _, has := m.Data[c.Key]; !has {
+			return fmt.Errorf("not found %v", c.Key)
+		}
+		m.remove(c.Key)
+	case opTxn:
+		for _, cmp := range c.Cmps {
+			if actual := m.Data[cmp.Key].Version; actual != cmp.Version {
+				return ErrConflict{Key: cmp.Key, Expected: cmp.Version, Actual: actual}
+			}
+		}
+		for _, op := range c.Ops {
+			if op.Delete {
+				m.remove(op.Key)
+				continue
+			}
+			m.put(op.Key, op.Value)
+		}
+	default:
+		return fmt.Errorf("unknown op %v", c.Op)
+	}
+	return nil
+}
+
+func (m *kv) Snapshot() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return json.Marshal(m.kvState)
+}
+
+func (m *kv) Restore(buff []byte) error {
+	state := kvState{}
+	if err := json.Unmarshal(buff, &state); err != nil {
+		return err
+	}
+	if state.Data == nil {
+		state.Data = map[string]item{}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Notify the changes between the states, as if they had been applied
+	for key := range m.Data {
+		if _, has := state.Data[key]; !has {
+			m.notify(Change{Key: key})
+		}
+	}
+	for key, v := range state.Data {
+		if m.Data[key].Version != v.Version {
+			m.notify(Change{Key: key, Value: v.Value, Version: v.Version})
+		}
+	}
+	m.kvState = state
+	return nil
+}
+
+// Store is a key-value map replicated by a raft cluster.  Writes are accepted only by the leader and return once
+// committed by a majority of the nodes.  Reads are served from the local replica, so they reflect the writes
+// committed and applied on this node.  The leader reports its leadership only once all the earlier writes are
+// applied, so the reads on the leader are current.
+type Store struct {
+	*Node
+
+	// ProposeTimeout is how long writes wait to be committed
+	ProposeTimeout time.Duration
+
+	kv *kv
+}
+
+// NewStore returns a store replicated by the node of the given config.  Call Start to join the cluster.
+func NewStore(config Config) (*Store, error) {
+	m := &kv{kvState: kvState{Data: map[string]item{}}}
+	node, err := NewNode(config, m)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		Node:           node,
+		ProposeTimeout: DefaultProposeTimeout,
+		kv:             m,
+	}, nil
+}
+
+func (s *Store) propose(c command) error {
+	buff, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return s.Propose(buff, s.ProposeTimeout)
+}
+
+// Put writes the value of the key
+func (s *Store) Put(key string, value []byte) error {
+	return s.propose(command{Op: opPut, Key: key, Value: value})
+}
+
+// Delete deletes the key.  An error is returned if the key does not exist.
+func (s *Store) Delete(key string) error {
+	return s.propose(command{Op: opDelete, Key: key})
+}
+
+// Txn applies the operations atomically if the keys compared are at their versions.  An ErrConflict is
+// returned if any key has changed.
+func (s *Store) Txn(cmps []Cmp, ops []Op) error {
+	return s.propose(command{Op: opTxn, Cmps: cmps, Ops: ops})
+}
+
+// Observe adds an observer of the changes, called as the changes are applied on this node.  The observer must not
+// block, or call the store.
+func (s *Store) Observe(observer func(Change)) {
+	s.kv.lock.Lock()
+	defer s.kv.lock.Unlock()
+	s.kv.observers = append(s.kv.observers, observer)
+}
+
+// Get returns the value of the key, and false if the key does not exist
+func (s *Store) Get(key string) ([]byte, bool) {
+	v, _, has := s.GetVersion(key)
+	return v, has
+}
+
+// GetVersion returns the value of the key and its version, and false if the key does not exist
+func (s *Store) GetVersion(key string) ([]byte, uint64, bool) {
+	s.kv.lock.RLock()
+	defer s.kv.lock.RUnlock()
+	v, has := s.kv.Data[key]
+	return v.Value, v.Version, has
+}
+
+// Keys returns the keys with the given prefix, sorted
+func (s *Store) Keys(prefix string) []string {
+	s.kv.lock.RLock()
+	defer s.kv.lock.RUnlock()
+	keys := []string{}
+	for k := range s.kv.Data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,214 @@
+package raft
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/rpc/auth"
+)
+
+const (
+	// VotePath is the path of the http endpoint for requests for vote
+	VotePath = "/raft/vote"
+
+	// AppendPath is the path of the http endpoint for appending entries
+	AppendPath = "/raft/append"
+
+	// SnapshotPath is the path of the http endpoint for installing snapshots
+	SnapshotPath = "/raft/snapshot"
+)
+
+// Network connects nodes in the same process, for testing.  Nodes can be disconnected to simulate partitions.
+type Network struct {
+	nodes        map[string]Handler
+	disconnected map[string]bool
+	lock         sync.RWMutex
+}
+
+// NewNetwork returns an in-process network
+func NewNetwork() *Network {
+	return &Network{
+		nodes:        map[string]Handler{},
+		disconnected: map[string]bool{},
+	}
+}
+
+// Register registers the handler of the node
+func (n *Network) Register(id string, h Handler) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.nodes[id] = h
+}
+
+// Disconnect disconnects the node from all the others
+func (n *Network) Disconnect(id string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.disconnected[id] = true
+}
+
+// Reconnect reconnects the node
+func (n *Network) Reconnect(id string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	delete(n.disconnected, id)
+}
+
+// Transport returns the transport of the node
+func (n *Network) Transport(id string) Transport {
+	return &memTransport{network: n, from: id}
+}
+
+type memTransport struct {
+	network *Network
+	from    string
+}
+
+func (t *memTransport) peer(id string) (Handler, error) {
+	t.network.lock.RLock()
+	defer t.network.lock.RUnlock()
+	h, has := t.network.nodes[id]
+	if !has || t.network.disconnected[id] || t.network.disconnected[t.from] {
+		return nil, fmt.Errorf("cannot reach %v from %v", id, t.from)
+	}
+	return h, nil
+}
+
+func (t *memTransport) RequestVote(peer string, req VoteRequest) (VoteResponse, error) {
+	h, err := t.peer(peer)
+	if err != nil {
+		return VoteResponse{}, err
+	}
+	return h.RequestVote(req)
+}
+
+func (t *memTransport) AppendEntries(peer string, req AppendRequest) (AppendResponse, error) {
+	h, err := t.peer(peer)
+	if err != nil {
+		return AppendResponse{}, err
+	}
+	return h.AppendEntries(req)
+}
+
+func (t *memTransport) InstallSnapshot(peer string, req SnapshotRequest) (SnapshotResponse, error) {
+	h, err := t.peer(peer)
+	if err != nil {
+		return SnapshotResponse{}, err
+	}
+	return h.InstallSnapshot(req)
+}
+
+type httpTransport struct {
+	peers  map[string]string
+	client *http.Client
+}
+
+// NewHTTPTransport returns a transport that posts the messages as json to the peers.  The peers map the id of
+// each node to its url, e.g. https://10.0.0.2:24865.  The config secures the connections to the peers over https,
+// and presents the certificate of the node if any; nil for plain http.
+func NewHTTPTransport(peers map[string]string, timeout time.Duration, config *tls.Config) Transport {
+	return &httpTransport{
+		peers: peers,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: config},
+		},
+	}
+}
+
+func (t *httpTransport) post(peer, path string, req, resp interface{}) error {
+	url, has := t.peers[peer]
+	if !has {
+		return fmt.Errorf("unknown peer %v", peer)
+	}
+	buff, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := t.client.Post(strings.TrimRight(url, "/")+path, "application/json", bytes.NewReader(buff))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("error from %v: %v", peer, r.Status)
+	}
+	return json.NewDecoder(r.Body).Decode(resp)
+}
+
+func (t *httpTransport) RequestVote(peer string, req VoteRequest) (resp VoteResponse, err error) {
+	err = t.post(peer, VotePath, req, &resp)
+	return
+}
+
+func (t *httpTransport) AppendEntries(peer string, req AppendRequest) (resp AppendResponse, err error) {
+	err = t.post(peer, AppendPath, req, &resp)
+	return
+}
+
+func (t *httpTransport) InstallSnapshot(peer string, req SnapshotRequest) (resp SnapshotResponse, err error) {
+	err = t.post(peer, SnapshotPath, req, &resp)
+	return
+}
+
+// NewHTTPHandler returns the http handler that serves the messages of the http transport to the node
+func NewHTTPHandler(h Handler) http.Handler {
+	serve := func(req interface{}, handle func() (interface{}, error)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err := handle()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(VotePath, func(w http.ResponseWriter, r *http.Request) {
+		req := VoteRequest{}
+		serve(&req, func() (interface{}, error) { return h.RequestVote(req) })(w, r)
+	})
+	mux.HandleFunc(AppendPath, func(w http.ResponseWriter, r *http.Request) {
+		req := AppendRequest{}
+		serve(&req, func() (interface{}, error) { return h.AppendEntries(req) })(w, r)
+	})
+	mux.HandleFunc(SnapshotPath, func(w http.ResponseWriter, r *http.Request) {
+		req := SnapshotRequest{}
+		serve(&req, func() (interface{}, error) { return h.InstallSnapshot(req) })(w, r)
+	})
+	return mux
+}
+
+// AllowPeers returns the handler that lets through to the next only the requests of the peers with a client
+// certificate verified by the TLS server, and one of the identities as common name.
+func AllowPeers(identities []string, next http.Handler) http.Handler {
+	allowed := map[string]bool{}
+	for _, identity := range identities {
+		allowed[identity] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := auth.Certificates{}.Authenticate(r)
+		if !allowed[identity] {
+			log.Warn("Refusing peer", "identity", identity, "remote", r.RemoteAddr)
+			http.Error(w, "not a peer", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
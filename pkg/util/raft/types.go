@@ -0,0 +1,180 @@
+package raft
+
+import (
+	"time"
+)
+
+// Entry is an entry of the replicated log
+type Entry struct {
+	// Term is the term of the leader that appended the entry
+	Term uint64
+
+	// Index is the position of the entry in the log, starting from 1
+	Index uint64
+
+	// Command is applied to the state machine once the entry is committed.  Nil for the entries that only
+	// mark the start of a term.
+	Command []byte `json:",omitempty"`
+}
+
+// Snapshot is the state of the state machine after applying all the entries up to and including Index
+type Snapshot struct {
+	// Index is the index of the last entry applied to the state
+	Index uint64
+
+	// Term is the term of the last entry applied to the state
+	Term uint64
+
+	// Data is the state, as returned by the state machine
+	Data []byte `json:",omitempty"`
+}
+
+// State is the state of a node that must survive restarts
+type State struct {
+	// Term is the latest term seen
+	Term uint64
+
+	// VotedFor is the candidate voted for in the current term, if any
+	VotedFor string
+
+	// Snapshot replaces the entries of the log that have been compacted
+	Snapshot Snapshot
+
+	// Log is the log of entries following the snapshot
+	Log []Entry
+}
+
+// VoteRequest is sent by candidates to gather votes
+type VoteRequest struct {
+	Term         uint64
+	Candidate    string
+	LastLogIndex uint64
+	LastLogTerm  uint64
+}
+
+// VoteResponse is the response to a VoteRequest
+type VoteResponse struct {
+	Term    uint64
+	Granted bool
+}
+
+// AppendRequest is sent by the leader to replicate entries, and as heartbeat
+type AppendRequest struct {
+	Term         uint64
+	Leader       string
+	PrevLogIndex uint64
+	PrevLogTerm  uint64
+	Entries      []Entry
+	LeaderCommit uint64
+}
+
+// AppendResponse is the response to an AppendRequest
+type AppendResponse struct {
+	Term    uint64
+	Success bool
+
+	// LastIndex is the index of the last entry matching the leader's log if successful, or a hint of where the
+	// logs may match otherwise.
+	LastIndex uint64
+}
+
+// SnapshotRequest is sent by the leader to the followers that lag behind the compacted part of its log
+type SnapshotRequest struct {
+	Term     uint64
+	Leader   string
+	Snapshot Snapshot
+}
+
+// SnapshotResponse is the response to a SnapshotRequest
+type SnapshotResponse struct {
+	Term uint64
+}
+
+// Handler handles the messages exchanged by the nodes of the cluster
+type Handler interface {
+	// RequestVote handles a request for vote
+	RequestVote(req VoteRequest) (VoteResponse, error)
+
+	// AppendEntries handles the replication of entries
+	AppendEntries(req AppendRequest) (AppendResponse, error)
+
+	// InstallSnapshot handles the replication of a snapshot
+	InstallSnapshot(req SnapshotRequest) (SnapshotResponse, error)
+}
+
+// Transport sends the messages to the other nodes of the cluster
+type Transport interface {
+	// RequestVote sends a request for vote to the peer
+	RequestVote(peer string, req VoteRequest) (VoteResponse, error)
+
+	// AppendEntries sends entries to the peer
+	AppendEntries(peer string, req AppendRequest) (AppendResponse, error)
+
+	// InstallSnapshot sends a snapshot to the peer
+	InstallSnapshot(peer string, req SnapshotRequest) (SnapshotResponse, error)
+}
+
+// Storage persists the state of a node.  The changes must be durable once saved.
+type Storage interface {
+	// Load returns the state saved, or the zero State if none
+	Load() (State, error)
+
+	// SaveVote saves the term and the vote of the term
+	SaveVote(term uint64, votedFor string) error
+
+	// Append saves the entries appended to the log.  The entries saved from the index of the first entry on are
+	// replaced, as when they conflict with the log of the leader.
+	Append(entries []Entry) error
+
+	// Save replaces all the state saved, as when the log is compacted
+	Save(state State) error
+}
+
+// StateMachine is the state replicated by the cluster
+type StateMachine interface {
+	// Apply applies a committed command.  The error is returned to the proposer of the command.
+	Apply(command []byte) error
+
+	// Snapshot returns the encoded state, for compacting the log
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the state with the encoded state
+	Restore(data []byte) error
+}
+
+// Config is the configuration of a node
+type Config struct {
+	// ID is the id of the node
+	ID string
+
+	// Members are the ids of all the nodes of the cluster, including this node.  The membership is static.
+	Members []string
+
+	// HeartbeatInterval is how often the leader contacts the followers
+	HeartbeatInterval time.Duration
+
+	// ElectionTimeout is how long a follower waits without hearing from the leader before starting an election.
+	// It is randomized up to twice this value.  A leader that cannot reach a majority within this time steps
+	// down.
+	ElectionTimeout time.Duration
+
+	// SnapshotThreshold is the number of entries applied after which the log is compacted
+	SnapshotThreshold int
+
+	// Transport sends the messages to the other members
+	Transport Transport
+
+	// Storage persists the state of the node
+	Storage Storage
+}
+
+const (
+	// DefaultHeartbeatInterval is the default heartbeat interval
+	DefaultHeartbeatInterval = 100 * time.Millisecond
+
+	// DefaultElectionTimeout is the default election timeout
+	DefaultElectionTimeout = 1 * time.Second
+
+	// DefaultSnapshotThreshold is the default number of entries between compactions of the log
+	DefaultSnapshotThreshold = 1024
+)